@@ -0,0 +1,58 @@
+package fastentity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// acronymStopWords are left out of acronyms, e.g. "University of New York" becomes "UNY".
+var acronymStopWords = map[string]bool{
+	"a": true, "an": true, "and": true, "at": true, "for": true, "in": true,
+	"of": true, "on": true, "the": true, "to": true, "&": true,
+}
+
+// Acronyms makes multi-word entities also match their acronyms, e.g. "University of New
+// York" also matches "UNY". Acronyms are made from the first letters of the words of an
+// entity, leaving out short function words such as "of" and "the", and must appear in
+// upper case in the text. Acronym matches are reported with Kind AcronymMatch and the
+// full entity as Canonical.
+func Acronyms() GroupOption {
+	return func(g *group) {
+		if g.acronyms != nil {
+			return
+		}
+		g.acronyms = make(map[string][]entry)
+		for _, ents := range g.entities {
+			for _, e := range ents {
+				g.addAcronym(e)
+			}
+		}
+	}
+}
+
+// Acronym returns the acronym of e as used by the Acronyms option, or "" if e has fewer
+// than two words which contribute to it.
+func Acronym(e []rune) string {
+	var b strings.Builder
+	n := 0
+	for _, w := range words(e) {
+		word := e[w[left]:w[right]]
+		if acronymStopWords[strings.ToLower(string(word))] {
+			continue
+		}
+		b.WriteRune(unicode.ToUpper(word[0]))
+		n++
+	}
+	if n < 2 {
+		return ""
+	}
+	return b.String()
+}
+
+// addAcronym adds e to the acronym index of the group. The caller must hold the group
+// lock.
+func (g *group) addAcronym(e entry) {
+	if a := Acronym(e.text); a != "" {
+		g.acronyms[a] = append(g.acronyms[a], e)
+	}
+}
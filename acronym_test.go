@@ -0,0 +1,48 @@
+package fastentity
+
+import "testing"
+
+func TestAcronym(t *testing.T) {
+	acronyms := map[string]string{
+		"University of New York":     "UNY",
+		"golang developer":           "GD",
+		"Master of Business Admin":   "MBA",
+		"the Bank of America":        "BA",
+		"PHP":                        "",
+		"Department of the Interior": "DI",
+	}
+	for e, expected := range acronyms {
+		if got := Acronym([]rune(e)); got != expected {
+			t.Errorf("Expected Acronym(%q) = %q, got %q", e, expected, got)
+		}
+	}
+}
+
+func TestAcronyms(t *testing.T) {
+	store := New()
+	store.Add("institutions", []rune("University of New York"))
+	store.Group("institutions").Configure(Acronyms())
+	store.Add("institutions", []rune("University of Houston"))
+
+	str := []rune("Master of Science, UNY 1990 and University of Houston (UH), not uh. ")
+	found := store.FindAll(str)["institutions"]
+	if len(found) != 3 {
+		t.Fatalf("Expected 3 entities, got %d: %v", len(found), found)
+	}
+	expected := []struct {
+		text, canonical string
+		offset          int
+		kind            MatchKind
+	}{
+		{"UNY", "University of New York", 19, AcronymMatch},
+		{"University of Houston", "University of Houston", 32, TextMatch},
+		{"UH", "University of Houston", 55, AcronymMatch},
+	}
+	for i, e := range expected {
+		f := found[i]
+		if string(f.Text) != e.text || string(f.Canonical) != e.canonical || f.Offset != e.offset || f.Kind != e.kind {
+			t.Errorf("Expected %q (%q) at %d as %v, got %q (%q) at %d as %v",
+				e.text, e.canonical, e.offset, e.kind, string(f.Text), string(f.Canonical), f.Offset, f.Kind)
+		}
+	}
+}
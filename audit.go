@@ -0,0 +1,190 @@
+package fastentity
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNotAuditing is returned when the history of a group is requested from a store without
+// an audit sink, see SetAuditSink.
+var ErrNotAuditing = errors.New("not auditing changes")
+
+// AuditOp is the kind of change recorded by an AuditEvent.
+type AuditOp string
+
+const (
+	// AuditAdd records entities being added to a group.
+	AuditAdd AuditOp = "add"
+	// AuditRemove records entities being removed from a group.
+	AuditRemove AuditOp = "remove"
+	// AuditRename records a group being renamed to To.
+	AuditRename AuditOp = "rename"
+	// AuditCopy records a group being copied to the new group To.
+	AuditCopy AuditOp = "copy"
+	// AuditRollback records a group being rolled back to Version, replacing its entities
+	// with those listed. Groups removed by the rollback are recorded without entities.
+	AuditRollback AuditOp = "rollback"
+)
+
+// AuditEvent records a change to the entities of a group.
+type AuditEvent struct {
+	Time     time.Time `json:"time"`
+	Actor    string    `json:"actor,omitempty"`
+	Group    string    `json:"group"`
+	Op       AuditOp   `json:"op"`
+	Entities []string  `json:"entities"`
+	// Weights are the weights of the entities added, if any differ from DefaultWeight.
+	Weights []float64 `json:"weights,omitempty"`
+	// To is the new name of a renamed group, or the name of a copy.
+	To string `json:"to,omitempty"`
+	// Version is the version a group was rolled back to.
+	Version string `json:"version,omitempty"`
+}
+
+// An AuditSink records the changes made to the entities of a store, for reviewing who
+// changed a dictionary and when, and returns the changes made to a group, oldest first.
+// Record and History may be called concurrently.
+type AuditSink interface {
+	Record(e AuditEvent) error
+	History(group string) ([]AuditEvent, error)
+}
+
+// SetAuditSink records the changes made to the entities of the store from now on in a, or
+// stops recording if a is nil: entities added and removed, including by Merge and
+// AddFromReader, groups renamed and copied, and rollbacks. Entities loaded from files or
+// snapshots aren't recorded.
+func (s *Store) SetAuditSink(a AuditSink) {
+	s.Lock()
+	s.audit = a
+	s.Unlock()
+}
+
+// AddAs adjoins the entities to the group identified by name as Add does, recording actor
+// as having added them. If the audit sink fails to record the change, the entities aren't
+// added and the error is returned, as is an error wrapping ErrGroupFrozen if the group is
+// frozen. Changes made by Add and the other methods which don't take an actor are recorded
+// without one, and made even if the sink fails.
+func (s *Store) AddAs(actor, name string, entities ...[]rune) error {
+	if err := s.checkFrozen(name); err != nil {
+		return err
+	}
+	if err := s.recordEntities(actor, AuditAdd, name, nil, entities...); err != nil {
+		return err
+	}
+	g := s.addGroup(name)
+	g.Lock()
+	for _, e := range entities {
+		g.add(newEntry(e))
+	}
+	g.Unlock()
+	s.bump()
+	return nil
+}
+
+// History returns the changes recorded to the group identified by name, oldest first,
+// including it being renamed or copied from another group. It returns ErrNotAuditing if
+// the store has no audit sink.
+func (s *Store) History(name string) ([]AuditEvent, error) {
+	s.RLock()
+	a := s.audit
+	s.RUnlock()
+	if a == nil {
+		return nil, ErrNotAuditing
+	}
+	return a.History(name)
+}
+
+// record records e in the audit sink, if there is one, at the current time.
+func (s *Store) record(e AuditEvent) error {
+	s.RLock()
+	a := s.audit
+	s.RUnlock()
+	if a == nil {
+		return nil
+	}
+	e.Time = time.Now()
+	return a.Record(e)
+}
+
+// recordEntities records the op on the entities, with the given weights if not nil, of
+// the group identified by name.
+func (s *Store) recordEntities(actor string, op AuditOp, name string, weights []float64, entities ...[]rune) error {
+	if len(entities) == 0 {
+		return nil
+	}
+	e := AuditEvent{
+		Actor:    actor,
+		Group:    name,
+		Op:       op,
+		Entities: make([]string, len(entities)),
+		Weights:  weights,
+	}
+	for i, ent := range entities {
+		e.Entities[i] = string(ent)
+	}
+	return s.record(e)
+}
+
+// recordEntries records the op on the entries of the group identified by name.
+func (s *Store) recordEntries(op AuditOp, name string, ents []entry) error {
+	if len(ents) == 0 {
+		return nil
+	}
+	e := AuditEvent{Group: name, Op: op}
+	e.Entities, e.Weights = auditEntries(ents)
+	return s.record(e)
+}
+
+// auditEntries returns the text of each entry, and their weights if any differ from
+// DefaultWeight.
+func auditEntries(ents []entry) ([]string, []float64) {
+	texts := make([]string, len(ents))
+	var weights []float64
+	for i, e := range ents {
+		texts[i] = string(e.text)
+		if e.weight != DefaultWeight && weights == nil {
+			weights = make([]float64, len(ents))
+			for j := range weights {
+				weights[j] = DefaultWeight
+			}
+		}
+		if weights != nil {
+			weights[i] = e.weight
+		}
+	}
+	return texts, weights
+}
+
+// AuditLog is an AuditSink holding the changes in memory.
+type AuditLog struct {
+	mu     sync.RWMutex
+	events []AuditEvent
+}
+
+// NewAuditLog returns an empty AuditLog.
+func NewAuditLog() *AuditLog {
+	return &AuditLog{}
+}
+
+// Record adds e to the log.
+func (l *AuditLog) Record(e AuditEvent) error {
+	l.mu.Lock()
+	l.events = append(l.events, e)
+	l.mu.Unlock()
+	return nil
+}
+
+// History returns the changes logged to the group, oldest first, including those
+// renaming or copying another group to it.
+func (l *AuditLog) History(group string) ([]AuditEvent, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	var events []AuditEvent
+	for _, e := range l.events {
+		if e.Group == group || e.To == group {
+			events = append(events, e)
+		}
+	}
+	return events, nil
+}
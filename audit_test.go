@@ -0,0 +1,105 @@
+package fastentity
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type failingSink struct{ *AuditLog }
+
+func (failingSink) Record(AuditEvent) error { return errors.New("sink down") }
+
+func TestAudit(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("go"))
+	if _, err := store.History("skills"); !errors.Is(err, ErrNotAuditing) {
+		t.Errorf("Expected ErrNotAuditing, got %v", err)
+	}
+
+	store.SetAuditSink(NewAuditLog())
+	if err := store.AddAs("alice", "skills", []rune("python"), []rune("rust")); err != nil {
+		t.Fatal(err)
+	}
+	store.AddWeighted("skills", []rune("java"), 2)
+	store.Add("locations", []rune("Sydney"))
+
+	history, err := store.History("skills")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 2 {
+		t.Fatalf("Expected 2 events, got %v", history)
+	}
+	if e := history[0]; e.Actor != "alice" || e.Op != AuditAdd || e.Group != "skills" ||
+		!reflect.DeepEqual(e.Entities, []string{"python", "rust"}) || e.Time.IsZero() {
+		t.Errorf("Unexpected event %+v", e)
+	}
+	if e := history[1]; e.Actor != "" || !reflect.DeepEqual(e.Entities, []string{"java"}) {
+		t.Errorf("Unexpected event %+v", e)
+	}
+
+	// Changes the sink fails to record aren't made
+	store.SetAuditSink(failingSink{NewAuditLog()})
+	if err := store.AddAs("bob", "skills", []rune("haskell")); err == nil {
+		t.Error("Expected the sink error")
+	}
+	if found := store.FindAll([]rune("haskell"))["skills"]; len(found) != 0 {
+		t.Errorf("Expected the entity not to be added, got %v", found)
+	}
+}
+
+func TestAuditChanges(t *testing.T) {
+	dir := t.TempDir()
+	store := New()
+	store.SetVersionDir(dir)
+	store.Add("skills", []rune("go"), []rune("rust"))
+	if err := store.Tag("v1"); err != nil {
+		t.Fatal(err)
+	}
+	log := NewAuditLog()
+	store.SetAuditSink(log)
+
+	if err := store.RemoveAs("alice", "skills", []rune("Rust")); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.CopyGroup("skills", "languages"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.RenameGroup("languages", "langs"); err != nil {
+		t.Fatal(err)
+	}
+	store.Merge(New())
+	if err := store.Rollback("v1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var ops []AuditOp
+	history, _ := store.History("skills")
+	for _, e := range history {
+		ops = append(ops, e.Op)
+	}
+	if want := []AuditOp{AuditRemove, AuditCopy, AuditRollback}; !reflect.DeepEqual(ops, want) {
+		t.Errorf("Expected ops %v, got %v", want, ops)
+	}
+	if e := history[0]; e.Actor != "alice" || !reflect.DeepEqual(e.Entities, []string{"Rust"}) {
+		t.Errorf("Unexpected event %+v", e)
+	}
+	if e := history[2]; e.Version != "v1" || !reflect.DeepEqual(e.Entities, []string{"go", "rust"}) {
+		t.Errorf("Unexpected event %+v", e)
+	}
+	history, _ = store.History("langs")
+	if len(history) != 2 || history[0].Op != AuditRename || history[0].To != "langs" ||
+		history[1].Op != AuditRollback || history[1].Entities != nil {
+		t.Errorf("Expected the rename and the group's removal, got %+v", history)
+	}
+
+	// Changes the sink fails to record aren't made
+	store.SetAuditSink(failingSink{NewAuditLog()})
+	if err := store.RemoveAs("bob", "skills", []rune("go")); err == nil {
+		t.Error("Expected the sink error")
+	}
+	if n := store.Group("skills").Len(); n != 2 {
+		t.Errorf("Expected the entity not to be removed, got %d entities", n)
+	}
+}
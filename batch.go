@@ -0,0 +1,42 @@
+package fastentity
+
+import "context"
+
+// FindAllMulti searches each of the documents, identified by ID, returning the results
+// of FindAll for each by ID, with the ID of the document as the Doc of each entity found,
+// see DocumentID. It's faster than calling FindAll for each document, since
+// the groups are locked once for the whole batch, which is also searched against the
+// same version of the store.
+func (s *Store) FindAllMulti(docs map[string][]rune) map[string]Results {
+	r, _ := s.FindAllMultiContext(context.Background(), docs)
+	return r
+}
+
+// FindAllMultiContext is like FindAllMulti, but stops searching when ctx is cancelled,
+// returning ctx.Err().
+func (s *Store) FindAllMultiContext(ctx context.Context, docs map[string][]rune) (map[string]Results, error) {
+	ds := make(map[string]*document, len(docs))
+	for id, rs := range docs {
+		ds[id] = s.preprocess(rs)
+	}
+
+	results := make(map[string]Results, len(docs))
+	c := &findConfig{scratch: new(Scratch)}
+	groups := s.rlockGroups()
+	for id, d := range ds {
+		r, err := findDocument(ctx, d, groups, c)
+		if err != nil {
+			runlockGroups(groups)
+			return nil, err
+		}
+		results[id] = r
+	}
+	runlockGroups(groups)
+
+	for id, r := range results {
+		r = s.filter(r)
+		setDocumentID(r, id)
+		results[id] = r
+	}
+	return results, nil
+}
@@ -0,0 +1,37 @@
+package fastentity
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindAllMulti(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"), []rune("golang"))
+	store.Add("locations", []rune("Sydney"))
+
+	docs := map[string][]rune{
+		"a": []rune("PHP developer in Sydney"),
+		"b": []rune("golang and PHP"),
+		"c": []rune("Nothing here"),
+	}
+	results := store.FindAllMulti(docs)
+	if len(results) != len(docs) {
+		t.Fatalf("Expected results for %d documents, got %d", len(docs), len(results))
+	}
+	for id, doc := range docs {
+		if expected := store.FindAll(doc, DocumentID(id)); !reflect.DeepEqual(results[id], expected) {
+			t.Errorf("%s: expected %v, got %v", id, expected, results[id])
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	long := map[string][]rune{"long": []rune(strings.Repeat("PHP ", 2*checkInterval))}
+	if _, err := store.FindAllMultiContext(ctx, long); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
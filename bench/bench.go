@@ -0,0 +1,188 @@
+// Package bench provides representative corpora and generated dictionaries for measuring
+// the performance of a fastentity Store against realistic workloads: resumes, news and
+// logs with the dictionaries of entities found in each, and synthetic dictionaries and
+// documents of any size and match rate.
+//
+// The benchmarks of this package are run with
+//
+//	go test -bench . -benchmem ./bench
+//
+// and the tests check the matches found in each corpus, so that changes to performance
+// are never changes to the results too.
+package bench
+
+import (
+	"embed"
+	"math/rand"
+	"strings"
+
+	"github.com/sajari/fastentity"
+)
+
+//go:embed corpus/*.txt
+var files embed.FS
+
+// Corpus is a representative document, with the dictionaries of the entities found in
+// documents like it.
+type Corpus struct {
+	Name string
+	Text string
+	// Groups are the entities of each group of the dictionary of the corpus, including
+	// some which aren't found in Text.
+	Groups map[string][]string
+	// Tokenizer is the tokenizer to search the corpus with.
+	Tokenizer fastentity.Tokenizer
+}
+
+// Store returns a new store with the entities of the groups of the corpus.
+func (c *Corpus) Store() *fastentity.Store {
+	store := fastentity.New()
+	for name, ents := range c.Groups {
+		store.Add(name, runes(ents)...)
+	}
+	store.SetTokenizer(c.Tokenizer)
+	return store
+}
+
+// Corpora returns the corpora resume, news and logs, in that order.
+func Corpora() []*Corpus {
+	logs := newCorpus("logs", map[string][]string{
+		"services":  {"api-gateway", "auth-service", "payments", "search", "indexer", "scheduler", "billing"},
+		"hosts":     {"web-01.syd.example.com", "web-02.syd.example.com", "web-03.syd.example.com", "db-01.mel.example.com", "cache-03.syd.example.com"},
+		"errors":    {"connection refused", "context deadline exceeded", "OutOfMemoryError", "NullPointerException", "TimeoutException", "too many open files", "certificate has expired", "disk full"},
+		"levels":    {"ERROR", "WARN"},
+		"databases": {"postgres", "redis", "elasticsearch"},
+	})
+	// key=value pairs split at '='
+	logs.Tokenizer = fastentity.CodeTokenizer()
+	logs.Tokenizer.BoundaryClass.Categories = []string{"Sm"}
+	return []*Corpus{
+		newCorpus("resume", map[string][]string{
+			"skills":    {"accounting", "tax", "Excel", "Lotus", "WordPerfect", "technical writing", "editing", "payroll", "research", "communication", "Spreadsheet Auditor", "Ami Pro", "ProComm Plus", "golang", "PHP", "project management"},
+			"jobTitles": {"Tax Accountant", "Tax Staff Accountant", "accountant", "Accounting", "golang developer", "office manager"},
+			"locations": {"Houston", "Texas", "TX", "New York", "Friendswood", "Bleeker Street", "Sydney", "San Francisco"},
+			"education": {"Bachelor of Science", "Master of Science", "Master of Business Administration", "University of Houston", "University of New York", "University of Sydney"},
+		}),
+		newCorpus("news", map[string][]string{
+			"locations":     {"Sydney", "Melbourne", "Brisbane", "Perth", "Parramatta", "North Sydney", "Surry Hills", "Australia", "New Zealand", "Wellington", "Auckland", "Singapore", "Tokyo", "China", "Asia Pacific", "London"},
+			"organizations": {"Australian Bureau of Statistics", "Google", "Atlassian", "Canva", "Amazon Web Services", "Reserve Bank of Australia", "Reserve Bank", "Australian Securities and Investments Commission", "Commonwealth Bank of Australia", "Westpac", "Xero", "Rocket Lab", "International Monetary Fund", "Microsoft"},
+			"skills":        {"Go", "Rust", "Kubernetes", "machine learning", "Python"},
+			"jobTitles":     {"software engineers", "data scientists", "machine learning engineers", "senior engineers", "managing director", "economists", "analysts"},
+			"people":        {"Priya Natarajan", "Sam Chen", "Maria Lopez"},
+		}),
+		logs,
+	}
+}
+
+// newCorpus returns the corpus name with its text read from the corpus directory.
+func newCorpus(name string, groups map[string][]string) *Corpus {
+	b, err := files.ReadFile("corpus/" + name + ".txt")
+	if err != nil {
+		panic(err)
+	}
+	return &Corpus{Name: name, Text: string(b), Groups: groups}
+}
+
+// Generator generates dictionaries and documents deterministically from a seed, so that
+// benchmarks measure the same workload from run to run.
+type Generator struct {
+	rand *rand.Rand
+}
+
+// NewGenerator returns a Generator seeded with seed.
+func NewGenerator(seed int64) *Generator {
+	return &Generator{rand: rand.New(rand.NewSource(seed))}
+}
+
+var (
+	onsets = []string{"b", "c", "d", "f", "g", "h", "k", "l", "m", "n", "p", "r", "s", "t", "v", "br", "ch", "st", "tr", "pl"}
+	nuclei = []string{"a", "e", "i", "o", "u", "ai", "ou", "ea"}
+	codas  = []string{"", "", "", "n", "r", "s", "t", "l", "nd", "ck"}
+)
+
+// Word returns a pronounceable word of one to four syllables, starting with a consonant.
+func (g *Generator) Word() string {
+	var b strings.Builder
+	for i := g.rand.Intn(4); i >= 0; i-- {
+		b.WriteString(onsets[g.rand.Intn(len(onsets))])
+		b.WriteString(nuclei[g.rand.Intn(len(nuclei))])
+		b.WriteString(codas[g.rand.Intn(len(codas))])
+	}
+	return b.String()
+}
+
+// filler returns a word starting with a vowel, which is never a word of the entities
+// returned by Entities.
+func (g *Generator) filler() string {
+	return nuclei[g.rand.Intn(len(nuclei))] + g.Word()
+}
+
+// Entities returns n distinct entities of one to four words, mostly one or two as in
+// dictionaries of skills, locations and organizations, with the first letter of each
+// word sometimes capitalized.
+func (g *Generator) Entities(n int) []string {
+	ents := make([]string, 0, n)
+	seen := make(map[string]bool, n)
+	for len(ents) < n {
+		words := 1 + g.rand.Intn(2)
+		if g.rand.Intn(8) == 0 {
+			words += 1 + g.rand.Intn(2)
+		}
+		ws := make([]string, words)
+		for i := range ws {
+			ws[i] = g.Word()
+			if g.rand.Intn(3) == 0 {
+				ws[i] = strings.ToUpper(ws[i][:1]) + ws[i][1:]
+			}
+		}
+		e := strings.Join(ws, " ")
+		if !seen[e] {
+			seen[e] = true
+			ents = append(ents, e)
+		}
+	}
+	return ents
+}
+
+// Document returns a document of about words words, of which the fraction density are
+// in entities drawn from ents, and the rest filler words which are never entities. Words
+// are separated by spaces, with the odd comma or full stop.
+func (g *Generator) Document(ents []string, words int, density float64) string {
+	var b strings.Builder
+	for n := 0; n < words; {
+		if n > 0 {
+			switch g.rand.Intn(12) {
+			case 0:
+				b.WriteString(", ")
+			case 1:
+				b.WriteString(". ")
+			default:
+				b.WriteByte(' ')
+			}
+		}
+		if len(ents) > 0 && g.rand.Float64() < density {
+			e := ents[g.rand.Intn(len(ents))]
+			b.WriteString(e)
+			n += strings.Count(e, " ") + 1
+			continue
+		}
+		b.WriteString(g.filler())
+		n++
+	}
+	return b.String()
+}
+
+// Store returns a new store with the entities ents in the group name.
+func Store(name string, ents []string) *fastentity.Store {
+	store := fastentity.New(name)
+	store.Add(name, runes(ents)...)
+	return store
+}
+
+func runes(ents []string) [][]rune {
+	rs := make([][]rune, len(ents))
+	for i, e := range ents {
+		rs[i] = []rune(e)
+	}
+	return rs
+}
@@ -0,0 +1,98 @@
+package bench
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	"github.com/sajari/fastentity"
+)
+
+// TestCorpora checks the number of matches in each group of the corpora, so that changes
+// made for performance don't change what is found.
+func TestCorpora(t *testing.T) {
+	want := map[string]map[string]int{
+		"resume": {"education": 6, "jobTitles": 10, "locations": 10, "skills": 34},
+		"news":   {"jobTitles": 7, "locations": 23, "organizations": 16, "people": 3, "skills": 4},
+		"logs":   {"databases": 22, "errors": 18, "hosts": 138, "levels": 58, "services": 120},
+	}
+	for _, c := range Corpora() {
+		got := make(map[string]int)
+		for name, ents := range c.Store().FindAll([]rune(c.Text)) {
+			if len(ents) > 0 {
+				got[name] = len(ents)
+			}
+		}
+		if !reflect.DeepEqual(got, want[c.Name]) {
+			t.Errorf("Expected %v matches in %s, got %v", want[c.Name], c.Name, got)
+		}
+	}
+}
+
+func TestGenerator(t *testing.T) {
+	ents := NewGenerator(1).Entities(1000)
+	if len(ents) != 1000 {
+		t.Fatalf("Expected 1000 entities, got %d", len(ents))
+	}
+	if again := NewGenerator(1).Entities(1000); !reflect.DeepEqual(ents, again) {
+		t.Error("Expected the same entities from the same seed")
+	}
+
+	store := Store("generated", ents)
+	g := NewGenerator(2)
+	if r := store.FindAll([]rune(g.Document(ents, 1000, 0))); len(r["generated"]) != 0 {
+		t.Errorf("Expected no matches in filler, got %v", r["generated"])
+	}
+	if r := store.FindAll([]rune(g.Document(ents, 1000, 0.5))); len(r["generated"]) < 250 {
+		t.Errorf("Expected entities in half the words, got %d matches", len(r["generated"]))
+	}
+}
+
+// BenchmarkCorpora finds the entities of each corpus in it.
+func BenchmarkCorpora(b *testing.B) {
+	for _, c := range Corpora() {
+		store, doc := c.Store(), []rune(c.Text)
+		b.Run(c.Name, func(b *testing.B) {
+			b.SetBytes(int64(len(c.Text)))
+			for n := 0; n < b.N; n++ {
+				store.FindAll(doc)
+			}
+		})
+	}
+}
+
+// BenchmarkMatchRate finds the entities of a dictionary of 10,000 in documents of 10,000
+// words, from sparse to dense matches.
+func BenchmarkMatchRate(b *testing.B) {
+	ents := NewGenerator(1).Entities(10000)
+	store := Store("generated", ents)
+	for _, density := range []float64{0, 0.01, 0.1, 0.5} {
+		doc := NewGenerator(2).Document(ents, 10000, density)
+		b.Run(fmt.Sprintf("density=%v", density), func(b *testing.B) {
+			benchmarkFind(b, store, doc)
+		})
+	}
+}
+
+// BenchmarkDictionarySize finds entities in documents of 1,000 words, 5% of them in
+// entities, with dictionaries of up to 1,000,000 entities.
+func BenchmarkDictionarySize(b *testing.B) {
+	for _, size := range []int{1000, 10000, 100000, 1000000} {
+		ents := NewGenerator(1).Entities(size)
+		store := Store("generated", ents)
+		doc := NewGenerator(2).Document(ents, 1000, 0.05)
+		b.Run(fmt.Sprint("entities=", size), func(b *testing.B) {
+			benchmarkFind(b, store, doc)
+		})
+	}
+}
+
+func benchmarkFind(b *testing.B, store *fastentity.Store, doc string) {
+	rs := []rune(doc)
+	b.SetBytes(int64(len(doc)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		store.FindAll(rs)
+	}
+}
@@ -0,0 +1,36 @@
+package fastentity
+
+// isBidiControl reports whether r is an invisible bidirectional formatting character,
+// such as the marks U+200E and U+200F, which are often found around words in right to
+// left text. They separate words, and are ignored within the text of entities.
+func isBidiControl(r rune) bool {
+	switch {
+	case r == '\u061c', r == '\u200e', r == '\u200f':
+		return true
+	case r >= '\u202a' && r <= '\u202e':
+		return true
+	case r >= '\u2066' && r <= '\u2069':
+		return true
+	}
+	return false
+}
+
+// hasBidiControls reports whether rs contains any bidirectional formatting characters.
+func hasBidiControls(rs []rune) bool {
+	for _, r := range rs {
+		if isBidiControl(r) {
+			return true
+		}
+	}
+	return false
+}
+
+// stripBidiControls appends text to buf without its bidirectional formatting characters.
+func stripBidiControls(buf, text []rune) []rune {
+	for _, r := range text {
+		if !isBidiControl(r) {
+			buf = append(buf, r)
+		}
+	}
+	return buf
+}
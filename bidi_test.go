@@ -0,0 +1,52 @@
+package fastentity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRightToLeft(t *testing.T) {
+	store := New()
+	store.Add("locations", []rune("תל אביב"), []rune("ירושלים"), []rune("القاهرة"), []rune("دبي"))
+	store.Add("skills", []rune("פייתון"))
+
+	for _, tt := range []struct {
+		doc  string
+		want []string
+	}{
+		{"טסתי מתל אביב לירושלים, ובחזרה לתל אביב.", nil},
+		{"טסתי מ־ירושלים אל תל אביב.", []string{"locations:7:7:ירושלים", "locations:18:7:תל אביב"}},
+		{"מפתח פייתון בירושלים? כן: ירושלים׃", []string{"skills:5:6:פייתון", "locations:26:7:ירושלים"}},
+		{"سافرت من القاهرة، إلى دبي؟", []string{"locations:9:7:القاهرة", "locations:22:3:دبي"}},
+		// Directional marks separate words, and are ignored within entities
+		{"‏ירושלים‏ ו‫תל‏ אביב‬", []string{"locations:1:7:ירושלים", "locations:12:8:תל‏ אביב"}},
+		{"؜القاهرة؜", []string{"locations:1:7:القاهرة"}},
+	} {
+		doc := []rune(tt.doc)
+		var got []string
+		for _, m := range store.FindAll(doc).Matches() {
+			got = append(got, m.String())
+			if string(doc[m.Offset:m.Offset+len(m.Text)]) != string(m.Text) {
+				t.Errorf("%q: text %q doesn't match the document at %d", tt.doc, string(m.Text), m.Offset)
+			}
+		}
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%q: expected %q, got %q", tt.doc, tt.want, got)
+		}
+	}
+}
+
+func TestRightToLeftNormalized(t *testing.T) {
+	store := New()
+	store.Add("locations", []rune("תל אביב"))
+	store.Group("locations").Configure(Normalize(func(w []rune) []rune { return w }))
+
+	var got []string
+	for _, m := range store.FindAll([]rune("ו‫תל‏ אביב‬")).Matches() {
+		got = append(got, m.String())
+	}
+	want := []string{"locations:2:8:תל‏ אביב"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
@@ -0,0 +1,188 @@
+package fastentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var (
+	// ErrInvalidDictionary is returned by Build when the dictionaries of the store fail
+	// validation, wrapped in a *BuildError listing the problems found.
+	ErrInvalidDictionary = errors.New("invalid dictionary")
+
+	// ErrEmptyGroup is the problem with groups without entities found by Build.
+	ErrEmptyGroup = errors.New("empty group")
+
+	// ErrDuplicateSpan is the problem with entities found by Build which match the same
+	// text as another entity of their group, once normalized, so that both would be
+	// reported for the same span of a document.
+	ErrDuplicateSpan = errors.New("duplicate span")
+
+	errEmptyEntity = errors.New("empty entity")
+)
+
+// BuildProblem is a problem with the dictionaries of a store found by Build.
+type BuildProblem struct {
+	Group string
+	// Entity is the entity with the problem, if it's not with the whole group.
+	Entity string
+	Err    error
+}
+
+func (p BuildProblem) String() string {
+	if p.Entity == "" {
+		return fmt.Sprintf("group %q: %v", p.Group, p.Err)
+	}
+	return fmt.Sprintf("group %q: %q: %v", p.Group, p.Entity, p.Err)
+}
+
+// BuildError lists the problems with the dictionaries of a store found by Build.
+type BuildError struct {
+	Problems []BuildProblem
+}
+
+func (e *BuildError) Error() string {
+	msg := fmt.Sprintf("%v: %v", ErrInvalidDictionary, e.Problems[0])
+	if len(e.Problems) > 1 {
+		msg += fmt.Sprintf(" (and %d more problems)", len(e.Problems)-1)
+	}
+	return msg
+}
+
+func (e *BuildError) Unwrap() error {
+	return ErrInvalidDictionary
+}
+
+// Frozen is an immutable copy of a store built by Store.Build, optimized for searching.
+// It is safe for concurrent use, and since it can't be modified its groups are searched
+// without waiting for writers.
+type Frozen struct {
+	s *Store
+}
+
+// Build validates the dictionaries of the store and returns an immutable copy optimized
+// for searching, so that problems with the dictionaries fail fast when they are deployed
+// rather than causing missed or spurious matches later. It returns a *BuildError
+// wrapping ErrInvalidDictionary listing every problem found: entities longer than
+// MaxEntityLen, which are never found, empty entities and groups, and entities matching
+// the same text as another of their group once normalized, see ErrDuplicateSpan.
+//
+// The copy has the configuration of the store, its groups, tokenizer, preprocessors and
+// result filters, as it was when Build was called. Lazy groups are loaded first, and the
+// entities of each group are indexed by their lower case text, so that searches compare
+// them with the text of documents without folding the case of each.
+func (s *Store) Build() (*Frozen, error) {
+	if err := s.Preload(); err != nil {
+		return nil, err
+	}
+
+	s.RLock()
+	b := &Store{
+		groups:        make(map[string]*group, len(s.groups)),
+		filters:       s.filters[:len(s.filters):len(s.filters)],
+		preprocessors: s.preprocessors[:len(s.preprocessors):len(s.preprocessors)],
+		wordLimit:     s.wordLimit,
+		tokenizer:     s.tokenizer,
+		shards:        s.shards,
+		version:       s.Version(),
+	}
+	var problems []BuildProblem
+	for name, g := range s.groups {
+		g.RLock()
+		c := g.clone(name)
+		g.RUnlock()
+		problems = append(problems, c.validate()...)
+		c.freeze()
+		b.groups[name] = c
+	}
+	s.RUnlock()
+
+	if len(problems) > 0 {
+		return nil, &BuildError{Problems: problems}
+	}
+	return &Frozen{s: b}, nil
+}
+
+// validate returns the problems with the entities of the group, see Build.
+func (g *group) validate() []BuildProblem {
+	if g.len() == 0 && g.provider == nil {
+		return []BuildProblem{{Group: g.name, Err: ErrEmptyGroup}}
+	}
+	var problems []BuildProblem
+	for _, ents := range g.entities {
+		for _, e := range ents {
+			if strings.TrimSpace(string(e.text)) == "" {
+				problems = append(problems, BuildProblem{Group: g.name, Entity: string(e.text), Err: errEmptyEntity})
+			} else if err := ValidateEntity(e.text); err != nil {
+				problems = append(problems, BuildProblem{Group: g.name, Entity: string(e.text), Err: err})
+			}
+		}
+	}
+	for _, ents := range g.normalized {
+		for i := 1; i < len(ents); i++ {
+			problems = append(problems, BuildProblem{
+				Group:  g.name,
+				Entity: string(ents[i].text),
+				Err:    fmt.Errorf("%w with %q", ErrDuplicateSpan, string(ents[0].text)),
+			})
+		}
+	}
+	return problems
+}
+
+// freeze prepares the group for searching once it will no longer be modified, indexing
+// its entities by their lower case text and compacting its indices. The caller must hold
+// the group lock, unless the group isn't shared.
+func (g *group) freeze() {
+	g.compact()
+	g.folded = nil
+	g.exact = make(map[string][]entry, g.len())
+	for _, ents := range g.entities {
+		for _, e := range ents {
+			g.exact[e.folded] = append(g.exact[e.folded], e)
+		}
+	}
+}
+
+// FindAll is Store.FindAll on the frozen store.
+func (f *Frozen) FindAll(rs []rune, opts ...FindOption) Results {
+	return f.s.FindAll(rs, opts...)
+}
+
+// FindAllContext is Store.FindAllContext on the frozen store.
+func (f *Frozen) FindAllContext(ctx context.Context, rs []rune, opts ...FindOption) (Results, error) {
+	return f.s.FindAllContext(ctx, rs, opts...)
+}
+
+// FindAllLanguage is Store.FindAllLanguage on the frozen store.
+func (f *Frozen) FindAllLanguage(lang string, rs []rune, opts ...FindOption) Results {
+	return f.s.FindAllLanguage(lang, rs, opts...)
+}
+
+// FindAllLanguageContext is Store.FindAllLanguageContext on the frozen store.
+func (f *Frozen) FindAllLanguageContext(ctx context.Context, lang string, rs []rune, opts ...FindOption) (Results, error) {
+	return f.s.FindAllLanguageContext(ctx, lang, rs, opts...)
+}
+
+// FindAllMulti is Store.FindAllMulti on the frozen store.
+func (f *Frozen) FindAllMulti(docs map[string][]rune) map[string]Results {
+	return f.s.FindAllMulti(docs)
+}
+
+// FindReader is Store.FindReader on the frozen store.
+func (f *Frozen) FindReader(r io.Reader, fn func(StreamMatch) bool, opts ...FindOption) error {
+	return f.s.FindReader(r, fn, opts...)
+}
+
+// Stats is Store.Stats on the frozen store.
+func (f *Frozen) Stats() map[string]GroupStats {
+	return f.s.Stats()
+}
+
+// Version returns the version of the store the frozen store was built from.
+func (f *Frozen) Version() uint64 {
+	return f.s.Version()
+}
@@ -0,0 +1,67 @@
+package fastentity
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBuild(t *testing.T) {
+	store := New()
+	store.Add("jobTitles", []rune("tax accountant"), []rune("Software Engineer"), []rune("IT"))
+	store.Add("locations", []rune("Sydney"), []rune("New South Wales"))
+	store.Group("jobTitles").Configure(ExactCase(3))
+	doc := []rune("A SOFTWARE ENGINEER and an it tax accountant in sydney, New South Wales, IT dept")
+
+	frozen, err := store.Build()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := store.FindAll(doc)
+	if got := frozen.FindAll(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected the frozen store to find %v, got %v", want, got)
+	}
+	if n := len(want["jobTitles"]); n != 3 {
+		t.Errorf("Expected 3 job titles, got %v", want["jobTitles"])
+	}
+
+	// Changes to the store don't affect the frozen store
+	store.Add("locations", []rune("Melbourne"))
+	if got := frozen.FindAll([]rune("Melbourne"))["locations"]; len(got) != 0 {
+		t.Errorf("Expected no matches after the store changed, got %v", got)
+	}
+}
+
+func TestBuildProblems(t *testing.T) {
+	long := strings.Repeat("x", MaxEntityLen+1)
+	store := New("empty")
+	store.Add("skills", []rune(long), []rune("Go"))
+	store.Add("jobTitles", []rune("tax accountant"), []rune("tax accountants"))
+	store.Group("jobTitles").Configure(FoldPlurals())
+
+	_, err := store.Build()
+	if !errors.Is(err, ErrInvalidDictionary) {
+		t.Fatalf("Expected ErrInvalidDictionary, got %v", err)
+	}
+	var be *BuildError
+	if !errors.As(err, &be) {
+		t.Fatalf("Expected a *BuildError, got %T", err)
+	}
+	problems := make(map[string]error)
+	for _, p := range be.Problems {
+		problems[p.Group] = p.Err
+	}
+	if len(be.Problems) != 3 {
+		t.Errorf("Expected 3 problems, got %v", be.Problems)
+	}
+	if !errors.Is(problems["empty"], ErrEmptyGroup) {
+		t.Errorf("Expected ErrEmptyGroup for empty, got %v", problems["empty"])
+	}
+	if !errors.Is(problems["skills"], ErrEntityTooLong) {
+		t.Errorf("Expected ErrEntityTooLong for skills, got %v", problems["skills"])
+	}
+	if !errors.Is(problems["jobTitles"], ErrDuplicateSpan) {
+		t.Errorf("Expected ErrDuplicateSpan for jobTitles, got %v", problems["jobTitles"])
+	}
+}
@@ -0,0 +1,155 @@
+package fastentity
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"io"
+	"sync"
+	"unicode/utf8"
+)
+
+// ResultCache caches the results of FindAll and its variants, for pipelines which search
+// the same documents again and again, see Store.SetResultCache. Implementations must be
+// safe for concurrent use, and may be backed by external stores such as memcached.
+type ResultCache interface {
+	// Get returns the results cached for key, and whether there were any. The results
+	// returned are copied before being passed on, so needn't be copied by the cache.
+	Get(key CacheKey) (Results, bool)
+	// Put caches the results of the search identified by key. The results are a copy
+	// which the cache may keep.
+	Put(key CacheKey, r Results)
+}
+
+// CacheKey identifies a search by FindAll: Doc is the SHA-256 hash of the language and
+// document searched, and Version the version of the store searched, so results are
+// cached afresh once the store changes.
+type CacheKey struct {
+	Doc     [sha256.Size]byte
+	Version uint64
+}
+
+// SetResultCache makes FindAll and its variants look up the results of each document in
+// c before searching it, and cache them there after, or stops caching if c is nil.
+// Results are cached once the result filters of the store have been applied, and before
+// any FindOptions, so the cache serves searches with any options.
+//
+// Documents found in the cache aren't counted in Stats or by CountMatches. Since stores
+// cloned from one another have the same versions, a cache shouldn't be shared between
+// stores.
+func (s *Store) SetResultCache(c ResultCache) {
+	s.Lock()
+	s.cache = c
+	s.Unlock()
+}
+
+// resultCache returns the result cache of the store, if any.
+func (s *Store) resultCache() ResultCache {
+	s.RLock()
+	defer s.RUnlock()
+	return s.cache
+}
+
+// cacheKey returns the key of the results of searching rs in the groups of the language
+// lang.
+func (s *Store) cacheKey(lang string, rs []rune) CacheKey {
+	h := sha256.New()
+	h.Write([]byte(lang))
+	h.Write([]byte{0})
+	writeRunes(h, rs)
+	k := CacheKey{Version: s.Version()}
+	h.Sum(k.Doc[:0])
+	return k
+}
+
+// writeRunes writes the UTF-8 encoding of rs to w a block at a time.
+func writeRunes(w io.Writer, rs []rune) {
+	var buf [4096]byte
+	b := buf[:0]
+	for _, r := range rs {
+		if len(b)+utf8.UTFMax > len(buf) {
+			w.Write(b)
+			b = buf[:0]
+		}
+		b = utf8.AppendRune(b, r)
+	}
+	w.Write(b)
+}
+
+// cloneResults copies r, along with the text of its entities, which refers to the
+// document searched.
+func cloneResults(r Results) Results {
+	c := make(Results, len(r))
+	for name, ents := range r {
+		if ents == nil {
+			c[name] = nil
+			continue
+		}
+		cents := make([]Entity, len(ents))
+		for i, e := range ents {
+			e.Text = append([]rune(nil), e.Text...)
+			cents[i] = e
+		}
+		c[name] = cents
+	}
+	return c
+}
+
+// LRUResultCache is a ResultCache in memory, which keeps the results of the documents
+// searched most recently.
+type LRUResultCache struct {
+	mu      sync.Mutex
+	size    int
+	entries map[CacheKey]*list.Element // of *cachedResults
+	lru     *list.List                 // most recently used first
+}
+
+type cachedResults struct {
+	key CacheKey
+	r   Results
+}
+
+// NewLRUResultCache returns a ResultCache keeping the results of the size documents
+// searched most recently.
+func NewLRUResultCache(size int) *LRUResultCache {
+	return &LRUResultCache{
+		size:    size,
+		entries: make(map[CacheKey]*list.Element, size),
+		lru:     list.New(),
+	}
+}
+
+// Get returns the results cached for key.
+func (c *LRUResultCache) Get(key CacheKey) (Results, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	c.lru.MoveToFront(el)
+	return el.Value.(*cachedResults).r, true
+}
+
+// Put caches r for key, evicting the results used least recently if the cache is full.
+func (c *LRUResultCache) Put(key CacheKey, r Results) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*cachedResults).r = r
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.entries[key] = c.lru.PushFront(&cachedResults{key: key, r: r})
+	for c.lru.Len() > c.size {
+		el := c.lru.Back()
+		c.lru.Remove(el)
+		delete(c.entries, el.Value.(*cachedResults).key)
+	}
+}
+
+// Len returns the number of documents whose results are cached.
+func (c *LRUResultCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
@@ -0,0 +1,51 @@
+package fastentity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResultCache(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"), []rune("golang"))
+	cache := NewLRUResultCache(2)
+	store.SetResultCache(cache)
+
+	doc := []rune("PHP and golang")
+	want := store.FindAll(doc)
+	if cache.Len() != 1 {
+		t.Fatalf("Expected 1 document cached, got %d", cache.Len())
+	}
+	// Results are copied, so changing them or the document doesn't change the cache
+	want["skills"][0].Offset = 100
+	copy(doc, []rune("XYZ"))
+	if r := store.FindAll([]rune("PHP and golang")); r["skills"][0].Offset != 0 || string(r["skills"][0].Text) != "PHP" {
+		t.Errorf("Expected the cached results to be unchanged, got %v", r)
+	}
+	if cache.Len() != 1 {
+		t.Errorf("Expected the document to be found in the cache, got %d cached", cache.Len())
+	}
+
+	// Options apply to cached results, and languages are cached separately
+	if r := store.FindAll([]rune("PHP and golang"), NonOverlapping()); len(r["skills"]) != 2 {
+		t.Errorf("Expected 2 skills, got %v", r)
+	}
+	store.FindAllLanguage("de", []rune("PHP and golang"))
+	if cache.Len() != 2 {
+		t.Errorf("Expected 2 documents cached, got %d", cache.Len())
+	}
+
+	// Changing the store changes the key
+	store.Add("skills", []rune("and"))
+	if r := store.FindAll([]rune("PHP and golang")); len(r["skills"]) != 3 {
+		t.Errorf("Expected 3 skills once the store changed, got %v", r)
+	}
+	if cache.Len() != 2 {
+		t.Errorf("Expected the cache to be limited to 2 documents, got %d", cache.Len())
+	}
+
+	store.SetResultCache(nil)
+	if r := store.FindAll([]rune("PHP and golang")); !reflect.DeepEqual(r, store.FindAll([]rune("PHP and golang"))) {
+		t.Errorf("Expected the same results without a cache, got %v", r)
+	}
+}
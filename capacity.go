@@ -0,0 +1,21 @@
+package fastentity
+
+// ExpectMatches allocates the results of each group named in counts with room for the
+// number of matches given, so that the results of dense documents aren't grown again and
+// again as matches are found. Fewer or more matches may be found than expected.
+func ExpectMatches(counts map[string]int) FindOption {
+	return func(c *findConfig) {
+		c.capacity = counts
+	}
+}
+
+// SizedLike allocates the results of each group with room for as many matches as the
+// group has in r, typically the results of the previous document of a stream of similar
+// documents, as ExpectMatches does.
+func SizedLike(r Results) FindOption {
+	counts := make(map[string]int, len(r))
+	for name, ents := range r {
+		counts[name] = len(ents)
+	}
+	return ExpectMatches(counts)
+}
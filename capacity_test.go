@@ -0,0 +1,27 @@
+package fastentity
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestExpectMatches(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"), []rune("golang"))
+	store.Add("locations", []rune("Sydney"))
+	doc := []rune(strings.Repeat("PHP and golang ", 10))
+
+	r := store.FindAll(doc, ExpectMatches(map[string]int{"skills": 32}))
+	if len(r["skills"]) != 20 || cap(r["skills"]) != 32 {
+		t.Errorf("Expected 20 skills with capacity 32, got %d with capacity %d", len(r["skills"]), cap(r["skills"]))
+	}
+	if r["locations"] != nil {
+		t.Errorf("Expected no locations, got %v", r["locations"])
+	}
+
+	sized := store.FindAll(doc, SizedLike(r))
+	if cap(sized["skills"]) != 20 || !reflect.DeepEqual(sized["skills"], r["skills"]) {
+		t.Errorf("Expected the same skills with capacity 20, got %v with capacity %d", sized["skills"], cap(sized["skills"]))
+	}
+}
@@ -0,0 +1,105 @@
+//go:build cgo
+
+// Command capi builds the matcher as a C shared library, so that services in other
+// languages can embed it in-process:
+//
+//	go build -buildmode=c-shared -o libfastentity.so ./capi
+//
+// which also writes the header libfastentity.h. Stores are referred to by handles, and
+// matches are returned as a JSON array of objects with the fields group, text, canonical,
+// offset, byte_offset, kind, score and weight. Offsets count code points, as Python and
+// Ruby strings do, and byte offsets count bytes of the UTF-8 text.
+//
+// Functions which can fail take a char **err, which is set to an error message on
+// failure. Strings returned, including error messages, must be freed with
+// fe_free_string, and stores with fe_free.
+package main
+
+/*
+#include <stdlib.h>
+*/
+import "C"
+
+import (
+	"unsafe"
+
+	"github.com/sajari/fastentity"
+)
+
+func main() {}
+
+// setError sets *errp to a copy of the error message, if errp isn't NULL.
+func setError(errp **C.char, err error) {
+	if errp != nil {
+		*errp = C.CString(err.Error())
+	}
+}
+
+// fe_new creates an empty store.
+//
+//export fe_new
+func fe_new() C.longlong {
+	return C.longlong(register(fastentity.New()))
+}
+
+// fe_load_dir creates a store from a directory of entity files, returning 0 on failure.
+//
+//export fe_load_dir
+func fe_load_dir(dir *C.char, errp **C.char) C.longlong {
+	s, err := fastentity.FromDir(C.GoString(dir))
+	if err != nil {
+		setError(errp, err)
+		return 0
+	}
+	return C.longlong(register(s))
+}
+
+// fe_load_snapshot creates a store from a snapshot file, returning 0 on failure.
+//
+//export fe_load_snapshot
+func fe_load_snapshot(path *C.char, errp **C.char) C.longlong {
+	s, err := fastentity.LoadSnapshot(C.GoString(path))
+	if err != nil {
+		setError(errp, err)
+		return 0
+	}
+	return C.longlong(register(s))
+}
+
+// fe_add adds an entity to a group of the store, returning 0 on success and -1 on
+// failure.
+//
+//export fe_add
+func fe_add(h C.longlong, group, entity *C.char, errp **C.char) C.int {
+	if err := add(int64(h), C.GoString(group), C.GoString(entity)); err != nil {
+		setError(errp, err)
+		return -1
+	}
+	return 0
+}
+
+// fe_find returns the matches in the UTF-8 text as JSON, or NULL on failure.
+//
+//export fe_find
+func fe_find(h C.longlong, text *C.char, errp **C.char) *C.char {
+	b, err := findJSON(int64(h), C.GoString(text))
+	if err != nil {
+		setError(errp, err)
+		return nil
+	}
+	return C.CString(string(b))
+}
+
+// fe_free releases the store. The handle is invalid afterwards.
+//
+//export fe_free
+func fe_free(h C.longlong) {
+	release(int64(h))
+}
+
+// fe_free_string frees a string returned by the library.
+//
+//export fe_free_string
+func fe_free_string(s *C.char) {
+	C.free(unsafe.Pointer(s))
+}
@@ -0,0 +1,72 @@
+//go:build cgo
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"sync"
+
+	"github.com/sajari/fastentity"
+)
+
+// Stores can't be passed to C, so they are referred to by handles, which are never
+// reused.
+var (
+	mu     sync.RWMutex
+	stores = make(map[int64]*fastentity.Store)
+	next   int64
+)
+
+var errInvalidHandle = errors.New("invalid store handle")
+
+// register returns a new handle for s.
+func register(s *fastentity.Store) int64 {
+	mu.Lock()
+	defer mu.Unlock()
+	next++
+	stores[next] = s
+	return next
+}
+
+func lookup(h int64) (*fastentity.Store, error) {
+	mu.RLock()
+	defer mu.RUnlock()
+	s, ok := stores[h]
+	if !ok {
+		return nil, errInvalidHandle
+	}
+	return s, nil
+}
+
+func release(h int64) {
+	mu.Lock()
+	delete(stores, h)
+	mu.Unlock()
+}
+
+// findJSON finds the entities in text with the store h, returning them as a JSON array
+// in document order. Offsets count runes, which are the code point offsets used by Python
+// and Ruby strings, and byte offsets bytes of the UTF-8 text.
+func findJSON(h int64, text string) ([]byte, error) {
+	s, err := lookup(h)
+	if err != nil {
+		return nil, err
+	}
+	doc := []rune(text)
+	return json.Marshal(s.FindAll(doc).MatchesIn(doc))
+}
+
+// add adds the entity to the group of the store h.
+func add(h int64, group, entity string) error {
+	s, err := lookup(h)
+	if err != nil {
+		return err
+	}
+	e := []rune(entity)
+	if err := fastentity.ValidateEntity(e); err != nil {
+		return err
+	}
+	s.Add(group, e)
+	return nil
+}
@@ -0,0 +1,52 @@
+//go:build cgo
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/sajari/fastentity"
+)
+
+func TestFindJSON(t *testing.T) {
+	h := register(fastentity.New())
+	if err := add(h, "skills", "PHP"); err != nil {
+		t.Fatal(err)
+	}
+	if err := add(h, "skills", strings.Repeat("x", fastentity.MaxEntityLen+1)); !errors.Is(err, fastentity.ErrEntityTooLong) {
+		t.Errorf("Expected ErrEntityTooLong, got %v", err)
+	}
+
+	b, err := findJSON(h, "日本 PHP")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ms []match
+	if err := json.Unmarshal(b, &ms); err != nil {
+		t.Fatal(err)
+	}
+	expected := match{Group: "skills", Text: "PHP", Canonical: "PHP", Offset: 3, ByteOffset: 7, Kind: "text", Score: 1, Weight: 1}
+	if len(ms) != 1 || ms[0] != expected {
+		t.Errorf("Expected %+v, got %s", expected, b)
+	}
+
+	release(h)
+	if _, err := findJSON(h, "PHP"); !errors.Is(err, errInvalidHandle) {
+		t.Errorf("Expected errInvalidHandle after release, got %v", err)
+	}
+}
+
+// match is a match in the JSON returned by fe_find.
+type match struct {
+	Group      string  `json:"group"`
+	Text       string  `json:"text"`
+	Canonical  string  `json:"canonical"`
+	Offset     int     `json:"offset"`
+	ByteOffset int     `json:"byte_offset"`
+	Kind       string  `json:"kind"`
+	Score      float64 `json:"score"`
+	Weight     float64 `json:"weight"`
+}
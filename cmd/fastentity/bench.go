@@ -0,0 +1,125 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"time"
+)
+
+// benchResult is the outcome of searching a corpus.
+type benchResult struct {
+	docs, bytes, matches int
+	elapsed              time.Duration
+	allocs, allocBytes   uint64
+}
+
+func runBench(args []string, _ io.Reader, stdout io.Writer) error {
+	flags := flag.NewFlagSet("bench", flag.ContinueOnError)
+	flags.Usage = func() {
+		fmt.Fprintf(flags.Output(), "Usage: fastentity bench [flags] corpus ...\n\n"+
+			"Searches each document of the corpus, given as files or directories of files,\n"+
+			"and reports the throughput and allocations of FindAll, and the time spent\n"+
+			"searching each group on its own.\n\n")
+		flags.PrintDefaults()
+	}
+	var dict dictFlags
+	dict.register(flags)
+	rounds := flags.Int("n", 1, "search the corpus `n` times")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return errors.New("no corpus given")
+	}
+
+	store, err := dict.load()
+	if err != nil {
+		return err
+	}
+	docs, err := readCorpus(flags.Args())
+	if err != nil {
+		return err
+	}
+	if len(docs) == 0 {
+		return errors.New("corpus is empty")
+	}
+
+	r := bench(docs, *rounds, func(doc []rune) int {
+		n := 0
+		for _, ents := range store.FindAll(doc) {
+			n += len(ents)
+		}
+		return n
+	})
+	fmt.Fprintf(stdout, "%d documents, %.1f MB, %d matches in %v\n", r.docs, float64(r.bytes)/1e6, r.matches, r.elapsed)
+	fmt.Fprintf(stdout, "%.2f MB/s, %.1f docs/s, %d allocs/doc, %d bytes allocated/doc\n\n",
+		float64(r.bytes)/1e6/r.elapsed.Seconds(), float64(r.docs)/r.elapsed.Seconds(),
+		r.allocs/uint64(r.docs), r.allocBytes/uint64(r.docs))
+
+	stats := store.Stats()
+	names := make([]string, 0, len(stats))
+	for name := range stats {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(stdout, "%-20s %10s %10s %12s %10s\n", "group", "entities", "matches", "time", "MB/s")
+	for _, name := range names {
+		g := store.Group(name)
+		r := bench(docs, *rounds, func(doc []rune) int {
+			return len(g.Find(doc))
+		})
+		fmt.Fprintf(stdout, "%-20s %10d %10d %12v %10.2f\n", name, stats[name].Entities, r.matches, r.elapsed, float64(r.bytes)/1e6/r.elapsed.Seconds())
+	}
+	return nil
+}
+
+// bench calls find for each document, rounds times, returning the totals.
+func bench(docs [][]rune, rounds int, find func(doc []rune) int) benchResult {
+	var r benchResult
+	var before, after runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+	start := time.Now()
+	for i := 0; i < rounds; i++ {
+		for _, doc := range docs {
+			r.matches += find(doc)
+			r.docs++
+			r.bytes += len(string(doc))
+		}
+	}
+	r.elapsed = time.Since(start)
+	runtime.ReadMemStats(&after)
+	r.allocs = after.Mallocs - before.Mallocs
+	r.allocBytes = after.TotalAlloc - before.TotalAlloc
+	return r
+}
+
+// readCorpus reads each file given, and the files in each directory given.
+func readCorpus(paths []string) ([][]rune, error) {
+	var docs [][]rune
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return err
+			}
+			b, err := ioutil.ReadFile(p)
+			if err != nil {
+				return err
+			}
+			docs = append(docs, []rune(string(b)))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return docs, nil
+}
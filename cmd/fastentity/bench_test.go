@@ -0,0 +1,40 @@
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestBench(t *testing.T) {
+	dir := writeDict(t, map[string]string{
+		"skills":    "PHP\ngolang\n",
+		"locations": "Sydney\n",
+	})
+	defer os.RemoveAll(dir)
+	corpus := filepath.Join(dir, "corpus")
+	if err := os.Mkdir(corpus, 0755); err != nil {
+		t.Fatal(err)
+	}
+	for i, doc := range []string{"Maybe PHP, or golang. ", "From Sydney, with PHP. "} {
+		if err := ioutil.WriteFile(filepath.Join(corpus, string(rune('a'+i))+".txt"), []byte(doc), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var out bytes.Buffer
+	if err := runBench([]string{"-dir", dir, "-n", "3", corpus}, nil, &out); err != nil {
+		t.Fatalf("Failed to bench: %v", err)
+	}
+	if !strings.HasPrefix(out.String(), "6 documents, 0.0 MB, 12 matches in ") {
+		t.Errorf("Unexpected totals:\n%s", out.String())
+	}
+	for _, line := range []string{"MB/s", "\nlocations                     1          3 ", "\nskills                        2          9 "} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Expected %q in:\n%s", line, out.String())
+		}
+	}
+}
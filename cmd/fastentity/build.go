@@ -0,0 +1,99 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/sajari/fastentity"
+)
+
+func runBuild(args []string, _ io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("build", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: fastentity build [flags] -dir directory -o file\n\n"+
+			"Compiles a directory of entity files into a snapshot, printing a report of the\n"+
+			"entities in each group and any problems found. Lines which aren't valid UTF-8\n"+
+			"or are longer than -max-len fail the build unless -skip-invalid is set.\n\n")
+		fs.PrintDefaults()
+	}
+	dir := fs.String("dir", "", "load dictionaries from the entity files in `directory`")
+	out := fs.String("o", "", "write the snapshot to `file`")
+	compress := fs.Bool("gzip", false, "compress the snapshot with gzip")
+	compressZstd := fs.Bool("zstd", false, "compress the snapshot with zstd")
+	weights := fs.Bool("weights", false, "read weights from the entity files, see fastentity.Weights")
+	maxLen := fs.Int("max-len", fastentity.MaxEntityLen, "maximum entity length in runes")
+	skipInvalid := fs.Bool("skip-invalid", false, "leave out invalid lines rather than failing")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *dir == "" || *out == "" {
+		fs.Usage()
+		return errors.New("-dir and -o must be set")
+	}
+
+	fastentity.MaxEntityLen = *maxLen
+	opts := []fastentity.LoadOption{fastentity.SkipErrors()}
+	if *weights {
+		opts = append(opts, fastentity.Weights())
+	}
+	store, report, err := fastentity.LoadDir(*dir, opts...)
+	if err != nil {
+		return err
+	}
+	skipped := printBuildReport(stdout, report)
+	if failed := report.Failed(); len(failed) > 0 {
+		return fmt.Errorf("%d files couldn't be loaded", len(failed))
+	}
+	if skipped > 0 && !*skipInvalid {
+		return fmt.Errorf("%d invalid lines", skipped)
+	}
+
+	var sopts []fastentity.SnapshotOption
+	switch {
+	case *compress && *compressZstd:
+		return errors.New("only one of -gzip and -zstd can be set")
+	case *compress:
+		sopts = append(sopts, fastentity.Compress(fastentity.Gzip))
+	case *compressZstd:
+		sopts = append(sopts, fastentity.Compress(fastentity.Zstd))
+	}
+	if err := store.SaveSnapshot(*out, sopts...); err != nil {
+		return err
+	}
+	fmt.Fprintf(stdout, "wrote %s (version %d)\n", *out, store.Version())
+	return nil
+}
+
+// printBuildReport writes the entities loaded into each group, and the problems found,
+// returning the number of lines skipped.
+func printBuildReport(w io.Writer, r *fastentity.LoadReport) int {
+	names := make([]string, 0, len(r.Entities))
+	for name := range r.Entities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "%-20s %10d entities\n", name, r.Entities[name])
+	}
+
+	skipped := 0
+	for _, f := range r.Files {
+		if f.Err != nil {
+			fmt.Fprintf(w, "error: %v\n", f.Err)
+		}
+		for _, l := range f.Skipped {
+			fmt.Fprintf(w, "invalid: %s:%d: %v\n", f.Path, l.Line, l.Reason)
+			skipped++
+		}
+	}
+	for _, d := range r.Duplicates {
+		fmt.Fprintf(w, "duplicate: %q in %s (%d times)\n", string(d.Entity), d.Group, len(d.Sources))
+	}
+	for _, c := range r.Conflicts {
+		fmt.Fprintf(w, "conflict: %q in %v\n", string(c.Entity), c.Groups)
+	}
+	return skipped
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/sajari/fastentity"
+)
+
+func TestBuild(t *testing.T) {
+	dir := writeDict(t, map[string]string{
+		"skills":    "PHP\ngolang\nphp\n",
+		"locations": "Sydney\n" + strings.Repeat("a", 40) + "\n",
+	})
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "dict.snap")
+
+	var report bytes.Buffer
+	if err := runBuild([]string{"-dir", dir, "-o", out}, nil, &report); err == nil {
+		t.Errorf("Expected the build to fail with an entity longer than MaxEntityLen")
+	}
+	if _, err := os.Stat(out); !os.IsNotExist(err) {
+		t.Errorf("Expected no snapshot to be written")
+	}
+
+	report.Reset()
+	if err := runBuild([]string{"-dir", dir, "-o", out, "-gzip", "-skip-invalid"}, nil, &report); err != nil {
+		t.Fatalf("Failed to build: %v", err)
+	}
+	for _, line := range []string{"skills                        2 entities", "invalid: ", `duplicate: "PHP" in skills (2 times)`} {
+		if !strings.Contains(report.String(), line) {
+			t.Errorf("Expected %q in the report:\n%s", line, report.String())
+		}
+	}
+
+	store, err := fastentity.LoadSnapshot(out)
+	if err != nil {
+		t.Fatalf("Failed to load snapshot: %v", err)
+	}
+	if n := store.Group("locations").Len(); n != 1 {
+		t.Errorf("Expected 1 location, got %d", n)
+	}
+}
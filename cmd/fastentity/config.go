@@ -0,0 +1,294 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sajari/fastentity"
+	"github.com/sajari/fastentity/server"
+	"gopkg.in/yaml.v3"
+)
+
+// serveConfig is the configuration of the serve command. It is read from a JSON or YAML
+// file given with -config, then overridden by FASTENTITY_* environment variables, and finally by any
+// flags set on the command line.
+type serveConfig struct {
+	Dir         string   `json:"dir" yaml:"dir"`
+	Snapshot    string   `json:"snapshot" yaml:"snapshot"`
+	Host        string   `json:"host" yaml:"host"`
+	Port        int      `json:"port" yaml:"port"`
+	GRPCPort    int      `json:"grpc_port" yaml:"grpc_port"`
+	Concurrency int      `json:"concurrency" yaml:"concurrency"`
+	MaxBody     int64    `json:"max_body" yaml:"max_body"`
+	Timeout     duration `json:"timeout" yaml:"timeout"`
+	Reload      duration `json:"reload" yaml:"reload"`
+	UI          bool     `json:"ui" yaml:"ui"`
+
+	// APIKeys, if set, are the keys accepted in the X-API-Key header of requests.
+	APIKeys []string `json:"api_keys" yaml:"api_keys"`
+	// TLSCert and TLSKey are the files of the certificate and key to serve HTTPS with.
+	// ClientCA is a file of CA certificates, one of which must have signed the
+	// certificates of clients.
+	TLSCert  string `json:"tls_cert" yaml:"tls_cert"`
+	TLSKey   string `json:"tls_key" yaml:"tls_key"`
+	ClientCA string `json:"client_ca" yaml:"client_ca"`
+
+	// Groups configures how the entities of each group are matched, by group name.
+	Groups map[string]groupConfig `json:"groups" yaml:"groups"`
+}
+
+// groupConfig configures a group, see the GroupOption of the same name for each field.
+// Profile is the language of the normalization profile to use, see fastentity.Profiles.
+type groupConfig struct {
+	Profile       string            `json:"profile" yaml:"profile"`
+	FoldPlurals   bool              `json:"fold_plurals" yaml:"fold_plurals"`
+	Abbreviations map[string]string `json:"abbreviations" yaml:"abbreviations"`
+	Phonetic      bool              `json:"phonetic" yaml:"phonetic"`
+	Acronyms      bool              `json:"acronyms" yaml:"acronyms"`
+	Synonyms      [][]string        `json:"synonyms" yaml:"synonyms"`
+
+	NumericVariants bool `json:"numeric_variants" yaml:"numeric_variants"`
+
+	MinMatchLength      int `json:"min_match_length" yaml:"min_match_length"`
+	ExactCase           int `json:"exact_case" yaml:"exact_case"`
+	MaxMatchesPerEntity int `json:"max_matches_per_entity" yaml:"max_matches_per_entity"`
+}
+
+func (g groupConfig) options() []fastentity.GroupOption {
+	var opts []fastentity.GroupOption
+	if profile, ok := fastentity.Profiles[g.Profile]; ok {
+		opts = append(opts, profile())
+	}
+	if g.FoldPlurals {
+		opts = append(opts, fastentity.FoldPlurals())
+	}
+	if len(g.Abbreviations) > 0 {
+		opts = append(opts, fastentity.Abbreviations(g.Abbreviations))
+	}
+	if g.Phonetic {
+		opts = append(opts, fastentity.Phonetic())
+	}
+	if g.Acronyms {
+		opts = append(opts, fastentity.Acronyms())
+	}
+	if len(g.Synonyms) > 0 {
+		opts = append(opts, fastentity.Synonyms(g.Synonyms...))
+	}
+	if g.NumericVariants {
+		opts = append(opts, fastentity.NumericVariants())
+	}
+	if g.MinMatchLength > 0 {
+		opts = append(opts, fastentity.MinMatchLength(g.MinMatchLength))
+	}
+	if g.ExactCase > 0 {
+		opts = append(opts, fastentity.ExactCase(g.ExactCase))
+	}
+	if g.MaxMatchesPerEntity > 0 {
+		opts = append(opts, fastentity.MaxMatchesPerEntity(g.MaxMatchesPerEntity))
+	}
+	return opts
+}
+
+// duration is a time.Duration written as a string in JSON and YAML, e.g. "5m".
+type duration time.Duration
+
+func (d *duration) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5m\": %w", err)
+	}
+	return d.parse(s)
+}
+
+func (d *duration) UnmarshalYAML(value *yaml.Node) error {
+	var s string
+	if err := value.Decode(&s); err != nil {
+		return fmt.Errorf("duration must be a string such as \"5m\": %w", err)
+	}
+	return d.parse(s)
+}
+
+func (d *duration) parse(s string) error {
+	v, err := time.ParseDuration(s)
+	if err != nil {
+		return err
+	}
+	*d = duration(v)
+	return nil
+}
+
+func defaultServeConfig() serveConfig {
+	return serveConfig{
+		Port:    8080,
+		MaxBody: server.DefaultMaxBodySize,
+	}
+}
+
+// readFile reads the configuration from the file at path, which is YAML if its extension
+// is .yaml or .yml and JSON otherwise. Fields missing from the file are left unchanged,
+// and unknown fields are an error.
+func (c *serveConfig) readFile(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(f)
+		dec.KnownFields(true)
+		err = dec.Decode(c)
+		if err == io.EOF {
+			err = nil // An empty file
+		}
+	default:
+		dec := json.NewDecoder(f)
+		dec.DisallowUnknownFields()
+		err = dec.Decode(c)
+	}
+	if err != nil {
+		return fmt.Errorf("%v: %w", path, err)
+	}
+	return nil
+}
+
+// envPrefix prefixes the names of the environment variables read by readEnv.
+const envPrefix = "FASTENTITY_"
+
+// readEnv overrides the configuration with the environment variables named after its
+// fields, e.g. FASTENTITY_MAX_BODY, looked up with lookup. Setting the dictionary
+// directory or snapshot replaces either from the file. FASTENTITY_API_KEYS is a comma
+// separated list.
+func (c *serveConfig) readEnv(lookup func(key string) (string, bool)) error {
+	vars := []struct {
+		name string
+		set  func(v string) error
+	}{
+		{"DIR", func(v string) error { c.Dir, c.Snapshot = v, ""; return nil }},
+		{"SNAPSHOT", func(v string) error { c.Snapshot, c.Dir = v, ""; return nil }},
+		{"HOST", func(v string) error { c.Host = v; return nil }},
+		{"PORT", func(v string) (err error) { c.Port, err = strconv.Atoi(v); return err }},
+		{"GRPC_PORT", func(v string) (err error) { c.GRPCPort, err = strconv.Atoi(v); return err }},
+		{"CONCURRENCY", func(v string) (err error) { c.Concurrency, err = strconv.Atoi(v); return err }},
+		{"MAX_BODY", func(v string) (err error) { c.MaxBody, err = strconv.ParseInt(v, 10, 64); return err }},
+		{"TIMEOUT", func(v string) error {
+			d, err := time.ParseDuration(v)
+			c.Timeout = duration(d)
+			return err
+		}},
+		{"RELOAD", func(v string) error {
+			d, err := time.ParseDuration(v)
+			c.Reload = duration(d)
+			return err
+		}},
+		{"UI", func(v string) (err error) { c.UI, err = strconv.ParseBool(v); return err }},
+		{"API_KEYS", func(v string) error { c.APIKeys = strings.Split(v, ","); return nil }},
+		{"TLS_CERT", func(v string) error { c.TLSCert = v; return nil }},
+		{"TLS_KEY", func(v string) error { c.TLSKey = v; return nil }},
+		{"CLIENT_CA", func(v string) error { c.ClientCA = v; return nil }},
+	}
+	_, dir := lookup(envPrefix + "DIR")
+	_, snapshot := lookup(envPrefix + "SNAPSHOT")
+	if dir && snapshot {
+		return fmt.Errorf("only one of %sDIR and %sSNAPSHOT can be set", envPrefix, envPrefix)
+	}
+	for _, ev := range vars {
+		v, ok := lookup(envPrefix + ev.name)
+		if !ok {
+			continue
+		}
+		if err := ev.set(v); err != nil {
+			return fmt.Errorf("%s%s: invalid value %q", envPrefix, ev.name, v)
+		}
+	}
+	return nil
+}
+
+// validate checks the configuration is complete and consistent.
+func (c *serveConfig) validate() error {
+	var errs []string
+	switch {
+	case c.Dir != "" && c.Snapshot != "":
+		errs = append(errs, "only one of dir and snapshot can be set")
+	case c.Dir == "" && c.Snapshot == "":
+		errs = append(errs, "one of dir or snapshot must be set")
+	}
+	if c.Port < 1 || c.Port > 65535 {
+		errs = append(errs, fmt.Sprintf("port %d is out of range", c.Port))
+	}
+	if c.GRPCPort < 0 || c.GRPCPort > 65535 {
+		errs = append(errs, fmt.Sprintf("grpc_port %d is out of range", c.GRPCPort))
+	} else if c.GRPCPort == c.Port {
+		errs = append(errs, "grpc_port must differ from port")
+	}
+	if c.Concurrency < 0 {
+		errs = append(errs, "concurrency can't be negative")
+	}
+	if c.MaxBody <= 0 {
+		errs = append(errs, "max_body must be positive")
+	}
+	if c.Timeout < 0 {
+		errs = append(errs, "timeout can't be negative")
+	}
+	if c.Reload < 0 {
+		errs = append(errs, "reload can't be negative")
+	}
+	for _, key := range c.APIKeys {
+		if key == "" {
+			errs = append(errs, "api_keys can't be empty")
+			break
+		}
+	}
+	for name, g := range c.Groups {
+		if _, ok := fastentity.Profiles[g.Profile]; g.Profile != "" && !ok {
+			errs = append(errs, fmt.Sprintf("group %q has unknown profile %q", name, g.Profile))
+		}
+		if g.MinMatchLength < 0 || g.ExactCase < 0 || g.MaxMatchesPerEntity < 0 {
+			errs = append(errs, fmt.Sprintf("group %q has negative limits", name))
+		}
+	}
+	if (c.TLSCert == "") != (c.TLSKey == "") {
+		errs = append(errs, "tls_cert and tls_key must be set together")
+	}
+	if c.ClientCA != "" && c.TLSCert == "" {
+		errs = append(errs, "client_ca requires tls_cert and tls_key")
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	msg := "invalid configuration:"
+	for _, e := range errs {
+		msg += "\n\t" + e
+	}
+	return errors.New(msg)
+}
+
+// tlsConfig returns the TLS configuration of the server, requiring client certificates
+// signed by ClientCA if it is set.
+func (c *serveConfig) tlsConfig() (*tls.Config, error) {
+	cfg := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.ClientCA == "" {
+		return cfg, nil
+	}
+	pem, err := ioutil.ReadFile(c.ClientCA)
+	if err != nil {
+		return nil, fmt.Errorf("reading client CA: %w", err)
+	}
+	cfg.ClientCAs = x509.NewCertPool()
+	if !cfg.ClientCAs.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("%v: no certificates found", c.ClientCA)
+	}
+	cfg.ClientAuth = tls.RequireAndVerifyClientCert
+	return cfg, nil
+}
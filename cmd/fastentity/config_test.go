@@ -0,0 +1,140 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sajari/fastentity"
+)
+
+func TestServeConfig(t *testing.T) {
+	dir := writeDict(t, map[string]string{"jobTitles": "tax accountant\n"})
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	config := `{
+		"dir": "dictionaries",
+		"port": 9000,
+		"reload": "5m",
+		"groups": {"jobTitles": {"fold_plurals": true}}
+	}`
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	cfg := defaultServeConfig()
+	if err := cfg.readFile(path); err != nil {
+		t.Fatal(err)
+	}
+	env := map[string]string{"FASTENTITY_DIR": dir, "FASTENTITY_CONCURRENCY": "4"}
+	lookup := func(key string) (string, bool) {
+		v, ok := env[key]
+		return v, ok
+	}
+	if err := cfg.readEnv(lookup); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Dir != dir || cfg.Port != 9000 || cfg.Concurrency != 4 || time.Duration(cfg.Reload) != 5*time.Minute {
+		t.Errorf("Expected settings from the file and environment, got %+v", cfg)
+	}
+
+	dict := dictFlags{dir: cfg.Dir, groups: map[string][]fastentity.GroupOption{
+		"jobTitles": cfg.Groups["jobTitles"].options(),
+	}}
+	store, err := dict.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ents := store.FindAll([]rune("Tax accountants wanted")); len(ents["jobTitles"]) != 1 {
+		t.Errorf("Expected the group to be configured, got %v", ents)
+	}
+
+	dict.groups["missing"] = nil
+	if _, err := dict.load(); err == nil {
+		t.Error("Expected an error configuring a missing group")
+	}
+}
+
+func TestServeConfigYAML(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.yaml")
+	config := `
+dir: dictionaries
+timeout: 2s
+api_keys: [k1, k2]
+groups:
+  skills:
+    min_match_length: 2
+    exact_case: 3
+    max_matches_per_entity: 1
+`
+	if err := ioutil.WriteFile(path, []byte(config), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cfg := defaultServeConfig()
+	if err := cfg.readFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if err := cfg.validate(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Dir != "dictionaries" || cfg.Port != 8080 || time.Duration(cfg.Timeout) != 2*time.Second || len(cfg.APIKeys) != 2 {
+		t.Errorf("Expected settings from the file, got %+v", cfg)
+	}
+
+	store := fastentity.New()
+	store.Add("skills", []rune("C"), []rune("IT"), []rune("Go"))
+	store.Group("skills").Configure(cfg.Groups["skills"].options()...)
+	ents := store.FindAll([]rune("C and it, Go and IT, Go again"))
+	if got := fmt.Sprint(ents["skills"]); len(ents["skills"]) != 2 {
+		t.Errorf("Expected the group limits to be configured, got %s", got)
+	}
+
+	ioutil.WriteFile(path, []byte("dir: d\nmax_bdoy: 10\n"), 0644)
+	if err := cfg.readFile(path); err == nil || !strings.Contains(err.Error(), "max_bdoy") {
+		t.Errorf("Expected an error for the unknown field, got %v", err)
+	}
+}
+
+func TestServeConfigErrors(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "config.json")
+	ioutil.WriteFile(path, []byte(`{"dir": "d", "max_bdoy": 10}`), 0644)
+	cfg := defaultServeConfig()
+	if err := cfg.readFile(path); err == nil || !strings.Contains(err.Error(), "max_bdoy") {
+		t.Errorf("Expected an error for the unknown field, got %v", err)
+	}
+
+	lookup := func(key string) (string, bool) {
+		return "lots", key == "FASTENTITY_PORT"
+	}
+	if err := cfg.readEnv(lookup); err == nil || !strings.Contains(err.Error(), "FASTENTITY_PORT") {
+		t.Errorf("Expected an error naming the variable, got %v", err)
+	}
+
+	cfg = serveConfig{Port: 70000, Concurrency: -1, TLSKey: "key.pem", ClientCA: "ca.pem", Groups: map[string]groupConfig{"g": {Profile: "xx"}}}
+	err = cfg.validate()
+	for _, msg := range []string{"one of dir or snapshot", "port 70000", "concurrency", "max_body", "tls_cert and tls_key", "client_ca", `unknown profile "xx"`} {
+		if err == nil || !strings.Contains(err.Error(), msg) {
+			t.Errorf("Expected an error containing %q, got %v", msg, err)
+		}
+	}
+}
@@ -0,0 +1,117 @@
+// Command fastentity loads dictionaries of entities and finds them in documents.
+//
+// Usage:
+//
+//	fastentity <command> [flags] [args]
+//
+// The commands are:
+//
+//	match    find entities in documents, writing matches as JSON Lines
+//	build    compile a directory of entity files into a snapshot
+//	serve    serve the HTTP matching API
+//	bench    measure the throughput of the matcher on a corpus
+//
+// Dictionaries are loaded from a directory of entity files with -dir, or a snapshot with
+// -snapshot. Run "fastentity <command> -h" for the flags of each command.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/sajari/fastentity"
+)
+
+// command is a subcommand, run with its arguments.
+type command struct {
+	name  string
+	usage string
+	run   func(args []string, stdin io.Reader, stdout io.Writer) error
+}
+
+var commands = []command{
+	{"match", "find entities in documents, writing matches as JSON Lines", runMatch},
+	{"build", "compile a directory of entity files into a snapshot", runBuild},
+	{"serve", "serve the HTTP matching API", runServe},
+	{"bench", "measure the throughput of the matcher on a corpus", runBench},
+}
+
+func main() {
+	if len(os.Args) < 2 {
+		usage(os.Stderr)
+		os.Exit(2)
+	}
+	name := os.Args[1]
+	for _, c := range commands {
+		if c.name != name {
+			continue
+		}
+		if err := c.run(os.Args[2:], os.Stdin, os.Stdout); err != nil {
+			if errors.Is(err, flag.ErrHelp) {
+				os.Exit(2)
+			}
+			fmt.Fprintf(os.Stderr, "fastentity %s: %v\n", name, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if name == "help" || name == "-h" || name == "-help" {
+		usage(os.Stdout)
+		return
+	}
+	fmt.Fprintf(os.Stderr, "fastentity: unknown command %q\n", name)
+	usage(os.Stderr)
+	os.Exit(2)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintf(w, "Usage: fastentity <command> [flags] [args]\n\nCommands:\n")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-8s %s\n", c.name, c.usage)
+	}
+}
+
+// dictFlags are the flags selecting the dictionaries to load.
+type dictFlags struct {
+	dir      string
+	snapshot string
+
+	// groups configures groups of the dictionaries by name once they are loaded.
+	groups map[string][]fastentity.GroupOption
+}
+
+func (d *dictFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&d.dir, "dir", "", "load dictionaries from the entity files in `directory`")
+	fs.StringVar(&d.snapshot, "snapshot", "", "load dictionaries from the snapshot `file`")
+}
+
+// load loads the store selected by the flags.
+func (d *dictFlags) load() (*fastentity.Store, error) {
+	var store *fastentity.Store
+	var err error
+	switch {
+	case d.dir != "" && d.snapshot != "":
+		return nil, errors.New("only one of -dir and -snapshot can be set")
+	case d.dir != "":
+		store, err = fastentity.FromDir(d.dir)
+	case d.snapshot != "":
+		store, err = fastentity.LoadSnapshot(d.snapshot)
+	default:
+		return nil, errors.New("one of -dir or -snapshot must be set")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for name, opts := range d.groups {
+		g, err := store.LookupGroup(name)
+		if err != nil {
+			return nil, fmt.Errorf("configuring group: %w", err)
+		}
+		g.Configure(opts...)
+	}
+	return store, nil
+}
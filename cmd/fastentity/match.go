@@ -0,0 +1,128 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/sajari/fastentity"
+)
+
+// matchRecord is a line of the output of the match command.
+type matchRecord struct {
+	Source     string  `json:"source"`
+	Line       int     `json:"line,omitempty"`
+	Group      string  `json:"group"`
+	Text       string  `json:"text"`
+	Canonical  string  `json:"canonical"`
+	Offset     int     `json:"offset"`
+	ByteOffset int     `json:"byte_offset"`
+	Kind       string  `json:"kind"`
+	Score      float64 `json:"score"`
+	Weight     float64 `json:"weight"`
+}
+
+func runMatch(args []string, stdin io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("match", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: fastentity match [flags] [file or glob ...]\n\n"+
+			"Finds entities in each file, or stdin if none are given, writing a JSON object\n"+
+			"per match. Offsets count runes, and byte_offset bytes, from the start of the\n"+
+			"document.\n\n")
+		fs.PrintDefaults()
+	}
+	var dict dictFlags
+	dict.register(fs)
+	lines := fs.Bool("lines", false, "treat each line of input as a separate document")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	store, err := dict.load()
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(stdout)
+	enc := json.NewEncoder(w)
+	if fs.NArg() == 0 {
+		if err := matchReader(store, enc, "-", stdin, *lines); err != nil {
+			return err
+		}
+		return w.Flush()
+	}
+	for _, pattern := range fs.Args() {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			return err
+		}
+		if len(paths) == 0 {
+			return fmt.Errorf("no files match %s", pattern)
+		}
+		for _, path := range paths {
+			if err := matchFile(store, enc, path, *lines); err != nil {
+				return err
+			}
+		}
+	}
+	return w.Flush()
+}
+
+func matchFile(store *fastentity.Store, enc *json.Encoder, path string, lines bool) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return matchReader(store, enc, path, f, lines)
+}
+
+// matchReader writes the matches in the document read from r, or in each of its lines.
+func matchReader(store *fastentity.Store, enc *json.Encoder, source string, r io.Reader, lines bool) error {
+	if !lines {
+		b, err := ioutil.ReadAll(r)
+		if err != nil {
+			return fmt.Errorf("reading %s: %w", source, err)
+		}
+		return writeMatches(store, enc, source, 0, string(b))
+	}
+
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 64<<20)
+	for n := 1; s.Scan(); n++ {
+		if err := writeMatches(store, enc, source, n, s.Text()); err != nil {
+			return err
+		}
+	}
+	if err := s.Err(); err != nil {
+		return fmt.Errorf("reading %s: %w", source, err)
+	}
+	return nil
+}
+
+func writeMatches(store *fastentity.Store, enc *json.Encoder, source string, line int, doc string) error {
+	rs := []rune(doc)
+	for _, m := range store.FindAll(rs).MatchesIn(rs) {
+		err := enc.Encode(matchRecord{
+			Source:     source,
+			Line:       line,
+			Group:      m.Group,
+			Text:       string(m.Text),
+			Canonical:  string(m.Canonical),
+			Offset:     m.Offset,
+			ByteOffset: m.ByteOffset,
+			Kind:       m.Kind.String(),
+			Score:      m.Score,
+			Weight:     m.Weight,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
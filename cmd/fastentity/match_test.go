@@ -0,0 +1,69 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeDict writes entity files to a temporary directory, returning its path.
+func writeDict(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".entities.csv"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestMatch(t *testing.T) {
+	dir := writeDict(t, map[string]string{
+		"skills":    "PHP\n本語\n",
+		"locations": "Sydney\n",
+	})
+	defer os.RemoveAll(dir)
+
+	var out bytes.Buffer
+	in := strings.NewReader("日 本語 in Sydney.\nMaybe PHP, or PDX.\n")
+	if err := runMatch([]string{"-dir", dir, "-lines"}, in, &out); err != nil {
+		t.Fatalf("Failed to match: %v", err)
+	}
+
+	var got []matchRecord
+	dec := json.NewDecoder(&out)
+	for dec.More() {
+		var r matchRecord
+		if err := dec.Decode(&r); err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, r)
+	}
+	expected := []matchRecord{
+		{Source: "-", Line: 1, Group: "skills", Text: "本語", Canonical: "本語", Offset: 2, ByteOffset: 4, Kind: "text", Score: 1, Weight: 1},
+		{Source: "-", Line: 1, Group: "locations", Text: "Sydney", Canonical: "Sydney", Offset: 8, ByteOffset: 14, Kind: "text", Score: 1, Weight: 1},
+		{Source: "-", Line: 2, Group: "skills", Text: "PHP", Canonical: "PHP", Offset: 6, ByteOffset: 6, Kind: "text", Score: 1, Weight: 1},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d matches, got %+v", len(expected), got)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("Expected match %d to be %+v, got %+v", i, expected[i], got[i])
+		}
+	}
+
+	if err := runMatch(nil, in, &out); err == nil {
+		t.Errorf("Expected an error without dictionaries")
+	}
+	if err := runMatch([]string{"-dir", dir, filepath.Join(dir, "*.missing")}, in, &out); err == nil {
+		t.Errorf("Expected an error for a glob matching no files")
+	}
+}
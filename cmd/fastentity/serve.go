@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/sajari/fastentity"
+	"github.com/sajari/fastentity/server"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+func runServe(args []string, _ io.Reader, stdout io.Writer) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: fastentity serve [flags]\n\n"+
+			"Serves the HTTP API of the server package for the dictionaries, and its gRPC\n"+
+			"API if -grpc-port is set, until interrupted.\n\n"+
+			"Settings are read from the JSON or YAML file given with -config or\n"+
+			"FASTENTITY_CONFIG, YAML if it is named *.yaml or *.yml,\n"+
+			"then from the environment variables FASTENTITY_<FLAG>, e.g.\n"+
+			"FASTENTITY_MAX_BODY, then from the flags. Groups can only be configured in\n"+
+			"the file, and API keys in the file or FASTENTITY_API_KEYS.\n\n")
+		fs.PrintDefaults()
+	}
+	var dict dictFlags
+	dict.register(fs)
+	config := fs.String("config", "", "read settings from the JSON or YAML `file`")
+	def := defaultServeConfig()
+	host := fs.String("host", def.Host, "`host` to listen on, all interfaces if empty")
+	port := fs.Int("port", def.Port, "`port` to listen on")
+	grpcPort := fs.Int("grpc-port", def.GRPCPort, "`port` to serve the gRPC API on, none if 0")
+	concurrency := fs.Int("concurrency", def.Concurrency, "maximum number of documents searched at once, unlimited if 0")
+	maxBody := fs.Int64("max-body", def.MaxBody, "maximum request body size in `bytes`")
+	timeout := fs.Duration("timeout", time.Duration(def.Timeout), "maximum `time` spent searching each document, unlimited if 0")
+	reload := fs.Duration("reload", time.Duration(def.Reload), "reload the dictionaries every `interval`, never if 0")
+	ui := fs.Bool("ui", def.UI, "serve a page at / for trying out the dictionaries")
+	tlsCert := fs.String("tls-cert", def.TLSCert, "serve HTTPS with the certificate in `file`")
+	tlsKey := fs.String("tls-key", def.TLSKey, "serve HTTPS with the key in `file`")
+	clientCA := fs.String("client-ca", def.ClientCA, "require client certificates signed by a CA in `file`")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if dict.dir != "" && dict.snapshot != "" {
+		return errors.New("only one of -dir and -snapshot can be set")
+	}
+	if *config == "" {
+		*config = os.Getenv(envPrefix + "CONFIG")
+	}
+	cfg := def
+	if *config != "" {
+		if err := cfg.readFile(*config); err != nil {
+			return fmt.Errorf("reading config: %w", err)
+		}
+	}
+	if err := cfg.readEnv(os.LookupEnv); err != nil {
+		return err
+	}
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "dir":
+			cfg.Dir, cfg.Snapshot = dict.dir, ""
+		case "snapshot":
+			cfg.Snapshot, cfg.Dir = dict.snapshot, ""
+		case "host":
+			cfg.Host = *host
+		case "port":
+			cfg.Port = *port
+		case "grpc-port":
+			cfg.GRPCPort = *grpcPort
+		case "concurrency":
+			cfg.Concurrency = *concurrency
+		case "max-body":
+			cfg.MaxBody = *maxBody
+		case "timeout":
+			cfg.Timeout = duration(*timeout)
+		case "reload":
+			cfg.Reload = duration(*reload)
+		case "ui":
+			cfg.UI = *ui
+		case "tls-cert":
+			cfg.TLSCert = *tlsCert
+		case "tls-key":
+			cfg.TLSKey = *tlsKey
+		case "client-ca":
+			cfg.ClientCA = *clientCA
+		}
+	})
+	if err := cfg.validate(); err != nil {
+		return err
+	}
+
+	dict = dictFlags{dir: cfg.Dir, snapshot: cfg.Snapshot, groups: make(map[string][]fastentity.GroupOption)}
+	for name, g := range cfg.Groups {
+		dict.groups[name] = g.options()
+	}
+	store, err := dict.load()
+	if err != nil {
+		return err
+	}
+	opts := []server.Option{
+		server.MaxConcurrency(cfg.Concurrency),
+		server.MaxBodySize(cfg.MaxBody),
+		server.Timeout(time.Duration(cfg.Timeout)),
+	}
+	if cfg.UI {
+		opts = append(opts, server.UI())
+	}
+	if len(cfg.APIKeys) > 0 {
+		opts = append(opts, server.Authenticate(server.APIKey("X-API-Key", cfg.APIKeys...)))
+	}
+	tlsConfig, err := cfg.tlsConfig()
+	if err != nil {
+		return err
+	}
+	srv := server.New(store, opts...)
+	logger := log.New(stdout, "", log.LstdFlags)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if cfg.Reload > 0 {
+		go reloadLoop(ctx, srv, &dict, time.Duration(cfg.Reload), logger)
+	}
+
+	hs := &http.Server{
+		Addr:              net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Handler:           srv,
+		ReadHeaderTimeout: 10 * time.Second,
+		TLSConfig:         tlsConfig,
+	}
+	go func() {
+		<-ctx.Done()
+		shutdown, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		defer cancel()
+		hs.Shutdown(shutdown)
+	}()
+	if cfg.GRPCPort > 0 {
+		gs, err := grpcServer(srv, &cfg, tlsConfig)
+		if err != nil {
+			return err
+		}
+		lis, err := net.Listen("tcp", net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.GRPCPort)))
+		if err != nil {
+			return err
+		}
+		go func() {
+			<-ctx.Done()
+			gs.GracefulStop()
+		}()
+		go func() {
+			if err := gs.Serve(lis); err != nil {
+				logger.Printf("serving gRPC: %v", err)
+			}
+		}()
+		logger.Printf("serving gRPC on %s", lis.Addr())
+	}
+	logger.Printf("serving %d groups (version %d) on %s", len(store.Stats()), store.Version(), hs.Addr)
+	if cfg.TLSCert != "" {
+		err = hs.ListenAndServeTLS(cfg.TLSCert, cfg.TLSKey)
+	} else {
+		err = hs.ListenAndServe()
+	}
+	if err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// grpcServer returns the gRPC server for srv, serving TLS with the certificate of the
+// configuration if it has one.
+func grpcServer(srv *server.Server, cfg *serveConfig, tlsConfig *tls.Config) (*grpc.Server, error) {
+	if cfg.TLSCert == "" {
+		return srv.GRPCServer(), nil
+	}
+	cert, err := tls.LoadX509KeyPair(cfg.TLSCert, cfg.TLSKey)
+	if err != nil {
+		return nil, err
+	}
+	tlsConfig = tlsConfig.Clone()
+	tlsConfig.Certificates = []tls.Certificate{cert}
+	return srv.GRPCServer(grpc.Creds(credentials.NewTLS(tlsConfig))), nil
+}
+
+// reloadLoop reloads the dictionaries into srv every interval until ctx is done. If the
+// dictionaries can't be loaded the previous ones are kept.
+func reloadLoop(ctx context.Context, srv *server.Server, dict *dictFlags, interval time.Duration, logger *log.Logger) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+		store, err := dict.load()
+		if err != nil {
+			logger.Printf("reloading dictionaries: %v", err)
+			continue
+		}
+		srv.SetStore(store)
+		logger.Printf("reloaded %d groups (version %d)", len(store.Stats()), store.Version())
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sajari/fastentity/server"
+)
+
+func TestReloadLoop(t *testing.T) {
+	dir := writeDict(t, map[string]string{"skills": "PHP\n"})
+	defer os.RemoveAll(dir)
+
+	dict := &dictFlags{dir: dir}
+	store, err := dict.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := server.New(store)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go reloadLoop(ctx, srv, dict, time.Millisecond, log.New(ioutil.Discard, "", 0))
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "skills.entities.csv"), []byte("PHP\ngolang\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for srv.Store().Group("skills").Len() != 2 {
+		if time.Now().After(deadline) {
+			t.Fatalf("Expected the dictionaries to be reloaded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	// Keep the last dictionaries if they can't be loaded
+	os.Remove(filepath.Join(dir, "skills.entities.csv"))
+	time.Sleep(20 * time.Millisecond)
+	if _, err := srv.Store().LookupGroup("skills"); err != nil {
+		t.Errorf("Expected the previous dictionaries to be kept, got %v", err)
+	}
+}
@@ -0,0 +1,104 @@
+package fastentity
+
+import (
+	"sort"
+	"unicode"
+)
+
+// largestCollisions is the number of the largest buckets listed in an IndexReport.
+const largestCollisions = 10
+
+// IndexReport describes how the entities of a group are indexed for matching, for
+// understanding and tuning the performance of searches of its dictionary. Entities are
+// indexed in buckets by their length and first three runes, ignoring case, and text of a
+// document is compared with every entity in its bucket, so large buckets slow searches.
+type IndexReport struct {
+	Group    string
+	Entities int
+	Buckets  int
+	// MeanBucket and MaxBucket are the mean and largest numbers of entities in a bucket.
+	MeanBucket float64
+	MaxBucket  int
+	// Sizes is the number of buckets with each number of entities.
+	Sizes map[int]int
+	// Collisions lists the largest buckets with more than one entity, up to ten, largest
+	// first.
+	Collisions []Collision
+}
+
+// Collision is a bucket of entities indexed together, see IndexReport.
+type Collision struct {
+	// Prefix is the first runes of the entities in lower case, and Length their length
+	// in runes.
+	Prefix string
+	Length int
+	// Entities are the entities in the bucket, in order.
+	Entities []string
+}
+
+// IndexReport reports how the entities of the group identified by name are indexed, or
+// returns an error wrapping ErrGroupNotFound if there is no such group. Entities with a
+// common prefix and length, such as "Bank of Melbourne" and "Bank of Brisbane", collide
+// in the same bucket, and the Collisions of the report show which prefixes cause the
+// largest.
+func (s *Store) IndexReport(name string) (*IndexReport, error) {
+	g, err := s.existingGroup(name)
+	if err != nil {
+		return nil, err
+	}
+	g.rlock()
+	defer g.RUnlock()
+
+	r := &IndexReport{Group: name, Sizes: make(map[int]int)}
+	var collisions [][]entry
+	for _, ents := range g.entities {
+		if len(ents) == 0 {
+			continue
+		}
+		r.Entities += len(ents)
+		r.Buckets++
+		r.Sizes[len(ents)]++
+		if len(ents) > r.MaxBucket {
+			r.MaxBucket = len(ents)
+		}
+		if len(ents) > 1 {
+			collisions = append(collisions, ents)
+		}
+	}
+	if r.Buckets > 0 {
+		r.MeanBucket = float64(r.Entities) / float64(r.Buckets)
+	}
+
+	sort.Slice(collisions, func(i, j int) bool {
+		if len(collisions[i]) != len(collisions[j]) {
+			return len(collisions[i]) > len(collisions[j])
+		}
+		return string(collisions[i][0].text) < string(collisions[j][0].text)
+	})
+	if len(collisions) > largestCollisions {
+		collisions = collisions[:largestCollisions]
+	}
+	for _, ents := range collisions {
+		r.Collisions = append(r.Collisions, newCollision(ents))
+	}
+	return r, nil
+}
+
+// newCollision describes the bucket of entities ents.
+func newCollision(ents []entry) Collision {
+	text := ents[0].text
+	prefix := text
+	if len(prefix) > 3 {
+		prefix = prefix[:3]
+	}
+	lower := make([]rune, len(prefix))
+	for i, r := range prefix {
+		lower[i] = unicode.ToLower(r)
+	}
+	c := Collision{Prefix: string(lower), Length: len(text), Entities: make([]string, len(ents))}
+	for i, e := range ents {
+		c.Entities[i] = string(e.text)
+	}
+	sort.Strings(c.Entities)
+	return c
+}
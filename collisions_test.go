@@ -0,0 +1,34 @@
+package fastentity
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestIndexReport(t *testing.T) {
+	store := New()
+	store.Add("banks", []rune("Bank of Brisbane"), []rune("Bank of Adelaide"), []rune("bank of Tasmania"), []rune("Bankwest"))
+	store.Add("banks", []rune("Westpac"), []rune("WESTERN"), []rune("ANZ"))
+
+	if _, err := store.IndexReport("skills"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("Expected ErrGroupNotFound, got %v", err)
+	}
+	r, err := store.IndexReport("banks")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Group != "banks" || r.Entities != 7 || r.Buckets != 4 || r.MaxBucket != 3 || r.MeanBucket != 1.75 {
+		t.Errorf("Expected 7 entities in 4 buckets, got %+v", r)
+	}
+	if want := map[int]int{1: 2, 2: 1, 3: 1}; !reflect.DeepEqual(r.Sizes, want) {
+		t.Errorf("Expected bucket sizes %v, got %v", want, r.Sizes)
+	}
+	want := []Collision{
+		{Prefix: "ban", Length: 16, Entities: []string{"Bank of Adelaide", "Bank of Brisbane", "bank of Tasmania"}},
+		{Prefix: "wes", Length: 7, Entities: []string{"WESTERN", "Westpac"}},
+	}
+	if !reflect.DeepEqual(r.Collisions, want) {
+		t.Errorf("Expected collisions %+v, got %+v", want, r.Collisions)
+	}
+}
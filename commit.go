@@ -0,0 +1,66 @@
+package fastentity
+
+// BeginBatch starts a batch of additions to the store, which become visible to searches
+// all at once when Commit is called, so that a dictionary being refreshed while the store
+// is live is never searched half loaded. Until then, entities added by Add, AddWeighted,
+// AddAs, AddFromReader, Load and TypedGroup.Add go to copies of their groups, which
+// replace the groups at Commit. Calling BeginBatch again before Commit has no effect.
+//
+// Other changes to a group made after entities are added to it in the batch, such as
+// removing entities or configuring the group, are lost when the batch is committed.
+func (s *Store) BeginBatch() {
+	s.Lock()
+	if s.batch == nil {
+		s.batch = make(map[string]*group)
+	}
+	s.Unlock()
+}
+
+// Commit makes the entities added since BeginBatch visible to searches, replacing the
+// groups they were added to at once. It has no effect if no batch has begun.
+func (s *Store) Commit() {
+	s.Lock()
+	batch := s.batch
+	for name, g := range batch {
+		s.groups[name] = g
+	}
+	s.batch = nil
+	s.Unlock()
+	if len(batch) > 0 {
+		s.bump()
+	}
+}
+
+// addGroup returns the group identified by name to add entities to, which is the copy of
+// the group in the batch begun by BeginBatch, if any, or else the group itself, creating
+// either if it doesn't exist.
+func (s *Store) addGroup(name string) *group {
+	s.Lock()
+	if s.batch == nil {
+		s.Unlock()
+		return s.group(name)
+	}
+	g, ok := s.batch[name]
+	if !ok {
+		if live, ok := s.groups[name]; ok {
+			live.RLock()
+			if live.frozen {
+				// Frozen groups skip the entities added, so needn't be copied
+				live.RUnlock()
+				s.Unlock()
+				return live
+			}
+			g = live.clone(name)
+			// Keep counting the matches of the entities found before the commit
+			for e, n := range live.counts {
+				g.counts[e] = n
+			}
+			live.RUnlock()
+		} else {
+			g = s.newGroup(name)
+		}
+		s.batch[name] = g
+	}
+	s.Unlock()
+	return g
+}
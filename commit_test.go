@@ -0,0 +1,42 @@
+package fastentity
+
+import "testing"
+
+func TestBeginBatch(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"))
+	store.CountMatches()
+	doc := []rune("PHP and golang developer in Sydney")
+	store.FindAll(doc)
+
+	store.BeginBatch()
+	store.Add("skills", []rune("golang"))
+	store.AddWeighted("locations", []rune("Sydney"), 2)
+	store.BeginBatch() // No effect
+	store.Add("skills", []rune("developer"))
+
+	r := store.FindAll(doc)
+	if len(r["skills"]) != 1 || len(r["locations"]) != 0 {
+		t.Errorf("Expected only PHP to be found before Commit, got %v", r)
+	}
+	if n := store.Group("skills").Len(); n != 1 {
+		t.Errorf("Expected 1 entity before Commit, got %d", n)
+	}
+
+	store.Commit()
+	r = store.FindAll(doc)
+	if len(r["skills"]) != 3 || len(r["locations"]) != 1 || r["locations"][0].Weight != 2 {
+		t.Errorf("Expected all entities to be found after Commit, got %v", r)
+	}
+	counts, err := store.MatchCounts("skills")
+	if err != nil || counts["PHP"] != 3 || counts["golang"] != 1 {
+		t.Errorf("Expected counts to be kept across the batch, got %v (%v)", counts, err)
+	}
+
+	// Without a batch, entities are visible once added
+	store.Commit()
+	store.Add("skills", []rune("Sydney"))
+	if r := store.FindAll(doc); len(r["skills"]) != 4 {
+		t.Errorf("Expected 4 skills found, got %v", r["skills"])
+	}
+}
@@ -0,0 +1,120 @@
+package fastentity
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// ErrNotCounting is returned when match counts are requested from a store which isn't
+// counting matches, see CountMatches.
+var ErrNotCounting = errors.New("not counting matches")
+
+// CountMatches makes the store count how often each entity is found by FindAll and
+// Matches, before any result filters are applied, so that entities which are never found
+// can be pruned from bloated dictionaries. Counting costs an allocation per match.
+func (s *Store) CountMatches() {
+	s.Lock()
+	defer s.Unlock()
+
+	if s.counting {
+		return
+	}
+	s.counting = true
+	for _, groups := range []map[string]*group{s.groups, s.batch} {
+		for _, g := range groups {
+			g.Lock()
+			g.startCounting()
+			g.Unlock()
+		}
+	}
+}
+
+// MatchCounts returns the number of times each entity in the group identified by name has
+// been found since CountMatches was called. It returns an error wrapping ErrGroupNotFound
+// if there is no such group, or ErrNotCounting if the store isn't counting matches.
+func (s *Store) MatchCounts(name string) (map[string]uint64, error) {
+	g, err := s.countedGroup(name)
+	if err != nil {
+		return nil, err
+	}
+	defer g.RUnlock()
+
+	counts := make(map[string]uint64, len(g.counts))
+	for e, n := range g.counts {
+		counts[e] = atomic.LoadUint64(n)
+	}
+	return counts, nil
+}
+
+// NeverMatched returns the entities in the group identified by name which haven't been
+// found since CountMatches was called, in order. It returns the same errors as
+// MatchCounts.
+func (s *Store) NeverMatched(name string) ([][]rune, error) {
+	g, err := s.countedGroup(name)
+	if err != nil {
+		return nil, err
+	}
+	defer g.RUnlock()
+
+	var never []string
+	for e, n := range g.counts {
+		if atomic.LoadUint64(n) == 0 {
+			never = append(never, e)
+		}
+	}
+	sort.Strings(never)
+	ents := make([][]rune, len(never))
+	for i, e := range never {
+		ents[i] = []rune(e)
+	}
+	return ents, nil
+}
+
+// countedGroup returns the group identified by name, read locked, if the store is counting
+// matches.
+func (s *Store) countedGroup(name string) (*group, error) {
+	s.RLock()
+	g, ok := s.groups[name]
+	counting := s.counting
+	s.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", name, ErrGroupNotFound)
+	}
+	if !counting {
+		return nil, ErrNotCounting
+	}
+	g.rlock()
+	return g, nil
+}
+
+// startCounting creates a match counter for each entity of the group. The caller must
+// hold the group lock.
+func (g *group) startCounting() {
+	g.counts = make(map[string]*uint64, g.len())
+	for _, ents := range g.entities {
+		for _, e := range ents {
+			g.addCounter(e)
+		}
+	}
+}
+
+// addCounter creates a match counter for e if it doesn't have one. The caller must hold
+// the group lock.
+func (g *group) addCounter(e entry) {
+	if _, ok := g.counts[string(e.text)]; !ok {
+		g.counts[string(e.text)] = new(uint64)
+	}
+}
+
+// count records a match of the entry, if the group is counting matches. The caller must
+// hold at least a read lock on the group.
+func (g *group) count(ent *entry) {
+	if g.counts == nil {
+		return
+	}
+	if n := g.counts[string(ent.text)]; n != nil {
+		atomic.AddUint64(n, 1)
+	}
+}
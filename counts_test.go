@@ -0,0 +1,47 @@
+package fastentity
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestCountMatches(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"), []rune("golang"), []rune("perl"))
+
+	if _, err := store.NeverMatched("skills"); !errors.Is(err, ErrNotCounting) {
+		t.Errorf("Expected ErrNotCounting, got %v", err)
+	}
+	store.CountMatches()
+	store.Add("skills", []rune("rust"))
+	store.Add("jobTitles", []rune("tax accountant"))
+
+	for i := 0; i < 3; i++ {
+		store.FindAll([]rune("Maybe PHP, or php. Or rust. "))
+	}
+
+	counts, err := store.MatchCounts("skills")
+	if err != nil {
+		t.Fatalf("Failed to get match counts: %v", err)
+	}
+	expected := map[string]uint64{"PHP": 6, "golang": 0, "perl": 0, "rust": 3}
+	for e, n := range expected {
+		if counts[e] != n {
+			t.Errorf("Expected %q to be found %d times, got %d", e, n, counts[e])
+		}
+	}
+
+	never, err := store.NeverMatched("skills")
+	if err != nil {
+		t.Fatalf("Failed to get entities never matched: %v", err)
+	}
+	if len(never) != 2 || string(never[0]) != "golang" || string(never[1]) != "perl" {
+		t.Errorf("Expected golang and perl never to be matched, got %q", never)
+	}
+	if never, _ := store.NeverMatched("jobTitles"); len(never) != 1 {
+		t.Errorf("Expected 1 job title never to be matched, got %q", never)
+	}
+	if _, err := store.NeverMatched("missing"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("Expected ErrGroupNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,56 @@
+package fastentity
+
+// Deny makes the group a deny list, whose entities are never reported as matches but
+// suppress the matches of other groups they overlap, killing false positives in the
+// phrases they're part of, e.g. a "not-a-skill" group with "excel at" stops "excel" being
+// found as a skill in "excel at teamwork". Deny lists are applied before the result
+// filters of the store and FindOptions such as NonOverlapping. Matches streamed by
+// FindReader, and those of Group.Find, which searches a single group, aren't suppressed.
+func Deny() GroupOption {
+	return func(g *group) {
+		g.deny = true
+	}
+}
+
+// span is the offsets of the runes of a match, from start up to end.
+type span struct {
+	start, end int
+}
+
+// suppressDenied drops the matches of the deny lists among groups from r, along with the
+// matches of other groups which overlap them.
+func suppressDenied(r Results, groups []*group) Results {
+	var denied []span
+	for _, g := range groups {
+		if !g.deny {
+			continue
+		}
+		for _, e := range r[g.name] {
+			denied = append(denied, span{e.Offset, e.Offset + len(e.Text)})
+		}
+		delete(r, g.name)
+	}
+	if len(denied) == 0 {
+		return r
+	}
+	for name, ents := range r {
+		kept := ents[:0]
+		for _, e := range ents {
+			if !overlapsAny(denied, span{e.Offset, e.Offset + len(e.Text)}) {
+				kept = append(kept, e)
+			}
+		}
+		r[name] = kept
+	}
+	return r
+}
+
+// overlapsAny reports whether s overlaps any of spans.
+func overlapsAny(spans []span, s span) bool {
+	for _, d := range spans {
+		if s.start < d.end && d.start < s.end {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,40 @@
+package fastentity
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDeny(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("excel"), []rune("teamwork"))
+	store.Add("not-a-skill", []rune("excel at"))
+	store.Group("not-a-skill").Configure(Deny())
+	doc := []rune("Skills: excel. I excel at teamwork")
+
+	want := "[skills:8:5:excel skills:26:8:teamwork]"
+	if got := fmt.Sprint(store.FindAll(doc).Matches()); got != want {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+	if _, ok := store.FindAll(doc)["not-a-skill"]; ok {
+		t.Error("Expected no results for the deny list")
+	}
+	if got := fmt.Sprint(store.FindAllParallel(doc, 2).Matches()); got != want {
+		t.Errorf("Expected %v searching in parallel, got %v", want, got)
+	}
+	if got := fmt.Sprint(store.FindAll(doc, NonOverlapping()).Matches()); got != want {
+		t.Errorf("Expected %v without overlaps, got %v", want, got)
+	}
+
+	// Streamed matches of the deny list aren't reported
+	err := store.FindReader(strings.NewReader(string(doc)), func(m StreamMatch) bool {
+		if m.Group == "not-a-skill" {
+			t.Errorf("Expected no matches of the deny list, got %v", m)
+		}
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,131 @@
+package fastentity
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// DiffReport describes the changes between the entities of two stores.
+type DiffReport struct {
+	// Groups lists the groups with changes, in order of name.
+	Groups []GroupDiff
+}
+
+// GroupDiff describes the changes to the entities of a single group. Entities are listed
+// in order.
+type GroupDiff struct {
+	Group string
+
+	// Added lists the entities only in the new store.
+	Added [][]rune
+	// Removed lists the entities only in the old store.
+	Removed [][]rune
+	// Changed lists the entities in both stores whose weights differ.
+	Changed []EntityChange
+}
+
+// EntityChange is an entity whose weight has changed.
+type EntityChange struct {
+	Entity               []rune
+	OldWeight, NewWeight float64
+}
+
+// Diff compares the entities of the stores a and b, reporting the changes made to a to
+// give b. Entities are compared by their exact text, so changes of case are reported as
+// one entity removed and another added. To compare dictionary releases saved as
+// snapshots, load them with LoadSnapshot first.
+func Diff(a, b *Store) *DiffReport {
+	before, after := a.entries(), b.entries()
+	names := make([]string, 0, len(before)+len(after))
+	for name := range before {
+		names = append(names, name)
+	}
+	for name := range after {
+		if _, ok := before[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	r := &DiffReport{}
+	for _, name := range names {
+		d := diffGroup(name, before[name], after[name])
+		if len(d.Added) > 0 || len(d.Removed) > 0 || len(d.Changed) > 0 {
+			r.Groups = append(r.Groups, d)
+		}
+	}
+	return r
+}
+
+func diffGroup(name string, before, after []entry) GroupDiff {
+	weights := func(ents []entry) map[string]float64 {
+		m := make(map[string]float64, len(ents))
+		for _, e := range ents {
+			m[string(e.text)] = e.weight
+		}
+		return m
+	}
+	ow, nw := weights(before), weights(after)
+
+	d := GroupDiff{Group: name}
+	for _, e := range sortedKeys(ow) {
+		w, ok := nw[e]
+		switch {
+		case !ok:
+			d.Removed = append(d.Removed, []rune(e))
+		case w != ow[e]:
+			d.Changed = append(d.Changed, EntityChange{Entity: []rune(e), OldWeight: ow[e], NewWeight: w})
+		}
+	}
+	for _, e := range sortedKeys(nw) {
+		if _, ok := ow[e]; !ok {
+			d.Added = append(d.Added, []rune(e))
+		}
+	}
+	return d
+}
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Empty reports whether the stores compared had the same entities.
+func (r *DiffReport) Empty() bool {
+	return len(r.Groups) == 0
+}
+
+// String formats the report as a change log, with a line for each entity added (+),
+// removed (-) or changed (~).
+func (r *DiffReport) String() string {
+	var b strings.Builder
+	for _, d := range r.Groups {
+		for _, e := range d.Added {
+			fmt.Fprintf(&b, "+ %s: %s\n", d.Group, string(e))
+		}
+		for _, e := range d.Removed {
+			fmt.Fprintf(&b, "- %s: %s\n", d.Group, string(e))
+		}
+		for _, c := range d.Changed {
+			fmt.Fprintf(&b, "~ %s: %s (weight %v -> %v)\n", d.Group, string(c.Entity), c.OldWeight, c.NewWeight)
+		}
+	}
+	return b.String()
+}
+
+// entries returns every entity in each group of the store.
+func (s *Store) entries() map[string][]entry {
+	s.RLock()
+	defer s.RUnlock()
+
+	m := make(map[string][]entry, len(s.groups))
+	for name, g := range s.groups {
+		m[name] = g.all()
+	}
+	return m
+}
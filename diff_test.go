@@ -0,0 +1,30 @@
+package fastentity
+
+import "testing"
+
+func TestDiff(t *testing.T) {
+	a := New("empty")
+	a.Add("skills", []rune("PHP"), []rune("golang"), []rune("perl"))
+	a.Add("locations", []rune("Sydney"))
+	a.Add("removed", []rune("gone"))
+
+	b := New()
+	b.Add("skills", []rune("PHP"), []rune("Golang"), []rune("rust"))
+	b.AddWeighted("skills", []rune("perl"), 0.5)
+	b.Add("locations", []rune("Sydney"))
+	b.Add("added", []rune("new"))
+
+	if r := Diff(a, a); !r.Empty() {
+		t.Errorf("Expected no changes comparing a store with itself, got %v", r)
+	}
+
+	expected := "+ added: new\n" +
+		"- removed: gone\n" +
+		"+ skills: Golang\n" +
+		"+ skills: rust\n" +
+		"- skills: golang\n" +
+		"~ skills: perl (weight 1 -> 0.5)\n"
+	if got := Diff(a, b).String(); got != expected {
+		t.Errorf("Expected change log:\n%s\ngot:\n%s", expected, got)
+	}
+}
@@ -0,0 +1,30 @@
+package fastentity
+
+import "unicode"
+
+// NoDigitBoundaries makes the group only find entities which start and end on word
+// boundaries other than those between letters and digits, with a Tokenizer with
+// DigitBoundaries set, for dictionaries of entities such as "Windows" and "MP3" which
+// shouldn't be found in "Windows11" or "MP3s". Other groups of the store still find
+// entities between letters and digits.
+func NoDigitBoundaries() GroupOption {
+	return func(g *group) {
+		g.noDigitBoundaries = true
+	}
+}
+
+// digitBoundary reports whether a word ends between rs[i-1] and rs[i] because one is a
+// letter and the other a digit, with DigitBoundaries.
+func (t Tokenizer) digitBoundary(rs []rune, i int) bool {
+	if !t.DigitBoundaries || i <= 0 || i >= len(rs) {
+		return false
+	}
+	a, b := rs[i-1], rs[i]
+	return (unicode.IsLetter(a) && unicode.IsDigit(b)) || (unicode.IsDigit(a) && unicode.IsLetter(b))
+}
+
+// splitsDigits reports whether the text of rs from start to end starts or ends on a
+// digit boundary.
+func (t Tokenizer) splitsDigits(rs []rune, start, end int) bool {
+	return t.digitBoundary(rs, start) || t.digitBoundary(rs, end)
+}
@@ -0,0 +1,40 @@
+package fastentity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDigitBoundaries(t *testing.T) {
+	store := New()
+	store.Add("locations", []rune("Texas"))
+	store.Add("products", []rune("Windows"), []rune("MP3"), []rune("Windows 11"))
+	doc := []rune("Houston, Texas77034 on Windows11 with MP3s and Windows 11th")
+
+	find := func() []string {
+		var got []string
+		for _, m := range store.FindAll(doc).Matches() {
+			got = append(got, m.String())
+		}
+		return got
+	}
+	want := []string{"products:47:7:Windows"}
+	if got := find(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q without digit boundaries, got %q", want, got)
+	}
+
+	store.SetTokenizer(Tokenizer{DigitBoundaries: true})
+	want = []string{"locations:9:5:Texas", "products:23:7:Windows", "products:38:3:MP3", "products:47:7:Windows", "products:47:10:Windows 11"}
+	if got := find(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+	if got, want := (Tokenizer{DigitBoundaries: true}).Tokenize([]rune("Texas77034 v2")), []Span{{0, 5}, {5, 10}, {11, 12}, {12, 13}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected words %v, got %v", want, got)
+	}
+
+	store.Group("products").Configure(NoDigitBoundaries())
+	want = []string{"locations:9:5:Texas", "products:47:7:Windows"}
+	if got := find(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q opting out, got %q", want, got)
+	}
+}
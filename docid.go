@@ -0,0 +1,20 @@
+package fastentity
+
+// DocumentID echoes id, such as the ID or key of the document searched, on every entity
+// found as Entity.Doc, so that results passed on through channels and services remain
+// attributable without wrapping them. FindAllMulti sets the ID of each document itself.
+// It applies to FindReader and Pool.Submit, for which other FindOptions have no effect.
+func DocumentID(id string) FindOption {
+	return func(c *findConfig) {
+		c.doc = id
+	}
+}
+
+// setDocumentID sets the Doc of every entity of r to id.
+func setDocumentID(r Results, id string) {
+	for _, ents := range r {
+		for i := range ents {
+			ents[i].Doc = id
+		}
+	}
+}
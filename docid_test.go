@@ -0,0 +1,58 @@
+package fastentity
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestDocumentID(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"), []rune("golang"))
+	store.SetResultCache(NewLRUResultCache(4))
+	doc := []rune("PHP and golang")
+
+	for _, id := range []string{"a", "b"} { // b is served from the cache
+		ms := store.FindAll(doc, DocumentID(id)).Matches()
+		if len(ms) != 2 {
+			t.Fatalf("Expected 2 matches, got %v", ms)
+		}
+		for _, m := range ms {
+			if m.Doc != id {
+				t.Errorf("Expected document %q, got %q", id, m.Doc)
+			}
+		}
+	}
+	for _, m := range store.FindAll(doc).Matches() {
+		if m.Doc != "" {
+			t.Errorf("Expected no document without DocumentID, got %q", m.Doc)
+		}
+	}
+
+	b, err := json.Marshal(store.FindAll(doc, DocumentID("a"))["skills"][0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e Entity
+	if err := json.Unmarshal(b, &e); err != nil || e.Doc != "a" || !strings.Contains(string(b), `"doc":"a"`) {
+		t.Errorf("Expected the document to be encoded, got %s (%v)", b, err)
+	}
+
+	err = store.FindReader(strings.NewReader(string(doc)), func(m StreamMatch) bool {
+		if m.Doc != "c" {
+			t.Errorf("Expected streamed matches of document c, got %q", m.Doc)
+		}
+		return true
+	}, DocumentID("c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	p := NewPool(store, 1, 1)
+	defer p.Shutdown(context.Background())
+	r, err := p.Submit(doc, DocumentID("d")).Wait()
+	if err != nil || len(r["skills"]) != 2 || r["skills"][0].Doc != "d" {
+		t.Errorf("Expected pooled matches of document d, got %v (%v)", r, err)
+	}
+}
@@ -0,0 +1,127 @@
+package fastentity
+
+import (
+	"sort"
+	"strings"
+)
+
+// Duplicate is an entity added more than once to the same group, ignoring case.
+type Duplicate struct {
+	Group string
+	// Entity is the text of the first occurrence.
+	Entity []rune
+	// Sources lists where each occurrence came from: the path of the entity file when
+	// loading, or empty for entities already in the store when merging.
+	Sources []string
+}
+
+// Conflict is an entity in more than one group, ignoring case.
+type Conflict struct {
+	// Entity is the text of the first occurrence.
+	Entity []rune
+	// Groups lists the groups the entity is in, in order.
+	Groups []string
+}
+
+// MergeReport describes the outcome of merging stores with Merge.
+type MergeReport struct {
+	// Entities is the number of entities merged into each group.
+	Entities map[string]int
+
+	// Duplicates lists the entities of the merged store which occur more than once in the
+	// same group, and Conflicts those in more than one group.
+	Duplicates []Duplicate
+	Conflicts  []Conflict
+}
+
+// Merge adds all the entities of src to the store, reporting any duplicates and conflicts
+// in the merged store.
+func (s *Store) Merge(src *Store) *MergeReport {
+	existing, added := s.entries(), src.entries()
+
+	var sources []source
+	for name, ents := range existing {
+		sources = append(sources, source{group: name, ents: ents})
+	}
+	r := &MergeReport{Entities: make(map[string]int, len(added))}
+	for name, ents := range added {
+		sources = append(sources, source{group: name, name: "merged", ents: ents})
+		s.recordEntries(AuditAdd, name, ents)
+		s.addEntries(name, ents)
+		r.Entities[name] = len(ents)
+	}
+	r.Duplicates, r.Conflicts = duplicates(sources)
+	return r
+}
+
+// source is a set of entities added to a group, from a file or another store.
+type source struct {
+	group string
+	name  string
+	ents  []entry
+}
+
+// duplicates finds the entities which occur more than once in the same group, and in
+// more than one group, across the sources.
+func duplicates(sources []source) ([]Duplicate, []Conflict) {
+	type occurrence struct {
+		text    []rune
+		sources []string
+	}
+	groups := make(map[string]map[string]*occurrence)
+	for _, src := range sources {
+		occs := groups[src.group]
+		if occs == nil {
+			occs = make(map[string]*occurrence)
+			groups[src.group] = occs
+		}
+		for _, e := range src.ents {
+			key := strings.ToLower(string(e.text))
+			o := occs[key]
+			if o == nil {
+				o = &occurrence{text: e.text}
+				occs[key] = o
+			}
+			o.sources = append(o.sources, src.name)
+		}
+	}
+
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var dups []Duplicate
+	conflicts := make(map[string]*Conflict)
+	for _, name := range names {
+		for key, o := range groups[name] {
+			if len(o.sources) > 1 {
+				dups = append(dups, Duplicate{Group: name, Entity: o.text, Sources: o.sources})
+			}
+			c := conflicts[key]
+			if c == nil {
+				c = &Conflict{Entity: o.text}
+				conflicts[key] = c
+			}
+			c.Groups = append(c.Groups, name)
+		}
+	}
+	sort.Slice(dups, func(i, j int) bool {
+		if dups[i].Group != dups[j].Group {
+			return dups[i].Group < dups[j].Group
+		}
+		return lessRunes(dups[i].Entity, dups[j].Entity)
+	})
+
+	var cs []Conflict
+	for _, c := range conflicts {
+		if len(c.Groups) > 1 {
+			cs = append(cs, *c)
+		}
+	}
+	sort.Slice(cs, func(i, j int) bool {
+		return lessRunes(cs[i].Entity, cs[j].Entity)
+	})
+	return dups, cs
+}
@@ -0,0 +1,70 @@
+package fastentity
+
+import (
+	"os"
+	"testing"
+)
+
+func TestLoadDirDuplicates(t *testing.T) {
+	dir := writeEntityFiles(t, map[string]string{
+		"skills.00" + entityFileSuffix: "PHP\ngolang\nphp\n",
+		"skills.01" + entityFileSuffix: "Golang\nrust\n",
+		"locations" + entityFileSuffix: "Sydney\nRust\n",
+	})
+	defer os.RemoveAll(dir)
+
+	_, report, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to load store: %v", err)
+	}
+
+	expected := map[string][]string{
+		"golang": {dir + "/skills.00" + entityFileSuffix, dir + "/skills.01" + entityFileSuffix},
+		"PHP":    {dir + "/skills.00" + entityFileSuffix, dir + "/skills.00" + entityFileSuffix},
+	}
+	if len(report.Duplicates) != len(expected) {
+		t.Fatalf("Expected %d duplicates, got %v", len(expected), report.Duplicates)
+	}
+	for _, d := range report.Duplicates {
+		sources := expected[string(d.Entity)]
+		if d.Group != "skills" || len(d.Sources) != len(sources) {
+			t.Errorf("Unexpected duplicate %v", d)
+			continue
+		}
+		for i := range sources {
+			if d.Sources[i] != sources[i] {
+				t.Errorf("Expected %q source %d to be %s, got %s", string(d.Entity), i, sources[i], d.Sources[i])
+			}
+		}
+	}
+
+	if len(report.Conflicts) != 1 {
+		t.Fatalf("Expected 1 conflict, got %v", report.Conflicts)
+	}
+	if c := report.Conflicts[0]; string(c.Entity) != "Rust" || len(c.Groups) != 2 || c.Groups[0] != "locations" || c.Groups[1] != "skills" {
+		t.Errorf("Expected Rust in locations and skills, got %v", c)
+	}
+}
+
+func TestMerge(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"), []rune("golang"))
+
+	src := New()
+	src.Add("skills", []rune("golang"), []rune("rust"))
+	src.Add("languages", []rune("PHP"))
+
+	r := store.Merge(src)
+	if r.Entities["skills"] != 2 || r.Entities["languages"] != 1 {
+		t.Errorf("Unexpected entities merged %v", r.Entities)
+	}
+	if store.Group("skills").Len() != 3 {
+		t.Errorf("Expected 3 skills, got %d", store.Group("skills").Len())
+	}
+	if len(r.Duplicates) != 1 || string(r.Duplicates[0].Entity) != "golang" {
+		t.Errorf("Expected golang to be duplicated, got %v", r.Duplicates)
+	}
+	if len(r.Conflicts) != 1 || string(r.Conflicts[0].Entity) != "PHP" {
+		t.Errorf("Expected PHP to conflict, got %v", r.Conflicts)
+	}
+}
@@ -0,0 +1,89 @@
+package fastentity
+
+import "unicode"
+
+// pictographic approximates the Extended_Pictographic property of Unicode, which Go's
+// unicode package doesn't provide, leaving out the runes which are punctuation. It also
+// includes the regional indicators of flags and the skin tone modifiers.
+var pictographic = &unicode.RangeTable{
+	LatinOffset: 1,
+	R16: []unicode.Range16{
+		{0x00a9, 0x00ae, 5},
+		{0x2122, 0x2139, 0x17},
+		{0x2194, 0x2199, 1},
+		{0x21a9, 0x21aa, 1},
+		{0x231a, 0x231b, 1},
+		{0x2328, 0x2388, 0x60},
+		{0x23cf, 0x23e9, 0x1a},
+		{0x23ea, 0x23f3, 1},
+		{0x23f8, 0x23fa, 1},
+		{0x24c2, 0x25aa, 0xe8},
+		{0x25ab, 0x25b6, 0xb},
+		{0x25c0, 0x25fb, 0x3b},
+		{0x25fc, 0x25fe, 1},
+		{0x2600, 0x2767, 1},
+		{0x2794, 0x27bf, 1},
+		{0x2934, 0x2935, 1},
+		{0x2b05, 0x2b07, 1},
+		{0x2b1b, 0x2b1c, 1},
+		{0x2b50, 0x2b55, 5},
+		{0x3297, 0x3299, 2},
+	},
+	R32: []unicode.Range32{
+		{0x1f000, 0x1f0ff, 1},
+		{0x1f10d, 0x1f10f, 1},
+		{0x1f12f, 0x1f16c, 0x3d},
+		{0x1f16d, 0x1f171, 1},
+		{0x1f17e, 0x1f17f, 1},
+		{0x1f18e, 0x1f191, 3},
+		{0x1f192, 0x1f19a, 1},
+		{0x1f1ad, 0x1f1ff, 1},
+		{0x1f201, 0x1f20f, 1},
+		{0x1f21a, 0x1f22f, 0x15},
+		{0x1f232, 0x1f23a, 1},
+		{0x1f23c, 0x1f23f, 1},
+		{0x1f249, 0x1f3fa, 1},
+		{0x1f3fb, 0x1f3ff, 1},
+		{0x1f400, 0x1f53d, 1},
+		{0x1f546, 0x1f64f, 1},
+		{0x1f680, 0x1f6ff, 1},
+		{0x1f774, 0x1f77f, 1},
+		{0x1f7d5, 0x1f7ff, 1},
+		{0x1f80c, 0x1f80f, 1},
+		{0x1f848, 0x1f84f, 1},
+		{0x1f85a, 0x1f85f, 1},
+		{0x1f888, 0x1f88f, 1},
+		{0x1f8ae, 0x1f8ff, 1},
+		{0x1f90c, 0x1f93a, 1},
+		{0x1f93c, 0x1f945, 1},
+		{0x1f947, 0x1faff, 1},
+		{0x1fc00, 0x1fffd, 1},
+	},
+}
+
+const (
+	zeroWidthJoiner   = '\u200d'
+	emojiPresentation = '\ufe0f' // variation selector 16
+	combiningKeycap   = '\u20e3'
+)
+
+// isEmoji reports whether r is an emoji, or part of one.
+func isEmoji(r rune) bool {
+	return r >= 0xa9 && unicode.Is(pictographic, r)
+}
+
+// inEmojiSequence reports whether rs[i] joins or modifies the emoji preceding it, so that
+// sequences such as "👩‍💻" are kept together.
+func inEmojiSequence(rs []rune, i int) bool {
+	for ; i > 0; i-- {
+		switch rs[i] {
+		case zeroWidthJoiner, emojiPresentation, combiningKeycap:
+		default:
+			return false
+		}
+		if isEmoji(rs[i-1]) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,31 @@
+package fastentity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEmoji(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("Python"), []rune("rocks"), []rune("I ❤️ NY"))
+	doc := []rune("Python🐍rocks, 👩‍💻Python and I ❤️ NY 👨‍👩‍👧")
+
+	var got []string
+	for _, m := range store.FindAll(doc).Matches() {
+		got = append(got, m.String())
+	}
+	want := []string{"skills:0:6:Python", "skills:7:5:rocks", "skills:17:6:Python", "skills:28:7:I ❤️ NY"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	spans := Tokenize([]rune("a👩‍💻b ❤️‍🔥c"))
+	if want := []Span{{0, 1}, {4, 5}, {10, 11}}; !reflect.DeepEqual(spans, want) {
+		t.Errorf("Expected spans %v, got %v", want, spans)
+	}
+
+	store.SetTokenizer(Tokenizer{EmojiInWords: true})
+	if got := store.FindAll(doc).Matches(); len(got) != 1 || string(got[0].Text) != "I ❤️ NY" {
+		t.Errorf("Expected only the entity with an emoji with EmojiInWords, got %v", got)
+	}
+}
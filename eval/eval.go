@@ -0,0 +1,225 @@
+// Package eval measures the accuracy of a fastentity Store against a corpus of documents
+// annotated with the entities they contain, so the effect of changes to dictionaries can
+// be measured.
+package eval
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	"github.com/sajari/fastentity"
+)
+
+// Document is a document annotated with the entities it contains.
+type Document struct {
+	Text     string       `json:"text"`
+	Entities []Annotation `json:"entities"`
+}
+
+// Annotation is an entity expected to be found in a document.
+type Annotation struct {
+	Group string `json:"group"`
+	// Offset is the offset of the entity in the document, in runes as for
+	// fastentity.Entity.
+	Offset int `json:"offset"`
+	// Text is the text of the entity as it appears in the document.
+	Text string `json:"text"`
+}
+
+// ReadCorpus reads annotated documents from r, one JSON encoded Document per line, e.g.
+//
+//	{"text": "A golang developer", "entities": [{"group": "jobTitles", "offset": 2, "text": "golang developer"}]}
+//
+// It returns an error if an annotation's text doesn't appear in the document at its
+// offset.
+func ReadCorpus(r io.Reader) ([]Document, error) {
+	var docs []Document
+	s := bufio.NewScanner(r)
+	s.Buffer(nil, 16<<20)
+	n := 0
+	for s.Scan() {
+		n++
+		if len(strings.TrimSpace(s.Text())) == 0 {
+			continue
+		}
+		var d Document
+		if err := json.Unmarshal(s.Bytes(), &d); err != nil {
+			return nil, fmt.Errorf("line %d: %w", n, err)
+		}
+		rs := []rune(d.Text)
+		for _, a := range d.Entities {
+			end := a.Offset + len([]rune(a.Text))
+			if a.Offset < 0 || end > len(rs) || string(rs[a.Offset:end]) != a.Text {
+				return nil, fmt.Errorf("line %d: %q is not at offset %d", n, a.Text, a.Offset)
+			}
+		}
+		docs = append(docs, d)
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return docs, nil
+}
+
+// Report describes the accuracy of a Store on a corpus.
+type Report struct {
+	// Groups holds the results for each group with annotations or matches.
+	Groups map[string]*GroupReport
+}
+
+// GroupReport describes the accuracy of a single group. A match is only correct if it has
+// the same offset and length as an annotation.
+type GroupReport struct {
+	TruePositives int
+	// FalsePositives lists the matches which weren't annotated.
+	FalsePositives []Mistake
+	// FalseNegatives lists the annotations which weren't matched.
+	FalseNegatives []Mistake
+}
+
+// Mistake is an entity which was matched but not annotated, or the reverse.
+type Mistake struct {
+	// Document is the index of the document in the corpus.
+	Document int
+	Offset   int
+	Text     string
+}
+
+// Precision returns the fraction of matches which were annotated.
+func (g *GroupReport) Precision() float64 {
+	return ratio(g.TruePositives, g.TruePositives+len(g.FalsePositives))
+}
+
+// Recall returns the fraction of annotations which were matched.
+func (g *GroupReport) Recall() float64 {
+	return ratio(g.TruePositives, g.TruePositives+len(g.FalseNegatives))
+}
+
+// F1 returns the harmonic mean of precision and recall.
+func (g *GroupReport) F1() float64 {
+	p, r := g.Precision(), g.Recall()
+	if p+r == 0 {
+		return 0
+	}
+	return 2 * p * r / (p + r)
+}
+
+func ratio(n, d int) float64 {
+	if d == 0 {
+		return 0
+	}
+	return float64(n) / float64(d)
+}
+
+// Evaluate searches each document of the corpus with s.FindAll, and compares the matches
+// with the annotations.
+func Evaluate(s *fastentity.Store, docs []Document) *Report {
+	r := &Report{Groups: make(map[string]*GroupReport)}
+	group := func(name string) *GroupReport {
+		g, ok := r.Groups[name]
+		if !ok {
+			g = &GroupReport{}
+			r.Groups[name] = g
+		}
+		return g
+	}
+
+	type span struct {
+		group  string
+		offset int
+		length int
+	}
+	for i, d := range docs {
+		expected := make(map[span]bool, len(d.Entities))
+		for _, a := range d.Entities {
+			expected[span{a.Group, a.Offset, len([]rune(a.Text))}] = true
+		}
+
+		found := make(map[span]bool)
+		for name, ents := range s.FindAll([]rune(d.Text)) {
+			for _, e := range ents {
+				sp := span{name, e.Offset, len(e.Text)}
+				if found[sp] {
+					continue // Matched more than one entity
+				}
+				found[sp] = true
+				if expected[sp] {
+					group(name).TruePositives++
+				} else {
+					g := group(name)
+					g.FalsePositives = append(g.FalsePositives, Mistake{Document: i, Offset: e.Offset, Text: string(e.Text)})
+				}
+			}
+		}
+		for _, a := range d.Entities {
+			if !found[span{a.Group, a.Offset, len([]rune(a.Text))}] {
+				g := group(a.Group)
+				g.FalseNegatives = append(g.FalseNegatives, Mistake{Document: i, Offset: a.Offset, Text: a.Text})
+			}
+		}
+	}
+
+	for _, g := range r.Groups {
+		sortMistakes(g.FalsePositives)
+		sortMistakes(g.FalseNegatives)
+	}
+	return r
+}
+
+func sortMistakes(ms []Mistake) {
+	sort.Slice(ms, func(i, j int) bool {
+		if ms[i].Document != ms[j].Document {
+			return ms[i].Document < ms[j].Document
+		}
+		return ms[i].Offset < ms[j].Offset
+	})
+}
+
+// Overall returns the results of all groups combined.
+func (r *Report) Overall() *GroupReport {
+	all := &GroupReport{}
+	for _, g := range r.Groups {
+		all.TruePositives += g.TruePositives
+		all.FalsePositives = append(all.FalsePositives, g.FalsePositives...)
+		all.FalseNegatives = append(all.FalseNegatives, g.FalseNegatives...)
+	}
+	sortMistakes(all.FalsePositives)
+	sortMistakes(all.FalseNegatives)
+	return all
+}
+
+// WriteTo writes a summary of the report to w: the precision, recall and F1 of each group
+// and overall, followed by the false positives and negatives of each group.
+func (r *Report) WriteTo(w io.Writer) (int64, error) {
+	names := make([]string, 0, len(r.Groups))
+	for name := range r.Groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%-20s %6s %6s %6s %9s %6s %6s\n", "group", "tp", "fp", "fn", "precision", "recall", "f1")
+	row := func(name string, g *GroupReport) {
+		fmt.Fprintf(&b, "%-20s %6d %6d %6d %9.3f %6.3f %6.3f\n", name, g.TruePositives, len(g.FalsePositives), len(g.FalseNegatives), g.Precision(), g.Recall(), g.F1())
+	}
+	for _, name := range names {
+		row(name, r.Groups[name])
+	}
+	row("overall", r.Overall())
+
+	for _, name := range names {
+		g := r.Groups[name]
+		for _, m := range g.FalsePositives {
+			fmt.Fprintf(&b, "FP %s: document %d offset %d %q\n", name, m.Document, m.Offset, m.Text)
+		}
+		for _, m := range g.FalseNegatives {
+			fmt.Fprintf(&b, "FN %s: document %d offset %d %q\n", name, m.Document, m.Offset, m.Text)
+		}
+	}
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
@@ -0,0 +1,61 @@
+package eval
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/sajari/fastentity"
+)
+
+const corpus = `{"text": "A golang developer from Sydney. ", "entities": [{"group": "jobTitles", "offset": 2, "text": "golang developer"}, {"group": "locations", "offset": 24, "text": "Sydney"}]}
+
+{"text": "Maybe PHP, or PDX in 本語. ", "entities": [{"group": "skills", "offset": 6, "text": "PHP"}, {"group": "skills", "offset": 14, "text": "PDX"}]}
+`
+
+func TestEvaluate(t *testing.T) {
+	docs, err := ReadCorpus(strings.NewReader(corpus))
+	if err != nil {
+		t.Fatalf("Failed to read corpus: %v", err)
+	}
+	if len(docs) != 2 {
+		t.Fatalf("Expected 2 documents, got %d", len(docs))
+	}
+
+	store := fastentity.New()
+	store.Add("jobTitles", []rune("golang developer"))
+	store.Add("locations", []rune("Sydney"))
+	store.Add("skills", []rune("PHP"), []rune("本語"))
+
+	r := Evaluate(store, docs)
+	skills := r.Groups["skills"]
+	if skills.TruePositives != 1 || len(skills.FalsePositives) != 1 || len(skills.FalseNegatives) != 1 {
+		t.Fatalf("Unexpected skills results %+v", skills)
+	}
+	if fp := skills.FalsePositives[0]; fp.Document != 1 || fp.Text != "本語" {
+		t.Errorf("Expected 本語 to be a false positive, got %+v", fp)
+	}
+	if fn := skills.FalseNegatives[0]; fn.Document != 1 || fn.Text != "PDX" {
+		t.Errorf("Expected PDX to be a false negative, got %+v", fn)
+	}
+	if p, rc, f1 := skills.Precision(), skills.Recall(), skills.F1(); p != 0.5 || rc != 0.5 || f1 != 0.5 {
+		t.Errorf("Expected precision, recall and F1 of 0.5, got %v, %v and %v", p, rc, f1)
+	}
+	if all := r.Overall(); all.TruePositives != 3 || all.Precision() != 0.75 {
+		t.Errorf("Unexpected overall results %+v", all)
+	}
+
+	var b strings.Builder
+	if _, err := r.WriteTo(&b); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(b.String(), `FN skills: document 1 offset 14 "PDX"`) {
+		t.Errorf("Expected false negatives in summary:\n%s", b.String())
+	}
+}
+
+func TestReadCorpusInvalid(t *testing.T) {
+	_, err := ReadCorpus(strings.NewReader(`{"text": "Maybe PHP", "entities": [{"group": "skills", "offset": 5, "text": "PHP"}]}`))
+	if err == nil {
+		t.Errorf("Expected an error for a misplaced annotation")
+	}
+}
@@ -0,0 +1,71 @@
+package fastentity
+
+import (
+	"io"
+	"io/ioutil"
+	"unicode/utf8"
+)
+
+// A TextExtractor extracts the plain text of a structured document, such as HTML or PDF,
+// for searching. Along with the text it returns an OffsetMap from the text back to the
+// document it was read from, so matches can be located in the source.
+type TextExtractor interface {
+	Extract(r io.Reader) ([]rune, OffsetMap, error)
+}
+
+// OffsetMap maps each rune of extracted text to the span of bytes of the source document
+// it came from. Runes inserted by the extractor, such as spaces separating blocks, map to
+// the source they replaced, or an empty span where they were inserted.
+type OffsetMap []Span
+
+// Source returns the span of bytes of the source document which the entity e, found in
+// the extracted text, came from.
+func (m OffsetMap) Source(e Entity) Span {
+	if len(e.Text) == 0 {
+		if e.Offset < len(m) {
+			return Span{m[e.Offset].Start, m[e.Offset].Start}
+		}
+		if len(m) > 0 {
+			return Span{m[len(m)-1].End, m[len(m)-1].End}
+		}
+		return Span{}
+	}
+	return Span{m[e.Offset].Start, m[e.Offset+len(e.Text)-1].End}
+}
+
+// HTMLExtractor returns a TextExtractor for HTML documents, which removes markup and
+// decodes character references as StripHTML does. Bytes of the document which aren't
+// valid UTF-8 are extracted as utf8.RuneError.
+func HTMLExtractor() TextExtractor {
+	return htmlExtractor{}
+}
+
+type htmlExtractor struct{}
+
+func (htmlExtractor) Extract(r io.Reader) ([]rune, OffsetMap, error) {
+	b, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	rs, offsets := decodeRunes(b)
+	text, spans := stripHTML(rs)
+	m := make(OffsetMap, len(spans))
+	for i, sp := range spans {
+		m[i] = Span{offsets[sp.Start], offsets[sp.End]}
+	}
+	return text, m, nil
+}
+
+// decodeRunes decodes b, returning its runes and the byte offset of each, followed by the
+// length of b.
+func decodeRunes(b []byte) ([]rune, []int) {
+	rs := make([]rune, 0, len(b))
+	offsets := make([]int, 0, len(b)+1)
+	for i := 0; i < len(b); {
+		r, size := utf8.DecodeRune(b[i:])
+		rs = append(rs, r)
+		offsets = append(offsets, i)
+		i += size
+	}
+	return rs, append(offsets, len(b))
+}
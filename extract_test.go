@@ -0,0 +1,33 @@
+package fastentity
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestHTMLExtractor(t *testing.T) {
+	doc := "<p>Développeur <b>PHP</b> à São&nbsp;Paulo</p>\n<p>\xff golang</p>"
+
+	store := New()
+	store.Add("skills", []rune("PHP"), []rune("golang"))
+	store.Add("locations", []rune("São Paulo"))
+
+	text, m, err := HTMLExtractor().Extract(strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m) != len(text) {
+		t.Fatalf("Expected a span for each of %d runes, got %d", len(text), len(m))
+	}
+	expected := map[string]string{"PHP": "PHP", "São Paulo": "São&nbsp;Paulo", "golang": "golang"}
+	found := store.FindAll(text).Matches()
+	if len(found) != len(expected) {
+		t.Fatalf("Expected %d matches, got %v", len(expected), found)
+	}
+	for _, f := range found {
+		sp := m.Source(f.Entity)
+		if src := doc[sp.Start:sp.End]; src != expected[string(f.Text)] {
+			t.Errorf("Expected %q in the source, got %q", expected[string(f.Text)], src)
+		}
+	}
+}
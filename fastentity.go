@@ -3,25 +3,43 @@ package fastentity
 
 import (
 	"bufio"
+	"context"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
-	"os"
+	"sort"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"unicode"
+	"unicode/utf8"
 )
 
 var (
 	// Maximum entity length.  NB: currently entities can be added which are longer
-	// but they will be ignored in the search.
-	// TODO: Maybe also error/warn on import?
+	// but they will be ignored in the search, use ValidateEntity to check them before
+	// adding.
 	MaxEntityLen = 30
 	// Number of entities to initially allocate when creating a Group.
 	DefaultGroupSize = 1000
 )
 
+// DefaultWeight is the weight of entities added without one.
+const DefaultWeight = 1.0
+
+var (
+	// ErrGroupNotFound is returned when an operation refers to a group which doesn't exist.
+	ErrGroupNotFound = errors.New("group not found")
+	// ErrGroupExists is returned when an operation would create a group which already
+	// exists.
+	ErrGroupExists = errors.New("group already exists")
+	// ErrNoEntityFiles is returned by FromDir when the directory contains no entity files.
+	ErrNoEntityFiles = errors.New("no entity files found")
+	// ErrEntityTooLong is returned for entities longer than MaxEntityLen, which would never
+	// be found.
+	ErrEntityTooLong = errors.New("entity too long")
+)
+
 const (
 	left  = 0
 	right = 1
@@ -30,32 +48,225 @@ const (
 type pair [2]int
 
 // Store is a collection of groups of entities.
+//
+// A Store is safe for concurrent use. Searches hold a read lock on every group they
+// search, so each sees all groups as they were when it started, and entities added
+// concurrently are added once the searches in progress are done.
 type Store struct {
+	version uint64 // accessed atomically, first for alignment
+	clock   uint64 // accessed atomically, counts searches for evicting groups
+
+	evictMu     sync.Mutex // serializes evicting groups
+	memoryLimit int64      // protected by the embedded RWMutex
+
 	sync.RWMutex // protects groups
 
-	groups map[string]*group
+	groups        map[string]*group
+	filters       []ResultFilter
+	preprocessors []Preprocessor
+	wordLimit     int
+	counting      bool
+	audit         AuditSink
+	versionDir    string
+	shards        []string
+	tokenizer     Tokenizer
+
+	// batch holds the groups changed since BeginBatch, by name, until Commit.
+	batch map[string]*group
+
+	// manifest is the manifest the store was loaded with, and sources the entity files it
+	// was loaded from, see Manifest.
+	manifest *Manifest
+	sources  []ManifestSource
+
+	// cache holds the results of documents searched, see SetResultCache.
+	cache ResultCache
+
+	// warming counts the WarmUps not yet done, see Ready.
+	warming int
 }
 
 type Entity struct {
-	Text   []rune
+	Text []rune
+	// Offset is the offset of the entity in the document in runes, Unicode code points,
+	// not bytes or UTF-16 code units, see OffsetIndex.
 	Offset int
+
+	// Canonical is the text of the entity as it was added to its group, which differs from
+	// Text in case, or when it was matched by normal form or acronym.
+	Canonical []rune
+	// Kind is how the entity was matched.
+	Kind MatchKind
+	// Score is the confidence in the match, from 0 to 1. Matches on text and acronyms
+	// score 1, while phonetic matches score lower the more the text differs, and matches
+	// which don't keep the capitals of proper nouns lower still, see ProperNouns.
+	Score float64
+	// Weight is the weight of the entity, see AddWeighted.
+	Weight float64
+	// Group is the name of the group the entity was found in, so that entities remain
+	// self-describing once collected from Results or Group.Find.
+	Group string
+	// Doc is the ID of the document the entity was found in, given by the DocumentID
+	// option or set by FindAllMulti, if any.
+	Doc string
+	// Head and Tail are set when the entity starts or ends part way through a rune of the
+	// document which a Preprocessor expanded into several, such as "ﬁ" folded to "fi":
+	// they count the runes expanded from the first and last runes of Text which come
+	// before and after the match, see Match.Original.
+	Head, Tail int
+}
+
+// MatchKind describes how an entity was matched.
+type MatchKind uint8
+
+const (
+	// TextMatch is a match on the text of the entity, after any normalization.
+	TextMatch MatchKind = iota
+	// AcronymMatch is a match on the acronym of the entity, see Acronyms.
+	AcronymMatch
+	// PhoneticMatch is a match on the sound of the entity, see Phonetic.
+	PhoneticMatch
+	// SynonymMatch is a match on the entity with a phrase replaced by a synonym, see
+	// Synonyms.
+	SynonymMatch
+)
+
+func (k MatchKind) String() string {
+	switch k {
+	case TextMatch:
+		return "text"
+	case AcronymMatch:
+		return "acronym"
+	case PhoneticMatch:
+		return "phonetic"
+	case SynonymMatch:
+		return "synonym"
+	}
+	return fmt.Sprintf("MatchKind(%d)", k)
+}
+
+// Match is an Entity found in a document, whose Group names the group it was found in.
+type Match struct {
+	Entity
 }
 
 type group struct {
+	stats groupStats // accessed atomically, first for alignment
+
+	// size is the estimated memory used by the entities of the group, and used the time
+	// it was last searched, see SetMemoryLimit. Both are accessed atomically.
+	size int64
+	used uint64
+
 	sync.RWMutex
 
 	name     string
-	entities map[string][][]rune
+	entities map[string][]entry
 	maxLen   int
+
+	// folded holds the lower case text of each entity, for skipping duplicates.
+	folded map[string]struct{}
+
+	// maxWords is the number of words in the entity with the most words, and wordLimit
+	// the maximum number of words in the entities which can be found, see
+	// Store.SetMaxEntityWords.
+	maxWords  int
+	wordLimit int
+
+	// counts is the number of times each entity has been found, see Store.CountMatches.
+	counts map[string]*uint64
+
+	// Normalized matching, see Normalize, Abbreviations and French.
+	normalizers   []Normalizer
+	abbreviations map[string][]rune
+	elisions      map[string]bool
+	normalized    map[string][]entry
+
+	// Acronym matching, see Acronyms.
+	acronyms map[string][]entry
+
+	// Phonetic matching, see Phonetic.
+	phonetic map[string][]entry
+
+	// Lazy loading, see Lazy. source loads the entities of the group, which is lazy until
+	// they have been loaded, and again once they have been evicted. evictable is set while
+	// the group holds only the entities loaded from source, and warm once they have first
+	// been loaded.
+	lazy      uint32 // accessed atomically
+	source    func() ([]entry, error)
+	loadErr   error
+	evictable bool
+	warm      bool
+
+	// Synonym matching, see Synonyms and NumericVariants.
+	synonymSets [][][]rune
+	synonyms    map[string][]entry
+	numeric     bool
+
+	// Provided entities, see Provide.
+	provider *providerCache
+
+	// Guards against short entities, see MinMatchLength and ExactCase.
+	minLen         int
+	exactCaseBelow int
+
+	// Scoring of matches of proper nouns in other case, see ProperNouns.
+	properNouns     bool
+	properNounScore float64
+
+	// noDigitBoundaries is set by NoDigitBoundaries.
+	noDigitBoundaries bool
+
+	// Limits on the matches of entities per document, see MaxMatchesPerEntity.
+	maxPerEntity int
+	entityLimits map[string]int
+
+	// exact indexes the entities by their lower case text in groups frozen by Build or
+	// FreezeGroup, replacing entities for matching on text. frozen is set while the group
+	// is frozen by FreezeGroup, and can't be changed.
+	exact  map[string][]entry
+	frozen bool
+
+	// deny is set for deny lists, see Deny.
+	deny bool
+
+	// recent orders the entities of groups bounded by KeepRecent.
+	recent *recentEntities
+}
+
+// entry is an entity stored in a group, along with its weight and any value attached to
+// it.
+type entry struct {
+	text   []rune
+	value  interface{}
+	weight float64
+
+	// folded is the UTF-8 encoding of the text in lower case, set once the entry is added
+	// to a group, against which text is compared as bytes rather than rune by rune.
+	folded string
+
+	// source is where the entity came from, see Group.Source.
+	source string
 }
 
-// Pops the last element and adds the new element to the front of stack.
+func newEntry(text []rune) entry {
+	return entry{
+		text:   text,
+		weight: DefaultWeight,
+	}
+}
+
+// Pops the last element and adds the new element to the front of stack, which holds at
+// most cap(s) elements, without allocating once it's full.
 func shift(n pair, s []pair) (pair, []pair) {
 	if len(s) == 0 {
 		return pair{}, append(s, n)
 	}
 	if len(s) == cap(s) {
-		return s[0], append(s[1:], n)
+		first := s[0]
+		copy(s, s[1:])
+		s[len(s)-1] = n
+		return first, s
 	}
 	return s[0], append(s, n)
 }
@@ -66,37 +277,192 @@ func New(groups ...string) *Store {
 		groups: make(map[string]*group, len(groups)),
 	}
 	for _, name := range groups {
-		g := &group{
-			name:     name,
-			entities: make(map[string][][]rune, DefaultGroupSize),
-		}
-		s.groups[name] = g
+		s.groups[name] = newGroup(name)
 	}
 	return s
 }
 
-// Add adjoins the entities to the group identified by name.
-func (s *Store) Add(name string, entities ...[]rune) {
+func newGroup(name string) *group {
+	return &group{
+		name:     name,
+		entities: make(map[string][]entry, DefaultGroupSize),
+		folded:   make(map[string]struct{}, DefaultGroupSize),
+	}
+}
+
+// Add adjoins the entities to the group identified by name, skipping those already in
+// the group, ignoring case. It returns the number of entities skipped, which are also
+// counted in GroupStats.Duplicates.
+func (s *Store) Add(name string, entities ...[]rune) int {
+	s.recordEntities("", AuditAdd, name, nil, entities...)
+	g := s.addGroup(name)
+	g.Lock()
+	skipped := 0
+	for _, e := range entities {
+		if !g.add(newEntry(e)) {
+			skipped++
+		}
+	}
+	g.Unlock()
+	s.bump()
+	return skipped
+}
+
+// AddWeighted adjoins the entity e to the group identified by name with the given weight,
+// which is reported on every match of the entity and used to rank matches by
+// Results.TopK. Entities added by Add have the weight DefaultWeight. If the group already
+// has the entity, ignoring case, it keeps its weight.
+func (s *Store) AddWeighted(name string, e []rune, weight float64) {
+	var weights []float64
+	if weight != DefaultWeight {
+		weights = []float64{weight}
+	}
+	s.recordEntities("", AuditAdd, name, weights, e)
+	g := s.addGroup(name)
+	g.Lock()
+	g.add(entry{text: e, weight: weight})
+	g.Unlock()
+	s.bump()
+}
+
+// ValidateEntity checks that e can be found once added to a group, returning an error
+// wrapping ErrEntityTooLong if it is longer than MaxEntityLen.
+func ValidateEntity(e []rune) error {
+	if len(e) > MaxEntityLen {
+		return fmt.Errorf("%q has length %d, maximum is %d: %w", string(e), len(e), MaxEntityLen, ErrEntityTooLong)
+	}
+	return nil
+}
+
+// group returns the group identified by name, creating it if it doesn't exist.
+func (s *Store) group(name string) *group {
 	s.Lock()
 	g, ok := s.groups[name]
 	if !ok {
-		g = &group{
-			name:     name,
-			entities: make(map[string][][]rune, DefaultGroupSize),
-		}
+		g = s.newGroup(name)
 		s.groups[name] = g
 	}
 	s.Unlock()
+	if !ok {
+		s.bump()
+	}
+	return g
+}
 
-	g.Lock()
-	for _, e := range entities {
-		h := hash([]rune(e))
-		g.entities[h] = append(g.entities[h], e)
-		if len(e) > g.maxLen {
-			g.maxLen = len(e)
+// newGroup returns a new group identified by name, configured as the store configures
+// all its groups. The caller must hold the store lock.
+func (s *Store) newGroup(name string) *group {
+	g := newGroup(name)
+	g.wordLimit = s.wordLimit
+	if s.counting {
+		g.startCounting()
+	}
+	return g
+}
+
+// SetMaxEntityWords limits the entities which can be found to those with at most n words,
+// bounding the work done for each word of a document. By default, or if n is 0, entities
+// with any number of words can be found. Note that entities are also limited to
+// MaxEntityLen runes.
+func (s *Store) SetMaxEntityWords(n int) {
+	s.Lock()
+	defer s.Unlock()
+	defer s.bump()
+
+	s.wordLimit = n
+	for _, groups := range []map[string]*group{s.groups, s.batch} {
+		for _, g := range groups {
+			g.Lock()
+			g.wordLimit = n
+			g.Unlock()
 		}
 	}
-	g.Unlock()
+}
+
+// window returns the length in runes of the longest text the group can match while
+// searching with sc, which is the length of its longest entity unless text is matched
+// other than by its own text, such as once normalized or with bidi controls stripped.
+func (g *group) window(sc *scratch) int {
+	if g.normalized != nil || g.acronyms != nil || g.phonetic != nil || g.synonyms != nil || g.provider != nil || sc.hasBidi || sc.tok.Splitters != "" {
+		return MaxEntityLen
+	}
+	return g.maxLen
+}
+
+// depth returns the maximum number of words in the entities which can be found.
+func (g *group) depth() int {
+	if g.wordLimit > 0 && g.wordLimit < g.maxWords {
+		return g.wordLimit
+	}
+	return g.maxWords
+}
+
+// depthFor returns the maximum number of words in the entities which can be found in
+// documents split by tok. A BoundaryClass or DigitBoundaries may split entities into more
+// words than they were counted as when added, as many as one in every two of their runes.
+func (g *group) depthFor(tok Tokenizer) int {
+	d := g.depth()
+	if tok.BoundaryClass.empty() && !tok.DigitBoundaries {
+		return d
+	}
+	if n := (g.maxLen + 1) / 2; n > d {
+		d = n
+		if g.wordLimit > 0 && g.wordLimit < d {
+			d = g.wordLimit
+		}
+	}
+	return d
+}
+
+// add inserts the entry e, unless the group already has an entity with the same text
+// ignoring case, in which case it counts a duplicate and returns false, or is frozen. The
+// caller must hold the group lock.
+func (g *group) add(e entry) bool {
+	if g.frozen {
+		return false
+	}
+	key := strings.ToLower(string(e.text))
+	if _, ok := g.folded[key]; ok {
+		atomic.AddUint64(&g.stats.duplicates, 1)
+		if g.recent != nil {
+			g.touch(key, e.text)
+		}
+		return false
+	}
+	g.folded[key] = struct{}{}
+	if e.folded == "" {
+		e.folded = string(appendFolded(nil, e.text))
+	}
+	h := hash(e.text)
+	g.evictable = false
+	atomic.AddInt64(&g.size, entrySize(e))
+	g.entities[h] = append(g.entities[h], e)
+	if len(e.text) > g.maxLen {
+		g.maxLen = len(e.text)
+	}
+	if n := wordCount(e.text); n > g.maxWords {
+		g.maxWords = n
+	}
+	if g.counts != nil {
+		g.addCounter(e)
+	}
+	if g.normalized != nil {
+		g.addNormalized(e)
+	}
+	if g.acronyms != nil {
+		g.addAcronym(e)
+	}
+	if g.phonetic != nil {
+		g.addPhonetic(e)
+	}
+	if g.synonyms != nil {
+		g.addSynonyms(e)
+	}
+	if g.recent != nil {
+		g.touch(key, e.text)
+		g.forgetOldest()
+	}
+	return true
 }
 
 func hash(rs []rune) string {
@@ -106,37 +472,260 @@ func hash(rs []rune) string {
 	if len(rs) > 1 {
 		return fmt.Sprintf("%s%s%03d", string(unicode.ToLower(rs[0])), string(unicode.ToLower(rs[1])), len(rs))
 	}
+	if len(rs) == 0 {
+		return "000"
+	}
 	return fmt.Sprintf("%s%03d", string(unicode.ToLower(rs[0])), len(rs))
 }
 
-// FindAll searches the input returning a maping group name -> found entities.
-func (s *Store) FindAll(rs []rune) map[string][]Entity {
-	result := make(map[string][]Entity, len(s.groups))
-	for s, g := range s.groups {
-		result[s] = g.Find(rs)
+// appendFolded appends the UTF-8 encoding of rs in lower case to b, mapping each rune with
+// unicode.ToLower as equalFold does.
+func appendFolded(b []byte, rs []rune) []byte {
+	for _, r := range rs {
+		if r < utf8.RuneSelf {
+			if 'A' <= r && r <= 'Z' {
+				r += 'a' - 'A'
+			}
+			b = append(b, byte(r))
+			continue
+		}
+		b = utf8.AppendRune(b, unicode.ToLower(r))
+	}
+	return b
+}
+
+// equalFold reports whether a and b are equal when compared case insensitively.
+func equalFold(a, b []rune) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i, r := range a {
+		if unicode.ToLower(r) != unicode.ToLower(b[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// lookup returns the first stored entry equal to e (case insensitively), or nil if there is
+// none. The caller must hold the group lock.
+func (g *group) lookup(e []rune) *entry {
+	ents := g.entities[hash(e)]
+	for i := range ents {
+		if equalFold(ents[i].text, e) {
+			return &ents[i]
+		}
+	}
+	return nil
+}
+
+// len returns the number of entities in the group. The caller must hold the group lock.
+func (g *group) len() int {
+	n := 0
+	for _, ents := range g.entities {
+		n += len(ents)
 	}
-	return result
+	return n
+}
+
+// all returns every entity in the group, in no particular order.
+func (g *group) all() []entry {
+	g.rlock()
+	defer g.RUnlock()
+
+	all := make([]entry, 0, g.len())
+	for _, ents := range g.entities {
+		all = append(all, ents...)
+	}
+	return all
+}
+
+// FindAll searches the input returning a maping group name -> found entities. The options
+// opts configure this search only, see FindOption.
+func (s *Store) FindAll(rs []rune, opts ...FindOption) Results {
+	r, _ := s.FindAllContext(context.Background(), rs, opts...)
+	return r
 }
 
-// Find only the entities of a given type = "key"
-func (g *group) Find(rs []rune) []Entity {
-	g.RLock()
-	ents := find(rs, []*group{g})
-	g.RUnlock()
-	return ents[g.name]
+// FindAllContext is like FindAll, but stops searching when ctx is cancelled, returning
+// ctx.Err(). Long documents are searched for some time, so this bounds the time spent on
+// each.
+func (s *Store) FindAllContext(ctx context.Context, rs []rune, opts ...FindOption) (Results, error) {
+	return s.FindAllLanguageContext(ctx, "", rs, opts...)
 }
 
-// Lock free find for use internally
-func find(rs []rune, groups []*group) map[string][]Entity {
-	results := make(map[string][]Entity, len(groups))
-	pairs := make([]pair, 0, 20)
+// findDocument searches the preprocessed document in the groups, which must be locked,
+// returning the entities found in each group before any result filters are applied. The
+// search is configured by c, which may be nil.
+func findDocument(ctx context.Context, d *document, groups []*group, c *findConfig) (Results, error) {
+	if c == nil {
+		c = &findConfig{}
+	}
+	result := make(Results, len(groups))
+	for _, g := range groups {
+		var ents []Entity
+		if n := c.capacity[g.name]; n > 0 {
+			ents = make([]Entity, 0, n)
+		}
+		result[g.name] = ents
+	}
+	buf := c.scratch
+	if buf == nil {
+		buf = new(Scratch)
+	}
+	limits := buf.limiter()
+	err := find(ctx, d.text, d.tok, groups, buf, func(g *group, ent *entry, e Entity) bool {
+		if !limits.allow(g, ent) {
+			return true
+		}
+		g.count(ent)
+		result[g.name] = append(result[g.name], d.original(e))
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	return suppressDenied(result, groups), nil
+}
+
+// rlockGroups read locks all the groups of the store and returns them. Groups are locked
+// in order of name, so that searches of several groups can't deadlock with each other
+// while writers wait for the locks.
+func (s *Store) rlockGroups() []*group {
+	return s.rlockGroupsWhere(nil)
+}
+
+// rlockGroupsWhere is like rlockGroups, but only locks and returns the groups whose names
+// keep returns true for, or all of them if keep is nil.
+func (s *Store) rlockGroupsWhere(keep func(name string) bool) []*group {
+	s.RLock()
+	groups := make([]*group, 0, len(s.groups))
+	for name, g := range s.groups {
+		if keep == nil || keep(name) {
+			groups = append(groups, g)
+		}
+	}
+	s.RUnlock()
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].name < groups[j].name
+	})
+	for {
+		// Load lazy groups before locking any, since loading needs the write lock, and
+		// evict others if they take the store over its memory limit
+		for _, g := range groups {
+			g.load()
+			atomic.StoreUint64(&g.used, atomic.AddUint64(&s.clock, 1))
+		}
+		s.evict(groups)
+		for _, g := range groups {
+			g.RLock()
+		}
+		// Groups may have been evicted by another search before they were locked
+		evicted := false
+		for _, g := range groups {
+			evicted = evicted || atomic.LoadUint32(&g.lazy) == 1
+		}
+		if !evicted {
+			return groups
+		}
+		runlockGroups(groups)
+	}
+}
+
+func runlockGroups(groups []*group) {
+	for _, g := range groups {
+		g.RUnlock()
+	}
+}
+
+// Lock free find for use internally. Calls fn for each entity in the order they are
+// found, along with the stored entry it matched, stopping early if fn returns false or
+// ctx is cancelled, in which case it returns ctx.Err(). rs is split into words by tok.
+//
+// Each word is checked as the last word of an entity, along with the words preceding it
+// on a stack which is as deep as the group with the most words in an entity. The buffers
+// of buf are used while searching, or new ones if it is nil.
+func find(ctx context.Context, rs []rune, tok Tokenizer, groups []*group, buf *Scratch, fn func(g *group, ent *entry, e Entity) bool) error {
+	if buf == nil {
+		buf = new(Scratch)
+	}
+	// Count the matches of each group for its stats
+	found := buf.counts(len(groups))
+	defer func() {
+		for i, g := range groups {
+			g.stats.record(found[i])
+		}
+	}()
+	return search(ctx, rs, tok, groups, found, buf, fn)
+}
+
+// search is find without recording stats, adding the number of matches of each group to
+// found instead.
+func search(ctx context.Context, rs []rune, tok Tokenizer, groups []*group, found []uint64, buf *Scratch, fn func(g *group, ent *entry, e Entity) bool) error {
+	if err := prefetchProvided(ctx, rs, tok, groups); err != nil {
+		return err
+	}
+	if buf == nil {
+		buf = new(Scratch)
+	}
+	sc := buf.reset(rs, tok)
+	depth, window := 1, 0
+	windows, depths := buf.windowsFor(len(groups))
+	for j, g := range groups {
+		depths[j] = g.depthFor(tok)
+		if depths[j] > depth {
+			depth = depths[j]
+		}
+		windows[j] = g.window(sc)
+		if windows[j] > window {
+			window = windows[j]
+		}
+	}
+	pairs := buf.pairs[:0]
+	if cap(pairs) != depth {
+		pairs = make([]pair, 0, depth)
+		buf.pairs = pairs
+	}
+
+	current := 0
+	count := func(g *group, ent *entry, e Entity) bool {
+		if !g.scoreCase(&e) || (g.noDigitBoundaries && tok.splitsDigits(rs, e.Offset, e.Offset+len(e.Text))) {
+			return true
+		}
+		found[current]++
+		return fn(g, ent, e)
+	}
+
+	// Run the stack, check for entities working backwards from the current position
+	check := func() bool {
+		p2 := pairs[len(pairs)-1]
+		for i := len(pairs) - 1; i >= 0; i-- {
+			p1 := pairs[i]
+			span := p2[right] - p1[left]
+			if span > window {
+				break // Too long for any group, can ignore it
+			}
+			for j, g := range groups {
+				if len(pairs)-i > depths[j] || span > windows[j] {
+					continue
+				}
+				current = j
+				if !g.match(rs, pairs[i:], sc, count) {
+					return false
+				}
+			}
+		}
+		return true
+	}
+
 	start := 0
 	prevSpace := true // First char of sequence is legit
 	space := false
-
-	for off, r := range rs {
+	n := 0
+	for off := range rs {
 		// What are we looking at?
-		space = unicode.IsPunct(r) || unicode.IsSpace(r)
+		space = tok.boundary(rs, off)
 
 		if prevSpace && !space {
 			// Word is beginning at this rune
@@ -144,45 +733,21 @@ func find(rs []rune, groups []*group) map[string][]Entity {
 		} else if space && !prevSpace {
 			// Word is ending, shift the pairs stack
 			_, pairs = shift(pair{start, off}, pairs)
-
-			// Run the stack, check for entities working backwards from the current position
-			if len(pairs) > 1 {
-				p2 := pairs[len(pairs)-1]
-				for i := len(pairs) - 1; i >= 0; i-- {
-					p1 := pairs[i]
-					if p2[right]-p1[left] > MaxEntityLen {
-						break // Too long or short, can ignore it
-					}
-					for _, g := range groups {
-						if p2[right]-p1[left] > g.maxLen {
-							continue
-						}
-						if ents, ok := g.entities[hash(rs[p1[left]:p2[right]])]; ok {
-							// We have at least one entity with this key
-							for _, ent := range ents {
-								if len(ent) != p2[right]-p1[left] {
-									break
-								}
-								match := true
-								for i, r := range ent {
-									if unicode.ToLower(r) != unicode.ToLower(rs[p1[left]+i]) {
-										match = false
-										break
-									}
-								}
-								if match {
-									results[g.name] = append(results[g.name],
-										Entity{
-											Text:   rs[p1[left]:p2[right]],
-											Offset: p1[left],
-										},
-									)
-								}
-							}
-						}
-					}
+			if !check() {
+				return nil
+			}
+			if n++; n%checkInterval == 0 {
+				if err := ctx.Err(); err != nil {
+					return err
 				}
 			}
+		} else if !space && tok.digitBoundary(rs, off) {
+			// Word is ending between a letter and a digit, and another beginning
+			_, pairs = shift(pair{start, off}, pairs)
+			if !check() {
+				return nil
+			}
+			start = off
 		}
 
 		// Mark prevSpace for the next loop
@@ -192,103 +757,251 @@ func find(rs []rune, groups []*group) map[string][]Entity {
 			prevSpace = false
 		}
 	}
-	return results
+
+	// The last word may end the sequence
+	if !prevSpace {
+		_, pairs = shift(pair{start, len(rs)}, pairs)
+		check()
+	}
+	return nil
 }
 
-var entityFileSuffix = ".entities.csv"
+// scratch holds buffers reused while searching a document.
+type scratch struct {
+	key   []byte
+	split []rune
+	bidi  []rune
 
-// FromDir creates a new Store by loading entity files from a given directory path. Any files
-// contained in the directory with names matching <group>.entities.csv will be imported,
-// and the entities added to the group <group>.
-func FromDir(dir string) (*Store, error) {
-	dir = strings.TrimRight(dir, "/")
-	files, err := ioutil.ReadDir(dir)
-	if err != nil {
-		return nil, err
+	// hasBidi is set if the document contains bidirectional formatting characters.
+	hasBidi bool
+
+	// tok is the tokenizer splitting the document into words.
+	tok Tokenizer
+
+	// Double Metaphone codes of the words of the document, by offset.
+	codes map[int][2]string
+}
+
+// match calls fn for each entity in the group which matches the text of rs spanning the
+// words ws, returning false if fn does.
+func (g *group) match(rs []rune, ws []pair, sc *scratch, fn func(g *group, ent *entry, e Entity) bool) bool {
+	p1, p2 := ws[0], ws[len(ws)-1]
+	text := rs[p1[left]:p2[right]]
+	if len(text) < g.minLen {
+		return true
 	}
 
-	s := New()
-	var wg sync.WaitGroup
-	count := struct {
-		sync.Mutex
-		n int
-	}{}
-
-	errCh := make(chan error, len(files))
-	for _, stat := range files {
-		if strings.HasSuffix(stat.Name(), entityFileSuffix) {
-			wg.Add(1)
-			go func(path string, group string) {
-				defer wg.Done()
-				f, err := os.Open(path)
-				if err != nil {
-					errCh <- fmt.Errorf("error opening %v: %v\n", path, err)
-					return
-				}
-				defer f.Close()
+	if g.provider != nil && !g.matchProvided(rs, ws, sc, fn) {
+		return false
+	}
 
-				err = AddFromReader(f, s, group)
-				if err != nil {
-					errCh <- fmt.Errorf("error reading from %v: %v\n", path, err)
-					return
-				}
-				count.Lock()
-				count.n++
-				count.Unlock()
-			}(fmt.Sprintf("%s/%s", dir, stat.Name()), strings.TrimSuffix(stat.Name(), entityFileSuffix))
+	if g.acronyms != nil && len(ws) == 1 {
+		ents := g.acronyms[string(text)]
+		for j := range ents {
+			e := Entity{
+				Text:      text,
+				Offset:    p1[left],
+				Canonical: ents[j].text,
+				Kind:      AcronymMatch,
+				Score:     1,
+				Weight:    ents[j].weight,
+				Group:     g.name,
+			}
+			if !fn(g, &ents[j], e) {
+				return false
+			}
 		}
 	}
-	wg.Wait()
-	close(errCh)
 
-	for e := range errCh {
-		if err == nil {
-			err = e
-		}
+	if g.phonetic != nil && !g.matchPhonetic(rs, ws, sc, fn) {
+		return false
 	}
-	if err != nil {
-		return nil, err
+
+	if g.synonyms != nil && !g.matchSynonyms(rs, ws, sc, fn) {
+		return false
 	}
 
-	if count.n == 0 {
-		return nil, errors.New("no entity files found")
+	if g.normalized != nil {
+		// Normalized keys are built from the words of the text, which are found again
+		// once bidi controls are stripped or compounds split
+		key, src, sws := text, rs, ws
+		if sc.hasBidi && len(ws) > 1 {
+			sc.bidi = stripBidiControls(sc.bidi[:0], text)
+			key = sc.bidi
+			src, sws = key, words(key)
+		}
+		if !g.matchNormalized(src, sws, text, p1[left], sc, fn) {
+			return false
+		}
+		if sc.tok.Splitters != "" && len(ws) > 1 {
+			var split bool
+			if sc.split, split = sc.tok.splitCompounds(sc.split[:0], key); split {
+				return g.matchNormalized(sc.split, words(sc.split), text, p1[left], sc, fn)
+			}
+		}
+		return true
 	}
-	return s, nil
-}
 
-// AddFromReader adds entities to the store under the group name from the io.Reader.
-func AddFromReader(r io.Reader, store *Store, name string) error {
-	s := bufio.NewScanner(r)
-	for s.Scan() {
-		rt := []rune(s.Text())
-		if len(rt) > 0 {
-			store.Add(name, rt)
+	key := text
+	if sc.hasBidi && len(ws) > 1 {
+		sc.bidi = stripBidiControls(sc.bidi[:0], text)
+		key = sc.bidi
+	}
+	if len(key) > g.maxLen {
+		return true
+	}
+	if !g.matchText(key, text, p1[left], sc, fn) {
+		return false
+	}
+	if sc.tok.Splitters != "" && len(ws) > 1 {
+		var split bool
+		if sc.split, split = sc.tok.splitCompounds(sc.split[:0], key); split {
+			return g.matchText(sc.split, text, p1[left], sc, fn)
 		}
 	}
-	return s.Err()
+	return true
 }
 
-// Save writes the existing entities to disk under the given directory path (assumed
-// to already exist). Each entity group becomes a file <group>.entities.csv.
-func (s *Store) Save(dir string) error {
-	s.RLock()
-	defer s.RUnlock()
-
-	dir = strings.TrimRight(dir, "/")
-	for name, g := range s.groups {
-		path := fmt.Sprintf("%s/%s", dir, strings.Replace(name, "/", "_", -1)+entityFileSuffix)
-		f, err := os.Create(path)
-		if err != nil {
-			return err
+// matchNormalized calls fn for each entity in the group with the normalized key of the
+// text of rs spanning the words ws, reporting them as matches of text at offset,
+// returning false if fn does.
+func (g *group) matchNormalized(rs []rune, ws []pair, text []rune, offset int, sc *scratch, fn func(g *group, ent *entry, e Entity) bool) bool {
+	if len(ws) == 0 {
+		return true
+	}
+	sc.key = g.normalizedKey(sc.key[:0], rs, ws)
+	ents := g.normalized[string(sc.key)]
+	for j := range ents {
+		if !g.caseMatches(ents[j].text, text) {
+			continue
+		}
+		e := Entity{
+			Text:      text,
+			Offset:    offset,
+			Canonical: ents[j].text,
+			Score:     1,
+			Weight:    ents[j].weight,
+			Group:     g.name,
+		}
+		if !fn(g, &ents[j], e) {
+			return false
 		}
-		defer f.Close()
+	}
+	return true
+}
 
-		for _, entities := range g.entities {
-			for _, e := range entities {
-				f.WriteString(string(e) + "\n")
+// matchText calls fn for each entity in the group equal to key ignoring case, reporting
+// them as matches of text at offset, returning false if fn does.
+//
+// Candidates are found by the hash of the key, or in groups frozen by Build by the key in
+// lower case, and verified by comparing the key in lower case with the folded text of each
+// as bytes, which the runtime compares many at a time, rather than folding them rune by
+// rune.
+func (g *group) matchText(key, text []rune, offset int, sc *scratch, fn func(g *group, ent *entry, e Entity) bool) bool {
+	sc.key = appendFolded(sc.key[:0], key)
+	var ents []entry
+	if g.exact != nil {
+		ents = g.exact[string(sc.key)]
+	} else {
+		ents = g.entities[hash(key)]
+	}
+	for j := range ents {
+		ent := &ents[j]
+		if len(ent.text) != len(key) {
+			break
+		}
+		if ent.folded == string(sc.key) && g.caseMatches(ent.text, key) {
+			e := Entity{
+				Text:      text,
+				Offset:    offset,
+				Canonical: ent.text,
+				Score:     1,
+				Weight:    ent.weight,
+				Group:     g.name,
+			}
+			if !fn(g, ent, e) {
+				return false
 			}
 		}
-		f.Close()
 	}
+	return true
+}
+
+// isBoundary reports whether r separates words, without the context Tokenizer uses.
+func isBoundary(r rune) bool {
+	return unicode.IsPunct(r) || unicode.IsSpace(r) || isEmoji(r) || isBidiControl(r)
+}
+
+// words returns the start and end offsets of the words in rs, as split by the zero
+// Tokenizer.
+func words(rs []rune) []pair {
+	return Tokenizer{}.words(rs)
+}
+
+var entityFileSuffix = ".entities.csv"
+
+// FromDir creates a new Store by loading entity files from a given directory path. Any files
+// contained in the directory with names matching <group>.entities.csv will be imported,
+// and the entities added to the group <group>. Files named <group>.entities.tsv and
+// <group>.entities.jsonl are imported too, as tab separated columns under a header and as
+// JSON Lines, with the entities' IDs and metadata attached as EntityInfo values:
+//
+//	text	id	weight	country
+//	Sydney	Q3130	2	AU
+//
+//	{"text": "Sydney", "id": "Q3130", "weight": 2, "metadata": {"country": "AU"}}
+func FromDir(dir string, opts ...LoadOption) (*Store, error) {
+	s, _, err := LoadDir(dir, opts...)
+	return s, err
+}
+
+// FromDirContext is like FromDir, but aborts loading when ctx is cancelled, returning an
+// error wrapping ctx.Err().
+func FromDirContext(ctx context.Context, dir string, opts ...LoadOption) (*Store, error) {
+	s, _, err := LoadDirContext(ctx, dir, opts...)
+	return s, err
+}
+
+// AddFromReader adds entities to the store under the group name from the io.Reader, one
+// per line as in CSV entity files. With the DetectFormat option, the entities can be in
+// any of the formats of entity files read by FromDir, detected from the first line,
+// Weights reads the weights of CSV entities, and SourceName names where they came from.
+// It returns an error wrapping ErrGroupFrozen if the group is frozen.
+func AddFromReader(r io.Reader, store *Store, name string, opts ...LoadOption) error {
+	if err := store.checkFrozen(name); err != nil {
+		return err
+	}
+	var c loadConfig
+	for _, opt := range opts {
+		opt.applyLoad(&c)
+	}
+	br := bufio.NewReader(r)
+	format := csvFormat
+	if c.detectFormat {
+		format = sniffFormat(br)
+	}
+	ents, _, err := readEntities(context.Background(), br, &c, format, nil, nil)
+	if err != nil {
+		return err
+	}
+	setSource(ents, c.source)
+	store.recordEntries(AuditAdd, name, ents)
+	store.addEntries(name, ents)
 	return nil
 }
+
+// addEntries adds the entries to the group identified by name, returning the number
+// added.
+func (s *Store) addEntries(name string, ents []entry) int {
+	g := s.addGroup(name)
+	g.Lock()
+	added := 0
+	for _, e := range ents {
+		if g.add(e) {
+			added++
+		}
+	}
+	g.Unlock()
+	s.bump()
+	return added
+}
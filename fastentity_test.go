@@ -1,8 +1,39 @@
 package fastentity
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"testing"
+	"unicode"
+)
 
-var resume_store *Store
+var (
+	resume_store *Store
+	resumeOnce   sync.Once
+)
+
+// resumeStore returns resume_store, creating it with entities commonly found in resumes.
+func resumeStore() *Store {
+	resumeOnce.Do(func() {
+		resume_store = New()
+		for name, ents := range map[string][]string{
+			"skills":    {"accounting", "tax", "Excel", "Lotus", "WordPerfect", "technical writing", "editing", "payroll", "research", "communication", "Spreadsheet Auditor", "Ami Pro", "ProComm Plus"},
+			"jobTitles": {"Tax Accountant", "Tax Staff Accountant", "accountant", "Accounting"},
+			"locations": {"Houston", "Texas", "TX", "New York", "Friendswood", "Bleeker Street"},
+			"education": {"Bachelor of Science", "Master of Science", "Master of Business Administration", "University of Houston", "University of New York"},
+		} {
+			for _, e := range ents {
+				resume_store.Add(name, []rune(e))
+			}
+		}
+	})
+	return resume_store
+}
 
 func TestHash(t *testing.T) {
 	strs := map[string]string{
@@ -11,6 +42,7 @@ func TestHash(t *testing.T) {
 		"PHP":                "php003",
 		"本語":                 "本語002",
 		"C":                  "c001", // Single char entity
+		"":                   "000",
 	}
 	for original, hashexpected := range strs {
 		hashed := hash([]rune(original))
@@ -20,6 +52,39 @@ func TestHash(t *testing.T) {
 	}
 }
 
+func TestAppendFolded(t *testing.T) {
+	for _, s := range []string{"Golang Developer", "ÉCOLE", "Straße", "İstanbul", "ΣΊΣΥΦΟΣ", "本語", ""} {
+		want := []rune(s)
+		for i, r := range want {
+			want[i] = unicode.ToLower(r)
+		}
+		if got := string(appendFolded(nil, []rune(s))); got != string(want) {
+			t.Errorf("Expected %q folded to be %q, got %q", s, string(want), got)
+		}
+	}
+}
+
+func TestWindow(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"), []rune("golang developer"))
+	store.Add("titles", []rune("Senior Vice President of Engineering"))
+	store.Add("places", []rune("St Kilda"))
+	store.Group("places").Configure(Abbreviations(map[string]string{"st": "street"}))
+
+	sc := &scratch{}
+	if w := store.group("skills").window(sc); w != len("golang developer") {
+		t.Errorf("Expected skills to match at most %d runes, got %d", len("golang developer"), w)
+	}
+	if w := store.group("places").window(sc); w != MaxEntityLen {
+		t.Errorf("Expected normalized places to match up to MaxEntityLen runes, got %d", w)
+	}
+
+	r := store.FindAll([]rune("A golang developer, Senior Vice President of Engineering at PHP Co on St. Kilda Rd"))
+	if len(r["skills"]) != 2 || len(r["titles"]) != 1 || len(r["places"]) != 1 {
+		t.Errorf("Expected 2 skills, 1 title and 1 place, got %v", r)
+	}
+}
+
 func TestFind(t *testing.T) {
 	str := []rune("日 本語. jack was a golang developer from sydney, for someone. San Francisco, USA... Or so they say. Maybe PHP, or PDX. Jody Shipway\\u0007\\n\\u0007")
 
@@ -82,13 +147,108 @@ func TestFind(t *testing.T) {
 	}
 }
 
+func TestFindWordStack(t *testing.T) {
+	defer func(n int) { MaxEntityLen = n }(MaxEntityLen)
+	MaxEntityLen = 100
+
+	long := strings.Repeat("a ", 24) + "b"
+	store := New()
+	store.Add("long", []rune(long))
+	store.Add("short", []rune("golang developer"), []rune("PHP"))
+
+	str := []rune("PHP, " + long + " golang developer")
+	results := store.FindAll(str)
+	if found := results["long"]; len(found) != 1 || found[0].Offset != 5 {
+		t.Errorf("Expected to find the 25 word entity at 5, got %v", found)
+	}
+	if found := results["short"]; len(found) != 2 || found[0].Offset != 0 || string(found[1].Text) != "golang developer" {
+		t.Errorf("Expected to find entities at the start and end of the text, got %v", found)
+	}
+
+	store.SetMaxEntityWords(2)
+	results = store.FindAll(str)
+	if found := results["long"]; len(found) != 0 {
+		t.Errorf("Expected entities longer than 2 words not to be found, got %v", found)
+	}
+	if found := results["short"]; len(found) != 2 {
+		t.Errorf("Expected 2 entities of at most 2 words, got %v", found)
+	}
+}
+
+// Run with -race to check concurrent searches and modifications.
+func TestConcurrentAddFindAll(t *testing.T) {
+	str := []rune("jack was a golang developer from sydney. Maybe PHP, or PDX. ")
+
+	store := New("skills")
+	store.Add("skills", []rune("PHP"))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				store.Add("skills", []rune(fmt.Sprintf("skill%d-%d", i, j)))
+				store.AddWeighted(fmt.Sprintf("group%d", j%10), []rune("sydney"), 2)
+				if j%25 == 0 {
+					store.Group("jobTitles").Configure(FoldPlurals())
+					store.SetMaxEntityWords(j % 3)
+				}
+			}
+		}(i)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				if found := store.FindAll(str)["skills"]; len(found) != 1 {
+					t.Errorf("Expected to find PHP, got %v", found)
+					return
+				}
+				store.Group("skills").Find(str)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := store.Group("skills").Len(); n != 401 {
+		t.Errorf("Expected 401 skills, got %d", n)
+	}
+}
+
+// resume is a resume size document.
+var resume = []rune("Jim Smith,  Bleeker Street Houston, Texas 77034  (315) 555-5145  jimsmith@example.com  Objective: Seeking a position in an accounting field where I can utilize my skills and abilities in the field of tax oriented job that offers professional tax accountant.  Educational Details:  Bachelor of Science in Accounting University of Houston, 1989 Master of Science of Taxation University of New York, 1990  Master of Business Administration in Finance University of New York, 1992  Summary of Qualifications:  •  6+ years of tax and accounting experience.  •  Experience in establishing corporate tax department.  •  Experience working in global business environment.  •  Experience in using technology tools to leverage data, increase process and tax return efficiency, and complete work.  •  Able to research tax issues, apply practical tax experience.  Skills:  •  Excellent technical writing and editing skills.  •  Strong verbal communication skills.  •  Strong influencing skills across business functions.  •  Advanced computer skills.  •  Excellent accounting skills.  Computer Skills:  Lotus, Excel, Ami Pro, WordPerfect, ProComm Plus, Spreadsheet Auditor, Flowcharting III. Professional Experience:  Leading Commercial Printer, Houston, TX, 1996-2000 Tax Accountant  Responsibilities:  •  Prepared individual, partnership, corporate and other types of tax returns.  •  Did research on various tax matters.  •  Ensured that all sales and use tax returns are filed timely and accurately.  •  Prepared written communication for sales and use tax issues.  •  Collected information for all sales tax, use tax, and personal property tax audits.  •  Performed other duties as assigned.  Hipping Agency, Friendswood, TX, 1992-1995  Tax Staff Accountant  Responsibilities:  •  Established a 401K plan for company employees, enhancing the company's benefits package.  •  Prepared payroll, sales, use & property & commercial rent returns.  •  Responded to both client and government inquiries.  •  Devised the spreadsheet packages, financial statements and tax filings.  •  Ensured that all legal fees and push down entries for separate companies are recorded  ")
+
 // Approximates finding entities in a resume size document
 func BenchmarkFind(b *testing.B) {
-	b.StopTimer()
-	str := []rune("Jim Smith,  Bleeker Street Houston, Texas 77034  (315) 555-5145  jimsmith@example.com  Objective: Seeking a position in an accounting field where I can utilize my skills and abilities in the field of tax oriented job that offers professional tax accountant.  Educational Details:  Bachelor of Science in Accounting University of Houston, 1989 Master of Science of Taxation University of New York, 1990  Master of Business Administration in Finance University of New York, 1992  Summary of Qualifications:  •  6+ years of tax and accounting experience.  •  Experience in establishing corporate tax department.  •  Experience working in global business environment.  •  Experience in using technology tools to leverage data, increase process and tax return efficiency, and complete work.  •  Able to research tax issues, apply practical tax experience.  Skills:  •  Excellent technical writing and editing skills.  •  Strong verbal communication skills.  •  Strong influencing skills across business functions.  •  Advanced computer skills.  •  Excellent accounting skills.  Computer Skills:  Lotus, Excel, Ami Pro, WordPerfect, ProComm Plus, Spreadsheet Auditor, Flowcharting III. Professional Experience:  Leading Commercial Printer, Houston, TX, 1996-2000 Tax Accountant  Responsibilities:  •  Prepared individual, partnership, corporate and other types of tax returns.  •  Did research on various tax matters.  •  Ensured that all sales and use tax returns are filed timely and accurately.  •  Prepared written communication for sales and use tax issues.  •  Collected information for all sales tax, use tax, and personal property tax audits.  •  Performed other duties as assigned.  Hipping Agency, Friendswood, TX, 1992-1995  Tax Staff Accountant  Responsibilities:  •  Established a 401K plan for company employees, enhancing the company's benefits package.  •  Prepared payroll, sales, use & property & commercial rent returns.  •  Responded to both client and government inquiries.  •  Devised the spreadsheet packages, financial statements and tax filings.  •  Ensured that all legal fees and push down entries for separate companies are recorded  ")
-	b.StartTimer()
+	store := resumeStore()
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		store.FindAll(resume)
+	}
+}
+
+// Finds long entities, which are verified against candidates of the same hash
+func BenchmarkFindLongEntities(b *testing.B) {
+	store := New()
+	var doc []rune
+	for i := 0; i < 100; i++ {
+		e := fmt.Sprintf("Department of Administrative and Regulatory Affairs Division %d", i)
+		store.Add("organizations", []rune(e))
+		doc = append(doc, []rune(strings.ToUpper(e)+" and ")...)
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		store.FindAll(doc)
+	}
+}
+
+// Approximates finding entities in a stream of resumes, sizing the results of each like
+// those of the last
+func BenchmarkFindSizedLike(b *testing.B) {
+	store := resumeStore()
+	last := store.FindAll(resume)
+	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
-		resume_store.FindAll(str)
+		last = store.FindAll(resume, SizedLike(last))
 	}
 }
 
@@ -112,3 +272,49 @@ func TestSaveLoad(t *testing.T) {
 		}
 	}
 }
+
+func TestErrors(t *testing.T) {
+	store := New("skills")
+	if _, err := store.LookupGroup("skills"); err != nil {
+		t.Errorf("Expected to find group 'skills', got %v", err)
+	}
+	if _, err := store.LookupGroup("missing"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("Expected ErrGroupNotFound, got %v", err)
+	}
+
+	if err := ValidateEntity([]rune("golang developer")); err != nil {
+		t.Errorf("Expected entity to be valid, got %v", err)
+	}
+	if err := ValidateEntity([]rune(strings.Repeat("a", MaxEntityLen+1))); !errors.Is(err, ErrEntityTooLong) {
+		t.Errorf("Expected ErrEntityTooLong, got %v", err)
+	}
+
+	dir, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err := FromDir(dir); !errors.Is(err, ErrNoEntityFiles) {
+		t.Errorf("Expected ErrNoEntityFiles, got %v", err)
+	}
+	if _, err := FromDir(dir + "/missing"); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected os.ErrNotExist, got %v", err)
+	}
+}
+
+func TestFindAllContext(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"))
+	doc := []rune(strings.Repeat("PHP and ", 2*checkInterval))
+
+	r, err := store.FindAllContext(context.Background(), doc)
+	if err != nil || len(r["skills"]) != 2*checkInterval {
+		t.Errorf("Expected %d matches, got %d (%v)", 2*checkInterval, len(r["skills"]), err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := store.FindAllContext(ctx, doc); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,79 @@
+//go:build !js
+
+package fastentity
+
+import (
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// The file system is accessed only through the functions in this file, which aren't
+// available when compiled to WebAssembly for the browser, see file_js.go.
+
+func openFile(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+// openReaderAt opens the file at path for random access, returning its size.
+func openReaderAt(path string) (readerAtCloser, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	stat, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, stat.Size(), nil
+}
+
+func createFile(path string) (io.WriteCloser, error) {
+	return os.Create(path)
+}
+
+func renameFile(old, new string) error {
+	return os.Rename(old, new)
+}
+
+func removeFile(path string) error {
+	return os.Remove(path)
+}
+
+func mkdirAll(dir string) error {
+	return os.MkdirAll(dir, 0755)
+}
+
+// listFiles returns the paths of the files in dir relative to it, with '/' separators,
+// including those in subdirectories if nested is set.
+func listFiles(dir string, nested bool) ([]string, error) {
+	var files []string
+	if !nested {
+		stats, err := ioutil.ReadDir(dir)
+		if err != nil {
+			return nil, err
+		}
+		for _, stat := range stats {
+			if !stat.IsDir() {
+				files = append(files, stat.Name())
+			}
+		}
+		return files, nil
+	}
+
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		files = append(files, filepath.ToSlash(rel))
+		return nil
+	})
+	return files, err
+}
@@ -0,0 +1,41 @@
+//go:build js
+
+package fastentity
+
+import (
+	"errors"
+	"io"
+)
+
+// errNoFileSystem is returned by the functions which read and write files when compiled
+// to WebAssembly, where there is no file system. Dictionaries can still be loaded with
+// ReadSnapshot, and built up with Add.
+var errNoFileSystem = errors.New("no file system on js/wasm")
+
+func openFile(path string) (io.ReadCloser, error) {
+	return nil, errNoFileSystem
+}
+
+func openReaderAt(path string) (readerAtCloser, int64, error) {
+	return nil, 0, errNoFileSystem
+}
+
+func createFile(path string) (io.WriteCloser, error) {
+	return nil, errNoFileSystem
+}
+
+func renameFile(old, new string) error {
+	return errNoFileSystem
+}
+
+func removeFile(path string) error {
+	return errNoFileSystem
+}
+
+func mkdirAll(dir string) error {
+	return errNoFileSystem
+}
+
+func listFiles(dir string, nested bool) ([]string, error) {
+	return nil, errNoFileSystem
+}
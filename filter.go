@@ -0,0 +1,184 @@
+package fastentity
+
+import (
+	"sort"
+	"unicode"
+)
+
+// ResultFilter post-processes the matches found by FindAll, returning the matches to keep.
+// Matches are passed in document order, and filters may reorder, drop or modify them.
+type ResultFilter func(ms []Match) []Match
+
+// AddResultFilter registers filters to be applied in order to the results of every
+// subsequent FindAll and Group.Find call, after any filters already registered. Results
+// from Matches are not filtered.
+func (s *Store) AddResultFilter(fs ...ResultFilter) {
+	s.Lock()
+	s.filters = append(s.filters, fs...)
+	s.Unlock()
+	s.bump()
+}
+
+// filter applies the registered result filters to r.
+func (s *Store) filter(r Results) Results {
+	s.RLock()
+	filters := s.filters
+	s.RUnlock()
+	if len(filters) == 0 {
+		return r
+	}
+
+	ms := r.Matches()
+	for _, f := range filters {
+		ms = f(ms)
+	}
+	for name := range r {
+		r[name] = nil
+	}
+	for _, m := range ms {
+		r[m.Group] = append(r[m.Group], m.Entity)
+	}
+	return r
+}
+
+// Matches returns the matches of all groups in document order, with matches at the same
+// offset ordered by group name, then in the order found.
+func (r Results) Matches() []Match {
+	var ms []Match
+	for name, ents := range r {
+		for _, e := range ents {
+			e.Group = name
+			ms = append(ms, Match{Entity: e})
+		}
+	}
+	sort.SliceStable(ms, func(i, j int) bool {
+		if ms[i].Offset != ms[j].Offset {
+			return ms[i].Offset < ms[j].Offset
+		}
+		return ms[i].Group < ms[j].Group
+	})
+	return ms
+}
+
+// DropSubMatches returns a ResultFilter which drops matches lying within a longer match,
+// in any group, e.g. "York" within "New York". Matches of the same text in several groups
+// are all kept.
+func DropSubMatches() ResultFilter {
+	return dropSubMatches
+}
+
+func dropSubMatches(ms []Match) []Match {
+	drop := make([]bool, len(ms))
+	maxEnd := -1 // furthest end of the matches starting before the current offset
+	for i := 0; i < len(ms); {
+		// Matches at the same offset are covered by the longest of them
+		j, longest := i, 0
+		for ; j < len(ms) && ms[j].Offset == ms[i].Offset; j++ {
+			if len(ms[j].Text) > longest {
+				longest = len(ms[j].Text)
+			}
+		}
+		for k := i; k < j; k++ {
+			end := ms[k].Offset + len(ms[k].Text)
+			drop[k] = end <= maxEnd || len(ms[k].Text) < longest
+		}
+		if end := ms[i].Offset + longest; end > maxEnd {
+			maxEnd = end
+		}
+		i = j
+	}
+
+	kept := ms[:0]
+	for i, m := range ms {
+		if !drop[i] {
+			kept = append(kept, m)
+		}
+	}
+	return kept
+}
+
+// MinLength returns a ResultFilter which drops matches of fewer than n runes.
+func MinLength(n int) ResultFilter {
+	return func(ms []Match) []Match {
+		kept := ms[:0]
+		for _, m := range ms {
+			if len(m.Text) >= n {
+				kept = append(kept, m)
+			}
+		}
+		return kept
+	}
+}
+
+// MaxPerGroup returns a ResultFilter which keeps the first n matches of each group.
+func MaxPerGroup(n int) ResultFilter {
+	return func(ms []Match) []Match {
+		counts := make(map[string]int)
+		kept := ms[:0]
+		for _, m := range ms {
+			if counts[m.Group] < n {
+				counts[m.Group]++
+				kept = append(kept, m)
+			}
+		}
+		return kept
+	}
+}
+
+// MergeAdjacent returns a ResultFilter which merges consecutive matches of the same group
+// separated only by whitespace into a single match, e.g. the skills "machine learning"
+// and "engineer" in "machine learning engineer". The merged match spans the text of both,
+// its Canonical joins theirs with a space, and it has the lower score and higher weight
+// of the two. Its Kind is that of the first match.
+//
+// Matches are only merged when the text between them is known, which is the case for
+// those found by FindAll but not necessarily for matches built by other filters.
+func MergeAdjacent() ResultFilter {
+	return mergeAdjacent
+}
+
+func mergeAdjacent(ms []Match) []Match {
+	kept := ms[:0]
+	last := make(map[string]int) // index in kept of the last match of each group
+	for _, m := range ms {
+		i, ok := last[m.Group]
+		if ok && i == len(kept)-1 {
+			if text, ok := joinText(kept[i].Entity, m.Entity); ok {
+				prev := &kept[i]
+				prev.Text = text
+				prev.Canonical = append(append(append([]rune(nil), prev.Canonical...), ' '), m.Canonical...)
+				if m.Score < prev.Score {
+					prev.Score = m.Score
+				}
+				if m.Weight > prev.Weight {
+					prev.Weight = m.Weight
+				}
+				continue
+			}
+		}
+		last[m.Group] = len(kept)
+		kept = append(kept, m)
+	}
+	return kept
+}
+
+// joinText returns the text spanning a and b, if b follows a in the same document
+// separated only by whitespace. Matches found in a document share its runes, so the text
+// between them is found by extending a.
+func joinText(a, b Entity) ([]rune, bool) {
+	gap := b.Offset - a.Offset - len(a.Text)
+	n := len(a.Text) + gap + len(b.Text)
+	if gap <= 0 || len(a.Text) == 0 || len(b.Text) == 0 || cap(a.Text) < n {
+		return nil, false
+	}
+	text := a.Text[:n]
+	if &text[len(a.Text)+gap] != &b.Text[0] {
+		return nil, false
+	}
+	for _, r := range text[len(a.Text) : len(a.Text)+gap] {
+		if !unicode.IsSpace(r) {
+			return nil, false
+		}
+	}
+	return text, true
+}
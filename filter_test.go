@@ -0,0 +1,92 @@
+package fastentity
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestResultFilter(t *testing.T) {
+	str := []rune("Skills: C, golang and PHP, PHP, PHP. Based in Sydney. ")
+
+	store := New()
+	store.Add("skills", []rune("C"), []rune("golang"), []rune("PHP"))
+	store.Add("locations", []rune("Sydney"))
+
+	// Drop single character matches
+	store.AddResultFilter(func(ms []Match) []Match {
+		kept := ms[:0]
+		for _, m := range ms {
+			if len(m.Text) > 1 {
+				kept = append(kept, m)
+			}
+		}
+		return kept
+	})
+	// Keep at most two matches per group
+	store.AddResultFilter(func(ms []Match) []Match {
+		counts := make(map[string]int)
+		kept := ms[:0]
+		for _, m := range ms {
+			if counts[m.Group] < 2 {
+				counts[m.Group]++
+				kept = append(kept, m)
+			}
+		}
+		return kept
+	})
+
+	results := store.FindAll(str)
+	skills := results["skills"]
+	if len(skills) != 2 || string(skills[0].Text) != "golang" || string(skills[1].Text) != "PHP" || skills[1].Offset != 22 {
+		t.Errorf("Expected golang and the first PHP, got %v", skills)
+	}
+	if len(results["locations"]) != 1 {
+		t.Errorf("Expected 1 location, got %d", len(results["locations"]))
+	}
+	if found := store.Group("skills").Find(str); !reflect.DeepEqual(found, skills) {
+		t.Errorf("Expected Find to be filtered as FindAll is, got %v", found)
+	}
+}
+
+func TestFilters(t *testing.T) {
+	str := []rune("Machine learning engineer in New York, York and New York City. ")
+
+	for _, c := range []struct {
+		name     string
+		filters  []ResultFilter
+		expected []string
+	}{
+		{"none", nil, []string{"Machine learning", "engineer", "New York", "York", "York", "York",
+			"York", "New York City", "New York", "York", "York"}},
+		{"sub-matches", []ResultFilter{DropSubMatches()}, []string{"Machine learning", "engineer", "New York",
+			"York", "York", "New York City"}},
+		{"min length", []ResultFilter{MinLength(5)}, []string{"Machine learning", "engineer", "New York",
+			"New York City", "New York"}},
+		{"merged", []ResultFilter{DropSubMatches(), MergeAdjacent()}, []string{"Machine learning engineer",
+			"New York", "York", "York", "New York City"}},
+		{"max per group", []ResultFilter{MaxPerGroup(1)}, []string{"Machine learning", "New York", "York"}},
+	} {
+		store := New()
+		store.Add("skills", []rune("machine learning"), []rune("engineer"))
+		store.Add("locations", []rune("New York"), []rune("York"))
+		store.Add("cities", []rune("York"), []rune("New York City"))
+		store.AddResultFilter(c.filters...)
+
+		var got []string
+		for _, m := range store.FindAll(str).Matches() {
+			got = append(got, string(m.Text))
+		}
+		if strings.Join(got, "|") != strings.Join(c.expected, "|") {
+			t.Errorf("%s: expected %q, got %q", c.name, c.expected, got)
+		}
+	}
+
+	store := New()
+	store.Add("skills", []rune("machine learning"), []rune("engineer"))
+	store.AddResultFilter(MergeAdjacent())
+	found := store.FindAll(str)["skills"]
+	if len(found) != 1 || string(found[0].Canonical) != "machine learning engineer" || found[0].Offset != 0 {
+		t.Errorf("Expected a single merged skill, got %v", found)
+	}
+}
@@ -0,0 +1,198 @@
+package fastentity
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// EntityInfo is the ID and metadata of an entity read from a TSV or JSON Lines entity
+// file, which is attached to the entity as its value, and returned with each match by a
+// TypedGroup[EntityInfo] of its group.
+type EntityInfo struct {
+	ID       string            `json:"id,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+}
+
+// entityFormat is the format of an entity file.
+type entityFormat int
+
+const (
+	// csvFormat is an entity per line, optionally followed by a weight, see Weights.
+	csvFormat entityFormat = iota
+	// tsvFormat is tab separated columns named by a header line, in which the column
+	// "text" is the entity, "id" its ID, "weight" its weight, and others its metadata.
+	tsvFormat
+	// jsonlFormat is a JSON object per line with the fields "text", "id", "weight" and
+	// "metadata", a JSON object of strings.
+	jsonlFormat
+)
+
+var (
+	tsvFileSuffix   = ".entities.tsv"
+	jsonlFileSuffix = ".entities.jsonl"
+)
+
+// formatOf returns the format of the entity file at path, by its extension.
+func formatOf(path string) entityFormat {
+	switch {
+	case strings.HasSuffix(path, ".tsv"):
+		return tsvFormat
+	case strings.HasSuffix(path, ".jsonl"):
+		return jsonlFormat
+	}
+	return csvFormat
+}
+
+// DetectFormat makes AddFromReader detect the format of the entities it reads from their
+// first line: JSON Lines if it is a JSON object, TSV if it is a header with a "text"
+// column, and otherwise CSV. Files loaded from a directory have the format of their
+// extension, so it has no effect on them.
+func DetectFormat() LoadOption {
+	return loadOptionFunc(func(c *loadConfig) {
+		c.detectFormat = true
+	})
+}
+
+// sniffFormat guesses the format of the entities read by br from their first line, which
+// is a JSON object in the JSON Lines format, and a header with a "text" column in TSV.
+func sniffFormat(br *bufio.Reader) entityFormat {
+	b, _ := br.Peek(4096)
+	b = bytes.TrimLeft(b, " \t\r\n")
+	if i := bytes.IndexByte(b, '\n'); i >= 0 {
+		b = b[:i]
+	}
+	if len(b) > 0 && b[0] == '{' {
+		return jsonlFormat
+	}
+	if bytes.IndexByte(b, '\t') < 0 {
+		return csvFormat
+	}
+	for _, col := range bytes.Split(bytes.TrimRight(b, "\r"), []byte("\t")) {
+		if string(col) == "text" {
+			return tsvFormat
+		}
+	}
+	return csvFormat
+}
+
+var (
+	errMissingText  = errors.New("missing text")
+	errNoTextColumn = errors.New(`no "text" column in header`)
+)
+
+// lineParser parses a line of an entity file, returning false if the line has no entity,
+// e.g. a header.
+type lineParser func(line string) (entry, bool, error)
+
+// newLineParser returns a lineParser for the lines of entity files of format f.
+func newLineParser(f entityFormat, c *loadConfig) lineParser {
+	switch f {
+	case tsvFormat:
+		return newTSVParser()
+	case jsonlFormat:
+		return parseJSONL
+	}
+	return func(line string) (entry, bool, error) {
+		weight := DefaultWeight
+		if c.weights {
+			var err error
+			if line, weight, err = parseWeight(line); err != nil {
+				return entry{}, false, err
+			}
+		}
+		return entry{text: []rune(line), weight: weight}, true, nil
+	}
+}
+
+// newTSVParser returns a lineParser for TSV entity files, which takes the first line as
+// the header.
+func newTSVParser() lineParser {
+	var header []string
+	text := -1
+	return func(line string) (entry, bool, error) {
+		line = strings.TrimSuffix(line, "\r")
+		if header == nil {
+			header = strings.Split(line, "\t")
+			for i, col := range header {
+				if col == "text" {
+					text = i
+				}
+			}
+			if text < 0 {
+				return entry{}, false, errNoTextColumn
+			}
+			return entry{}, false, nil
+		}
+		if text < 0 {
+			return entry{}, false, errNoTextColumn
+		}
+
+		cols := strings.Split(line, "\t")
+		if len(cols) != len(header) {
+			return entry{}, false, fmt.Errorf("%d columns, header has %d", len(cols), len(header))
+		}
+		if cols[text] == "" {
+			return entry{}, false, errMissingText
+		}
+		e := newEntry([]rune(cols[text]))
+		var info EntityInfo
+		for i, col := range cols {
+			switch header[i] {
+			case "text":
+			case "id":
+				info.ID = col
+			case "weight":
+				if col == "" {
+					continue
+				}
+				w, err := strconv.ParseFloat(col, 64)
+				if err != nil {
+					return entry{}, false, fmt.Errorf("invalid weight %q", col)
+				}
+				e.weight = w
+			default:
+				if col == "" {
+					continue
+				}
+				if info.Metadata == nil {
+					info.Metadata = make(map[string]string)
+				}
+				info.Metadata[header[i]] = col
+			}
+		}
+		if info.ID != "" || info.Metadata != nil {
+			e.value = info
+		}
+		return e, true, nil
+	}
+}
+
+// jsonlEntity is a line of a JSON Lines entity file.
+type jsonlEntity struct {
+	Text   string   `json:"text"`
+	Weight *float64 `json:"weight"`
+	EntityInfo
+}
+
+func parseJSONL(line string) (entry, bool, error) {
+	var je jsonlEntity
+	if err := json.Unmarshal([]byte(line), &je); err != nil {
+		return entry{}, false, err
+	}
+	if je.Text == "" {
+		return entry{}, false, errMissingText
+	}
+	e := newEntry([]rune(je.Text))
+	if je.Weight != nil {
+		e.weight = *je.Weight
+	}
+	if je.ID != "" || len(je.Metadata) > 0 {
+		e.value = je.EntityInfo
+	}
+	return e, true, nil
+}
@@ -0,0 +1,93 @@
+//go:build go1.18
+
+package fastentity
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestLoadFormats(t *testing.T) {
+	dir := writeEntityFiles(t, map[string]string{
+		"skills.entities.csv": "PHP\n",
+		"locations.entities.tsv": "text\tid\tweight\tcountry\n" +
+			"Sydney\tQ3130\t2\tAU\n" +
+			"Houston\t\t\t\n" +
+			"Paris\tQ90\n",
+		"people.entities.jsonl": `{"text": "Ada Lovelace", "id": "Q7259", "metadata": {"born": "1815"}}` + "\n" +
+			`{"text": "Alan Turing", "weight": 3}` + "\n" +
+			`{"id": "Q1"}` + "\n",
+	})
+	defer os.RemoveAll(dir)
+
+	store, r, err := LoadDir(dir, SkipErrors())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"skills": 1, "locations": 2, "people": 2}
+	if !reflect.DeepEqual(r.Entities, want) {
+		t.Errorf("Expected %v entities, got %v", want, r.Entities)
+	}
+	for _, f := range r.Files {
+		if len(f.Skipped) != 1 && f.Group != "skills" {
+			t.Errorf("Expected a line of %s skipped, got %v", f.Path, f.Skipped)
+		}
+	}
+
+	locations := NewTypedGroup[EntityInfo](store, "locations")
+	ents := locations.Find([]rune("From Sydney to Houston"))
+	if len(ents) != 2 {
+		t.Fatalf("Expected 2 locations, got %v", ents)
+	}
+	if info := ents[0].Value; info.ID != "Q3130" || info.Metadata["country"] != "AU" || ents[0].Weight != 2 {
+		t.Errorf("Expected Sydney with its ID, metadata and weight, got %+v", ents[0])
+	}
+	if info := ents[1].Value; info.ID != "" || info.Metadata != nil || ents[1].Weight != DefaultWeight {
+		t.Errorf("Expected Houston without ID, metadata or weight, got %+v", ents[1])
+	}
+
+	people := NewTypedGroup[EntityInfo](store, "people")
+	if info, _ := people.Value([]rune("Ada Lovelace")); info.ID != "Q7259" || info.Metadata["born"] != "1815" {
+		t.Errorf("Expected Ada Lovelace with an ID and metadata, got %+v", info)
+	}
+	if ents := people.Find([]rune("Alan Turing")); len(ents) != 1 || ents[0].Weight != 3 {
+		t.Errorf("Expected Alan Turing with weight 3, got %+v", ents)
+	}
+
+	if _, err := FromDir(dir); err == nil || !strings.Contains(err.Error(), "line 4") {
+		t.Errorf("Expected an error for the line with too few columns, got %v", err)
+	}
+}
+
+func TestAddFromReaderFormats(t *testing.T) {
+	for _, tt := range []struct {
+		name, content string
+		opts          []LoadOption
+		n             int
+	}{
+		{"csv", "golang\nmachine learning\n", nil, 2},
+		{"tsv as csv", "id\ttext\n1\tgolang\n2\tmachine learning\n", nil, 3},
+		{"jsonl as csv", "{\"text\": \"golang\"}\n", nil, 1},
+		{"detected csv", "golang\nmachine learning\n", []LoadOption{DetectFormat()}, 2},
+		{"detected tsv", "id\ttext\n1\tgolang\n2\tmachine learning\n", []LoadOption{DetectFormat()}, 2},
+		{"detected jsonl", "{\"text\": \"golang\"}\n{\"text\": \"machine learning\"}\n", []LoadOption{DetectFormat()}, 2},
+	} {
+		store := New()
+		if err := AddFromReader(strings.NewReader(tt.content), store, "skills", tt.opts...); err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if n := store.Stats()["skills"].Entities; n != tt.n {
+			t.Errorf("%s: expected %d entities, got %d", tt.name, tt.n, n)
+		}
+	}
+
+	store := New()
+	if err := AddFromReader(strings.NewReader("golang,2\n"), store, "skills", Weights()); err != nil {
+		t.Fatal(err)
+	}
+	if found := store.FindAll([]rune("golang"))["skills"]; len(found) != 1 || found[0].Weight != 2 {
+		t.Errorf("Expected golang with weight 2, got %v", found)
+	}
+}
@@ -0,0 +1,123 @@
+package fastentity
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+)
+
+// ErrGroupFrozen is returned by changes to groups frozen by FreezeGroup.
+var ErrGroupFrozen = errors.New("group is frozen")
+
+// FreezeGroup makes the group identified by name immutable and optimizes it for
+// searching, as Build does for every group of a copy of the store, so that large static
+// dictionaries are searched on the fast path while other groups, such as trending terms,
+// stay editable. A lazy group is loaded first. It returns an error wrapping
+// ErrGroupNotFound if there is no such group.
+//
+// Entities added to a frozen group are skipped, and counted as skipped by Add, removing
+// entities from it removes none, and configuring it has no effect. AddAs, RemoveAs and
+// AddFromReader return an error wrapping ErrGroupFrozen instead. The group can still be
+// renamed, copied or deleted, and ThawGroup makes it editable again.
+func (s *Store) FreezeGroup(name string) error {
+	g, err := s.existingGroup(name)
+	if err != nil {
+		return err
+	}
+	for {
+		if err := g.load(); err != nil {
+			return err
+		}
+		g.Lock()
+		if atomic.LoadUint32(&g.lazy) == 0 {
+			break
+		}
+		g.Unlock() // Evicted before it was locked
+	}
+	frozen := g.frozen
+	if !frozen {
+		g.freeze()
+		g.frozen = true
+	}
+	g.Unlock()
+	if !frozen {
+		s.bump()
+	}
+	return nil
+}
+
+// ThawGroup makes the group identified by name, frozen by FreezeGroup, editable again. It
+// has no effect on groups which aren't frozen, and returns an error wrapping
+// ErrGroupNotFound if there is no such group.
+func (s *Store) ThawGroup(name string) error {
+	g, err := s.existingGroup(name)
+	if err != nil {
+		return err
+	}
+	g.Lock()
+	frozen := g.frozen
+	if frozen {
+		g.thaw()
+	}
+	g.Unlock()
+	if frozen {
+		s.bump()
+	}
+	return nil
+}
+
+// Frozen reports whether the group is frozen, see Store.FreezeGroup. Missing groups
+// aren't frozen.
+func (g *Group) Frozen() bool {
+	grp, err := g.s.existingGroup(g.name)
+	if err != nil {
+		return false
+	}
+	grp.RLock()
+	defer grp.RUnlock()
+	return grp.frozen
+}
+
+// existingGroup returns the group identified by name, or an error wrapping
+// ErrGroupNotFound if there is no such group.
+func (s *Store) existingGroup(name string) (*group, error) {
+	s.RLock()
+	g, ok := s.groups[name]
+	s.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", name, ErrGroupNotFound)
+	}
+	return g, nil
+}
+
+// checkFrozen returns an error wrapping ErrGroupFrozen if the group identified by name is
+// frozen.
+func (s *Store) checkFrozen(name string) error {
+	s.RLock()
+	g, ok := s.groups[name]
+	s.RUnlock()
+	if !ok {
+		return nil
+	}
+	g.RLock()
+	frozen := g.frozen
+	g.RUnlock()
+	if frozen {
+		return fmt.Errorf("%q: %w", name, ErrGroupFrozen)
+	}
+	return nil
+}
+
+// thaw undoes freeze, indexing the entities by their lower case text for skipping
+// duplicates again. The caller must hold the group lock.
+func (g *group) thaw() {
+	g.exact = nil
+	g.folded = make(map[string]struct{}, g.len())
+	for _, ents := range g.entities {
+		for _, e := range ents {
+			g.folded[strings.ToLower(string(e.text))] = struct{}{}
+		}
+	}
+	g.frozen = false
+}
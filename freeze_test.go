@@ -0,0 +1,88 @@
+package fastentity
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestFreezeGroup(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("golang"), []rune("PHP"))
+	store.Add("trending", []rune("rust"))
+	doc := []rune("golang, PHP and rust developer")
+
+	if err := store.FreezeGroup("locations"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("Expected ErrGroupNotFound freezing a missing group, got %v", err)
+	}
+	version := store.Version()
+	if err := store.FreezeGroup("skills"); err != nil {
+		t.Fatal(err)
+	}
+	if store.Version() <= version {
+		t.Errorf("Expected freezing to bump the version from %d, got %d", version, store.Version())
+	}
+	version = store.Version()
+	if err := store.FreezeGroup("skills"); err != nil || store.Version() != version {
+		t.Errorf("Expected freezing a frozen group to have no effect, got version %d (%v)", store.Version(), err)
+	}
+	if !store.Group("skills").Frozen() || store.Group("trending").Frozen() {
+		t.Error("Expected only skills to be frozen")
+	}
+	if r := store.FindAll(doc); len(r["skills"]) != 2 || len(r["trending"]) != 1 {
+		t.Errorf("Expected the frozen group to be searched, got %v", r)
+	}
+
+	// The frozen group can't be changed, while others can
+	if n := store.Add("skills", []rune("developer")); n != 1 {
+		t.Errorf("Expected the entity added to the frozen group to be skipped, got %d", n)
+	}
+	if n := store.Remove("skills", []rune("PHP")); n != 0 {
+		t.Errorf("Expected no entities removed from the frozen group, got %d", n)
+	}
+	if err := store.AddAs("jim", "skills", []rune("developer")); !errors.Is(err, ErrGroupFrozen) {
+		t.Errorf("Expected ErrGroupFrozen from AddAs, got %v", err)
+	}
+	if err := store.RemoveAs("jim", "skills", []rune("PHP")); !errors.Is(err, ErrGroupFrozen) {
+		t.Errorf("Expected ErrGroupFrozen from RemoveAs, got %v", err)
+	}
+	if err := AddFromReader(strings.NewReader("developer\n"), store, "skills"); !errors.Is(err, ErrGroupFrozen) {
+		t.Errorf("Expected ErrGroupFrozen from AddFromReader, got %v", err)
+	}
+	store.BeginBatch()
+	store.Add("skills", []rune("developer"))
+	store.Commit()
+	store.Add("trending", []rune("developer"))
+	if r := store.FindAll(doc); len(r["skills"]) != 2 || len(r["trending"]) != 2 {
+		t.Errorf("Expected only the group which isn't frozen to change, got %v", r)
+	}
+
+	version = store.Version()
+	if err := store.ThawGroup("skills"); err != nil {
+		t.Fatal(err)
+	}
+	if store.Version() <= version {
+		t.Errorf("Expected thawing to bump the version from %d, got %d", version, store.Version())
+	}
+	if store.Group("skills").Frozen() {
+		t.Error("Expected skills to be thawed")
+	}
+	if n := store.Add("skills", []rune("developer"), []rune("php")); n != 1 {
+		t.Errorf("Expected only the duplicate to be skipped once thawed, got %d", n)
+	}
+	if n := store.Remove("skills", []rune("PHP")); n != 1 {
+		t.Errorf("Expected PHP to be removed once thawed, got %d", n)
+	}
+	if r := store.FindAll(doc); len(r["skills"]) != 2 {
+		t.Errorf("Expected golang and developer to be found, got %v", r["skills"])
+	}
+
+	skills := store.Group("skills")
+	if err := store.RenameGroup("skills", "languages"); err != nil {
+		t.Fatal(err)
+	}
+	version = store.Version()
+	if skills.Frozen() || store.Version() != version {
+		t.Errorf("Expected a missing group not to be frozen or created, got version %d", store.Version())
+	}
+}
@@ -0,0 +1,79 @@
+package fastentity
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// checkFind checks invariants of FindAll for a dictionary and document, for use by fuzz
+// tests: every match must be of a stored entity, ignoring case, and must start and end on
+// word boundaries at the offset reported, and the results must be the same once the
+// groups have been written to entity files and read back again.
+func checkFind(dict []string, doc string) error {
+	store := New()
+	stored := make(map[string]map[string]bool)
+	for i, e := range dict {
+		if strings.ContainsAny(e, "\r\n") {
+			continue // Can't be saved
+		}
+		name := fmt.Sprintf("g%d", i%2)
+		store.Add(name, []rune(e))
+		if stored[name] == nil {
+			stored[name] = make(map[string]bool)
+		}
+		stored[name][string([]rune(e))] = true
+	}
+
+	rs := []rune(doc)
+	results := store.FindAll(rs)
+	for name, ents := range results {
+		for _, e := range ents {
+			end := e.Offset + len(e.Text)
+			if e.Offset < 0 || end > len(rs) || string(rs[e.Offset:end]) != string(e.Text) {
+				return fmt.Errorf("%s: %q is not at offset %d", name, string(e.Text), e.Offset)
+			}
+			if !stored[name][string(e.Canonical)] || !strings.EqualFold(string(e.Text), string(e.Canonical)) {
+				return fmt.Errorf("%s: %q at %d doesn't match a stored entity", name, string(e.Text), e.Offset)
+			}
+			if (e.Offset > 0 && !isBoundary(rs[e.Offset-1])) || (end < len(rs) && !isBoundary(rs[end])) {
+				return fmt.Errorf("%s: %q at %d isn't on word boundaries", name, string(e.Text), e.Offset)
+			}
+		}
+	}
+	if len(stored) == 0 {
+		return nil
+	}
+
+	loaded := New()
+	for name, g := range store.groups {
+		var buf bytes.Buffer
+		if err := writeEntities(context.Background(), &buf, g.all(), false); err != nil {
+			return fmt.Errorf("saving %s: %w", name, err)
+		}
+		ents, _, err := readEntities(context.Background(), &buf, &loadConfig{}, csvFormat, nil, nil)
+		if err != nil {
+			return fmt.Errorf("loading %s: %w", name, err)
+		}
+		loaded.addEntries(name, ents)
+	}
+	if a, b := matchList(results), matchList(loaded.FindAll(rs)); !reflect.DeepEqual(a, b) {
+		return fmt.Errorf("found %v before saving, but %v after loading", a, b)
+	}
+	return nil
+}
+
+// matchList lists the matches in r in a canonical order, for comparison.
+func matchList(r Results) []string {
+	var ms []string
+	for name, ents := range r {
+		for _, e := range ents {
+			ms = append(ms, fmt.Sprintf("%s:%d:%s", name, e.Offset, string(e.Canonical)))
+		}
+	}
+	sort.Strings(ms)
+	return ms
+}
@@ -0,0 +1,19 @@
+//go:build gofuzz
+// +build gofuzz
+
+package fastentity
+
+import "strings"
+
+// Fuzz is the entry point for go-fuzz. The input is a dictionary of entities, one per
+// line, followed by a NUL byte and the document to search.
+func Fuzz(data []byte) int {
+	dict, doc := string(data), ""
+	if i := strings.IndexByte(dict, 0); i >= 0 {
+		dict, doc = dict[:i], dict[i+1:]
+	}
+	if err := checkFind(strings.Split(dict, "\n"), doc); err != nil {
+		panic(err)
+	}
+	return 0
+}
@@ -0,0 +1,17 @@
+package fastentity
+
+import (
+	"strings"
+	"testing"
+)
+
+func FuzzFind(f *testing.F) {
+	f.Add("San Francisco, USA\ngolang developer\nPHP\n本語", "日 本語. jack was a golang developer from sydney, for someone. San Francisco, USA... Maybe PHP")
+	f.Add("C\nC++\nc#", "Knows C, C++ and C#")
+	f.Add("", "")
+	f.Fuzz(func(t *testing.T, dict, doc string) {
+		if err := checkFind(strings.Split(dict, "\n"), doc); err != nil {
+			t.Error(err)
+		}
+	})
+}
@@ -0,0 +1,309 @@
+package fastentity
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+)
+
+// Group is a handle to a single group of entities in a Store, for code which works with
+// one group and would otherwise repeat its name on every call.
+//
+// The handle is resolved by name on each call, so it stays valid as the group is
+// modified, and recreates the group if needed.
+type Group struct {
+	s    *Store
+	name string
+}
+
+// GroupOption configures the behaviour of a group.
+type GroupOption func(g *group)
+
+// Capacity reserves space for n entities in the group, avoiding repeated growth when the
+// size of a group is known before it is loaded.
+func Capacity(n int) GroupOption {
+	return func(g *group) {
+		entities := make(map[string][]entry, n)
+		for h, ents := range g.entities {
+			entities[h] = ents
+		}
+		g.entities = entities
+		folded := make(map[string]struct{}, n)
+		for key := range g.folded {
+			folded[key] = struct{}{}
+		}
+		g.folded = folded
+	}
+}
+
+// Group returns a handle to the group identified by name, creating the group if it doesn't
+// exist.
+func (s *Store) Group(name string) *Group {
+	s.group(name)
+	return &Group{
+		s:    s,
+		name: name,
+	}
+}
+
+// Name returns the name of the group.
+func (g *Group) Name() string {
+	return g.name
+}
+
+// Add adjoins the entities to the group, returning the number skipped as duplicates, see
+// Store.Add.
+func (g *Group) Add(entities ...[]rune) int {
+	return g.s.Add(g.name, entities...)
+}
+
+// Find searches the input returning the entities of this group found, as FindAll does
+// with the store's preprocessors, match limits and result filters. Filters only see the
+// matches of this group.
+func (g *Group) Find(rs []rune) []Entity {
+	return g.s.findGroup(g.name, rs)
+}
+
+// findGroup searches rs for the entities of the group name as FindAll does.
+func (s *Store) findGroup(name string, rs []rune) []Entity {
+	s.group(name)
+	d := s.preprocess(rs)
+	groups := s.rlockGroupsWhere(func(n string) bool { return n == name })
+	r, _ := findDocument(context.Background(), d, groups, nil)
+	runlockGroups(groups)
+	return s.filter(r)[name]
+}
+
+// Len returns the number of entities in the group, or 0 if there is no such group.
+func (g *Group) Len() int {
+	grp, err := g.s.existingGroup(g.name)
+	if err != nil {
+		return 0
+	}
+	grp.rlock()
+	defer grp.RUnlock()
+	return grp.len()
+}
+
+// Range calls fn for each entity in the group, in no particular order, stopping early if
+// fn returns false. The group must not be modified from within fn.
+func (g *Group) Range(fn func(e []rune) bool) {
+	grp, err := g.s.existingGroup(g.name)
+	if err != nil {
+		return
+	}
+	grp.rlock()
+	defer grp.RUnlock()
+
+	for _, ents := range grp.entities {
+		for _, e := range ents {
+			if !fn(e.text) {
+				return
+			}
+		}
+	}
+}
+
+// Configure applies the options to the group, unless it's frozen.
+func (g *Group) Configure(opts ...GroupOption) {
+	grp := g.s.group(g.name)
+	grp.Lock()
+	if !grp.frozen {
+		for _, opt := range opts {
+			opt(grp)
+		}
+	}
+	grp.Unlock()
+	g.s.bump()
+}
+
+// LookupGroup returns a handle to the existing group identified by name, or an error
+// wrapping ErrGroupNotFound if there is no such group.
+func (s *Store) LookupGroup(name string) (*Group, error) {
+	s.RLock()
+	_, ok := s.groups[name]
+	s.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%q: %w", name, ErrGroupNotFound)
+	}
+	return &Group{
+		s:    s,
+		name: name,
+	}, nil
+}
+
+// RenameGroup renames the group identified by old to new. It returns an error wrapping
+// ErrGroupNotFound if there is no such group, or ErrGroupExists if a group named new
+// already exists. Handles to the group by its old name will recreate it if used.
+func (s *Store) RenameGroup(old, new string) error {
+	if err := s.renameGroup(old, new); err != nil {
+		return err
+	}
+	s.record(AuditEvent{Group: old, Op: AuditRename, To: new})
+	return nil
+}
+
+func (s *Store) renameGroup(old, new string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	g, ok := s.groups[old]
+	if !ok {
+		return fmt.Errorf("%q: %w", old, ErrGroupNotFound)
+	}
+	if _, ok := s.groups[new]; ok {
+		return fmt.Errorf("%q: %w", new, ErrGroupExists)
+	}
+	g.Lock()
+	g.name = new
+	g.Unlock()
+	delete(s.groups, old)
+	s.groups[new] = g
+	s.bump()
+	return nil
+}
+
+// CopyGroup creates the group dst with the entities and configuration of the group src,
+// sharing its indices rather than adding each entity again. It returns an error wrapping
+// ErrGroupNotFound if there is no group src, or ErrGroupExists if dst already exists.
+func (s *Store) CopyGroup(src, dst string) error {
+	if err := s.copyGroup(src, dst); err != nil {
+		return err
+	}
+	s.record(AuditEvent{Group: src, Op: AuditCopy, To: dst})
+	return nil
+}
+
+func (s *Store) copyGroup(src, dst string) error {
+	s.Lock()
+	defer s.Unlock()
+
+	g, ok := s.groups[src]
+	if !ok {
+		return fmt.Errorf("%q: %w", src, ErrGroupNotFound)
+	}
+	if _, ok := s.groups[dst]; ok {
+		return fmt.Errorf("%q: %w", dst, ErrGroupExists)
+	}
+	g.RLock()
+	s.groups[dst] = g.clone(dst)
+	g.RUnlock()
+	s.bump()
+	return nil
+}
+
+// Clone returns a copy of the store, with the groups, configuration, result filters and
+// preprocessors of the store, so that changes can be tried on the copy, and its results
+// compared with those of the store, without affecting it. Groups share their entities with
+// those of the store until either is changed, so cloning is cheaper than loading the
+// store again.
+//
+// The copy has the version of the store, but no audit sink or version directory, so that
+// changes to it aren't recorded as changes to the store. Match counts, see CountMatches,
+// start again from zero, and entities added to the store in a batch which hasn't been
+// committed aren't copied.
+func (s *Store) Clone() *Store {
+	s.RLock()
+	defer s.RUnlock()
+
+	c := &Store{
+		version:       s.Version(),
+		memoryLimit:   s.memoryLimit,
+		groups:        make(map[string]*group, len(s.groups)),
+		filters:       s.filters[:len(s.filters):len(s.filters)],
+		preprocessors: s.preprocessors[:len(s.preprocessors):len(s.preprocessors)],
+		wordLimit:     s.wordLimit,
+		counting:      s.counting,
+		shards:        s.shards,
+		tokenizer:     s.tokenizer,
+		manifest:      s.manifest,
+		sources:       s.sources,
+	}
+	for name, g := range s.groups {
+		g.RLock()
+		c.groups[name] = g.clone(name)
+		g.RUnlock()
+	}
+	return c
+}
+
+// clone returns a copy of the group with the given name. The copy shares the entries of
+// the group, but adding to either doesn't affect the other. The caller must hold the
+// group lock.
+func (g *group) clone(name string) *group {
+	c := &group{
+		name:        name,
+		entities:    cloneIndex(g.entities),
+		folded:      make(map[string]struct{}, len(g.folded)),
+		maxLen:      g.maxLen,
+		maxWords:    g.maxWords,
+		wordLimit:   g.wordLimit,
+		normalizers: g.normalizers[:len(g.normalizers):len(g.normalizers)],
+		normalized:  cloneIndex(g.normalized),
+		acronyms:    cloneIndex(g.acronyms),
+		phonetic:    cloneIndex(g.phonetic),
+		synonymSets: g.synonymSets[:len(g.synonymSets):len(g.synonymSets)],
+		synonyms:    cloneIndex(g.synonyms),
+		numeric:     g.numeric,
+		size:        atomic.LoadInt64(&g.size),
+		lazy:        atomic.LoadUint32(&g.lazy),
+		source:      g.source,
+		loadErr:     g.loadErr,
+		evictable:   g.evictable,
+		warm:        g.warm,
+		provider:    g.provider,
+
+		minLen:         g.minLen,
+		exactCaseBelow: g.exactCaseBelow,
+		maxPerEntity:   g.maxPerEntity,
+		exact:          g.exact,
+		frozen:         g.frozen,
+		deny:           g.deny,
+
+		properNouns:     g.properNouns,
+		properNounScore: g.properNounScore,
+
+		noDigitBoundaries: g.noDigitBoundaries,
+	}
+	for key := range g.folded {
+		c.folded[key] = struct{}{}
+	}
+	if g.counts != nil {
+		c.startCounting()
+	}
+	if g.abbreviations != nil {
+		c.abbreviations = make(map[string][]rune, len(g.abbreviations))
+		for abbr, exp := range g.abbreviations {
+			c.abbreviations[abbr] = exp
+		}
+	}
+	if g.entityLimits != nil {
+		c.entityLimits = make(map[string]int, len(g.entityLimits))
+		for e, n := range g.entityLimits {
+			c.entityLimits[e] = n
+		}
+	}
+	if g.recent != nil {
+		c.recent = g.recent.clone()
+	}
+	if g.elisions != nil {
+		c.elisions = make(map[string]bool, len(g.elisions))
+		for e := range g.elisions {
+			c.elisions[e] = true
+		}
+	}
+	return c
+}
+
+// cloneIndex copies the index m. The slices of entries are shared, but capped so that
+// appending to them in either index copies them.
+func cloneIndex(m map[string][]entry) map[string][]entry {
+	if m == nil {
+		return nil
+	}
+	c := make(map[string][]entry, len(m))
+	for k, ents := range m {
+		c[k] = ents[:len(ents):len(ents)]
+	}
+	return c
+}
@@ -0,0 +1,144 @@
+package fastentity
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestGroup(t *testing.T) {
+	store := New()
+	skills := store.Group("skills")
+	skills.Configure(Capacity(10))
+	skills.Add([]rune("PHP"), []rune("golang"))
+	skills.Add([]rune("本語"))
+
+	if skills.Name() != "skills" {
+		t.Errorf("Expected group name 'skills', got %q", skills.Name())
+	}
+	if n := skills.Len(); n != 3 {
+		t.Errorf("Expected 3 entities, got %d", n)
+	}
+
+	seen := 0
+	skills.Range(func(e []rune) bool {
+		seen++
+		return true
+	})
+	if seen != 3 {
+		t.Errorf("Expected Range to visit 3 entities, got %d", seen)
+	}
+
+	found := skills.Find([]rune("日 本語. jack was a golang developer. "))
+	if len(found) != 2 {
+		t.Errorf("Expected to find 2 entities, got %d", len(found))
+	}
+	for _, e := range found {
+		if e.Group != "skills" {
+			t.Errorf("Expected %q to be found in skills, got group %q", string(e.Text), e.Group)
+		}
+	}
+	if len(store.FindAll([]rune("Maybe PHP, or PDX. "))["skills"]) != 1 {
+		t.Errorf("Expected entities added through the handle to be found by FindAll")
+	}
+}
+
+func TestRenameCopyGroup(t *testing.T) {
+	str := []rune("Maybe PHP, or tax accountants. ")
+
+	store := New()
+	store.Add("skills", []rune("PHP"))
+	jobs := store.Group("jobs")
+	jobs.Configure(FoldPlurals())
+	store.Add("jobs", []rune("tax accountant"))
+
+	if err := store.RenameGroup("jobs", "jobTitles"); err != nil {
+		t.Fatalf("Failed to rename group: %v", err)
+	}
+	// Reading through a handle to the old group doesn't recreate it
+	version := store.Version()
+	if n := jobs.Len(); n != 0 {
+		t.Errorf("Expected no entities in the old group, got %d", n)
+	}
+	jobs.Range(func(e []rune) bool {
+		t.Errorf("Expected no entities in the old group, got %q", string(e))
+		return true
+	})
+	if store.Version() != version {
+		t.Errorf("Expected reading the old group not to change the version from %d, got %d", version, store.Version())
+	}
+	if _, err := store.LookupGroup("jobs"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("Expected the old group to be gone, got %v", err)
+	}
+	if err := store.RenameGroup("jobs", "other"); !errors.Is(err, ErrGroupNotFound) {
+		t.Errorf("Expected ErrGroupNotFound, got %v", err)
+	}
+	if err := store.RenameGroup("skills", "jobTitles"); !errors.Is(err, ErrGroupExists) {
+		t.Errorf("Expected ErrGroupExists, got %v", err)
+	}
+
+	if err := store.CopyGroup("jobTitles", "roles"); err != nil {
+		t.Fatalf("Failed to copy group: %v", err)
+	}
+	if err := store.CopyGroup("jobTitles", "skills"); !errors.Is(err, ErrGroupExists) {
+		t.Errorf("Expected ErrGroupExists, got %v", err)
+	}
+	store.Add("roles", []rune("PHP"))
+
+	results := store.FindAll(str)
+	if found := results["jobTitles"]; len(found) != 1 {
+		t.Errorf("Expected the renamed group to match plurals, got %v", found)
+	}
+	if found := results["roles"]; len(found) != 2 {
+		t.Errorf("Expected the copied group to match plurals and its own entities, got %v", found)
+	}
+	if n := store.Group("jobTitles").Len(); n != 1 {
+		t.Errorf("Expected adding to the copy not to change the original, got %d entities", n)
+	}
+}
+
+func TestGroupFindPipeline(t *testing.T) {
+	str := []rune("<p>New <b>York</b> and New York</p>")
+
+	store := New()
+	store.Add("locations", []rune("New York"), []rune("York"))
+	store.AddPreprocessor(StripHTML())
+	store.AddResultFilter(DropSubMatches())
+	store.Group("locations").Configure(MaxMatchesPerEntity(1))
+
+	want := store.FindAll(str)["locations"]
+	found := store.Group("locations").Find(str)
+	if !reflect.DeepEqual(found, want) {
+		t.Errorf("Expected Find to match FindAll with %v, got %v", want, found)
+	}
+	if len(found) != 1 || string(found[0].Text) != "New <b>York" {
+		t.Errorf("Expected one match of New York in the original text, got %v", found)
+	}
+}
+
+func TestClone(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"), []rune("golang"))
+	store.Add("locations", []rune("New York"))
+	store.Group("locations").Configure(Abbreviations(map[string]string{"ny": "new york"}))
+	doc := []rune("PHP and golang and java in NY")
+	before := store.FindAll(doc)
+
+	clone := store.Clone()
+	if clone.Version() != store.Version() {
+		t.Errorf("Expected version %d, got %d", store.Version(), clone.Version())
+	}
+	if r := clone.FindAll(doc); !reflect.DeepEqual(r, before) {
+		t.Errorf("Expected the clone to find %v, got %v", before, r)
+	}
+
+	clone.Add("skills", []rune("java"))
+	clone.Remove("skills", []rune("PHP"))
+	clone.AddResultFilter(MinLength(5))
+	if r := clone.FindAll(doc); len(r["skills"]) != 1 || string(r["skills"][0].Text) != "golang" || len(r["locations"]) != 0 {
+		t.Errorf("Expected the clone to find only golang, got %v", r)
+	}
+	if r := store.FindAll(doc); !reflect.DeepEqual(r, before) {
+		t.Errorf("Expected the store to be unchanged, finding %v, got %v", before, r)
+	}
+}
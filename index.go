@@ -0,0 +1,387 @@
+package fastentity
+
+import (
+	"bufio"
+	"container/list"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"math"
+	"sync"
+)
+
+// ErrCorruptIndex is returned when an index file can't be decoded.
+var ErrCorruptIndex = errors.New("corrupt index")
+
+// An index file, in the manner of a constant database, starts with indexMagic and is
+// followed by a record for each entity: the lengths of its key and text as uint32s, its
+// weight as a float64, the key and then the text. Keys are the lower case text of the
+// entity. The records are followed by 256 open addressing hash tables of twice as many
+// slots as they have records, each slot holding the hash of a key and the offset of its
+// record, or 0 if empty. The file ends with the offset and number of slots of each table,
+// the number of records and indexMagic. Integers are little endian.
+const (
+	indexMagic      = "FEINDEX1"
+	indexTables     = 256
+	indexSlotSize   = 16
+	indexRecordSize = 16
+	indexTrailer    = indexTables*16 + 8 + len(indexMagic)
+)
+
+// indexPageSize is the size of the pages of index files cached in memory, and
+// indexCachePages the number of pages cached for each index.
+const (
+	indexPageSize   = 4 << 10
+	indexCachePages = 1024
+)
+
+func indexHash(key string) uint64 {
+	h := fnv.New64a()
+	io.WriteString(h, key)
+	return h.Sum64()
+}
+
+type indexSlot struct {
+	hash, off uint64
+}
+
+// IndexWriter writes an index file of entities, which can be searched from disk with
+// OpenIndex rather than held in memory. Entities are written as they are added, keeping
+// only 16 bytes for each in memory until the index is closed.
+type IndexWriter struct {
+	w     *bufio.Writer
+	off   uint64
+	n     uint64
+	slots [indexTables][]indexSlot
+	err   error
+}
+
+// NewIndexWriter returns an IndexWriter writing to w, which must be closed to finish
+// the index.
+func NewIndexWriter(w io.Writer) *IndexWriter {
+	iw := &IndexWriter{w: bufio.NewWriter(w)}
+	iw.write([]byte(indexMagic))
+	return iw
+}
+
+func (iw *IndexWriter) write(b []byte) {
+	if iw.err != nil {
+		return
+	}
+	_, iw.err = iw.w.Write(b)
+	iw.off += uint64(len(b))
+}
+
+// Add adds the entity e with the given weight to the index, returning an error wrapping
+// ErrEntityTooLong if it is longer than MaxEntityLen, or the error writing it.
+func (iw *IndexWriter) Add(e []rune, weight float64) error {
+	if err := ValidateEntity(e); err != nil {
+		return err
+	}
+	key, text := string(lowerRunes(e)), string(e)
+	h := indexHash(key)
+	iw.slots[h%indexTables] = append(iw.slots[h%indexTables], indexSlot{hash: h, off: iw.off})
+	iw.n++
+
+	var hdr [indexRecordSize]byte
+	binary.LittleEndian.PutUint32(hdr[0:], uint32(len(key)))
+	binary.LittleEndian.PutUint32(hdr[4:], uint32(len(text)))
+	binary.LittleEndian.PutUint64(hdr[8:], math.Float64bits(weight))
+	iw.write(hdr[:])
+	iw.write([]byte(key))
+	iw.write([]byte(text))
+	return iw.err
+}
+
+// Close writes the hash tables of the index, finishing it. It doesn't close the
+// underlying writer.
+func (iw *IndexWriter) Close() error {
+	var offsets [indexTables]uint64
+	var buf [indexSlotSize]byte
+	for i, recs := range iw.slots {
+		offsets[i] = iw.off
+		table := make([]indexSlot, 2*len(recs))
+		for _, rec := range recs {
+			j := (rec.hash / indexTables) % uint64(len(table))
+			for table[j].off != 0 {
+				j = (j + 1) % uint64(len(table))
+			}
+			table[j] = rec
+		}
+		for _, slot := range table {
+			binary.LittleEndian.PutUint64(buf[0:], slot.hash)
+			binary.LittleEndian.PutUint64(buf[8:], slot.off)
+			iw.write(buf[:])
+		}
+	}
+	for i, off := range offsets {
+		binary.LittleEndian.PutUint64(buf[0:], off)
+		binary.LittleEndian.PutUint64(buf[8:], uint64(2*len(iw.slots[i])))
+		iw.write(buf[:])
+	}
+	binary.LittleEndian.PutUint64(buf[:8], iw.n)
+	iw.write(buf[:8])
+	iw.write([]byte(indexMagic))
+	if iw.err != nil {
+		return iw.err
+	}
+	return iw.w.Flush()
+}
+
+// SaveIndex writes the entities of the group to an index file at path, see OpenIndex.
+func (g *Group) SaveIndex(path string) error {
+	f, err := createFile(path)
+	if err != nil {
+		return fmt.Errorf("error creating %v: %w", path, err)
+	}
+	defer f.Close()
+
+	iw := NewIndexWriter(f)
+	for _, e := range g.s.group(g.name).all() {
+		if err := iw.Add(e.text, e.weight); err != nil {
+			return fmt.Errorf("error writing to %v: %w", path, err)
+		}
+	}
+	err = iw.Close()
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("error writing to %v: %w", path, err)
+	}
+	return nil
+}
+
+type readerAtCloser interface {
+	io.ReaderAt
+	io.Closer
+}
+
+// Index is an index file of entities opened with OpenIndex, which looks up entities by
+// reading the file, caching the pages read most recently. It is a GroupProvider, so a
+// group can be backed by an index too large to hold in memory:
+//
+//	x, err := fastentity.OpenIndex("products.idx")
+//	store.Group("products").Configure(fastentity.Provide(x, fastentity.ProviderOptions{}))
+//
+// An Index is safe for concurrent use.
+type Index struct {
+	r      readerAtCloser
+	size   int64
+	n      uint64
+	tables [indexTables]struct{ off, slots uint64 }
+	cache  *pageCache
+}
+
+// OpenIndex opens the index file at path, written by an IndexWriter or Group.SaveIndex.
+// Errors decoding the file wrap ErrCorruptIndex. The index must be closed once done with.
+func OpenIndex(path string) (*Index, error) {
+	r, size, err := openReaderAt(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %v: %w", path, err)
+	}
+	x, err := newIndex(r, size)
+	if err != nil {
+		r.Close()
+		return nil, fmt.Errorf("error reading %v: %w", path, err)
+	}
+	return x, nil
+}
+
+func newIndex(r readerAtCloser, size int64) (*Index, error) {
+	if size < int64(len(indexMagic)+indexTrailer) {
+		return nil, fmt.Errorf("too short: %w", ErrCorruptIndex)
+	}
+	magic := make([]byte, len(indexMagic))
+	if _, err := r.ReadAt(magic, 0); err != nil {
+		return nil, err
+	}
+	trailer := make([]byte, indexTrailer)
+	if _, err := r.ReadAt(trailer, size-int64(indexTrailer)); err != nil {
+		return nil, err
+	}
+	if string(magic) != indexMagic || string(trailer[indexTrailer-len(indexMagic):]) != indexMagic {
+		return nil, fmt.Errorf("not an index: %w", ErrCorruptIndex)
+	}
+
+	x := &Index{
+		r:     r,
+		size:  size,
+		n:     binary.LittleEndian.Uint64(trailer[indexTables*16:]),
+		cache: newPageCache(r, size, indexCachePages),
+	}
+	for i := range x.tables {
+		t := &x.tables[i]
+		t.off = binary.LittleEndian.Uint64(trailer[i*16:])
+		t.slots = binary.LittleEndian.Uint64(trailer[i*16+8:])
+		if t.off > uint64(size) || t.slots > (uint64(size)-t.off)/indexSlotSize {
+			return nil, fmt.Errorf("table %d out of range: %w", i, ErrCorruptIndex)
+		}
+	}
+	return x, nil
+}
+
+// Len returns the number of entities in the index.
+func (x *Index) Len() int {
+	return int(x.n)
+}
+
+// Close closes the index file.
+func (x *Index) Close() error {
+	return x.r.Close()
+}
+
+// Lookup returns the entities of the index matching each of keys, ignoring case, leaving
+// out keys with no entities.
+func (x *Index) Lookup(ctx context.Context, keys []string) (map[string][]ProvidedEntity, error) {
+	found := make(map[string][]ProvidedEntity)
+	for i, key := range keys {
+		if i%checkInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		ents, err := x.lookup(string(lowerRunes([]rune(key))))
+		if err != nil {
+			return nil, err
+		}
+		if len(ents) > 0 {
+			found[key] = ents
+		}
+	}
+	return found, nil
+}
+
+// lookup returns the entities with the lower case key.
+func (x *Index) lookup(key string) ([]ProvidedEntity, error) {
+	h := indexHash(key)
+	t := x.tables[h%indexTables]
+	if t.slots == 0 {
+		return nil, nil
+	}
+	var ents []ProvidedEntity
+	var buf [indexSlotSize]byte
+	j := (h / indexTables) % t.slots
+	for probes := uint64(0); probes < t.slots; probes++ {
+		if err := x.cache.readAt(buf[:], int64(t.off+j*indexSlotSize)); err != nil {
+			return nil, err
+		}
+		hash, off := binary.LittleEndian.Uint64(buf[0:]), binary.LittleEndian.Uint64(buf[8:])
+		if off == 0 {
+			break
+		}
+		if hash == h {
+			e, ok, err := x.record(off, key)
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				ents = append(ents, e)
+			}
+		}
+		j = (j + 1) % t.slots
+	}
+	return ents, nil
+}
+
+// record reads the record at off, returning its entity if its key is key.
+func (x *Index) record(off uint64, key string) (ProvidedEntity, bool, error) {
+	var hdr [indexRecordSize]byte
+	if off+indexRecordSize > uint64(x.size) {
+		return ProvidedEntity{}, false, fmt.Errorf("record out of range: %w", ErrCorruptIndex)
+	}
+	if err := x.cache.readAt(hdr[:], int64(off)); err != nil {
+		return ProvidedEntity{}, false, err
+	}
+	keyLen := uint64(binary.LittleEndian.Uint32(hdr[0:]))
+	textLen := uint64(binary.LittleEndian.Uint32(hdr[4:]))
+	if keyLen != uint64(len(key)) {
+		return ProvidedEntity{}, false, nil
+	}
+	if off+indexRecordSize+keyLen+textLen > uint64(x.size) {
+		return ProvidedEntity{}, false, fmt.Errorf("record out of range: %w", ErrCorruptIndex)
+	}
+	b := make([]byte, keyLen+textLen)
+	if err := x.cache.readAt(b, int64(off+indexRecordSize)); err != nil {
+		return ProvidedEntity{}, false, err
+	}
+	if string(b[:keyLen]) != key {
+		return ProvidedEntity{}, false, nil
+	}
+	return ProvidedEntity{
+		Text:   string(b[keyLen:]),
+		Weight: math.Float64frombits(binary.LittleEndian.Uint64(hdr[8:])),
+	}, true, nil
+}
+
+// pageCache caches the most recently read pages of a file.
+type pageCache struct {
+	r    io.ReaderAt
+	size int64
+	max  int
+
+	mu    sync.Mutex
+	pages map[int64]*list.Element // of *cachedPage
+	lru   *list.List              // most recently used first
+}
+
+type cachedPage struct {
+	n    int64
+	data []byte
+}
+
+func newPageCache(r io.ReaderAt, size int64, max int) *pageCache {
+	return &pageCache{
+		r:     r,
+		size:  size,
+		max:   max,
+		pages: make(map[int64]*list.Element),
+		lru:   list.New(),
+	}
+}
+
+// readAt fills b with the bytes of the file from off, through the cache.
+func (c *pageCache) readAt(b []byte, off int64) error {
+	if off < 0 || off+int64(len(b)) > c.size {
+		return fmt.Errorf("read out of range: %w", ErrCorruptIndex)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for len(b) > 0 {
+		p, err := c.page(off / indexPageSize)
+		if err != nil {
+			return err
+		}
+		n := copy(b, p[off%indexPageSize:])
+		b, off = b[n:], off+int64(n)
+	}
+	return nil
+}
+
+// page returns the nth page of the file, reading it if it isn't cached. The caller must
+// hold the lock.
+func (c *pageCache) page(n int64) ([]byte, error) {
+	if el, ok := c.pages[n]; ok {
+		c.lru.MoveToFront(el)
+		return el.Value.(*cachedPage).data, nil
+	}
+	start := n * indexPageSize
+	end := start + indexPageSize
+	if end > c.size {
+		end = c.size
+	}
+	data := make([]byte, end-start)
+	if _, err := c.r.ReadAt(data, start); err != nil {
+		return nil, err
+	}
+	c.pages[n] = c.lru.PushFront(&cachedPage{n: n, data: data})
+	if c.lru.Len() > c.max {
+		el := c.lru.Back()
+		delete(c.pages, el.Value.(*cachedPage).n)
+		c.lru.Remove(el)
+	}
+	return data, nil
+}
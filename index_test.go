@@ -0,0 +1,63 @@
+package fastentity
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestIndex(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := New()
+	store.Add("skills", []rune("Go"), []rune("machine learning"), []rune("Rust"))
+	store.AddWeighted("skills", []rune("Python"), 3)
+	for i := 0; i < 5000; i++ {
+		store.Add("skills", []rune(fmt.Sprintf("skill %d", i)))
+	}
+	path := filepath.Join(dir, "skills.idx")
+	if err := store.Group("skills").SaveIndex(path); err != nil {
+		t.Fatal(err)
+	}
+
+	x, err := OpenIndex(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer x.Close()
+	if x.Len() != 5004 {
+		t.Errorf("Expected 5004 entities, got %d", x.Len())
+	}
+	found, err := x.Lookup(context.Background(), []string{"go", "python", "skill 4999", "java"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(found["go"]) != 1 || len(found["skill 4999"]) != 1 || found["java"] != nil {
+		t.Errorf("Unexpected entities %v", found)
+	}
+	if e := found["python"]; len(e) != 1 || e[0].Text != "Python" || e[0].Weight != 3 {
+		t.Errorf("Expected Python with weight 3, got %v", e)
+	}
+
+	// Search a group backed by the index
+	disk := New()
+	disk.Group("skills").Configure(Provide(x, ProviderOptions{MaxWords: 2}))
+	doc := []rune("Machine learning in Python, skill 42 and go")
+	if want, got := store.FindAll(doc), disk.FindAll(doc); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	ioutil.WriteFile(path, []byte("not an index"), 0644)
+	if _, err := OpenIndex(path); !errors.Is(err, ErrCorruptIndex) {
+		t.Errorf("Expected ErrCorruptIndex, got %v", err)
+	}
+}
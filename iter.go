@@ -0,0 +1,108 @@
+//go:build go1.23
+
+package fastentity
+
+import (
+	"context"
+	"iter"
+)
+
+// Matches returns an iterator over the entities found in rs across all groups, in the
+// order they are found. The search stops as soon as the loop body breaks, and no results
+// are collected in between, except that where a group is a deny list the matches of each
+// sentence are held until the sentence is searched, to drop those the deny list overlaps
+// as FindAll does.
+//
+// The store must not be modified from within the loop body.
+func (s *Store) Matches(rs []rune) iter.Seq[Match] {
+	return func(yield func(Match) bool) {
+		d := s.preprocess(rs)
+		groups := s.rlockGroups()
+		defer runlockGroups(groups)
+		limits := make(matchLimiter)
+		deny := make(map[string]bool)
+		for _, g := range groups {
+			if g.deny {
+				deny[g.name] = true
+			}
+		}
+		if len(deny) == 0 {
+			find(context.Background(), d.text, d.tok, groups, nil, func(g *group, ent *entry, e Entity) bool {
+				if !limits.allow(g, ent) {
+					return true
+				}
+				g.count(ent)
+				return yield(Match{Entity: d.original(e)})
+			})
+			return
+		}
+
+		sentences := Sentences(d.text)
+		var held []Entity
+		flush := func() bool {
+			var denied []span
+			for _, e := range held {
+				if deny[e.Group] {
+					denied = append(denied, span{e.Offset, e.Offset + len(e.Text)})
+				}
+			}
+			ents := held
+			held = held[:0]
+			for _, e := range ents {
+				if deny[e.Group] || overlapsAny(denied, span{e.Offset, e.Offset + len(e.Text)}) {
+					continue
+				}
+				if !yield(Match{Entity: e}) {
+					return false
+				}
+			}
+			return true
+		}
+		stopped := false
+		find(context.Background(), d.text, d.tok, groups, nil, func(g *group, ent *entry, e Entity) bool {
+			if !limits.allow(g, ent) {
+				return true
+			}
+			g.count(ent)
+			// Matches are found in order of where they end
+			end := e.Offset + len(e.Text)
+			for len(sentences) > 0 && end > sentences[0].End {
+				sentences = sentences[1:]
+				if !flush() {
+					stopped = true
+					return false
+				}
+			}
+			held = append(held, d.original(e))
+			return true
+		})
+		if !stopped {
+			flush()
+		}
+	}
+}
+
+// Entities returns an iterator over the entities in the group identified by name. The
+// iterator yields nothing if there is no such group.
+//
+// The store must not be modified from within the loop body.
+func (s *Store) Entities(name string) iter.Seq[[]rune] {
+	return func(yield func([]rune) bool) {
+		s.RLock()
+		g, ok := s.groups[name]
+		s.RUnlock()
+		if !ok {
+			return
+		}
+
+		g.rlock()
+		defer g.RUnlock()
+		for _, ents := range g.entities {
+			for _, e := range ents {
+				if !yield(e.text) {
+					return
+				}
+			}
+		}
+	}
+}
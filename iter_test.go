@@ -0,0 +1,89 @@
+//go:build go1.23
+
+package fastentity
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestMatches(t *testing.T) {
+	str := []rune("jack was a golang developer from sydney, for someone. Maybe PHP, or PDX. ")
+
+	store := New()
+	store.Add("jobTitles", []rune("golang developer"))
+	store.Add("skills", []rune("PHP"), []rune("golang"))
+
+	found := map[string]int{}
+	for m := range store.Matches(str) {
+		found[m.Group+":"+string(m.Text)] = m.Offset
+	}
+	expected := map[string]int{
+		"jobTitles:golang developer": 11,
+		"skills:golang":              11,
+		"skills:PHP":                 60,
+	}
+	if len(found) != len(expected) {
+		t.Errorf("Expected %d matches, got %d: %v", len(expected), len(found), found)
+	}
+	for k, off := range expected {
+		if got, ok := found[k]; !ok || got != off {
+			t.Errorf("Expected match %s at %d, got %v", k, off, found)
+		}
+	}
+
+	n := 0
+	for range store.Matches(str) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("Expected iteration to stop after break, got %d", n)
+	}
+}
+
+func TestMatchesDeny(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("excel"), []rune("teamwork"))
+	store.Add("not-a-skill", []rune("excel at"))
+	store.Group("not-a-skill").Configure(Deny())
+
+	for _, doc := range []string{"I excel at teamwork", "Skills: excel. I excel at teamwork. Excel at"} {
+		var found Results
+		for m := range store.Matches([]rune(doc)) {
+			if found == nil {
+				found = make(Results)
+			}
+			found[m.Group] = append(found[m.Group], m.Entity)
+		}
+		want := fmt.Sprint(store.FindAll([]rune(doc)).Matches())
+		if got := fmt.Sprint(found.Matches()); got != want {
+			t.Errorf("Expected matches of %q to agree with FindAll %v, got %v", doc, want, got)
+		}
+	}
+
+	n := 0
+	for range store.Matches([]rune("Skills: excel. I excel at teamwork")) {
+		n++
+		break
+	}
+	if n != 1 {
+		t.Errorf("Expected iteration to stop after break, got %d", n)
+	}
+}
+
+func TestEntities(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"), []rune("golang"), []rune("本語"))
+
+	seen := map[string]bool{}
+	for e := range store.Entities("skills") {
+		seen[string(e)] = true
+	}
+	if len(seen) != 3 || !seen["PHP"] || !seen["golang"] || !seen["本語"] {
+		t.Errorf("Unexpected entities: %v", seen)
+	}
+	for range store.Entities("missing") {
+		t.Errorf("Expected no entities for missing group")
+	}
+}
@@ -0,0 +1,132 @@
+package fastentity
+
+import (
+	"encoding/json"
+	"fmt"
+	"unicode/utf8"
+)
+
+// MarshalText encodes the kind as its name, e.g. "acronym".
+func (k MatchKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// UnmarshalText decodes a kind encoded by MarshalText.
+func (k *MatchKind) UnmarshalText(b []byte) error {
+	for kind := TextMatch; kind <= SynonymMatch; kind++ {
+		if kind.String() == string(b) {
+			*k = kind
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown match kind %q", b)
+}
+
+// entityJSON is the JSON encoding of an Entity, and with ByteOffset of a StreamMatch.
+type entityJSON struct {
+	Group      string    `json:"group,omitempty"`
+	Text       string    `json:"text"`
+	Canonical  string    `json:"canonical"`
+	Offset     int       `json:"offset"`
+	ByteOffset *int      `json:"byte_offset,omitempty"`
+	Kind       MatchKind `json:"kind"`
+	Score      float64   `json:"score"`
+	Weight     float64   `json:"weight"`
+	Doc        string    `json:"doc,omitempty"`
+	Head       int       `json:"head,omitempty"`
+	Tail       int       `json:"tail,omitempty"`
+}
+
+func newEntityJSON(e Entity) entityJSON {
+	return entityJSON{
+		Group:     e.Group,
+		Text:      string(e.Text),
+		Canonical: string(e.Canonical),
+		Offset:    e.Offset,
+		Kind:      e.Kind,
+		Score:     e.Score,
+		Weight:    e.Weight,
+		Doc:       e.Doc,
+		Head:      e.Head,
+		Tail:      e.Tail,
+	}
+}
+
+func (ej *entityJSON) entity() Entity {
+	return Entity{
+		Text:      []rune(ej.Text),
+		Offset:    ej.Offset,
+		Canonical: []rune(ej.Canonical),
+		Kind:      ej.Kind,
+		Score:     ej.Score,
+		Weight:    ej.Weight,
+		Group:     ej.Group,
+		Doc:       ej.Doc,
+		Head:      ej.Head,
+		Tail:      ej.Tail,
+	}
+}
+
+// MarshalJSON encodes the entity as a JSON object with its text as strings, e.g.
+//
+//	{"group": "locations", "text": "sydney", "canonical": "Sydney", "offset": 24, "kind": "text", "score": 1, "weight": 1}
+//
+// Results, mapping groups to their entities, are encoded as a JSON object of arrays of
+// entities by group.
+func (e Entity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(newEntityJSON(e))
+}
+
+// UnmarshalJSON decodes an entity encoded by MarshalJSON.
+func (e *Entity) UnmarshalJSON(b []byte) error {
+	var ej entityJSON
+	if err := json.Unmarshal(b, &ej); err != nil {
+		return err
+	}
+	*e = ej.entity()
+	return nil
+}
+
+// MarshalJSON encodes the match as a Match, along with its byte offset, e.g.
+//
+//	{"group": "locations", "text": "sydney", "canonical": "Sydney", "offset": 24, "byte_offset": 26, "kind": "text", "score": 1, "weight": 1}
+//
+// This is the form in which the server package returns matches.
+func (m StreamMatch) MarshalJSON() ([]byte, error) {
+	ej := newEntityJSON(m.Entity)
+	ej.ByteOffset = &m.ByteOffset
+	return json.Marshal(ej)
+}
+
+// UnmarshalJSON decodes a match encoded by MarshalJSON.
+func (m *StreamMatch) UnmarshalJSON(b []byte) error {
+	var ej entityJSON
+	if err := json.Unmarshal(b, &ej); err != nil {
+		return err
+	}
+	*m = StreamMatch{Match: Match{Entity: ej.entity()}}
+	if ej.ByteOffset != nil {
+		m.ByteOffset = *ej.ByteOffset
+	}
+	return nil
+}
+
+// MatchesIn returns the matches in r in document order, as Matches does, along with their
+// offsets in bytes from the start of doc, the document searched.
+func (r Results) MatchesIn(doc []rune) []StreamMatch {
+	ms := r.Matches()
+	sms := make([]StreamMatch, len(ms))
+	// Matches are in document order, so byte offsets are counted on from the previous one
+	off, b := 0, 0
+	for i, m := range ms {
+		for ; off < m.Offset && off < len(doc); off++ {
+			size := utf8.RuneLen(doc[off])
+			if size < 0 {
+				size = utf8.RuneLen(utf8.RuneError) // As encoded by string(doc)
+			}
+			b += size
+		}
+		sms[i] = StreamMatch{Match: m, ByteOffset: b}
+	}
+	return sms
+}
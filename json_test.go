@@ -0,0 +1,81 @@
+package fastentity
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEntityJSON(t *testing.T) {
+	e := Entity{Text: []rune("NYC"), Offset: 3, Canonical: []rune("New York City"), Kind: AcronymMatch, Score: 0.5, Weight: 2}
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"text":"NYC","canonical":"New York City","offset":3,"kind":"acronym","score":0.5,"weight":2}`
+	if string(b) != want {
+		t.Errorf("Expected %s, got %s", want, b)
+	}
+	var got Entity
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, e) {
+		t.Errorf("Expected %+v after round trip, got %+v", e, got)
+	}
+
+	if err := json.Unmarshal([]byte(`{"text":"x","kind":"guess"}`), &got); err == nil {
+		t.Error("Expected error decoding an unknown kind")
+	}
+}
+
+func TestMatchesIn(t *testing.T) {
+	store := New()
+	store.Add("locations", []rune("Sydney"), []rune("São Paulo"))
+	doc := []rune("From São Paulo 🙂 to Sydney")
+
+	ms := store.FindAll(doc).MatchesIn(doc)
+	if len(ms) != 2 {
+		t.Fatalf("Expected 2 matches, got %d", len(ms))
+	}
+	for _, m := range ms {
+		if want := strings.Index(string(doc), string(m.Text)); m.ByteOffset != want {
+			t.Errorf("Expected %q at byte %d, got %d", string(m.Text), want, m.ByteOffset)
+		}
+	}
+
+	b, err := json.Marshal(ms)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `[{"group":"locations","text":"São Paulo","canonical":"São Paulo","offset":5,"byte_offset":5,"kind":"text","score":1,"weight":1},` +
+		`{"group":"locations","text":"Sydney","canonical":"Sydney","offset":20,"byte_offset":24,"kind":"text","score":1,"weight":1}]`
+	if string(b) != want {
+		t.Errorf("Expected %s, got %s", want, b)
+	}
+	var got []StreamMatch
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, ms) {
+		t.Errorf("Expected %+v after round trip, got %+v", ms, got)
+	}
+}
+
+func TestResultsJSON(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("golang"))
+	rs := store.FindAll([]rune("I know golang"))
+	b, err := json.Marshal(rs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Results
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, rs) {
+		t.Errorf("Expected %+v after round trip, got %+v", rs, got)
+	}
+}
@@ -0,0 +1,122 @@
+package fastentity
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// LanguageSeparator separates the name of a group from its language, e.g. "skills@de" is
+// the German group of skills. Groups without a language are language agnostic.
+const LanguageSeparator = "@"
+
+// SplitLanguage splits a group name into the name and language, which is empty for
+// language agnostic groups.
+func SplitLanguage(name string) (string, string) {
+	i := strings.LastIndex(name, LanguageSeparator)
+	if i < 0 {
+		return name, ""
+	}
+	return name[:i], name[i+len(LanguageSeparator):]
+}
+
+// FindAllLanguage is like FindAll, but only searches the groups of the given language and
+// those which are language agnostic, so documents are only searched for entities of their
+// language. If lang is empty all groups are searched. The language of a document can be
+// detected with DetectLanguage.
+func (s *Store) FindAllLanguage(lang string, rs []rune, opts ...FindOption) Results {
+	r, _ := s.FindAllLanguageContext(context.Background(), lang, rs, opts...)
+	return r
+}
+
+// FindAllLanguageContext is like FindAllLanguage, but stops searching when ctx is
+// cancelled, returning ctx.Err().
+func (s *Store) FindAllLanguageContext(ctx context.Context, lang string, rs []rune, opts ...FindOption) (Results, error) {
+	c := newFindConfig(opts)
+	cache := s.resultCache()
+	var key CacheKey
+	if cache != nil {
+		key = s.cacheKey(lang, rs)
+		if r, ok := cache.Get(key); ok {
+			return applyFindOptions(cloneResults(r), c), nil
+		}
+	}
+
+	d := s.preprocess(rs)
+	var keep func(name string) bool
+	if lang != "" {
+		keep = func(name string) bool {
+			l := languageOf(name)
+			return l == "" || l == lang
+		}
+	}
+	groups := s.rlockGroupsWhere(keep)
+	result, err := findDocument(ctx, d, groups, c)
+	runlockGroups(groups)
+	if err != nil {
+		return nil, err
+	}
+	result = s.filter(result)
+	if cache != nil {
+		cache.Put(key, cloneResults(result))
+	}
+	return applyFindOptions(result, c), nil
+}
+
+func languageOf(name string) string {
+	_, lang := SplitLanguage(name)
+	return lang
+}
+
+// stopwords are common words of each language detected by DetectLanguage.
+var stopwords = map[string][]string{
+	"en": {"the", "and", "of", "to", "in", "is", "that", "for", "with", "on", "are", "this", "was", "be"},
+	"de": {"der", "die", "und", "das", "ist", "nicht", "mit", "den", "von", "zu", "ein", "eine", "auf", "für", "sich", "wir"},
+	"fr": {"le", "la", "les", "et", "des", "est", "une", "pour", "dans", "que", "du", "pas", "sur", "nous", "avec"},
+	"es": {"el", "la", "los", "las", "y", "que", "en", "por", "con", "una", "para", "es", "del", "se", "como"},
+	"it": {"il", "di", "che", "e", "la", "per", "una", "sono", "gli", "non", "con", "della", "nel", "anche"},
+	"nl": {"de", "het", "een", "en", "van", "is", "dat", "niet", "op", "met", "voor", "zijn", "ook", "wij"},
+	"pt": {"o", "os", "as", "e", "de", "que", "não", "uma", "para", "com", "do", "da", "em", "são"},
+}
+
+// stopwordLanguages maps each stopword to the languages it is common in.
+var stopwordLanguages = func() map[string][]string {
+	m := make(map[string][]string)
+	for lang, words := range stopwords {
+		for _, w := range words {
+			m[w] = append(m[w], lang)
+		}
+	}
+	return m
+}()
+
+// DetectLanguage guesses the language of a document from the common words it contains,
+// returning its ISO 639-1 code, or "" if it isn't clear. It distinguishes English, German,
+// French, Spanish, Italian, Dutch and Portuguese, and needs a sentence or so of text.
+func DetectLanguage(rs []rune) string {
+	scores := make(map[string]int)
+	var word []rune
+	for _, w := range words(rs) {
+		word = word[:0]
+		for _, r := range rs[w[left]:w[right]] {
+			word = append(word, unicode.ToLower(r))
+		}
+		for _, lang := range stopwordLanguages[string(word)] {
+			scores[lang]++
+		}
+	}
+
+	best, bestN, secondN := "", 0, 0
+	for lang, n := range scores {
+		switch {
+		case n > bestN:
+			best, bestN, secondN = lang, n, bestN
+		case n > secondN:
+			secondN = n
+		}
+	}
+	if bestN < 2 || bestN == secondN {
+		return "" // Too little text, or a tie
+	}
+	return best
+}
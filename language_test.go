@@ -0,0 +1,54 @@
+package fastentity
+
+import "testing"
+
+func TestSplitLanguage(t *testing.T) {
+	for name, expected := range map[string][2]string{
+		"skills@de": {"skills", "de"},
+		"skills":    {"skills", ""},
+		"a@b@en":    {"a@b", "en"},
+	} {
+		if g, l := SplitLanguage(name); g != expected[0] || l != expected[1] {
+			t.Errorf("%q: expected %q, %q, got %q, %q", name, expected[0], expected[1], g, l)
+		}
+	}
+}
+
+func TestFindAllLanguage(t *testing.T) {
+	store := New()
+	store.Add("skills@en", []rune("Java"), []rune("accounting"))
+	store.Add("skills@de", []rune("Java"), []rune("Buchhaltung"))
+	store.Add("locations", []rune("Berlin"))
+
+	doc := []rune("Wir suchen einen Java Entwickler für die Buchhaltung in Berlin")
+	lang := DetectLanguage(doc)
+	if lang != "de" {
+		t.Fatalf("Expected the document to be German, got %q", lang)
+	}
+	r := store.FindAllLanguage(lang, doc)
+	if _, ok := r["skills@en"]; ok {
+		t.Errorf("Expected English skills not to be searched, got %v", r)
+	}
+	if len(r["skills@de"]) != 2 || len(r["locations"]) != 1 {
+		t.Errorf("Expected German skills and locations to be found, got %v", r)
+	}
+
+	if r := store.FindAllLanguage("", doc); len(r) != 3 {
+		t.Errorf("Expected all groups to be searched without a language, got %v", r)
+	}
+}
+
+func TestDetectLanguage(t *testing.T) {
+	for text, expected := range map[string]string{
+		"The developer is responsible for the design of the system":           "en",
+		"Le développeur est responsable de la conception et des tests":        "fr",
+		"El desarrollador es responsable del diseño y de las pruebas":         "es",
+		"De ontwikkelaar is verantwoordelijk voor het ontwerp van de website": "nl",
+		"Java":    "",
+		"PHP SQL": "",
+	} {
+		if lang := DetectLanguage([]rune(text)); lang != expected {
+			t.Errorf("%q: expected %q, got %q", text, expected, lang)
+		}
+	}
+}
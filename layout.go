@@ -0,0 +1,176 @@
+package fastentity
+
+import (
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Layout describes how the groups of a Store map to entity files in a directory. It can
+// be passed as an option to FromDir, LoadDir and Save, and the zero value is the default
+// layout: files named <group>.entities.csv directly within the directory.
+//
+// Files named <name>.00.entities.csv, <name>.01.entities.csv and so on are shards
+// written by Save with the ShardSize option, and are all loaded into the group <name>.
+// Only two or more files numbered contiguously from 00 are taken as shards, so a file
+// such as release.2023.entities.csv is loaded into the group "release.2023".
+type Layout struct {
+	// Suffix identifies entity files when loading, and is appended to file names when
+	// saving. Defaults to ".entities.csv", when files ending ".entities.tsv" and
+	// ".entities.jsonl" are loaded too. Files are read as TSV or JSON Lines if the suffix
+	// ends ".tsv" or ".jsonl", but always saved an entity per line.
+	Suffix string
+
+	// Nested makes subdirectories act as group namespaces: entity files in subdirectories
+	// are loaded into groups named by their path relative to the directory (e.g.
+	// "skills/it.entities.csv" is loaded into the group "skills/it"), and groups with '/'
+	// in their names are saved to subdirectories. Otherwise subdirectories are ignored,
+	// and '/' in group names is replaced by '_' when saving.
+	Nested bool
+
+	// FileName maps a group name to the path of the file it is saved to, relative to the
+	// directory and without the suffix. Overrides the default mapping described by
+	// Nested.
+	FileName func(group string) string
+
+	// GroupName maps the path of an entity file, relative to the directory and without the
+	// suffix, to the group its entities are loaded into. Defaults to the path itself.
+	GroupName func(path string) string
+}
+
+func (l Layout) applyLoad(c *loadConfig) {
+	c.layout = l
+}
+
+func (l Layout) applySave(c *saveConfig) {
+	c.layout = l
+}
+
+func (l Layout) suffix() string {
+	if l.Suffix == "" {
+		return entityFileSuffix
+	}
+	return l.Suffix
+}
+
+// entityFiles lists the entity files in dir, along with the groups they are loaded into.
+func (l Layout) entityFiles(dir string) ([]FileReport, error) {
+	paths, err := listFiles(dir, l.Nested)
+	if err != nil {
+		return nil, err
+	}
+
+	suffixes := []string{l.Suffix}
+	if l.Suffix == "" {
+		suffixes = []string{entityFileSuffix, tsvFileSuffix, jsonlFileSuffix}
+	}
+	var rels, names []string
+	for _, rel := range paths {
+		if rel == ManifestFile {
+			continue
+		}
+		if suffix := matchSuffix(rel, suffixes); suffix != "" {
+			rels = append(rels, rel)
+			names = append(names, strings.TrimSuffix(rel, suffix))
+		}
+	}
+	shards := shardBases(names)
+	files := make([]FileReport, len(rels))
+	for i, rel := range rels {
+		name := names[i]
+		if base, ok := shards[name]; ok {
+			name = base
+		}
+		if l.GroupName != nil {
+			name = l.GroupName(name)
+		}
+		files[i] = FileReport{
+			Path:  fmt.Sprintf("%s/%s", dir, rel),
+			Group: name,
+		}
+	}
+	return files, nil
+}
+
+// matchSuffix returns the suffix of name among suffixes, or "" if it has none.
+func matchSuffix(name string, suffixes []string) string {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(name, suffix) {
+			return suffix
+		}
+	}
+	return ""
+}
+
+// noShard is passed to Layout.path for groups which aren't sharded.
+const noShard = -1
+
+// splitShard splits a file name ending in a shard number, two or more digits formatted as
+// by Layout.path, into the name and number.
+func splitShard(name string) (string, int, bool) {
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 || len(name)-i-1 < 2 {
+		return name, 0, false
+	}
+	n, err := strconv.Atoi(name[i+1:])
+	if err != nil || n < 0 || fmt.Sprintf("%02d", n) != name[i+1:] {
+		return name, 0, false
+	}
+	return name[:i], n, true
+}
+
+// shardBases returns the file names among names, without suffixes, which are shards of a
+// group, mapped to the name of the group. Only sets of two or more files numbered
+// contiguously from 00 are shards, as written by Save with ShardSize.
+func shardBases(names []string) map[string]string {
+	numbers := make(map[string][]int)
+	for _, name := range names {
+		if base, n, ok := splitShard(name); ok {
+			numbers[base] = append(numbers[base], n)
+		}
+	}
+	shards := make(map[string]string)
+	for base, ns := range numbers {
+		if len(ns) < 2 {
+			continue
+		}
+		sort.Ints(ns)
+		contiguous := true
+		for i, n := range ns {
+			contiguous = contiguous && n == i
+		}
+		if !contiguous {
+			continue
+		}
+		for _, n := range ns {
+			shards[fmt.Sprintf("%s.%02d", base, n)] = base
+		}
+	}
+	return shards
+}
+
+// path returns the path of the file the group identified by name is saved to in dir, or
+// of the given shard of the group.
+func (l Layout) path(dir, name string, shard int) (string, error) {
+	var rel string
+	switch {
+	case l.FileName != nil:
+		rel = l.FileName(name)
+	case l.Nested:
+		rel = name
+	default:
+		rel = strings.Replace(name, "/", "_", -1)
+	}
+
+	// Keep the file within dir whatever the group is called.
+	rel = path.Clean("/" + rel)[1:]
+	if rel == "" {
+		return "", fmt.Errorf("invalid file name for group %q", name)
+	}
+	if shard != noShard {
+		rel = fmt.Sprintf("%s.%02d", rel, shard)
+	}
+	return fmt.Sprintf("%s/%s%s", dir, rel, l.suffix()), nil
+}
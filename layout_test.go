@@ -0,0 +1,82 @@
+package fastentity
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestLayoutNested(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := New()
+	store.Add("skills/it", []rune("golang"), []rune("PHP"))
+	store.Add("skills/finance", []rune("tax accounting"))
+	store.Add("locations", []rune("Sydney"))
+
+	layout := Layout{Suffix: ".txt", Nested: true}
+	if err := store.Save(dir, layout); err != nil {
+		t.Fatalf("Failed to save store: %v", err)
+	}
+	if _, err := os.Stat(dir + "/skills/it.txt"); err != nil {
+		t.Errorf("Expected nested group to be saved in a subdirectory: %v", err)
+	}
+
+	loaded, err := FromDir(dir, layout)
+	if err != nil {
+		t.Fatalf("Failed to load store: %v", err)
+	}
+	for _, name := range []string{"skills/it", "skills/finance", "locations"} {
+		if _, ok := loaded.groups[name]; !ok {
+			t.Errorf("Expected group %q to be loaded", name)
+		}
+	}
+	if n := loaded.Group("skills/it").Len(); n != 2 {
+		t.Errorf("Expected 2 entities in 'skills/it', got %d", n)
+	}
+
+	// Without nesting, subdirectories are ignored.
+	flat, err := FromDir(dir, Layout{Suffix: ".txt"})
+	if err != nil {
+		t.Fatalf("Failed to load store: %v", err)
+	}
+	if len(flat.groups) != 1 {
+		t.Errorf("Expected only the top level group to be loaded, got %d groups", len(flat.groups))
+	}
+}
+
+func TestLayoutNaming(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := New()
+	store.Add("Job Titles", []rune("golang developer"))
+	store.Add("../escape", []rune("Sydney"))
+
+	layout := Layout{
+		FileName:  func(group string) string { return strings.Replace(group, " ", "-", -1) },
+		GroupName: func(path string) string { return strings.Replace(path, "-", " ", -1) },
+	}
+	if err := store.Save(dir, layout); err != nil {
+		t.Fatalf("Failed to save store: %v", err)
+	}
+	if _, err := os.Stat(dir + "/escape.entities.csv"); err != nil {
+		t.Errorf("Expected group file to be kept within the directory: %v", err)
+	}
+
+	loaded, err := FromDir(dir, layout)
+	if err != nil {
+		t.Fatalf("Failed to load store: %v", err)
+	}
+	if _, ok := loaded.groups["Job Titles"]; !ok {
+		t.Errorf("Expected group 'Job Titles' to be loaded")
+	}
+}
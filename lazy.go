@@ -0,0 +1,193 @@
+package fastentity
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync/atomic"
+)
+
+// Lazy defers loading the entities of each group until the group is first searched or
+// otherwise used, or until it's loaded with Store.Preload. Loading only lists the groups
+// and where their entities are, so stores with many rarely used groups start faster and
+// use less memory. It can be passed to FromDir, LoadDir and LoadSnapshot.
+//
+// The files must not change while any groups remain to be loaded. Groups which can't be
+// loaded when they are used are empty, and the error is returned by Preload. Groups
+// report no entities in Stats until they are loaded, and with LoadDir the LoadReport only
+// lists the files found, without counting their entities or finding duplicates.
+func Lazy() LoadOption {
+	return loadOptionFunc(func(c *loadConfig) {
+		c.lazy = true
+	})
+}
+
+// lazyGroup returns a group which loads its entities from source when first used.
+func lazyGroup(name string, source func() ([]entry, error)) *group {
+	g := newGroup(name)
+	g.source = source
+	g.lazy = 1
+	return g
+}
+
+// load loads the entities of the group if it's lazy, returning the error if they
+// couldn't be.
+func (g *group) load() error {
+	if atomic.LoadUint32(&g.lazy) == 0 {
+		return g.loadErr
+	}
+	g.Lock()
+	defer g.Unlock()
+	if atomic.LoadUint32(&g.lazy) == 1 {
+		// Entities added before the group was loaded would be lost if it were evicted
+		unmodified := atomic.LoadInt64(&g.size) == 0
+		ents, err := g.source()
+		for _, e := range ents {
+			g.add(e)
+		}
+		g.loadErr, g.evictable, g.warm = err, err == nil && unmodified, true
+		atomic.StoreUint32(&g.lazy, 0)
+	}
+	return g.loadErr
+}
+
+// rlock read locks the group once its entities have been loaded.
+func (g *group) rlock() {
+	for {
+		g.load()
+		g.RLock()
+		if atomic.LoadUint32(&g.lazy) == 0 {
+			return
+		}
+		g.RUnlock() // Evicted before it was locked
+	}
+}
+
+// Preload loads the groups identified by names which haven't been loaded yet, or all
+// groups if no names are given, returning the first error loading them. Groups which
+// failed to load when they were first used return their error again.
+func (s *Store) Preload(names ...string) error {
+	s.RLock()
+	var groups []*group
+	if len(names) == 0 {
+		for _, g := range s.groups {
+			groups = append(groups, g)
+		}
+	}
+	for _, name := range names {
+		g, ok := s.groups[name]
+		if !ok {
+			s.RUnlock()
+			return fmt.Errorf("%q: %w", name, ErrGroupNotFound)
+		}
+		groups = append(groups, g)
+	}
+	s.RUnlock()
+
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].name < groups[j].name
+	})
+	for _, g := range groups {
+		if err := g.load(); err != nil {
+			return fmt.Errorf("loading group %q: %w", g.name, err)
+		}
+	}
+	return nil
+}
+
+// lazyDir returns a store with a lazy group for each group of files.
+func lazyDir(files []FileReport, c *loadConfig) *Store {
+	paths := make(map[string][]string)
+	for _, f := range files {
+		paths[f.Group] = append(paths[f.Group], f.Path)
+	}
+	s := New()
+	for name, ps := range paths {
+		ps := ps
+		s.groups[name] = lazyGroup(name, func() ([]entry, error) {
+			var all []entry
+			for _, path := range ps {
+				f := FileReport{Path: path}
+				ents, err := readFile(context.Background(), &f, c, nil)
+				if err != nil {
+					return nil, err
+				}
+				all = append(all, ents...)
+			}
+			return all, nil
+		})
+	}
+	return s
+}
+
+// lazySnapshot returns a store with a lazy group for each group in the snapshot file at
+// path.
+func lazySnapshot(path string) (*Store, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %v: %w", path, err)
+	}
+	defer f.Close()
+	sr, err := newSnapshotReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading from %v: %w", path, err)
+	}
+	defer sr.Close()
+
+	// Note which chunks hold each group, skipping their entities
+	chunks := make(map[string][]int)
+	for i := 0; i < sr.header.Chunks; i++ {
+		var c struct{ Group string }
+		if err := sr.dec.Decode(&c); err != nil {
+			return nil, fmt.Errorf("error reading from %v: decoding group: %v: %w", path, err, ErrCorruptSnapshot)
+		}
+		chunks[c.Group] = append(chunks[c.Group], i)
+	}
+
+	s := New()
+	for name, cs := range chunks {
+		cs := cs
+		s.groups[name] = lazyGroup(name, func() ([]entry, error) {
+			return readSnapshotChunks(path, sr.header.Version, cs)
+		})
+	}
+	s.shards, s.manifest, s.tokenizer = sr.header.Shards, sr.header.Manifest, sr.header.Tokenizer
+	atomic.StoreUint64(&s.version, sr.header.Version)
+	return s, nil
+}
+
+// readSnapshotChunks reads the entities in the chunks with the given indices, in
+// increasing order, from the snapshot file at path, which must be at the given version.
+func readSnapshotChunks(path string, version uint64, chunks []int) ([]entry, error) {
+	f, err := openFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %v: %w", path, err)
+	}
+	defer f.Close()
+	sr, err := newSnapshotReader(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading from %v: %w", path, err)
+	}
+	defer sr.Close()
+	if sr.header.Version != version {
+		return nil, fmt.Errorf("snapshot %v changed since it was loaded", path)
+	}
+
+	var ents []entry
+	for i := 0; len(chunks) > 0; i++ {
+		if i != chunks[0] {
+			var skip struct{ Group string }
+			if err := sr.dec.Decode(&skip); err != nil {
+				return nil, fmt.Errorf("error reading from %v: decoding group: %v: %w", path, err, ErrCorruptSnapshot)
+			}
+			continue
+		}
+		chunks = chunks[1:]
+		c, err := sr.chunk()
+		if err != nil {
+			return nil, fmt.Errorf("error reading from %v: %w", path, err)
+		}
+		ents = appendChunk(ents, c)
+	}
+	return ents, nil
+}
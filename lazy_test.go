@@ -0,0 +1,75 @@
+package fastentity
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+func TestLazy(t *testing.T) {
+	dir := writeEntityFiles(t, map[string]string{
+		"skills.entities.csv":       "PHP\ngolang\n",
+		"locations.00.entities.csv": "Sydney,2\n",
+		"locations.01.entities.csv": "Houston\n",
+		"names.entities.csv":        "John Smith\n",
+	})
+	defer os.RemoveAll(dir)
+
+	store, err := FromDir(dir, Lazy(), Weights())
+	if err != nil {
+		t.Fatal(err)
+	}
+	big := New()
+	for i := 0; i < snapshotChunkSize+10; i++ {
+		big.Add("names", []rune(fmt.Sprintf("name%d", i)))
+	}
+	big.Add("skills", []rune("PHP"), []rune("golang"))
+	big.AddWeighted("locations", []rune("Sydney"), 2)
+	big.Add("locations", []rune("Houston"))
+	snap := dir + "/store.snap"
+	if err := big.SaveSnapshot(snap, Compress(Gzip)); err != nil {
+		t.Fatal(err)
+	}
+	fromSnap, err := LoadSnapshot(snap, Lazy())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, s := range []*Store{store, fromSnap} {
+		if n := s.Stats()["skills"].Entities; n != 0 {
+			t.Errorf("Expected groups not to be loaded, got %d skills", n)
+		}
+		found := s.FindAll([]rune("PHP in Sydney"))
+		if len(found["skills"]) != 1 || len(found["locations"]) != 1 || found["locations"][0].Weight != 2 {
+			t.Errorf("Expected a skill and a location, got %v", found)
+		}
+		if n := s.Stats()["locations"].Entities; n != 2 {
+			t.Errorf("Expected the locations to be loaded, got %d", n)
+		}
+		if err := s.Preload("names"); err != nil {
+			t.Fatal(err)
+		}
+		if n := s.Stats()["names"].Entities; n == 0 {
+			t.Errorf("Expected the names to be preloaded")
+		}
+	}
+	if n := fromSnap.Group("names").Len(); n != snapshotChunkSize+10 {
+		t.Errorf("Expected %d names, got %d", snapshotChunkSize+10, n)
+	}
+
+	// Groups which can't be loaded are empty
+	store, err = FromDir(dir, Lazy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(dir + "/names.entities.csv"); err != nil {
+		t.Fatal(err)
+	}
+	if found := store.FindAll([]rune("John Smith")); len(found["names"]) != 0 {
+		t.Errorf("Expected no names, got %v", found["names"])
+	}
+	if err := store.Preload(); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("Expected the error loading the names, got %v", err)
+	}
+}
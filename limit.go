@@ -0,0 +1,60 @@
+package fastentity
+
+import "strings"
+
+// MaxMatchesPerEntity limits the matches of each entity of the group reported for a
+// document to n, keeping the earliest, so boilerplate entities appearing many times in a
+// document don't drown the others. Matches of an entity are counted by the entity they
+// matched, however they were matched. It applies to FindAll and its variants, Matches and
+// FindReader, and n of 0 removes the limit.
+func MaxMatchesPerEntity(n int) GroupOption {
+	return func(g *group) {
+		g.maxPerEntity = n
+	}
+}
+
+// EntityMatchLimits limits the matches reported for a document of the entities given,
+// ignoring case, as MaxMatchesPerEntity does for every entity of the group, overriding
+// it. Limits are added to any already configured for the group, and a limit of 0
+// removes the limit of an entity.
+func EntityMatchLimits(limits map[string]int) GroupOption {
+	return func(g *group) {
+		if g.entityLimits == nil {
+			g.entityLimits = make(map[string]int, len(limits))
+		}
+		for e, n := range limits {
+			g.entityLimits[strings.ToLower(e)] = n
+		}
+	}
+}
+
+// limitKey identifies an entity of a group whose matches are limited.
+type limitKey struct {
+	g    *group
+	text string
+}
+
+// matchLimiter counts the matches of the entities with limits while searching a
+// document, see MaxMatchesPerEntity.
+type matchLimiter map[limitKey]int
+
+// allow reports whether a match of the entity ent of g is within its limit, counting it
+// if so.
+func (l matchLimiter) allow(g *group, ent *entry) bool {
+	if g.maxPerEntity == 0 && g.entityLimits == nil {
+		return true
+	}
+	key := limitKey{g, strings.ToLower(string(ent.text))}
+	n, ok := g.entityLimits[key.text]
+	if !ok {
+		n = g.maxPerEntity
+	}
+	if n <= 0 {
+		return true
+	}
+	if l[key] >= n {
+		return false
+	}
+	l[key]++
+	return true
+}
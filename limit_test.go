@@ -0,0 +1,42 @@
+package fastentity
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestMaxMatchesPerEntity(t *testing.T) {
+	store := New()
+	store.Add("legal", []rune("Terms of Service"), []rune("Privacy Policy"), []rune("Copyright"))
+	store.Group("legal").Configure(MaxMatchesPerEntity(2), EntityMatchLimits(map[string]int{"copyright": 1, "privacy policy": 0}))
+	doc := []rune(strings.Repeat("Copyright. Terms of Service. Privacy Policy. ", 4))
+
+	count := func(ents []Entity) map[string]int {
+		counts := make(map[string]int)
+		for _, e := range ents {
+			counts[string(e.Canonical)]++
+		}
+		return counts
+	}
+	want := map[string]int{"Copyright": 1, "Terms of Service": 2, "Privacy Policy": 4}
+	ents := store.FindAll(doc)["legal"]
+	if got := count(ents); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v matches, got %v", want, got)
+	}
+	if ents[0].Offset != 0 || ents[1].Offset != 11 {
+		t.Errorf("Expected the earliest matches to be kept, got %v", ents)
+	}
+
+	var streamed []Entity
+	err := store.FindReader(strings.NewReader(string(doc)), func(m StreamMatch) bool {
+		streamed = append(streamed, m.Entity)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := count(streamed); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v matches streamed, got %v", want, got)
+	}
+}
@@ -0,0 +1,70 @@
+package fastentity
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// FindLines searches each line read from r as a separate document, calling fn with the
+// number of the line, starting at 1, and the matches found in it in document order, for
+// each line with matches. Offsets are from the start of the line, which excludes its
+// "\n" or "\r\n" terminator.
+//
+// Lines are searched as soon as they have been read, so FindLines can follow a log file
+// as it is written, e.g. from a pipe or a reader which waits for more data at the end of
+// the file, and returns once r is exhausted. Lines are searched as by FindAll, so the
+// store may be modified while lines are read.
+func (s *Store) FindLines(r io.Reader, fn func(lineNo int, matches []Match)) error {
+	return s.FindLinesContext(context.Background(), r, fn)
+}
+
+// FindLinesContext is like FindLines, but stops once the line being read has been
+// searched when ctx is cancelled, returning ctx.Err().
+func (s *Store) FindLinesContext(ctx context.Context, r io.Reader, fn func(lineNo int, matches []Match)) error {
+	br := bufio.NewReader(r)
+	var line []byte
+	for n := 1; ; n++ {
+		var err error
+		line, err = readLine(br, line[:0])
+		if err == io.EOF && len(line) == 0 {
+			return nil
+		}
+		if err != nil && err != io.EOF {
+			return err
+		}
+		results, ferr := s.FindAllContext(ctx, []rune(string(line)))
+		if ferr != nil {
+			return ferr
+		}
+		if ms := results.Matches(); len(ms) > 0 {
+			fn(n, ms)
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// readLine appends the next line read from br to buf, without its terminator. It returns
+// io.EOF with the last line if it isn't terminated.
+func readLine(br *bufio.Reader, buf []byte) ([]byte, error) {
+	for {
+		b, err := br.ReadSlice('\n')
+		buf = append(buf, b...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			return buf, err
+		}
+		buf = buf[:len(buf)-1]
+		if len(buf) > 0 && buf[len(buf)-1] == '\r' {
+			buf = buf[:len(buf)-1]
+		}
+		return buf, nil
+	}
+}
@@ -0,0 +1,52 @@
+package fastentity
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFindLines(t *testing.T) {
+	store := New()
+	store.Add("hosts", []rune("db-1"), []rune("web-2"))
+	store.Add("levels", []rune("ERROR"))
+
+	long := strings.Repeat("x ", 4096)
+	log := "INFO started\r\nERROR db-1 unreachable\n" + long + "web-2\n\nwarn web-2 slow"
+	type line struct {
+		n     int
+		texts []string
+		first int
+	}
+	var got []line
+	err := store.FindLines(iotest.HalfReader(strings.NewReader(log)), func(n int, ms []Match) {
+		l := line{n: n, first: ms[0].Offset}
+		for _, m := range ms {
+			l.texts = append(l.texts, string(m.Text))
+		}
+		got = append(got, l)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []line{
+		{2, []string{"ERROR", "db-1"}, 0},
+		{3, []string{"web-2"}, len(long)},
+		{5, []string{"web-2"}, 5},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %d lines with matches, got %+v", len(expected), got)
+	}
+	for i, e := range expected {
+		g := got[i]
+		if g.n != e.n || strings.Join(g.texts, ",") != strings.Join(e.texts, ",") || g.first != e.first {
+			t.Errorf("Expected line %d with %v from %d, got line %d with %v from %d", e.n, e.texts, e.first, g.n, g.texts, g.first)
+		}
+	}
+
+	err = store.FindLines(iotest.ErrReader(io.ErrUnexpectedEOF), func(int, []Match) {})
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Expected the read error, got %v", err)
+	}
+}
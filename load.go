@@ -0,0 +1,368 @@
+package fastentity
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// ProgressInterval is the number of lines read from a file between LinesRead progress
+// events.
+var ProgressInterval = 100000
+
+// LoadOption configures how entity files are loaded by FromDir and LoadDir.
+type LoadOption interface {
+	applyLoad(c *loadConfig)
+}
+
+type loadOptionFunc func(c *loadConfig)
+
+func (f loadOptionFunc) applyLoad(c *loadConfig) {
+	f(c)
+}
+
+type loadConfig struct {
+	layout       Layout
+	skipErrors   bool
+	weights      bool
+	lazy         bool
+	progress     func(LoadProgress)
+	checkScripts bool
+	detectFormat bool
+	source       string
+}
+
+// FileOption configures both loading and saving entity files.
+type FileOption interface {
+	LoadOption
+	SaveOption
+}
+
+// Weights reads and writes the weights of entities in entity files, as a column following
+// the entity and a comma, e.g. "San Francisco, USA,2.5". When loading, lines without a
+// comma have the weight DefaultWeight, so entities containing commas must be followed by
+// a weight.
+func Weights() FileOption {
+	return weightsOption{}
+}
+
+type weightsOption struct{}
+
+func (weightsOption) applyLoad(c *loadConfig) {
+	c.weights = true
+}
+
+func (weightsOption) applySave(c *saveConfig) {
+	c.weights = true
+}
+
+// parseWeight splits a line of an entity file into the entity and its weight.
+func parseWeight(line string) (string, float64, error) {
+	i := strings.LastIndexByte(line, ',')
+	if i < 0 {
+		return line, DefaultWeight, nil
+	}
+	w, err := strconv.ParseFloat(strings.TrimSpace(line[i+1:]), 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid weight %q", line[i+1:])
+	}
+	return line[:i], w, nil
+}
+
+// SkipErrors enables partial loading: files which can't be read are skipped rather than
+// failing the whole load, as are lines which aren't valid UTF-8 or are longer than
+// MaxEntityLen. Everything skipped is recorded in the LoadReport.
+func SkipErrors() LoadOption {
+	return loadOptionFunc(func(c *loadConfig) {
+		c.skipErrors = true
+	})
+}
+
+// ProgressKind identifies the kind of a LoadProgress event.
+type ProgressKind int
+
+const (
+	// FileStarted is sent when loading a file begins.
+	FileStarted ProgressKind = iota
+	// LinesRead is sent every ProgressInterval lines read from a file.
+	LinesRead
+	// FileDone is sent when loading a file has finished, successfully or not.
+	FileDone
+)
+
+// LoadProgress is an event describing the progress of loading a single entity file.
+type LoadProgress struct {
+	Kind  ProgressKind
+	Path  string
+	Group string
+
+	// Lines is the number of lines read from the file so far.
+	Lines int
+	// Elapsed is the time since loading the file started.
+	Elapsed time.Duration
+	// Err is set on FileDone if the file couldn't be loaded.
+	Err error
+}
+
+// WithProgress registers fn to be called as files are loaded. Files are loaded
+// concurrently, but calls to fn are serialized so it needn't be safe for concurrent use.
+func WithProgress(fn func(LoadProgress)) LoadOption {
+	return loadOptionFunc(func(c *loadConfig) {
+		var mu sync.Mutex
+		c.progress = func(p LoadProgress) {
+			mu.Lock()
+			fn(p)
+			mu.Unlock()
+		}
+	})
+}
+
+// LoadReport describes the outcome of loading a directory of entity files.
+type LoadReport struct {
+	// Files describes each entity file found, in directory order.
+	Files []FileReport
+	// Entities is the number of entities loaded into each group.
+	Entities map[string]int
+
+	// Duplicates lists the entities loaded more than once into the same group, and
+	// Conflicts those loaded into more than one group, which are often mistakes in the
+	// data. Only the first occurrence of a duplicate is added, while conflicts are added
+	// to each group.
+	Duplicates []Duplicate
+	Conflicts  []Conflict
+}
+
+// FileReport describes the outcome of loading a single entity file.
+type FileReport struct {
+	Path  string
+	Group string
+
+	// Entities is the number of entities added from the file, not counting those already
+	// in the group.
+	Entities int
+	// SHA256 is the hex encoded SHA-256 checksum of the file, recorded in the Manifest of
+	// stores saved after loading it.
+	SHA256 string
+	// Skipped lists the lines of the file which were not added.
+	Skipped []SkippedLine
+	// Err is the reason the file couldn't be loaded, in which case none of its entities
+	// were added.
+	Err error
+}
+
+// SkippedLine is a line of an entity file which was not added to the store.
+type SkippedLine struct {
+	Line   int
+	Reason error
+}
+
+// Failed returns the reports of the files which couldn't be loaded.
+func (r *LoadReport) Failed() []FileReport {
+	var failed []FileReport
+	for _, f := range r.Files {
+		if f.Err != nil {
+			failed = append(failed, f)
+		}
+	}
+	return failed
+}
+
+var errInvalidUTF8 = errors.New("invalid UTF-8")
+
+// checkInterval is the number of lines or entities processed between checks for
+// cancellation when reading or writing entity files, and of words when searching.
+const checkInterval = 1024
+
+// LoadDir is like FromDir, but also returns a LoadReport describing the files loaded, the
+// number of entities added to each group, and anything which was skipped.
+func LoadDir(dir string, opts ...LoadOption) (*Store, *LoadReport, error) {
+	return LoadDirContext(context.Background(), dir, opts...)
+}
+
+// LoadDirContext is like LoadDir, but aborts loading when ctx is cancelled, returning
+// an error wrapping ctx.Err().
+func LoadDirContext(ctx context.Context, dir string, opts ...LoadOption) (*Store, *LoadReport, error) {
+	var c loadConfig
+	for _, opt := range opts {
+		opt.applyLoad(&c)
+	}
+
+	dir = strings.TrimRight(dir, "/")
+	files, err := c.layout.entityFiles(dir)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error reading directory %v: %w", dir, err)
+	}
+
+	r := &LoadReport{
+		Files:    files,
+		Entities: make(map[string]int),
+	}
+	if len(r.Files) == 0 {
+		return nil, r, fmt.Errorf("%v: %w", dir, ErrNoEntityFiles)
+	}
+	manifest, err := readManifest(dir)
+	if err != nil {
+		return nil, r, err
+	}
+	if c.lazy {
+		s := lazyDir(r.Files, &c)
+		s.setManifest(manifest, r.Files)
+		return s, r, nil
+	}
+
+	s := New()
+	sources := make([]source, len(r.Files))
+	var wg sync.WaitGroup
+	for i := range r.Files {
+		wg.Add(1)
+		go func(f *FileReport, src *source) {
+			defer wg.Done()
+			src.ents, f.Err = loadFile(ctx, s, f, &c)
+			src.group, src.name = f.Group, f.Path
+		}(&r.Files[i], &sources[i])
+	}
+	wg.Wait()
+
+	if err := ctx.Err(); err != nil {
+		return nil, r, fmt.Errorf("loading %v: %w", dir, err)
+	}
+
+	for _, f := range r.Files {
+		if f.Err != nil {
+			if !c.skipErrors {
+				return nil, r, f.Err
+			}
+			continue
+		}
+		r.Entities[f.Group] += f.Entities
+	}
+	if len(r.Failed()) == len(r.Files) {
+		return nil, r, fmt.Errorf("no entity files could be loaded: %w", r.Files[0].Err)
+	}
+	r.Duplicates, r.Conflicts = duplicates(sources)
+	s.setManifest(manifest, r.Files)
+	return s, r, nil
+}
+
+// loadFile adds the entities from the file described by f to s, recording the outcome
+// in f, and returns the entities added.
+func loadFile(ctx context.Context, s *Store, f *FileReport, c *loadConfig) (ents []entry, err error) {
+	var onLines func(n int)
+	if c.progress != nil {
+		start := time.Now()
+		lines := 0
+		onLines = func(n int) {
+			lines = n
+			c.progress(LoadProgress{Kind: LinesRead, Path: f.Path, Group: f.Group, Lines: n, Elapsed: time.Since(start)})
+		}
+		c.progress(LoadProgress{Kind: FileStarted, Path: f.Path, Group: f.Group})
+		defer func() {
+			c.progress(LoadProgress{Kind: FileDone, Path: f.Path, Group: f.Group, Lines: lines, Elapsed: time.Since(start), Err: err})
+		}()
+	}
+
+	ents, err = readFile(ctx, f, c, onLines)
+	if err != nil {
+		return nil, err
+	}
+	f.Entities = s.addEntries(f.Group, ents)
+	return ents, nil
+}
+
+// readFile reads the entities from the file described by f, recording the lines skipped
+// in f. onLines is passed to readEntities.
+func readFile(ctx context.Context, f *FileReport, c *loadConfig, onLines func(n int)) ([]entry, error) {
+	file, err := openFile(f.Path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %v: %w", f.Path, err)
+	}
+	defer file.Close()
+
+	var check func(e []rune) error
+	if c.checkScripts {
+		check = scriptCheck(f.Group)
+	}
+	h := sha256.New()
+	ents, skipped, err := readEntities(ctx, io.TeeReader(file, h), c, formatOf(f.Path), check, onLines)
+	if err != nil {
+		return nil, fmt.Errorf("error reading from %v: %w", f.Path, err)
+	}
+	f.Skipped, f.SHA256 = skipped, hex.EncodeToString(h.Sum(nil))
+	setSource(ents, f.Path)
+	return ents, nil
+}
+
+// readEntities reads entities from r in the format f, one per line, ignoring empty lines.
+// With the SkipErrors option, lines which aren't valid UTF-8, fail ValidateEntity or
+// can't be parsed are skipped and returned separately, as are entities failing check if
+// it's non-nil, with or without SkipErrors. If onLines is non-nil it is called
+// every ProgressInterval lines, and with the total number of lines once r is exhausted.
+// Reading stops early if ctx is cancelled.
+func readEntities(ctx context.Context, r io.Reader, c *loadConfig, f entityFormat, check func(e []rune) error, onLines func(n int)) ([]entry, []SkippedLine, error) {
+	var ents []entry
+	var skipped []SkippedLine
+	validate := c.skipErrors
+	parse := newLineParser(f, c)
+
+	s := bufio.NewScanner(r)
+	n := 0
+	for s.Scan() {
+		n++
+		if onLines != nil && n%ProgressInterval == 0 {
+			onLines(n)
+		}
+		if n%checkInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, nil, err
+			}
+		}
+		line := s.Text()
+		if len(line) == 0 {
+			continue
+		}
+		if validate && !utf8.ValidString(line) {
+			skipped = append(skipped, SkippedLine{Line: n, Reason: errInvalidUTF8})
+			continue
+		}
+		e, ok, err := parse(line)
+		if err != nil {
+			if !validate {
+				return nil, nil, fmt.Errorf("line %d: %w", n, err)
+			}
+			skipped = append(skipped, SkippedLine{Line: n, Reason: err})
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if validate {
+			if err := ValidateEntity(e.text); err != nil {
+				skipped = append(skipped, SkippedLine{Line: n, Reason: err})
+				continue
+			}
+		}
+		if check != nil {
+			if err := check(e.text); err != nil {
+				skipped = append(skipped, SkippedLine{Line: n, Reason: err})
+				continue
+			}
+		}
+		ents = append(ents, e)
+	}
+	if err := s.Err(); err != nil {
+		return nil, nil, err
+	}
+	if onLines != nil && n%ProgressInterval != 0 {
+		onLines(n)
+	}
+	return ents, skipped, nil
+}
@@ -0,0 +1,134 @@
+package fastentity
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func writeEntityFiles(t *testing.T, files map[string]string) string {
+	dir, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range files {
+		if err := ioutil.WriteFile(dir+"/"+name, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadDirSkipErrors(t *testing.T) {
+	dir := writeEntityFiles(t, map[string]string{
+		"skills.entities.csv":    "PHP\ngolang\n\n本語\n",
+		"locations.entities.csv": "Sydney\n\xff\xfe\n" + strings.Repeat("a", MaxEntityLen+1) + "\nHouston\n",
+		"corrupt.entities.csv":   strings.Repeat("x", bufio.MaxScanTokenSize+1) + "\n",
+		"ignored.txt":            "ignored\n",
+	})
+	defer os.RemoveAll(dir)
+
+	if _, err := FromDir(dir); !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("Expected loading a corrupt file to fail, got %v", err)
+	}
+
+	s, r, err := LoadDir(dir, SkipErrors())
+	if err != nil {
+		t.Fatalf("Failed to load store: %v", err)
+	}
+	if len(r.Files) != 3 {
+		t.Errorf("Expected 3 files in the report, got %d", len(r.Files))
+	}
+	if failed := r.Failed(); len(failed) != 1 || failed[0].Group != "corrupt" {
+		t.Errorf("Expected the corrupt file to fail, got %v", failed)
+	}
+	if r.Entities["skills"] != 3 || r.Entities["locations"] != 2 {
+		t.Errorf("Unexpected entity counts: %v", r.Entities)
+	}
+	for _, f := range r.Files {
+		if f.Group != "locations" {
+			continue
+		}
+		if len(f.Skipped) != 2 || f.Skipped[0].Line != 2 || !errors.Is(f.Skipped[1].Reason, ErrEntityTooLong) {
+			t.Errorf("Unexpected skipped lines: %v", f.Skipped)
+		}
+	}
+	if _, ok := s.groups["corrupt"]; ok {
+		t.Errorf("Expected no group for the corrupt file")
+	}
+	if n := s.Group("locations").Len(); n != 2 {
+		t.Errorf("Expected 2 locations, got %d", n)
+	}
+}
+
+func TestLoadDirProgress(t *testing.T) {
+	dir := writeEntityFiles(t, map[string]string{
+		"skills.entities.csv":    "PHP\ngolang\n本語\n",
+		"locations.entities.csv": "Sydney\nHouston\n",
+	})
+	defer os.RemoveAll(dir)
+
+	defer func(n int) { ProgressInterval = n }(ProgressInterval)
+	ProgressInterval = 2
+
+	events := map[string][]LoadProgress{}
+	_, err := FromDir(dir, WithProgress(func(p LoadProgress) {
+		events[p.Group] = append(events[p.Group], p)
+	}))
+	if err != nil {
+		t.Fatalf("Failed to load store: %v", err)
+	}
+
+	expected := map[string][]ProgressKind{
+		"skills":    {FileStarted, LinesRead, LinesRead, FileDone},
+		"locations": {FileStarted, LinesRead, FileDone},
+	}
+	for group, kinds := range expected {
+		got := events[group]
+		if len(got) != len(kinds) {
+			t.Errorf("Expected %d events for %s, got %v", len(kinds), group, got)
+			continue
+		}
+		for i, k := range kinds {
+			if got[i].Kind != k {
+				t.Errorf("Expected event %d for %s to be %v, got %v", i, group, k, got[i].Kind)
+			}
+		}
+		if last := got[len(got)-1]; last.Err != nil {
+			t.Errorf("Unexpected final event for %s: %v", group, last)
+		}
+	}
+	if n := events["skills"][3].Lines; n != 3 {
+		t.Errorf("Expected 3 lines read from skills, got %d", n)
+	}
+	if n := events["locations"][2].Lines; n != 2 {
+		t.Errorf("Expected 2 lines read from locations, got %d", n)
+	}
+}
+
+func TestContextCancelled(t *testing.T) {
+	dir := writeEntityFiles(t, map[string]string{
+		"skills.entities.csv": "PHP\ngolang\n本語\n",
+	})
+	defer os.RemoveAll(dir)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := FromDirContext(ctx, dir); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected loading to be cancelled, got %v", err)
+	}
+	if _, _, err := LoadDirContext(ctx, dir, SkipErrors()); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected partial loading to be cancelled, got %v", err)
+	}
+
+	store := New()
+	store.Add("skills", []rune("PHP"))
+	if err := store.SaveContext(ctx, dir); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected saving to be cancelled, got %v", err)
+	}
+}
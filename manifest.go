@@ -0,0 +1,138 @@
+package fastentity
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"runtime/debug"
+	"time"
+)
+
+// ManifestFile is the name of the file Save writes the manifest of the store to, in the
+// directory alongside the entity files.
+const ManifestFile = "manifest.json"
+
+// modulePath is the path of the module of this package, for finding its version.
+const modulePath = "github.com/sajari/fastentity"
+
+// Manifest describes the build of the dictionaries of a store, written by Save and
+// WriteSnapshot, so that the dictionaries a store is running can be traced back to where
+// they came from.
+type Manifest struct {
+	// Built is when the manifest was written.
+	Built time.Time `json:"built"`
+	// LibraryVersion is the version of this package in the program which wrote the
+	// manifest, or "(devel)" if it isn't known.
+	LibraryVersion string `json:"library_version"`
+	// Version is the version of the store, see Store.Version.
+	Version uint64 `json:"version"`
+	// Groups is the number of entities in each group.
+	Groups map[string]int `json:"groups"`
+	// Sources lists the entity files the store was loaded from by FromDir or LoadDir.
+	// Stores loaded from a snapshot or directory with a manifest keep its sources.
+	Sources []ManifestSource `json:"sources,omitempty"`
+}
+
+// ManifestSource is an entity file a store was loaded from.
+type ManifestSource struct {
+	Path  string `json:"path"`
+	Group string `json:"group"`
+	// SHA256 is the hex encoded SHA-256 checksum of the file, which is empty for files
+	// loaded with the Lazy option.
+	SHA256 string `json:"sha256,omitempty"`
+}
+
+// Manifest returns the manifest the store was loaded with, from a snapshot or the
+// ManifestFile of a directory, or nil if it was loaded without one or created with New.
+// The manifest must not be modified.
+func (s *Store) Manifest() *Manifest {
+	s.RLock()
+	defer s.RUnlock()
+	return s.manifest
+}
+
+// newManifest returns a manifest of the store, given the number of entities in each
+// group. The caller must hold the store lock.
+func (s *Store) newManifest(groups map[string]int) *Manifest {
+	m := &Manifest{
+		Built:          time.Now().UTC(),
+		LibraryVersion: libraryVersion(),
+		Version:        s.Version(),
+		Groups:         groups,
+		Sources:        s.sources,
+	}
+	if m.Sources == nil && s.manifest != nil {
+		m.Sources = s.manifest.Sources
+	}
+	return m
+}
+
+// setManifest sets the manifest of a store loaded from the files, which are its sources
+// unless it has a manifest from an earlier save.
+func (s *Store) setManifest(m *Manifest, files []FileReport) {
+	s.manifest = m
+	if m != nil {
+		return
+	}
+	for _, f := range files {
+		if f.Err == nil {
+			s.sources = append(s.sources, ManifestSource{Path: f.Path, Group: f.Group, SHA256: f.SHA256})
+		}
+	}
+}
+
+// libraryVersion returns the version of this package in the running program.
+func libraryVersion() string {
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if bi.Main.Path == modulePath && bi.Main.Version != "" {
+			return bi.Main.Version
+		}
+		for _, d := range bi.Deps {
+			if d.Path == modulePath {
+				return d.Version
+			}
+		}
+	}
+	return "(devel)"
+}
+
+// writeManifest writes the manifest m to the ManifestFile of dir.
+func writeManifest(dir string, m *Manifest) error {
+	path := dir + "/" + ManifestFile
+	f, err := createFile(path)
+	if err != nil {
+		return fmt.Errorf("error creating %v: %w", path, err)
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(m)
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("error writing to %v: %w", path, err)
+	}
+	return nil
+}
+
+// readManifest reads the ManifestFile of dir, returning nil if there is none.
+func readManifest(dir string) (*Manifest, error) {
+	path := dir + "/" + ManifestFile
+	f, err := openFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error opening %v: %w", path, err)
+	}
+	defer f.Close()
+
+	var m Manifest
+	if err := json.NewDecoder(f).Decode(&m); err != nil {
+		return nil, fmt.Errorf("error reading from %v: %w", path, err)
+	}
+	return &m, nil
+}
@@ -0,0 +1,83 @@
+package fastentity
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestManifest(t *testing.T) {
+	src, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(src)
+	dst, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dst)
+
+	data := []byte("golang\nPHP\n")
+	if err := ioutil.WriteFile(src+"/skills"+entityFileSuffix, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(data)
+	sources := []ManifestSource{{Path: src + "/skills" + entityFileSuffix, Group: "skills", SHA256: hex.EncodeToString(sum[:])}}
+
+	store, report, err := LoadDir(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Manifest() != nil {
+		t.Errorf("Expected no manifest loading a directory without one, got %+v", store.Manifest())
+	}
+	if report.Files[0].SHA256 != sources[0].SHA256 {
+		t.Errorf("Expected checksum %s, got %s", sources[0].SHA256, report.Files[0].SHA256)
+	}
+
+	// Saving records the files loaded, which are kept when loading the saved store
+	store.Add("locations", []rune("Sydney"))
+	if err := store.Save(dst); err != nil {
+		t.Fatal(err)
+	}
+	loaded, report, err := LoadDir(dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Files) != 2 {
+		t.Errorf("Expected the manifest not to be loaded as entities, got %+v", report.Files)
+	}
+	m := loaded.Manifest()
+	if m == nil {
+		t.Fatal("Expected a manifest")
+	}
+	if want := map[string]int{"skills": 2, "locations": 1}; !reflect.DeepEqual(m.Groups, want) {
+		t.Errorf("Expected groups %v, got %v", want, m.Groups)
+	}
+	if !reflect.DeepEqual(m.Sources, sources) {
+		t.Errorf("Expected sources %+v, got %+v", sources, m.Sources)
+	}
+	if m.Built.IsZero() || m.LibraryVersion == "" || m.Version != store.Version() {
+		t.Errorf("Expected the build time and versions to be set, got %+v", m)
+	}
+
+	var buf bytes.Buffer
+	if err := loaded.WriteSnapshot(&buf, Compress(Zstd)); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sm := restored.Manifest(); sm == nil || sm.Version != loaded.Version() || !reflect.DeepEqual(sm.Sources, sources) || !reflect.DeepEqual(sm.Groups, m.Groups) {
+		t.Errorf("Expected the snapshot manifest to keep the sources, got %+v", sm)
+	}
+	if New().Manifest() != nil {
+		t.Error("Expected no manifest for a new store")
+	}
+}
@@ -0,0 +1,127 @@
+package fastentity
+
+import (
+	"container/list"
+	"sort"
+	"sync/atomic"
+)
+
+// entryOverhead is the estimated memory used by each entity besides its text, in its
+// group's indices.
+const entryOverhead = 96
+
+// entrySize returns the estimated memory used by the entity e: its text as runes, and in
+// lower case as bytes, mostly one per rune.
+func entrySize(e entry) int64 {
+	return int64(5*len(e.text) + entryOverhead)
+}
+
+// SetMemoryLimit limits the estimated memory used by the entities of the store to n bytes,
+// or removes the limit if n is 0. When searching takes the store over the limit, the
+// groups searched least recently are evicted, freeing their entities, until it is under
+// the limit again. Evicted groups are loaded again from their files the next time they
+// are used, so stores serving thousands of dictionaries, few of which are in use at once,
+// fit in memory.
+//
+// Only groups loaded with the Lazy option and not modified since are evicted, along with
+// any configuration such as Normalize, which is applied again as they are reloaded.
+// Groups counting their matches are never evicted. The groups being searched are kept,
+// even if they alone are over the limit.
+func (s *Store) SetMemoryLimit(n int64) {
+	s.Lock()
+	s.memoryLimit = n
+	s.Unlock()
+	s.evict(nil)
+}
+
+// MemoryUsage returns the estimated memory used by the entities of the store, in bytes.
+func (s *Store) MemoryUsage() int64 {
+	s.RLock()
+	defer s.RUnlock()
+	var n int64
+	for _, g := range s.groups {
+		n += atomic.LoadInt64(&g.size)
+	}
+	return n
+}
+
+// evict evicts the least recently searched groups, other than those in keep, until the
+// store is under its memory limit.
+func (s *Store) evict(keep []*group) {
+	s.RLock()
+	limit := s.memoryLimit
+	if limit <= 0 {
+		s.RUnlock()
+		return
+	}
+	var total int64
+	groups := make([]*group, 0, len(s.groups))
+	for _, g := range s.groups {
+		total += atomic.LoadInt64(&g.size)
+		groups = append(groups, g)
+	}
+	s.RUnlock()
+	if total <= limit {
+		return
+	}
+
+	s.evictMu.Lock()
+	defer s.evictMu.Unlock()
+	kept := make(map[*group]bool, len(keep))
+	for _, g := range keep {
+		kept[g] = true
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return atomic.LoadUint64(&groups[i].used) < atomic.LoadUint64(&groups[j].used)
+	})
+	for _, g := range groups {
+		if total <= limit {
+			return
+		}
+		if !kept[g] {
+			total -= g.unload()
+		}
+	}
+}
+
+// unload frees the entities of the group if they can be loaded again, returning the
+// estimated memory freed.
+func (g *group) unload() int64 {
+	g.Lock()
+	defer g.Unlock()
+	if !g.evictable || g.frozen || g.counts != nil || atomic.LoadUint32(&g.lazy) == 1 {
+		return 0
+	}
+
+	g.reset()
+	atomic.StoreUint32(&g.lazy, 1)
+	return atomic.SwapInt64(&g.size, 0)
+}
+
+// reset removes the entities of the group, keeping its configuration. The caller must hold
+// the group lock.
+func (g *group) reset() {
+	g.entities = make(map[string][]entry)
+	g.folded = make(map[string]struct{})
+	g.maxLen, g.maxWords = 0, 0
+	if g.provider != nil {
+		g.maxWords = g.provider.opts.MaxWords
+	}
+	if g.normalized != nil {
+		g.normalized = make(map[string][]entry)
+	}
+	if g.acronyms != nil {
+		g.acronyms = make(map[string][]entry)
+	}
+	if g.phonetic != nil {
+		g.phonetic = make(map[string][]entry)
+	}
+	if g.synonyms != nil {
+		g.synonyms = make(map[string][]entry)
+	}
+	if g.recent != nil {
+		g.recent.order.Init()
+		g.recent.index = make(map[string]*list.Element)
+	}
+	g.evictable = false
+}
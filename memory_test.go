@@ -0,0 +1,57 @@
+package fastentity
+
+import (
+	"os"
+	"testing"
+)
+
+func TestMemoryLimit(t *testing.T) {
+	dir := writeEntityFiles(t, map[string]string{
+		"skills.entities.csv":    "PHP\ngolang\n",
+		"locations.entities.csv": "Sydney\nHouston\n",
+		"names.entities.csv":     "John Smith\n",
+	})
+	defer os.RemoveAll(dir)
+
+	store, err := FromDir(dir, Lazy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Group("skills").Configure(FoldPlurals())
+	skills := entrySize(newEntry([]rune("PHP"))) + entrySize(newEntry([]rune("golang")))
+	store.SetMemoryLimit(skills)
+
+	loaded := func() map[string]bool {
+		m := make(map[string]bool)
+		for name, gs := range store.Stats() {
+			m[name] = gs.Entities > 0
+		}
+		return m
+	}
+	store.Group("names").Find([]rune("John Smith"))
+	store.Group("skills").Find([]rune("PHPs"))
+	if l := loaded(); !l["skills"] || l["names"] || l["locations"] {
+		t.Errorf("Expected only the skills to be loaded, got %v", l)
+	}
+	if n := store.MemoryUsage(); n != skills {
+		t.Errorf("Expected %d bytes in use, got %d", skills, n)
+	}
+
+	// Modified groups can't be reloaded, so are kept
+	store.Add("locations", []rune("Paris"))
+	if found := store.Group("locations").Find([]rune("Paris or Sydney")); len(found) != 2 {
+		t.Errorf("Expected 2 locations, got %v", found)
+	}
+	if found := store.Group("skills").Find([]rune("golang developers")); len(found) != 1 {
+		t.Errorf("Expected the skill to be reloaded, got %v", found)
+	}
+	if l := loaded(); !l["skills"] || l["names"] || !l["locations"] {
+		t.Errorf("Expected the skills and locations to be loaded, got %v", l)
+	}
+
+	store.SetMemoryLimit(0)
+	store.FindAll([]rune("John Smith"))
+	if l := loaded(); !l["skills"] || !l["names"] || !l["locations"] {
+		t.Errorf("Expected all groups to be loaded, got %v", l)
+	}
+}
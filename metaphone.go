@@ -0,0 +1,616 @@
+package fastentity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// metaphoneMaxLen is the maximum length of Double Metaphone codes.
+const metaphoneMaxLen = 4
+
+// DoubleMetaphone returns the primary and alternate Double Metaphone codes for word, which
+// are equal for words which sound alike in English and many other languages, e.g. "Smith"
+// and "Smyth" both have the primary code "SM0". Characters other than letters are ignored.
+func DoubleMetaphone(word string) (primary, alternate string) {
+	var rs []rune
+	for _, r := range strings.ToUpper(word) {
+		if unicode.IsLetter(r) || r == ' ' {
+			rs = append(rs, r)
+		}
+	}
+	if len(rs) == 0 {
+		return "", ""
+	}
+
+	m := metaphone{
+		value:         rs,
+		slavoGermanic: isSlavoGermanic(rs),
+	}
+	m.encode()
+	return string(m.primary), string(m.alternate)
+}
+
+// metaphone holds the state of encoding a single word, following the reference Double
+// Metaphone algorithm by Lawrence Philips.
+type metaphone struct {
+	value              []rune
+	slavoGermanic      bool
+	primary, alternate []rune
+}
+
+func isSlavoGermanic(rs []rune) bool {
+	s := string(rs)
+	return strings.ContainsAny(s, "WK") || strings.Contains(s, "CZ") || strings.Contains(s, "WITZ")
+}
+
+func isVowel(r rune) bool {
+	return strings.ContainsRune("AEIOUY", r)
+}
+
+// at returns the character at i, or 0 if i is out of range.
+func (m *metaphone) at(i int) rune {
+	if i < 0 || i >= len(m.value) {
+		return 0
+	}
+	return m.value[i]
+}
+
+// contains reports whether the n characters starting at i equal any of the criteria.
+func (m *metaphone) contains(i, n int, criteria ...string) bool {
+	if i < 0 || i+n > len(m.value) {
+		return false
+	}
+	s := string(m.value[i : i+n])
+	for _, c := range criteria {
+		if s == c {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *metaphone) appendPrimary(s string) {
+	for _, r := range s {
+		if len(m.primary) < metaphoneMaxLen {
+			m.primary = append(m.primary, r)
+		}
+	}
+}
+
+func (m *metaphone) appendAlternate(s string) {
+	for _, r := range s {
+		if len(m.alternate) < metaphoneMaxLen {
+			m.alternate = append(m.alternate, r)
+		}
+	}
+}
+
+// add appends the primary code, and the alternate code if given or the primary otherwise.
+func (m *metaphone) add(primary string, alternate ...string) {
+	m.appendPrimary(primary)
+	if len(alternate) > 0 {
+		m.appendAlternate(alternate[0])
+	} else {
+		m.appendAlternate(primary)
+	}
+}
+
+func (m *metaphone) complete() bool {
+	return len(m.primary) >= metaphoneMaxLen && len(m.alternate) >= metaphoneMaxLen
+}
+
+// skip returns i+2 if the next character is c, otherwise i+1.
+func (m *metaphone) skip(i int, c rune) int {
+	if m.at(i+1) == c {
+		return i + 2
+	}
+	return i + 1
+}
+
+func (m *metaphone) encode() {
+	i := 0
+	if m.contains(0, 2, "GN", "KN", "PN", "WR", "PS") {
+		i = 1
+	}
+	for !m.complete() && i < len(m.value) {
+		switch m.at(i) {
+		case 'A', 'E', 'I', 'O', 'U', 'Y':
+			if i == 0 {
+				m.add("A")
+			}
+			i++
+		case 'B':
+			m.add("P")
+			i = m.skip(i, 'B')
+		case 'Ç':
+			m.add("S")
+			i++
+		case 'C':
+			i = m.c(i)
+		case 'D':
+			i = m.d(i)
+		case 'F':
+			m.add("F")
+			i = m.skip(i, 'F')
+		case 'G':
+			i = m.g(i)
+		case 'H':
+			i = m.h(i)
+		case 'J':
+			i = m.j(i)
+		case 'K':
+			m.add("K")
+			i = m.skip(i, 'K')
+		case 'L':
+			i = m.l(i)
+		case 'M':
+			m.add("M")
+			if m.conditionM0(i) {
+				i += 2
+			} else {
+				i++
+			}
+		case 'N':
+			m.add("N")
+			i = m.skip(i, 'N')
+		case 'Ñ':
+			m.add("N")
+			i++
+		case 'P':
+			i = m.p(i)
+		case 'Q':
+			m.add("K")
+			i = m.skip(i, 'Q')
+		case 'R':
+			i = m.r(i)
+		case 'S':
+			i = m.s(i)
+		case 'T':
+			i = m.t(i)
+		case 'V':
+			m.add("F")
+			i = m.skip(i, 'V')
+		case 'W':
+			i = m.w(i)
+		case 'X':
+			i = m.x(i)
+		case 'Z':
+			i = m.z(i)
+		default:
+			i++
+		}
+	}
+}
+
+func (m *metaphone) c(i int) int {
+	switch {
+	case m.conditionC0(i):
+		m.add("K")
+		return i + 2
+	case i == 0 && m.contains(i, 6, "CAESAR"):
+		m.add("S")
+		return i + 2
+	case m.contains(i, 2, "CH"):
+		return m.ch(i)
+	case m.contains(i, 2, "CZ") && !m.contains(i-2, 4, "WICZ"):
+		// "Czerny"
+		m.add("S", "X")
+		return i + 2
+	case m.contains(i+1, 3, "CIA"):
+		// "focaccia"
+		m.add("X")
+		return i + 3
+	case m.contains(i, 2, "CC") && !(i == 1 && m.at(0) == 'M'):
+		// Double "cc" but not "McClelland"
+		return m.cc(i)
+	case m.contains(i, 2, "CK", "CG", "CQ"):
+		m.add("K")
+		return i + 2
+	case m.contains(i, 2, "CI", "CE", "CY"):
+		// Italian vs. English
+		if m.contains(i, 3, "CIO", "CIE", "CIA") {
+			m.add("S", "X")
+		} else {
+			m.add("S")
+		}
+		return i + 2
+	}
+
+	m.add("K")
+	switch {
+	case m.contains(i+1, 2, " C", " Q", " G"):
+		// "Mac Caffrey", "Mac Gregor"
+		return i + 3
+	case m.contains(i+1, 1, "C", "K", "Q") && !m.contains(i+1, 2, "CE", "CI"):
+		return i + 2
+	}
+	return i + 1
+}
+
+func (m *metaphone) cc(i int) int {
+	if m.contains(i+2, 1, "I", "E", "H") && !m.contains(i+2, 2, "HU") {
+		// "bellocchio" but not "bacchus"
+		if (i == 1 && m.at(i-1) == 'A') || m.contains(i-1, 5, "UCCEE", "UCCES") {
+			// "accident", "accede", "succeed"
+			m.add("KS")
+		} else {
+			// "bacci", "bertucci", other Italian
+			m.add("X")
+		}
+		return i + 3
+	}
+	// Pierce's rule
+	m.add("K")
+	return i + 2
+}
+
+func (m *metaphone) ch(i int) int {
+	switch {
+	case i > 0 && m.contains(i, 4, "CHAE"):
+		// "Michael"
+		m.add("K", "X")
+	case m.conditionCH0(i), m.conditionCH1(i):
+		// Greek roots, e.g. "chemistry", "chorus", or Germanic
+		m.add("K")
+	case i > 0:
+		if m.contains(0, 2, "MC") {
+			m.add("K")
+		} else {
+			m.add("X", "K")
+		}
+	default:
+		m.add("X")
+	}
+	return i + 2
+}
+
+func (m *metaphone) d(i int) int {
+	switch {
+	case m.contains(i, 2, "DG"):
+		if m.contains(i+2, 1, "I", "E", "Y") {
+			// "edge"
+			m.add("J")
+			return i + 3
+		}
+		// "Edgar"
+		m.add("TK")
+		return i + 2
+	case m.contains(i, 2, "DT", "DD"):
+		m.add("T")
+		return i + 2
+	}
+	m.add("T")
+	return i + 1
+}
+
+func (m *metaphone) g(i int) int {
+	switch {
+	case m.at(i+1) == 'H':
+		return m.gh(i)
+	case m.at(i+1) == 'N':
+		switch {
+		case i == 1 && isVowel(m.at(0)) && !m.slavoGermanic:
+			m.add("KN", "N")
+		case !m.contains(i+2, 2, "EY") && m.at(i+1) != 'Y' && !m.slavoGermanic:
+			m.add("N", "KN")
+		default:
+			m.add("KN")
+		}
+		return i + 2
+	case m.contains(i+1, 2, "LI") && !m.slavoGermanic:
+		m.add("KL", "L")
+		return i + 2
+	case i == 0 && (m.at(i+1) == 'Y' || m.contains(i+1, 2, "ES", "EP", "EB", "EL", "EY", "IB", "IL", "IN", "IE", "EI", "ER")):
+		// -ges-, -gep-, -gel-, -gie- at the beginning
+		m.add("K", "J")
+		return i + 2
+	case (m.contains(i+1, 2, "ER") || m.at(i+1) == 'Y') &&
+		!m.contains(0, 6, "DANGER", "RANGER", "MANGER") &&
+		!m.contains(i-1, 1, "E", "I") &&
+		!m.contains(i-1, 3, "RGY", "OGY"):
+		// -ger-, -gy-
+		m.add("K", "J")
+		return i + 2
+	case m.contains(i+1, 1, "E", "I", "Y") || m.contains(i-1, 4, "AGGI", "OGGI"):
+		// Italian "biaggi"
+		switch {
+		case m.contains(0, 4, "VAN ", "VON ") || m.contains(0, 3, "SCH") || m.contains(i+1, 2, "ET"):
+			// Obviously Germanic
+			m.add("K")
+		case m.contains(i+1, 3, "IER"):
+			m.add("J")
+		default:
+			m.add("J", "K")
+		}
+		return i + 2
+	case m.at(i+1) == 'G':
+		m.add("K")
+		return i + 2
+	}
+	m.add("K")
+	return i + 1
+}
+
+func (m *metaphone) gh(i int) int {
+	switch {
+	case i > 0 && !isVowel(m.at(i-1)):
+		m.add("K")
+	case i == 0:
+		if m.at(i+2) == 'I' {
+			m.add("J")
+		} else {
+			m.add("K")
+		}
+	case (i > 1 && m.contains(i-2, 1, "B", "H", "D")) ||
+		(i > 2 && m.contains(i-3, 1, "B", "H", "D")) ||
+		(i > 3 && m.contains(i-4, 1, "B", "H")):
+		// Parker's rule, e.g. "hugh"
+	case i > 2 && m.at(i-1) == 'U' && m.contains(i-3, 1, "C", "G", "L", "R", "T"):
+		// "laugh", "McLaughlin", "cough", "gough", "rough", "tough"
+		m.add("F")
+	case i > 0 && m.at(i-1) != 'I':
+		m.add("K")
+	}
+	return i + 2
+}
+
+func (m *metaphone) h(i int) int {
+	// Only keep if first & before a vowel or between two vowels
+	if (i == 0 || isVowel(m.at(i-1))) && isVowel(m.at(i+1)) {
+		m.add("H")
+		return i + 2
+	}
+	return i + 1
+}
+
+func (m *metaphone) j(i int) int {
+	if m.contains(i, 4, "JOSE") || m.contains(0, 4, "SAN ") {
+		// Obviously Spanish, e.g. "Jose", "San Jacinto"
+		if (i == 0 && (m.at(i+4) == ' ' || len(m.value) == 4)) || m.contains(0, 4, "SAN ") {
+			m.add("H")
+		} else {
+			m.add("J", "H")
+		}
+		return i + 1
+	}
+
+	switch {
+	case i == 0:
+		m.add("J", "A")
+	case isVowel(m.at(i-1)) && !m.slavoGermanic && (m.at(i+1) == 'A' || m.at(i+1) == 'O'):
+		m.add("J", "H")
+	case i == len(m.value)-1:
+		m.add("J", "")
+	case !m.contains(i+1, 1, "L", "T", "K", "S", "N", "M", "B", "Z") && !m.contains(i-1, 1, "S", "K", "L"):
+		m.add("J")
+	}
+	return m.skip(i, 'J')
+}
+
+func (m *metaphone) l(i int) int {
+	if m.at(i+1) == 'L' {
+		if m.conditionL0(i) {
+			m.appendPrimary("L")
+		} else {
+			m.add("L")
+		}
+		return i + 2
+	}
+	m.add("L")
+	return i + 1
+}
+
+func (m *metaphone) p(i int) int {
+	if m.at(i+1) == 'H' {
+		m.add("F")
+		return i + 2
+	}
+	m.add("P")
+	if m.contains(i+1, 1, "P", "B") {
+		return i + 2
+	}
+	return i + 1
+}
+
+func (m *metaphone) r(i int) int {
+	if i == len(m.value)-1 && !m.slavoGermanic && m.contains(i-2, 2, "IE") && !m.contains(i-4, 2, "ME", "MA") {
+		m.appendAlternate("R")
+	} else {
+		m.add("R")
+	}
+	return m.skip(i, 'R')
+}
+
+func (m *metaphone) s(i int) int {
+	switch {
+	case m.contains(i-1, 3, "ISL", "YSL"):
+		// "island", "isle", "carlisle", "carlysle"
+		return i + 1
+	case i == 0 && m.contains(i, 5, "SUGAR"):
+		m.add("X", "S")
+		return i + 1
+	case m.contains(i, 2, "SH"):
+		if m.contains(i+1, 4, "HEIM", "HOEK", "HOLM", "HOLZ") {
+			// Germanic
+			m.add("S")
+		} else {
+			m.add("X")
+		}
+		return i + 2
+	case m.contains(i, 3, "SIO", "SIA") || m.contains(i, 4, "SIAN"):
+		// Italian and Armenian
+		if m.slavoGermanic {
+			m.add("S")
+		} else {
+			m.add("S", "X")
+		}
+		return i + 3
+	case (i == 0 && m.contains(i+1, 1, "M", "N", "L", "W")) || m.contains(i+1, 1, "Z"):
+		// German & anglicisations, e.g. "smith" matches "schmidt", "snider" matches
+		// "schneider", also -sz- in Slavic languages
+		m.add("S", "X")
+		if m.contains(i+1, 1, "Z") {
+			return i + 2
+		}
+		return i + 1
+	case m.contains(i, 2, "SC"):
+		return m.sc(i)
+	}
+
+	if i == len(m.value)-1 && m.contains(i-2, 2, "AI", "OI") {
+		// French, e.g. "resnais", "artois"
+		m.appendAlternate("S")
+	} else {
+		m.add("S")
+	}
+	if m.contains(i+1, 1, "S", "Z") {
+		return i + 2
+	}
+	return i + 1
+}
+
+func (m *metaphone) sc(i int) int {
+	switch {
+	case m.at(i+2) == 'H':
+		// Schlesinger's rule
+		switch {
+		case m.contains(i+3, 2, "ER", "EN"):
+			// "schermerhorn", "schenker"
+			m.add("X", "SK")
+		case m.contains(i+3, 2, "OO", "UY", "ED", "EM"):
+			// Dutch origin, e.g. "school", "schooner"
+			m.add("SK")
+		case i == 0 && !isVowel(m.at(3)) && m.at(3) != 'W':
+			m.add("X", "S")
+		default:
+			m.add("X")
+		}
+	case m.contains(i+2, 1, "I", "E", "Y"):
+		m.add("S")
+	default:
+		m.add("SK")
+	}
+	return i + 3
+}
+
+func (m *metaphone) t(i int) int {
+	switch {
+	case m.contains(i, 4, "TION"), m.contains(i, 3, "TIA", "TCH"):
+		m.add("X")
+		return i + 3
+	case m.contains(i, 2, "TH") || m.contains(i, 3, "TTH"):
+		if m.contains(i+2, 2, "OM", "AM") || m.contains(0, 4, "VAN ", "VON ") || m.contains(0, 3, "SCH") {
+			// "thomas", "thames" or Germanic
+			m.add("T")
+		} else {
+			m.add("0", "T")
+		}
+		return i + 2
+	}
+	m.add("T")
+	if m.contains(i+1, 1, "T", "D") {
+		return i + 2
+	}
+	return i + 1
+}
+
+func (m *metaphone) w(i int) int {
+	switch {
+	case m.contains(i, 2, "WR"):
+		m.add("R")
+		return i + 2
+	case i == 0 && (isVowel(m.at(i+1)) || m.contains(i, 2, "WH")):
+		if isVowel(m.at(i + 1)) {
+			// "Wasserman" matches "Vasserman"
+			m.add("A", "F")
+		} else {
+			// "Uomo" matches "Womo"
+			m.add("A")
+		}
+		return i + 1
+	case (i == len(m.value)-1 && isVowel(m.at(i-1))) ||
+		m.contains(i-1, 5, "EWSKI", "EWSKY", "OWSKI", "OWSKY") ||
+		m.contains(0, 3, "SCH"):
+		// "Arnow" matches "Arnoff"
+		m.appendAlternate("F")
+		return i + 1
+	case m.contains(i, 4, "WICZ", "WITZ"):
+		// Polish, e.g. "filipowicz"
+		m.add("TS", "FX")
+		return i + 4
+	}
+	return i + 1
+}
+
+func (m *metaphone) x(i int) int {
+	if i == 0 {
+		m.add("S")
+		return i + 1
+	}
+	if !(i == len(m.value)-1 && (m.contains(i-3, 3, "IAU", "EAU") || m.contains(i-2, 2, "AU", "OU"))) {
+		// Not French, e.g. "breaux"
+		m.add("KS")
+	}
+	if m.contains(i+1, 1, "C", "X") {
+		return i + 2
+	}
+	return i + 1
+}
+
+func (m *metaphone) z(i int) int {
+	if m.at(i+1) == 'H' {
+		// Chinese pinyin, e.g. "zhao"
+		m.add("J")
+		return i + 2
+	}
+	if m.contains(i+1, 2, "ZO", "ZI", "ZA") || (m.slavoGermanic && i > 0 && m.at(i-1) != 'T') {
+		m.add("S", "TS")
+	} else {
+		m.add("S")
+	}
+	return m.skip(i, 'Z')
+}
+
+func (m *metaphone) conditionC0(i int) bool {
+	switch {
+	case m.contains(i, 4, "CHIA"):
+		return true
+	case i <= 1, isVowel(m.at(i - 2)), !m.contains(i-1, 3, "ACH"):
+		return false
+	}
+	c := m.at(i + 2)
+	return (c != 'I' && c != 'E') || m.contains(i-2, 6, "BACHER", "MACHER")
+}
+
+func (m *metaphone) conditionCH0(i int) bool {
+	if i != 0 {
+		return false
+	}
+	if !m.contains(i+1, 5, "HARAC", "HARIS") && !m.contains(i+1, 3, "HOR", "HYM", "HIA", "HEM") {
+		return false
+	}
+	return !m.contains(0, 5, "CHORE")
+}
+
+func (m *metaphone) conditionCH1(i int) bool {
+	return m.contains(0, 4, "VAN ", "VON ") || m.contains(0, 3, "SCH") ||
+		m.contains(i-2, 6, "ORCHES", "ARCHIT", "ORCHID") ||
+		m.contains(i+2, 1, "T", "S") ||
+		((m.contains(i-1, 1, "A", "O", "U", "E") || i == 0) &&
+			(m.contains(i+2, 1, "L", "R", "N", "M", "B", "H", "F", "V", "W", " ") || i+1 == len(m.value)-1))
+}
+
+func (m *metaphone) conditionL0(i int) bool {
+	if i == len(m.value)-3 && m.contains(i-1, 4, "ILLO", "ILLA", "ALLE") {
+		return true
+	}
+	return (m.contains(len(m.value)-2, 2, "AS", "OS") || m.contains(len(m.value)-1, 1, "A", "O")) &&
+		m.contains(i-1, 4, "ALLE")
+}
+
+func (m *metaphone) conditionM0(i int) bool {
+	if m.at(i+1) == 'M' {
+		return true
+	}
+	return m.contains(i-1, 3, "UMB") && (i+1 == len(m.value)-1 || m.contains(i+2, 2, "ER"))
+}
@@ -0,0 +1,163 @@
+package fastentity
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Normalizer maps a word to its normal form. Normalizers are applied to each word of
+// entities and documents after they have been lowercased, so that words with the same
+// normal form match.
+type Normalizer func(word []rune) []rune
+
+// Normalize enables normalized matching for a group: each word of its entities and of the
+// documents searched is passed through the normalizers in turn, and entities match
+// wherever the normalized words are equal. The separators between words must still match
+// exactly, ignoring case.
+//
+// Normalizers are added to any already configured for the group, and applied in the
+// order they were configured. Normalized groups don't use the length of their entities to
+// skip work, so are slower to search than other groups.
+func Normalize(ns ...Normalizer) GroupOption {
+	return func(g *group) {
+		if len(ns) == 0 {
+			return
+		}
+		g.normalizers = append(g.normalizers[:len(g.normalizers):len(g.normalizers)], ns...)
+		g.reindex()
+	}
+}
+
+// FoldPlurals makes plural words match their singular forms, e.g. "tax accountants"
+// matches the entity "tax accountant" and vice versa. See Singular.
+func FoldPlurals() GroupOption {
+	return Normalize(Singular)
+}
+
+// Abbreviations makes abbreviated words match their expansions, ignoring case, e.g. with
+// the mapping "Univ." to "University", "Univ. of Houston" matches the entity "University
+// of Houston" and vice versa. A full stop following an abbreviation in the text is
+// optional, and expansions may contain several words separated by single spaces.
+//
+// Abbreviations are added to any already configured for the group, and are expanded
+// before any other normalizers are applied.
+func Abbreviations(m map[string]string) GroupOption {
+	return func(g *group) {
+		if len(m) == 0 {
+			return
+		}
+		if g.abbreviations == nil {
+			g.abbreviations = make(map[string][]rune, len(m))
+		}
+		for abbr, exp := range m {
+			abbr = strings.TrimRight(strings.ToLower(abbr), ".")
+			g.abbreviations[abbr] = []rune(strings.ToLower(exp))
+		}
+		g.reindex()
+	}
+}
+
+// Singular is a Normalizer which maps regular English plurals to their singular forms
+// using simple suffix rules, e.g. "accountants" becomes "accountant", "companies" becomes
+// "company" and "taxes" becomes "tax". Irregular plurals are left unchanged. Since it is
+// applied to entities and documents alike, words it maps incorrectly (e.g. "news" to
+// "new") still match each other.
+func Singular(word []rune) []rune {
+	n := len(word)
+	if n <= 3 || word[n-1] != 's' {
+		return word
+	}
+	switch {
+	case hasSuffix(word, "ss"), hasSuffix(word, "us"), hasSuffix(word, "is"):
+		return word
+	case hasSuffix(word, "ies") && n > 4:
+		return append(word[:n-3:n-3], 'y')
+	case hasSuffix(word, "sses"), hasSuffix(word, "xes"), hasSuffix(word, "zes"),
+		hasSuffix(word, "ches"), hasSuffix(word, "shes"):
+		return word[:n-2]
+	}
+	return word[:n-1]
+}
+
+func hasSuffix(word []rune, suffix string) bool {
+	n := len(word) - utf8.RuneCountInString(suffix)
+	if n < 0 {
+		return false
+	}
+	for _, r := range suffix {
+		if word[n] != r {
+			return false
+		}
+		n++
+	}
+	return true
+}
+
+// reindex rebuilds the normalized index of the group after its normalizers have changed.
+// The caller must hold the group lock.
+func (g *group) reindex() {
+	if len(g.normalizers) == 0 && len(g.abbreviations) == 0 && len(g.elisions) == 0 {
+		g.normalized = nil
+		return
+	}
+	g.normalized = make(map[string][]entry, len(g.entities))
+	for _, ents := range g.entities {
+		for _, e := range ents {
+			g.addNormalized(e)
+		}
+	}
+}
+
+// addNormalized adds e to the normalized index of the group. The caller must hold the
+// group lock.
+func (g *group) addNormalized(e entry) {
+	ws := words(e.text)
+	if len(ws) == 0 {
+		return
+	}
+	key := string(g.normalizedKey(nil, e.text, ws))
+	g.normalized[key] = append(g.normalized[key], e)
+}
+
+// normalizedKey appends the key for the normalized index of the text rs spanning the
+// words ws to buf. Elided words are left out of the key along with the apostrophe
+// following them.
+func (g *group) normalizedKey(buf []byte, rs []rune, ws []pair) []byte {
+	var word []rune
+	abbreviated, elided := false, false
+	for i, w := range ws {
+		if i > 0 && !elided {
+			sep := rs[ws[i-1][right]:w[left]]
+			if abbreviated && len(sep) > 1 && sep[0] == '.' {
+				sep = sep[1:]
+			}
+			for _, r := range sep {
+				buf = utf8.AppendRune(buf, unicode.ToLower(r))
+			}
+		}
+		word = word[:0]
+		for _, r := range rs[w[left]:w[right]] {
+			word = append(word, unicode.ToLower(r))
+		}
+		nw := word
+		abbreviated = false
+		elided = g.elisions[string(word)] && i+1 < len(ws) && isApostrophe(rs[w[right]:ws[i+1][left]])
+		if elided {
+			continue
+		}
+		if g.abbreviations != nil {
+			if exp, ok := g.abbreviations[string(word)]; ok {
+				nw = exp
+				abbreviated = true
+			}
+		}
+		for _, n := range g.normalizers {
+			nw = n(nw)
+		}
+		for _, r := range nw {
+			buf = utf8.AppendRune(buf, r)
+		}
+	}
+	return buf
+}
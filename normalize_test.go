@@ -0,0 +1,79 @@
+package fastentity
+
+import "testing"
+
+func TestSingular(t *testing.T) {
+	words := map[string]string{
+		"accountants": "accountant",
+		"companies":   "company",
+		"taxes":       "tax",
+		"classes":     "class",
+		"matches":     "match",
+		"class":       "class",
+		"status":      "status",
+		"analysis":    "analysis",
+		"bus":         "bus",
+		"accountant":  "accountant",
+	}
+	for word, expected := range words {
+		if got := string(Singular([]rune(word))); got != expected {
+			t.Errorf("Expected Singular(%q) = %q, got %q", word, expected, got)
+		}
+	}
+}
+
+func TestFoldPlurals(t *testing.T) {
+	str := []rune("Now seeking tax accountants and Software Companies, or a tax accountant. ")
+
+	store := New()
+	store.Add("jobTitles", []rune("tax accountant"), []rune("software company"))
+	store.Group("jobTitles").Configure(FoldPlurals())
+	store.Add("jobTitles", []rune("seekings"))
+
+	found := store.FindAll(str)["jobTitles"]
+	expected := []Entity{
+		{Text: []rune("seeking"), Offset: 4},
+		{Text: []rune("tax accountants"), Offset: 12},
+		{Text: []rune("Software Companies"), Offset: 32},
+		{Text: []rune("tax accountant"), Offset: 57},
+	}
+	if len(found) != len(expected) {
+		t.Fatalf("Expected %d entities, got %d: %v", len(expected), len(found), found)
+	}
+	for i, e := range expected {
+		if string(found[i].Text) != string(e.Text) || found[i].Offset != e.Offset {
+			t.Errorf("Expected %q at %d, got %q at %d", string(e.Text), e.Offset, string(found[i].Text), found[i].Offset)
+		}
+	}
+
+	// Separators must still match.
+	if found := store.FindAll([]rune("a tax-accountant. "))["jobTitles"]; len(found) != 0 {
+		t.Errorf("Expected no entities, got %v", found)
+	}
+}
+
+func TestAbbreviations(t *testing.T) {
+	store := New()
+	store.Add("locations", []rune("Bleeker Street"), []rune("University of Houston"), []rune("Mt Everest"))
+	store.Group("locations").Configure(
+		Abbreviations(map[string]string{"St.": "Street", "Univ.": "University", "Mt": "Mount"}),
+		FoldPlurals(),
+	)
+
+	str := []rune("Jim Smith, 1 Bleeker St. Houston, Univ. of Houston, Mount Everest and Bleeker Streets. ")
+	found := store.FindAll(str)["locations"]
+	expected := map[string]int{
+		"Bleeker St":       13,
+		"Univ. of Houston": 34,
+		"Mount Everest":    52,
+		"Bleeker Streets":  70,
+	}
+	if len(found) != len(expected) {
+		t.Fatalf("Expected %d entities, got %d: %v", len(expected), len(found), found)
+	}
+	for _, f := range found {
+		if off, ok := expected[string(f.Text)]; !ok || off != f.Offset {
+			t.Errorf("Unexpected entity %q at %d", string(f.Text), f.Offset)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package fastentity
+
+import (
+	"strconv"
+	"sync"
+)
+
+// maxNumeral is the largest number whose forms NumericVariants matches.
+const maxNumeral = 100
+
+// numeralWords is the most words in a form of a number, e.g. "one hundredth".
+const numeralWords = 2
+
+// NumericVariants makes the numbers and ordinals from 1 to 100 in the entities of the
+// group match in both numeric and written form, ignoring case, so the entity
+// "3rd Street" also matches "Third Street", and "Route Sixty-Six" matches "Route 66".
+// Written forms of compound numbers match with or without a hyphen. Variants are
+// generated as entities are added, as for Synonyms, and are reported with Kind
+// SynonymMatch and the entity as added in Canonical.
+//
+// Only one number of an entity is replaced at a time, so "1st Avenue and 2nd Street"
+// matches "First Avenue and 2nd Street" but not "First Avenue and Second Street".
+func NumericVariants() GroupOption {
+	return func(g *group) {
+		if g.numeric {
+			return
+		}
+		g.numeric = true
+		g.synonyms = make(map[string][]entry)
+		for _, ents := range g.entities {
+			for _, e := range ents {
+				g.addSynonyms(e)
+			}
+		}
+	}
+}
+
+var (
+	numeralsOnce sync.Once
+	// numerals maps each form of the numbers and ordinals to all the forms of the same.
+	numerals map[string][][]rune
+)
+
+var (
+	units = []string{"", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine",
+		"ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"}
+	unitOrdinals = []string{"", "first", "second", "third", "fourth", "fifth", "sixth", "seventh", "eighth", "ninth",
+		"tenth", "eleventh", "twelfth", "thirteenth", "fourteenth", "fifteenth", "sixteenth", "seventeenth", "eighteenth", "nineteenth"}
+	tens        = []string{"", "", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"}
+	tenOrdinals = []string{"", "", "twentieth", "thirtieth", "fortieth", "fiftieth", "sixtieth", "seventieth", "eightieth", "ninetieth"}
+)
+
+// numeralForms returns the forms of each number and ordinal from 1 to maxNumeral.
+func numeralForms() map[string][][]rune {
+	numeralsOnce.Do(func() {
+		numerals = make(map[string][][]rune)
+		add := func(forms ...string) {
+			set := make([][]rune, len(forms))
+			for i, f := range forms {
+				set[i] = []rune(f)
+			}
+			for _, f := range forms {
+				numerals[f] = set
+			}
+		}
+		for n := 1; n <= maxNumeral; n++ {
+			digits := strconv.Itoa(n)
+			ordinal := digits + ordinalSuffix(n)
+			switch {
+			case n < 20:
+				add(digits, units[n])
+				add(ordinal, unitOrdinals[n])
+			case n%10 == 0 && n < 100:
+				add(digits, tens[n/10])
+				add(ordinal, tenOrdinals[n/10])
+			case n < 100:
+				t, u := tens[n/10], n%10
+				add(digits, t+"-"+units[u], t+" "+units[u])
+				add(ordinal, t+"-"+unitOrdinals[u], t+" "+unitOrdinals[u])
+			default:
+				add(digits, "one hundred", "a hundred")
+				add(ordinal, "one hundredth", "hundredth")
+			}
+		}
+	})
+	return numerals
+}
+
+// ordinalSuffix returns the suffix of the ordinal of n in digits, e.g. "rd" for 23.
+func ordinalSuffix(n int) string {
+	if n%100 >= 11 && n%100 <= 13 {
+		return "th"
+	}
+	switch n % 10 {
+	case 1:
+		return "st"
+	case 2:
+		return "nd"
+	case 3:
+		return "rd"
+	}
+	return "th"
+}
+
+// addNumericVariants adds the forms of e with a number replaced by each of its other
+// forms to the synonym index of the group, given the lower case text of e, its words ws,
+// and the forms already added. Where forms of numbers overlap, such as "twenty" and
+// "twenty one", the longest is replaced. The caller must hold the group lock.
+func (g *group) addNumericVariants(e entry, text []rune, ws []pair, seen map[string]bool) {
+	forms := numeralForms()
+	for i := range ws {
+		var set [][]rune
+		end := 0
+		for j := i; j < len(ws) && j < i+numeralWords; j++ {
+			if s, ok := forms[string(text[ws[i][left]:ws[j][right]])]; ok {
+				set, end = s, ws[j][right]
+			}
+		}
+		for _, alt := range set {
+			form := make([]rune, 0, len(text)-(end-ws[i][left])+len(alt))
+			form = append(append(append(form, text[:ws[i][left]]...), alt...), text[end:]...)
+			key := string(form)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			g.synonyms[key] = append(g.synonyms[key], e)
+			if n := wordCount(form); n > g.maxWords {
+				g.maxWords = n
+			}
+		}
+	}
+}
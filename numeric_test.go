@@ -0,0 +1,40 @@
+package fastentity
+
+import "testing"
+
+func TestNumericVariants(t *testing.T) {
+	store := New()
+	store.Add("titles", []rune("Ocean's 11"))
+	store.Group("titles").Configure(NumericVariants())
+	store.Add("titles", []rune("3rd Street"), []rune("Route Sixty-Six"), []rune("21st Century"), []rune("The Hundredth Monkey"))
+
+	doc := []rune("Third Street, route 66, Ocean's Eleven, the twenty first century, Twenty-First Century, the 100th Monkey and 3 Street")
+	var got []string
+	for _, e := range store.FindAll(doc)["titles"] {
+		if e.Kind != SynonymMatch {
+			t.Errorf("Expected a synonym match, got %v", e.Kind)
+		}
+		got = append(got, string(e.Text)+"="+string(e.Canonical))
+	}
+	want := []string{"Third Street=3rd Street", "route 66=Route Sixty-Six", "Ocean's Eleven=Ocean's 11", "twenty first century=21st Century", "Twenty-First Century=21st Century", "the 100th Monkey=The Hundredth Monkey"}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %q, got %q", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected %q, got %q", want[i], got[i])
+		}
+	}
+
+	store.Remove("titles", []rune("3rd Street"))
+	if r := store.FindAll([]rune("Third Street")); len(r["titles"]) != 0 {
+		t.Errorf("Expected the variants of removed entities to be removed, got %v", r["titles"])
+	}
+
+	forms := numeralForms()
+	for form, want := range map[string]string{"11th": "eleventh", "12th": "twelfth", "13th": "thirteenth", "22nd": "twenty-second", "43rd": "forty-third", "90": "ninety", "100": "one hundred"} {
+		if set := forms[form]; len(set) < 2 || string(set[1]) != want {
+			t.Errorf("Expected %s to be %s, got %q", form, want, set)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package fastentity
+
+import (
+	"sort"
+	"unicode"
+	"unicode/utf8"
+)
+
+// OffsetIndex converts the rune offsets of a document, such as Entity.Offset, to and from
+// byte offsets in its UTF-8 encoding, offsets in UTF-16 code units, and line and column
+// positions.
+//
+// Rune offsets count Unicode code points, so characters outside the Basic Multilingual
+// Plane, such as most emoji, count as one rune but two UTF-16 code units, a surrogate
+// pair. Offsets used to index strings in JavaScript, Java and C# are in UTF-16 code units,
+// and must be converted with UTF16.
+type OffsetIndex struct {
+	bytes []int // byte offset of each rune, and of the end of the document
+	units []int // UTF-16 offset of each rune, and of the end of the document
+	lines []int // rune offset of the start of each line
+}
+
+// Position is a line and column in a document, both starting at 1. Columns count runes.
+type Position struct {
+	Line, Column int
+}
+
+// NewOffsetIndex indexes the offsets of rs. Lines are separated by '\n'.
+func NewOffsetIndex(rs []rune) *OffsetIndex {
+	x := &OffsetIndex{
+		bytes: make([]int, len(rs)+1),
+		units: make([]int, len(rs)+1),
+		lines: []int{0},
+	}
+	n, u := 0, 0
+	for i, r := range rs {
+		x.bytes[i], x.units[i] = n, u
+		size := utf8.RuneLen(r)
+		if size < 0 {
+			size = utf8.RuneLen(utf8.RuneError) // As encoded by string(rs)
+		}
+		n += size
+		u += utf16Len(r)
+		if r == '\n' {
+			x.lines = append(x.lines, i+1)
+		}
+	}
+	x.bytes[len(rs)], x.units[len(rs)] = n, u
+	return x
+}
+
+// utf16Len returns the number of UTF-16 code units encoding r. Invalid runes are encoded
+// as U+FFFD, in one.
+func utf16Len(r rune) int {
+	if r >= 0x10000 && r <= unicode.MaxRune {
+		return 2 // A surrogate pair
+	}
+	return 1
+}
+
+// Byte returns the byte offset of the rune at offset off, which may be the length of the
+// document.
+func (x *OffsetIndex) Byte(off int) int {
+	return x.bytes[off]
+}
+
+// Rune returns the rune offset of the rune containing the byte at offset b, which may be
+// the length of the document in bytes.
+func (x *OffsetIndex) Rune(b int) int {
+	return sort.Search(len(x.bytes), func(i int) bool { return x.bytes[i] > b }) - 1
+}
+
+// UTF16 returns the offset in UTF-16 code units of the rune at offset off, which may be
+// the length of the document. The text of an entity e is at UTF16(e.Offset) up to
+// UTF16(e.Offset+len(e.Text)), e.g. for text.slice(start, end) in JavaScript.
+func (x *OffsetIndex) UTF16(off int) int {
+	return x.units[off]
+}
+
+// RuneFromUTF16 returns the rune offset of the rune containing the UTF-16 code unit at
+// offset u, which may be the length of the document in UTF-16.
+func (x *OffsetIndex) RuneFromUTF16(u int) int {
+	return sort.Search(len(x.units), func(i int) bool { return x.units[i] > u }) - 1
+}
+
+// Position returns the line and column of the rune at offset off.
+func (x *OffsetIndex) Position(off int) Position {
+	line := sort.Search(len(x.lines), func(i int) bool { return x.lines[i] > off }) - 1
+	return Position{Line: line + 1, Column: off - x.lines[line] + 1}
+}
+
+// Offset returns the rune offset of the position p, or -1 if the document has no such
+// line. Columns past the end of a line are not checked.
+func (x *OffsetIndex) Offset(p Position) int {
+	if p.Line < 1 || p.Line > len(x.lines) {
+		return -1
+	}
+	return x.lines[p.Line-1] + p.Column - 1
+}
@@ -0,0 +1,98 @@
+package fastentity
+
+import (
+	"reflect"
+	"testing"
+	"unicode/utf16"
+)
+
+func TestOffsetIndex(t *testing.T) {
+	str := "日 本語.\nSan Francisco, USA\n\nPHP"
+	rs := []rune(str)
+	x := NewOffsetIndex(rs)
+
+	tests := []struct {
+		off  int
+		b    int
+		line int
+		col  int
+	}{
+		{0, 0, 1, 1},
+		{2, 4, 1, 3},
+		{5, 11, 1, 6},
+		{6, 12, 2, 1},
+		{10, 16, 2, 5},
+		{25, 31, 3, 1},
+		{26, 32, 4, 1},
+		{29, 35, 4, 4},
+	}
+	for _, tt := range tests {
+		if b := x.Byte(tt.off); b != tt.b {
+			t.Errorf("Byte(%d): expected %d, got %d", tt.off, tt.b, b)
+		}
+		if off := x.Rune(tt.b); off != tt.off {
+			t.Errorf("Rune(%d): expected %d, got %d", tt.b, tt.off, off)
+		}
+		p := x.Position(tt.off)
+		if p.Line != tt.line || p.Column != tt.col {
+			t.Errorf("Position(%d): expected %d:%d, got %d:%d", tt.off, tt.line, tt.col, p.Line, p.Column)
+		}
+		if off := x.Offset(p); off != tt.off {
+			t.Errorf("Offset(%v): expected %d, got %d", p, tt.off, off)
+		}
+	}
+	if off := x.Rune(5); off != 2 {
+		t.Errorf("Expected byte 5 to be within rune 2, got %d", off)
+	}
+	if x.Byte(len(rs)) != len(str) {
+		t.Errorf("Expected end of document at byte %d, got %d", len(str), x.Byte(len(rs)))
+	}
+	if off := x.Offset(Position{Line: 5, Column: 1}); off != -1 {
+		t.Errorf("Expected -1 for a missing line, got %d", off)
+	}
+}
+
+func TestOffsetIndexUTF16(t *testing.T) {
+	// Emoji, a ZWJ sequence, a flag, a musical symbol and a CJK Extension B ideograph are
+	// outside the Basic Multilingual Plane, encoded as surrogate pairs in UTF-16
+	str := "👩‍💻 Go in 𝄞 Sydney 🇦🇺, 𠮷野家 and Zürich 😀"
+	store := New()
+	store.Add("skills", []rune("Go"))
+	store.Add("locations", []rune("Sydney"), []rune("Zürich"), []rune("𠮷野家"))
+
+	rs := []rune(str)
+	x := NewOffsetIndex(rs)
+	units := utf16.Encode(rs)
+	if n := x.UTF16(len(rs)); n != len(units) {
+		t.Fatalf("Expected end of document at UTF-16 offset %d, got %d", len(units), n)
+	}
+
+	r := store.FindAll(rs)
+	want := map[string]int{"Go": 6, "Sydney": 15, "𠮷野家": 28, "Zürich": 37}
+	got := make(map[string]int)
+	for _, m := range r.Matches() {
+		// Offsets count runes, so the text of each entity is at its offset in rs
+		if s := string(rs[m.Offset : m.Offset+len(m.Text)]); s != string(m.Text) {
+			t.Errorf("Expected %q at rune offset %d, got %q", string(m.Text), m.Offset, s)
+		}
+		start, end := x.UTF16(m.Offset), x.UTF16(m.Offset+len(m.Text))
+		if s := string(utf16.Decode(units[start:end])); s != string(m.Text) {
+			t.Errorf("Expected %q at UTF-16 offsets %d-%d, got %q", string(m.Text), start, end, s)
+		}
+		if off := x.RuneFromUTF16(start); off != m.Offset {
+			t.Errorf("RuneFromUTF16(%d): expected %d, got %d", start, m.Offset, off)
+		}
+		got[string(m.Text)] = start
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected UTF-16 offsets %v, got %v", want, got)
+	}
+
+	// The second unit of a surrogate pair is within the rune of the pair
+	if off := x.RuneFromUTF16(1); off != 0 {
+		t.Errorf("Expected UTF-16 offset 1 to be within rune 0, got %d", off)
+	}
+	if off := x.RuneFromUTF16(len(units)); off != len(rs) {
+		t.Errorf("Expected the end of the document at rune %d, got %d", len(rs), off)
+	}
+}
@@ -0,0 +1,98 @@
+package fastentity
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// entrySlotSize is the size of an entry in the buckets of an index.
+const entrySlotSize = int64(unsafe.Sizeof(entry{}))
+
+// Optimize rebuilds the indices of every group with buckets of exactly the capacity they
+// need, dropping empty buckets and recomputing the length and number of words of the
+// longest entities, which can otherwise be left over-allocated or stale as entities are
+// added and removed and groups rebuilt. It returns the estimated number of bytes reclaimed.
+//
+// Groups are locked while they are rebuilt, so searches of each group wait for it. Lazy
+// groups which haven't been loaded are skipped.
+func (s *Store) Optimize() int64 {
+	s.RLock()
+	groups := make([]*group, 0, len(s.groups))
+	for _, g := range s.groups {
+		groups = append(groups, g)
+	}
+	s.RUnlock()
+
+	var reclaimed int64
+	for _, g := range groups {
+		reclaimed += g.optimize()
+	}
+	return reclaimed
+}
+
+// optimize rebuilds the indices of the group compactly, returning the estimated number of
+// bytes reclaimed.
+func (g *group) optimize() int64 {
+	g.Lock()
+	defer g.Unlock()
+	if atomic.LoadUint32(&g.lazy) == 1 {
+		return 0
+	}
+	return g.compact()
+}
+
+// compact rebuilds the indices of the group compactly, as optimize does. The caller must
+// hold the group lock.
+func (g *group) compact() int64 {
+	var reclaimed, n int64
+	g.entities, n = compactIndex(g.entities)
+	reclaimed += n
+	g.normalized, n = compactIndex(g.normalized)
+	reclaimed += n
+	g.acronyms, n = compactIndex(g.acronyms)
+	reclaimed += n
+	g.phonetic, n = compactIndex(g.phonetic)
+	reclaimed += n
+	g.synonyms, n = compactIndex(g.synonyms)
+	reclaimed += n
+
+	g.maxLen, g.maxWords = 0, 0
+	for _, ents := range g.entities {
+		for _, e := range ents {
+			if len(e.text) > g.maxLen {
+				g.maxLen = len(e.text)
+			}
+			if n := wordCount(e.text); n > g.maxWords {
+				g.maxWords = n
+			}
+		}
+	}
+	for form := range g.synonyms {
+		if n := wordCount([]rune(form)); n > g.maxWords {
+			g.maxWords = n
+		}
+	}
+	if g.provider != nil && g.provider.opts.MaxWords > g.maxWords {
+		g.maxWords = g.provider.opts.MaxWords
+	}
+	return reclaimed
+}
+
+// compactIndex returns a copy of the index m without empty buckets, with each bucket at
+// its length, and the estimated number of bytes reclaimed.
+func compactIndex(m map[string][]entry) (map[string][]entry, int64) {
+	if m == nil {
+		return nil, 0
+	}
+	c := make(map[string][]entry, len(m))
+	var reclaimed int64
+	for key, ents := range m {
+		reclaimed += int64(cap(ents)-len(ents)) * entrySlotSize
+		if len(ents) == 0 {
+			reclaimed += int64(len(key))
+			continue
+		}
+		c[key] = append(make([]entry, 0, len(ents)), ents...)
+	}
+	return c, reclaimed
+}
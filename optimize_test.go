@@ -0,0 +1,66 @@
+package fastentity
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestOptimize(t *testing.T) {
+	store := New()
+	for i := 0; i < 100; i++ {
+		store.Add("skills", []rune(fmt.Sprintf("go %d", i)))
+	}
+	store.Add("skills", []rune("machine learning engineer"))
+	store.Group("skills").Configure(Acronyms())
+
+	g := store.groups["skills"]
+	g.maxLen, g.maxWords = 100, 10 // stale
+	if reclaimed := store.Optimize(); reclaimed <= 0 {
+		t.Errorf("Expected bytes to be reclaimed, got %d", reclaimed)
+	}
+	if g.maxLen != len("machine learning engineer") || g.maxWords != 3 {
+		t.Errorf("Expected maxLen 25 and maxWords 3, got %d and %d", g.maxLen, g.maxWords)
+	}
+	for key, ents := range g.entities {
+		if cap(ents) != len(ents) {
+			t.Errorf("Expected bucket %q to be compact, got length %d and capacity %d", key, len(ents), cap(ents))
+		}
+	}
+	if reclaimed := store.Optimize(); reclaimed != 0 {
+		t.Errorf("Expected nothing more to reclaim, got %d", reclaimed)
+	}
+
+	found := store.FindAll([]rune("A machine learning engineer (MLE) who knows go 42"))["skills"]
+	if len(found) != 3 {
+		t.Errorf("Expected 3 matches, got %v", found)
+	}
+}
+
+func TestOptimizeAfterRemove(t *testing.T) {
+	store := New()
+	var removed [][]rune
+	for i := 0; i < 100; i++ {
+		e := []rune(fmt.Sprintf("go %d", i))
+		store.Add("skills", e)
+		if i > 0 {
+			removed = append(removed, e)
+		}
+	}
+	store.Add("skills", []rune("machine learning engineer"))
+	store.Group("skills").Configure(Acronyms())
+	store.Remove("skills", append(removed, []rune("Machine Learning Engineer"))...)
+
+	// Remove rebuilds the indices, so Optimize has only the buckets of the entity left
+	g := store.groups["skills"]
+	store.Optimize()
+	if g.maxLen != len("go 0") || g.maxWords != 2 {
+		t.Errorf("Expected maxLen 4 and maxWords 2, got %d and %d", g.maxLen, g.maxWords)
+	}
+	if len(g.entities) != 1 || len(g.acronyms) != 1 {
+		t.Errorf("Expected only the buckets of the entity left, got %v and %v", g.entities, g.acronyms)
+	}
+	found := store.FindAll([]rune("go 0, go 1, machine learning engineer (MLE)"))["skills"]
+	if len(found) != 1 {
+		t.Errorf("Expected only go 0, got %v", found)
+	}
+}
@@ -0,0 +1,77 @@
+package fastentity
+
+// FindOption configures a single search by FindAll and its variants, unlike the result
+// filters of the store which apply to every search.
+type FindOption func(c *findConfig)
+
+type findConfig struct {
+	nonOverlapping bool
+
+	// doc is the ID of the document searched, see DocumentID.
+	doc string
+
+	// capacity is the number of matches expected in each group, see ExpectMatches.
+	capacity map[string]int
+	// scratch holds the buffers to search with, see WithScratch.
+	scratch *Scratch
+}
+
+// newFindConfig returns the configuration of a search with the options opts.
+func newFindConfig(opts []FindOption) *findConfig {
+	var c findConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+	return &c
+}
+
+// NonOverlapping reports a single layer of matches, for highlighting: of overlapping
+// matches, in any group, only the one starting first is kept, and of those starting at
+// the same offset the longest. Matches of the same text in several groups are resolved to
+// the group first by name. By default every match is reported, overlapping or not, which
+// suits indexing. It applies after the result filters of the store.
+func NonOverlapping() FindOption {
+	return func(c *findConfig) {
+		c.nonOverlapping = true
+	}
+}
+
+// applyFindOptions applies the options of c to r.
+func applyFindOptions(r Results, c *findConfig) Results {
+	if c.doc != "" {
+		setDocumentID(r, c.doc)
+	}
+	if !c.nonOverlapping {
+		return r
+	}
+
+	ms := dropOverlapping(r.Matches())
+	for name := range r {
+		r[name] = nil
+	}
+	for _, m := range ms {
+		r[m.Group] = append(r[m.Group], m.Entity)
+	}
+	return r
+}
+
+// dropOverlapping keeps the leftmost longest of the matches ms, in document order, which
+// don't overlap.
+func dropOverlapping(ms []Match) []Match {
+	kept := ms[:0]
+	end := 0 // end of the last match kept
+	for i := 0; i < len(ms); {
+		j, longest := i, i
+		for ; j < len(ms) && ms[j].Offset == ms[i].Offset; j++ {
+			if len(ms[j].Text) > len(ms[longest].Text) {
+				longest = j
+			}
+		}
+		if m := ms[longest]; m.Offset >= end {
+			kept = append(kept, m)
+			end = m.Offset + len(m.Text)
+		}
+		i = j
+	}
+	return kept
+}
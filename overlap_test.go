@@ -0,0 +1,26 @@
+package fastentity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestNonOverlapping(t *testing.T) {
+	store := New()
+	store.Add("locations", []rune("New York"), []rune("York City"), []rune("York"), []rune("Sydney"))
+	store.Add("teams", []rune("New York"), []rune("Sydney"))
+	doc := []rune("From New York City to Sydney")
+
+	if n := len(store.FindAll(doc).Matches()); n != 6 {
+		t.Errorf("Expected 6 overlapping matches, got %d", n)
+	}
+
+	var got []string
+	for _, m := range store.FindAll(doc, NonOverlapping()).Matches() {
+		got = append(got, m.String())
+	}
+	want := []string{"locations:5:8:New York", "locations:22:6:Sydney"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
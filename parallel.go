@@ -0,0 +1,144 @@
+package fastentity
+
+import (
+	"context"
+	"runtime"
+	"sort"
+	"sync"
+)
+
+// FindAllParallel is like FindAll, but splits the document into shards which are searched
+// concurrently, reducing the time taken to search a single large document. Each shard is
+// searched along with the next MaxEntityLen runes so that entities spanning shards are
+// found, and matches are reported by the shard they start in, so the results are the same
+// as those of FindAll. If shards is less than 1, runtime.GOMAXPROCS(0) shards are used.
+//
+// Splitting a document costs more than it saves for short documents, which are searched
+// as a single shard.
+func (s *Store) FindAllParallel(rs []rune, shards int) Results {
+	r, _ := s.FindAllParallelContext(context.Background(), rs, shards)
+	return r
+}
+
+// FindAllParallelContext is like FindAllParallel, but stops searching when ctx is
+// cancelled, returning ctx.Err().
+func (s *Store) FindAllParallelContext(ctx context.Context, rs []rune, shards int) (Results, error) {
+	if shards < 1 {
+		shards = runtime.GOMAXPROCS(0)
+	}
+	d := s.preprocess(rs)
+	groups := s.rlockGroups()
+	result, err := findParallel(ctx, d, groups, d.tok.splitShards(d.text, shards))
+	runlockGroups(groups)
+	if err != nil {
+		return nil, err
+	}
+	return s.filter(result), nil
+}
+
+// minShardLen is the shortest shard a document is split into by FindAllParallel.
+const minShardLen = 4 << 10
+
+// splitShards returns the offsets at which each of up to n shards of rs starts, each at
+// the start of a word, followed by the length of rs.
+func (t Tokenizer) splitShards(rs []rune, n int) []int {
+	if limit := len(rs) / minShardLen; n > limit {
+		n = limit
+	}
+	starts := []int{0}
+	for i := 1; i < n; i++ {
+		start := t.firstWord(rs, i*len(rs)/n, len(rs))
+		if start > starts[len(starts)-1] && start < len(rs) {
+			starts = append(starts, start)
+		}
+	}
+	return append(starts, len(rs))
+}
+
+// shardMatch is a match found in a shard, before the results are merged.
+type shardMatch struct {
+	group int
+	ent   *entry
+	e     Entity
+}
+
+// findParallel searches the preprocessed document in the groups, which must be locked,
+// searching the shards starting at each offset in starts concurrently. It returns the
+// entities found in each group before any result filters are applied.
+func findParallel(ctx context.Context, d *document, groups []*group, starts []int) (Results, error) {
+	if len(starts) <= 2 {
+		return findDocument(ctx, d, groups, nil)
+	}
+
+	rs := d.text
+	shards := make([][]shardMatch, len(starts)-1)
+	errs := make([]error, len(shards))
+	index := make(map[*group]int, len(groups))
+	for j, g := range groups {
+		index[g] = j
+	}
+	var wg sync.WaitGroup
+	for i := range shards {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			start, end := starts[i], starts[i+1]
+			// Search up to the end of the last word an entity starting in the shard could
+			// reach, keeping only the matches which start in it
+			padded := end + MaxEntityLen
+			if padded > len(rs) {
+				padded = len(rs)
+			}
+			for padded < len(rs) && !d.tok.boundary(rs, padded) {
+				padded++
+			}
+			errs[i] = search(ctx, rs[start:padded], d.tok, groups, make([]uint64, len(groups)), nil, func(g *group, ent *entry, e Entity) bool {
+				if e.Offset >= end-start {
+					return true
+				}
+				e.Offset += start
+				shards[i] = append(shards[i], shardMatch{group: index[g], ent: ent, e: e})
+				return true
+			})
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Order the matches of each group as a single search would, by the end of the match
+	// and then from the shortest to the longest. Matches ending in the same place may come
+	// from different shards, but those starting in the same place are from one shard and
+	// already in order.
+	found := make([][]shardMatch, len(groups))
+	for _, ms := range shards {
+		for _, m := range ms {
+			found[m.group] = append(found[m.group], m)
+		}
+	}
+	result := make(Results, len(groups))
+	limits := make(matchLimiter)
+	for j, g := range groups {
+		ms := found[j]
+		sort.SliceStable(ms, func(a, b int) bool {
+			ea, eb := ms[a].e.Offset+len(ms[a].e.Text), ms[b].e.Offset+len(ms[b].e.Text)
+			if ea != eb {
+				return ea < eb
+			}
+			return ms[a].e.Offset > ms[b].e.Offset
+		})
+		result[g.name] = nil
+		for _, m := range ms {
+			if !limits.allow(g, m.ent) {
+				continue
+			}
+			g.count(m.ent)
+			result[g.name] = append(result[g.name], d.original(m.e))
+		}
+		g.stats.record(uint64(len(ms)))
+	}
+	return suppressDenied(result, groups), nil
+}
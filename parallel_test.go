@@ -0,0 +1,48 @@
+package fastentity
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFindAllParallel(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"), []rune("golang"), []rune("machine learning"))
+	store.Add("locations", []rune("Sydney"), []rune("São Paulo"), []rune("New York City"), []rune("York"))
+	store.Group("titles").Configure(FoldPlurals())
+	store.Add("titles", []rune("golang developer"))
+
+	words := []string{"PHP", "golang", "developers", "machine", "learning", "Sydney", "São", "Paulo",
+		"New", "York", "City", "and", "in", "the", strings.Repeat("x", 2*MaxEntityLen)}
+	seps := []string{" ", ", ", ". ", "\n"}
+	rng := rand.New(rand.NewSource(1))
+	var b strings.Builder
+	for i := 0; i < 20000; i++ {
+		b.WriteString(words[rng.Intn(len(words))])
+		b.WriteString(seps[rng.Intn(len(seps))])
+	}
+	doc := []rune(b.String())
+
+	if n := len(Tokenizer{}.splitShards(doc, 8)) - 1; n != 8 {
+		t.Errorf("Expected 8 shards, got %d", n)
+	}
+	expected := store.FindAll(doc)
+	for _, shards := range []int{0, 1, 3, 8} {
+		if got := store.FindAllParallel(doc, shards); !reflect.DeepEqual(got, expected) {
+			t.Errorf("%d shards: expected the same results as FindAll", shards)
+		}
+	}
+	if s := store.Stats()["skills"]; s.Documents != 5 || s.Matched != 5 {
+		t.Errorf("Expected each search to be counted once, got %+v", s)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := store.FindAllParallelContext(ctx, doc, 8); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,316 @@
+//go:build go1.18
+
+// Package parquet writes the matches found by a fastentity Store to Apache Parquet files,
+// so that the results of large tagging jobs can be landed directly in a data lake for
+// analytics.
+//
+// Each match is a row of the columns doc, group, text, start, end and entity_id, all
+// required: the ID of the document, the group the entity was found in, the text matched,
+// the offsets in runes of the start and end of the match, and the ID of the entity. Files
+// are written uncompressed, with plain encoding, which any Parquet reader can read.
+package parquet
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"unicode/utf8"
+
+	"github.com/sajari/fastentity"
+)
+
+// DefaultRowGroupSize is the number of rows a Writer buffers and writes at a time, unless
+// its RowGroupSize is set.
+const DefaultRowGroupSize = 65536
+
+const magic = "PAR1"
+
+// Parquet physical types and other values of its metadata used for the columns.
+const (
+	typeInt64     = 2
+	typeByteArray = 6
+
+	repetitionRequired = 0
+	convertedUTF8      = 0
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecUncompressed  = 0
+	pageData           = 0
+)
+
+// ErrClosed is returned for matches written to a Writer which has been closed.
+var ErrClosed = errors.New("writer closed")
+
+// column is a column of the file, and the values buffered for the current row group.
+type column struct {
+	name    string
+	typ     int32
+	strings []string
+	ints    []int64
+}
+
+// chunk is the metadata of a column chunk written in a row group.
+type chunk struct {
+	offset, size, values int64
+}
+
+// rowGroup is the metadata of a row group written.
+type rowGroup struct {
+	chunks []chunk
+	size   int64
+	rows   int64
+}
+
+// Writer writes matches to a Parquet file, buffering RowGroupSize rows at a time. Close
+// must be called to write the footer of the file. A Writer isn't safe for concurrent use.
+type Writer struct {
+	// EntityID returns the ID of the entity matched, for the entity_id column, which is
+	// empty if it's nil. See StoreIDs.
+	EntityID func(m fastentity.Match) string
+	// RowGroupSize is the number of rows in each row group, by default
+	// DefaultRowGroupSize.
+	RowGroupSize int
+
+	w       io.Writer
+	offset  int64
+	columns []*column
+	rows    int
+	groups  []rowGroup
+	closed  bool
+	err     error
+}
+
+// NewWriter returns a Writer writing a Parquet file to w.
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{
+		w: w,
+		columns: []*column{
+			{name: "doc", typ: typeByteArray},
+			{name: "group", typ: typeByteArray},
+			{name: "text", typ: typeByteArray},
+			{name: "start", typ: typeInt64},
+			{name: "end", typ: typeInt64},
+			{name: "entity_id", typ: typeByteArray},
+		},
+	}
+}
+
+// StoreIDs returns a function for Writer.EntityID which looks up the IDs of the entities
+// of s, from the EntityInfo attached to them when they were loaded from TSV or JSON Lines
+// entity files, or from Wikidata.
+func StoreIDs(s *fastentity.Store) func(m fastentity.Match) string {
+	return func(m fastentity.Match) string {
+		info, _ := fastentity.NewTypedGroup[fastentity.EntityInfo](s, m.Group).Value(m.Canonical)
+		return info.ID
+	}
+}
+
+// Write writes the matches of the results r of the document doc, in document order.
+func (w *Writer) Write(doc string, r fastentity.Results) error {
+	for _, m := range r.Matches() {
+		if err := w.WriteMatch(doc, m); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteMatch writes the match m of the document doc.
+func (w *Writer) WriteMatch(doc string, m fastentity.Match) error {
+	if w.closed {
+		return ErrClosed
+	}
+	if w.err != nil {
+		return w.err
+	}
+	var id string
+	if w.EntityID != nil {
+		id = w.EntityID(m)
+	}
+	c := w.columns
+	c[0].strings = append(c[0].strings, doc)
+	c[1].strings = append(c[1].strings, m.Group)
+	c[2].strings = append(c[2].strings, string(m.Text))
+	c[3].ints = append(c[3].ints, int64(m.Offset))
+	c[4].ints = append(c[4].ints, int64(m.Offset+len(m.Text)))
+	c[5].strings = append(c[5].strings, id)
+	w.rows++
+
+	size := w.RowGroupSize
+	if size <= 0 {
+		size = DefaultRowGroupSize
+	}
+	if w.rows >= size {
+		return w.flush()
+	}
+	return nil
+}
+
+// Close writes the rows buffered and the footer of the file. It doesn't close the
+// underlying io.Writer.
+func (w *Writer) Close() error {
+	if w.closed {
+		return w.err
+	}
+	if err := w.flush(); err != nil {
+		return err
+	}
+	w.closed = true
+	if err := w.start(); err != nil {
+		return err
+	}
+	footer := w.footer()
+	footer = appendUint32(footer, uint32(len(footer)))
+	w.write(append(footer, magic...))
+	return w.err
+}
+
+// start writes the magic number at the start of the file, if it hasn't been written.
+func (w *Writer) start() error {
+	if w.offset == 0 {
+		w.write([]byte(magic))
+	}
+	return w.err
+}
+
+// write writes b to the file, keeping the first error.
+func (w *Writer) write(b []byte) {
+	if w.err != nil {
+		return
+	}
+	n, err := w.w.Write(b)
+	w.offset += int64(n)
+	w.err = err
+}
+
+// flush writes the rows buffered as a row group, with a column chunk of a single data
+// page for each column.
+func (w *Writer) flush() error {
+	if w.rows == 0 || w.err != nil {
+		return w.err
+	}
+	if err := w.start(); err != nil {
+		return err
+	}
+	rg := rowGroup{rows: int64(w.rows)}
+	for _, c := range w.columns {
+		data := c.plain()
+		var t thriftWriter
+		t.begin()
+		t.i32(1, pageData)
+		t.i32(2, int32(len(data)))
+		t.i32(3, int32(len(data)))
+		t.structField(5)
+		t.i32(1, int32(w.rows))
+		t.i32(2, encodingPlain)
+		t.i32(3, encodingRLE) // Of levels, which required columns don't have
+		t.i32(4, encodingRLE)
+		t.end()
+		t.end()
+
+		ch := chunk{offset: w.offset, size: int64(len(t.buf) + len(data)), values: int64(w.rows)}
+		w.write(t.buf)
+		w.write(data)
+		rg.chunks = append(rg.chunks, ch)
+		rg.size += ch.size
+		c.strings, c.ints = c.strings[:0], c.ints[:0]
+	}
+	w.groups = append(w.groups, rg)
+	w.rows = 0
+	return w.err
+}
+
+// plain returns the values buffered in the column in the plain encoding.
+func (c *column) plain() []byte {
+	if c.typ == typeInt64 {
+		b := make([]byte, 0, 8*len(c.ints))
+		for _, v := range c.ints {
+			b = appendUint64(b, uint64(v))
+		}
+		return b
+	}
+	n := 0
+	for _, s := range c.strings {
+		n += 4 + len(s)
+	}
+	b := make([]byte, 0, n)
+	for _, s := range c.strings {
+		if !utf8.ValidString(s) {
+			s = string([]rune(s)) // Replace invalid UTF-8, which the column must be
+		}
+		b = appendUint32(b, uint32(len(s)))
+		b = append(b, s...)
+	}
+	return b
+}
+
+// footer returns the FileMetaData of the file.
+func (w *Writer) footer() []byte {
+	var rows int64
+	for _, rg := range w.groups {
+		rows += rg.rows
+	}
+
+	var t thriftWriter
+	t.begin()
+	t.i32(1, 1) // Version
+	t.list(2, typeStruct, len(w.columns)+1)
+	t.begin()
+	t.string(4, "schema")
+	t.i32(5, int32(len(w.columns)))
+	t.end()
+	for _, c := range w.columns {
+		t.begin()
+		t.i32(1, c.typ)
+		t.i32(3, repetitionRequired)
+		t.string(4, c.name)
+		if c.typ == typeByteArray {
+			t.i32(6, convertedUTF8)
+		}
+		t.end()
+	}
+	t.i64(3, rows)
+	t.list(4, typeStruct, len(w.groups))
+	for _, rg := range w.groups {
+		t.begin()
+		t.list(1, typeStruct, len(rg.chunks))
+		for i, ch := range rg.chunks {
+			c := w.columns[i]
+			t.begin()
+			t.i64(2, ch.offset)
+			t.structField(3)
+			t.i32(1, c.typ)
+			t.list(2, typeI32, 1)
+			t.listI32(encodingPlain)
+			t.list(3, typeBinary, 1)
+			t.listString(c.name)
+			t.i32(4, codecUncompressed)
+			t.i64(5, ch.values)
+			t.i64(6, ch.size)
+			t.i64(7, ch.size)
+			t.i64(9, ch.offset)
+			t.end()
+			t.end()
+		}
+		t.i64(2, rg.size)
+		t.i64(3, rg.rows)
+		t.end()
+	}
+	t.string(6, "fastentity")
+	t.end()
+	return t.buf
+}
+
+// appendUint32 appends v to b in little endian order.
+func appendUint32(b []byte, v uint32) []byte {
+	var buf [4]byte
+	binary.LittleEndian.PutUint32(buf[:], v)
+	return append(b, buf[:]...)
+}
+
+// appendUint64 appends v to b in little endian order.
+func appendUint64(b []byte, v uint64) []byte {
+	var buf [8]byte
+	binary.LittleEndian.PutUint64(buf[:], v)
+	return append(b, buf[:]...)
+}
@@ -0,0 +1,198 @@
+//go:build go1.18
+
+package parquet
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/sajari/fastentity"
+)
+
+// thriftReader decodes structs in the Thrift compact protocol into maps by field ID.
+type thriftReader struct {
+	b   []byte
+	err error
+}
+
+func (t *thriftReader) varint() int64 {
+	v, n := binary.Varint(t.b)
+	if n <= 0 {
+		t.err = fmt.Errorf("bad varint")
+		return 0
+	}
+	t.b = t.b[n:]
+	return v
+}
+
+func (t *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(t.b)
+	if n <= 0 {
+		t.err = fmt.Errorf("bad uvarint")
+		return 0
+	}
+	t.b = t.b[n:]
+	return v
+}
+
+func (t *thriftReader) value(typ byte) interface{} {
+	switch typ {
+	case 1, 2:
+		return typ == 1
+	case typeI32, typeI64:
+		return t.varint()
+	case typeBinary:
+		n := int(t.uvarint())
+		if t.err != nil || n > len(t.b) {
+			t.err = fmt.Errorf("bad binary")
+			return nil
+		}
+		s := string(t.b[:n])
+		t.b = t.b[n:]
+		return s
+	case typeList:
+		h := t.b[0]
+		t.b = t.b[1:]
+		n, elem := int(h>>4), h&0xf
+		if n == 15 {
+			n = int(t.uvarint())
+		}
+		l := make([]interface{}, n)
+		for i := range l {
+			l[i] = t.value(elem)
+		}
+		return l
+	case typeStruct:
+		s := make(map[int16]interface{})
+		var last int16
+		for t.err == nil && len(t.b) > 0 {
+			h := t.b[0]
+			t.b = t.b[1:]
+			if h == 0 {
+				return s
+			}
+			id := last + int16(h>>4)
+			if h>>4 == 0 {
+				id = int16(t.varint())
+			}
+			s[id], last = t.value(h&0xf), id
+		}
+		t.err = fmt.Errorf("unterminated struct")
+	}
+	t.err = fmt.Errorf("unknown type %d", typ)
+	return nil
+}
+
+func (t *thriftReader) readStruct() map[int16]interface{} {
+	s, _ := t.value(typeStruct).(map[int16]interface{})
+	return s
+}
+
+// readFile reads the rows of a file written by Writer, checking its structure.
+func readFile(t *testing.T, b []byte) ([][]interface{}, map[int16]interface{}) {
+	t.Helper()
+	if !bytes.HasPrefix(b, []byte(magic)) || !bytes.HasSuffix(b, []byte(magic)) {
+		t.Fatalf("Expected the magic number at both ends")
+	}
+	n := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	tr := &thriftReader{b: b[len(b)-8-n : len(b)-8]}
+	meta := tr.readStruct()
+	if tr.err != nil || len(tr.b) != 0 {
+		t.Fatalf("Failed to read the footer: %v", tr.err)
+	}
+
+	var rows [][]interface{}
+	for _, rg := range meta[4].([]interface{}) {
+		rg := rg.(map[int16]interface{})
+		start := len(rows)
+		for i, cc := range rg[1].([]interface{}) {
+			md := cc.(map[int16]interface{})[3].(map[int16]interface{})
+			pr := &thriftReader{b: b[md[9].(int64):]}
+			ph := pr.readStruct()
+			if pr.err != nil {
+				t.Fatalf("Failed to read a page header: %v", pr.err)
+			}
+			data := pr.b[:ph[3].(int64)]
+			if size := int64(len(b[md[9].(int64):])-len(pr.b)) + ph[3].(int64); size != md[7].(int64) {
+				t.Errorf("Expected a column chunk of %d bytes, got %d", md[7], size)
+			}
+			for j := 0; j < int(md[5].(int64)); j++ {
+				if start+j == len(rows) {
+					rows = append(rows, make([]interface{}, 6))
+				}
+				if md[1].(int64) == typeInt64 {
+					rows[start+j][i] = int64(binary.LittleEndian.Uint64(data))
+					data = data[8:]
+					continue
+				}
+				l := binary.LittleEndian.Uint32(data)
+				rows[start+j][i] = string(data[4 : 4+l])
+				data = data[4+l:]
+			}
+		}
+		if int64(len(rows)-start) != rg[3].(int64) {
+			t.Errorf("Expected %d rows in the row group, got %d", rg[3], len(rows)-start)
+		}
+	}
+	return rows, meta
+}
+
+func TestWriter(t *testing.T) {
+	store := fastentity.New()
+	err := fastentity.AddFromReader(strings.NewReader("text\tid\nSydney\tQ3130\n"), store, "locations", fastentity.DetectFormat())
+	if err != nil {
+		t.Fatal(err)
+	}
+	store.Add("skills", []rune("golang"))
+
+	var buf bytes.Buffer
+	w := NewWriter(&buf)
+	w.EntityID = StoreIDs(store)
+	w.RowGroupSize = 2
+	docs := []string{"golang developer in Sydney", "Sydney 🌉 golang"}
+	for i, doc := range docs {
+		if err := w.Write(fmt.Sprint("doc", i), store.FindAll([]rune(doc))); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if err := w.WriteMatch("doc", fastentity.Match{}); err != ErrClosed {
+		t.Errorf("Expected ErrClosed once closed, got %v", err)
+	}
+
+	rows, meta := readFile(t, buf.Bytes())
+	want := [][]interface{}{
+		{"doc0", "skills", "golang", int64(0), int64(6), ""},
+		{"doc0", "locations", "Sydney", int64(20), int64(26), "Q3130"},
+		{"doc1", "locations", "Sydney", int64(0), int64(6), "Q3130"},
+		{"doc1", "skills", "golang", int64(9), int64(15), ""},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("Expected rows %v, got %v", want, rows)
+	}
+	if meta[3].(int64) != 4 || len(meta[4].([]interface{})) != 2 {
+		t.Errorf("Expected 4 rows in 2 row groups, got %v", meta)
+	}
+	var names []string
+	for _, se := range meta[2].([]interface{}) {
+		names = append(names, se.(map[int16]interface{})[4].(string))
+	}
+	if want := []string{"schema", "doc", "group", "text", "start", "end", "entity_id"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("Expected schema %q, got %q", want, names)
+	}
+
+	// An empty file is still valid
+	buf.Reset()
+	if err := NewWriter(&buf).Close(); err != nil {
+		t.Fatal(err)
+	}
+	if rows, meta := readFile(t, buf.Bytes()); len(rows) != 0 || meta[3].(int64) != 0 {
+		t.Errorf("Expected no rows, got %v", rows)
+	}
+}
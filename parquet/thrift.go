@@ -0,0 +1,97 @@
+package parquet
+
+import "encoding/binary"
+
+// Types of the fields of the Thrift compact protocol, in which Parquet file metadata is
+// encoded.
+const (
+	typeI32    = 5
+	typeI64    = 6
+	typeBinary = 8
+	typeList   = 9
+	typeStruct = 12
+)
+
+// thriftWriter encodes structs in the Thrift compact protocol.
+type thriftWriter struct {
+	buf  []byte
+	last []int16 // ID of the last field written in each struct being written
+}
+
+// begin starts a struct, either at the top level or as an element of a list.
+func (t *thriftWriter) begin() {
+	t.last = append(t.last, 0)
+}
+
+// end ends the struct begun last.
+func (t *thriftWriter) end() {
+	t.buf = append(t.buf, 0) // Stop field
+	t.last = t.last[:len(t.last)-1]
+}
+
+// field writes the header of the field id of type typ in the current struct.
+func (t *thriftWriter) field(id int16, typ byte) {
+	last := &t.last[len(t.last)-1]
+	if delta := id - *last; delta > 0 && delta <= 15 {
+		t.buf = append(t.buf, byte(delta)<<4|typ)
+	} else {
+		t.buf = append(t.buf, typ)
+		t.varint(int64(id))
+	}
+	*last = id
+}
+
+// varint writes v zigzag encoded.
+func (t *thriftWriter) varint(v int64) {
+	var b [binary.MaxVarintLen64]byte
+	t.buf = append(t.buf, b[:binary.PutVarint(b[:], v)]...)
+}
+
+// uvarint writes v.
+func (t *thriftWriter) uvarint(v uint64) {
+	var b [binary.MaxVarintLen64]byte
+	t.buf = append(t.buf, b[:binary.PutUvarint(b[:], v)]...)
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, typeI32)
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, typeI64)
+	t.varint(v)
+}
+
+func (t *thriftWriter) string(id int16, s string) {
+	t.field(id, typeBinary)
+	t.uvarint(uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
+
+// structField begins the struct field id, which is ended by end.
+func (t *thriftWriter) structField(id int16) {
+	t.field(id, typeStruct)
+	t.begin()
+}
+
+// list writes the header of the list field id of n elements of type typ, which are then
+// written with listI32, listString or begin and end for structs.
+func (t *thriftWriter) list(id int16, typ byte, n int) {
+	t.field(id, typeList)
+	if n < 15 {
+		t.buf = append(t.buf, byte(n)<<4|typ)
+		return
+	}
+	t.buf = append(t.buf, 0xf0|typ)
+	t.uvarint(uint64(n))
+}
+
+func (t *thriftWriter) listI32(v int32) {
+	t.varint(int64(v))
+}
+
+func (t *thriftWriter) listString(s string) {
+	t.uvarint(uint64(len(s)))
+	t.buf = append(t.buf, s...)
+}
@@ -0,0 +1,156 @@
+package fastentity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Phonetic makes entities also match text which sounds alike, e.g. "Jon Smyth" matches
+// the entity "John Smith", by comparing the Double Metaphone codes of each word. This
+// suits groups of names, but matches loosely, so phonetic matches are reported with Kind
+// PhoneticMatch and a Score below 1 reflecting how much the text differs from the entity.
+func Phonetic() GroupOption {
+	return func(g *group) {
+		if g.phonetic != nil {
+			return
+		}
+		g.phonetic = make(map[string][]entry)
+		for _, ents := range g.entities {
+			for _, e := range ents {
+				g.addPhonetic(e)
+			}
+		}
+	}
+}
+
+// phoneticKeys returns the keys for the phonetic index of the text rs spanning the words
+// ws, using the primary and alternate codes of each word.
+func phoneticKeys(rs []rune, ws []pair, code func(w pair) [2]string) (string, string) {
+	var primary, alternate strings.Builder
+	for i, w := range ws {
+		if i > 0 {
+			primary.WriteByte(' ')
+			alternate.WriteByte(' ')
+		}
+		c := code(w)
+		primary.WriteString(c[0])
+		alternate.WriteString(c[1])
+	}
+	return primary.String(), alternate.String()
+}
+
+func wordCode(rs []rune, w pair) [2]string {
+	p, a := DoubleMetaphone(string(rs[w[left]:w[right]]))
+	return [2]string{p, a}
+}
+
+// addPhonetic adds e to the phonetic index of the group. The caller must hold the group
+// lock.
+func (g *group) addPhonetic(e entry) {
+	ws := words(e.text)
+	if len(ws) == 0 {
+		return
+	}
+	primary, alternate := phoneticKeys(e.text, ws, func(w pair) [2]string {
+		return wordCode(e.text, w)
+	})
+	g.phonetic[primary] = append(g.phonetic[primary], e)
+	if alternate != primary {
+		g.phonetic[alternate] = append(g.phonetic[alternate], e)
+	}
+}
+
+// matchPhonetic calls fn for each entity in the group which sounds like the text of rs
+// spanning the words ws, but doesn't match it exactly, returning false if fn does.
+func (g *group) matchPhonetic(rs []rune, ws []pair, sc *scratch, fn func(g *group, ent *entry, e Entity) bool) bool {
+	if sc.codes == nil {
+		sc.codes = make(map[int][2]string)
+	}
+	primary, alternate := phoneticKeys(rs, ws, func(w pair) [2]string {
+		c, ok := sc.codes[w[left]]
+		if !ok {
+			c = wordCode(rs, w)
+			sc.codes[w[left]] = c
+		}
+		return c
+	})
+	if primary == "" {
+		return true
+	}
+
+	text := rs[ws[0][left]:ws[len(ws)-1][right]]
+	ents := g.phonetic[primary]
+	if alternate != primary {
+		ents = append(ents[:len(ents):len(ents)], g.phonetic[alternate]...)
+	}
+	for j := range ents {
+		ent := &ents[j]
+		if equalFold(ent.text, text) || seenEntry(ents[:j], ent) {
+			continue // Reported as a text match, or under the other key
+		}
+		e := Entity{
+			Text:      text,
+			Offset:    ws[0][left],
+			Canonical: ent.text,
+			Kind:      PhoneticMatch,
+			Score:     similarity(ent.text, text),
+			Weight:    ent.weight,
+			Group:     g.name,
+		}
+		if !fn(g, ent, e) {
+			return false
+		}
+	}
+	return true
+}
+
+// seenEntry reports whether e is in ents, comparing the identity of the entity text since
+// the indexes hold copies of entries.
+func seenEntry(ents []entry, e *entry) bool {
+	for i := range ents {
+		if len(ents[i].text) > 0 && len(e.text) > 0 && &ents[i].text[0] == &e.text[0] {
+			return true
+		}
+	}
+	return false
+}
+
+// similarity returns 1 minus the edit distance between a and b, ignoring case, as a
+// proportion of the length of the longer.
+func similarity(a, b []rune) float64 {
+	n := len(a)
+	if len(b) > n {
+		n = len(b)
+	}
+	if n == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(a, b))/float64(n)
+}
+
+// levenshtein returns the edit distance between a and b, ignoring case.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if unicode.ToLower(a[i-1]) == unicode.ToLower(b[j-1]) {
+				cost = 0
+			}
+			cur[j] = prev[j-1] + cost
+			if d := prev[j] + 1; d < cur[j] {
+				cur[j] = d
+			}
+			if d := cur[j-1] + 1; d < cur[j] {
+				cur[j] = d
+			}
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
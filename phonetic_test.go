@@ -0,0 +1,48 @@
+package fastentity
+
+import "testing"
+
+func TestDoubleMetaphone(t *testing.T) {
+	codes := map[string][2]string{
+		"Smith":    {"SM0", "XMT"},
+		"Smyth":    {"SM0", "XMT"},
+		"Schmidt":  {"XMT", "SMT"},
+		"John":     {"JN", "AN"},
+		"Jon":      {"JN", "AN"},
+		"Jose":     {"HS", "HS"},
+		"Michael":  {"MKL", "MXL"},
+		"Arnow":    {"ARN", "ARNF"},
+		"Knight":   {"NT", "NT"},
+		"Xavier":   {"SF", "SFR"},
+		"Caesar":   {"SSR", "SSR"},
+		"Gallegos": {"KLKS", "KKS"},
+		"":         {"", ""},
+		"1990":     {"", ""},
+	}
+	for word, expected := range codes {
+		p, a := DoubleMetaphone(word)
+		if p != expected[0] || a != expected[1] {
+			t.Errorf("Expected DoubleMetaphone(%q) = %q, %q, got %q, %q", word, expected[0], expected[1], p, a)
+		}
+	}
+}
+
+func TestPhonetic(t *testing.T) {
+	store := New()
+	store.Add("names", []rune("John Smith"), []rune("Jim Brown"))
+	store.Group("names").Configure(Phonetic())
+
+	str := []rune("Contact Jon Smyth, or John Smith, but not Jim Green. ")
+	found := store.FindAll(str)["names"]
+	if len(found) != 2 {
+		t.Fatalf("Expected 2 entities, got %d: %v", len(found), found)
+	}
+	if f := found[0]; string(f.Text) != "Jon Smyth" || string(f.Canonical) != "John Smith" || f.Kind != PhoneticMatch || f.Offset != 8 {
+		t.Errorf("Expected phonetic match of 'Jon Smyth', got %+v", f)
+	} else if f.Score <= 0 || f.Score >= 1 {
+		t.Errorf("Expected phonetic match to score between 0 and 1, got %v", f.Score)
+	}
+	if f := found[1]; string(f.Text) != "John Smith" || f.Kind != TextMatch || f.Score != 1 {
+		t.Errorf("Expected text match of 'John Smith', got %+v", f)
+	}
+}
@@ -0,0 +1,165 @@
+package fastentity
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrPoolClosed is returned for documents submitted to a Pool which has been shut down.
+	ErrPoolClosed = errors.New("pool closed")
+	// ErrPoolFull is returned by Pool.TrySubmit when the queue of the pool is full.
+	ErrPoolFull = errors.New("pool queue full")
+)
+
+// Pool searches documents in a store with a fixed number of workers, queueing a bounded
+// number of documents waiting to be searched. Submitting documents blocks, or fails with
+// TrySubmit, while the queue is full, so services using a pool push back on clients as
+// load spikes rather than starting a goroutine and allocating for every request.
+//
+// A Pool is safe for concurrent use.
+type Pool struct {
+	store *Store
+	jobs  chan *Future
+	wg    sync.WaitGroup
+
+	mu     sync.RWMutex // held for writing to close jobs
+	closed bool
+}
+
+// Future is the pending result of searching a document submitted to a Pool.
+type Future struct {
+	ctx     context.Context
+	doc     []rune
+	opts    []FindOption
+	done    chan struct{}
+	results Results
+	err     error
+}
+
+// NewPool starts a pool searching documents in s with the given number of workers, and a
+// queue of up to queue documents waiting for a worker. The pool must be shut down to stop
+// the workers.
+func NewPool(s *Store, workers, queue int) *Pool {
+	if workers < 1 {
+		workers = 1
+	}
+	if queue < 0 {
+		queue = 0
+	}
+	p := &Pool{
+		store: s,
+		jobs:  make(chan *Future, queue),
+	}
+	p.wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go p.work()
+	}
+	return p
+}
+
+func (p *Pool) work() {
+	defer p.wg.Done()
+	var buf Scratch
+	for f := range p.jobs {
+		if f.err = f.ctx.Err(); f.err == nil {
+			f.results, f.err = p.store.FindAllContext(f.ctx, f.doc, append(f.opts, WithScratch(&buf))...)
+		}
+		close(f.done)
+	}
+}
+
+// Submit queues doc to be searched as FindAll does with the options opts, waiting while
+// the queue is full.
+func (p *Pool) Submit(doc []rune, opts ...FindOption) *Future {
+	return p.SubmitContext(context.Background(), doc, opts...)
+}
+
+// SubmitContext is like Submit, but stops waiting for room in the queue when ctx is
+// cancelled, and the search is abandoned if ctx is cancelled before it's done. The Future
+// then fails with ctx.Err().
+func (p *Pool) SubmitContext(ctx context.Context, doc []rune, opts ...FindOption) *Future {
+	f := newFuture(ctx, doc, opts)
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		f.fail(ErrPoolClosed)
+		return f
+	}
+	select {
+	case p.jobs <- f:
+	case <-ctx.Done():
+		f.fail(ctx.Err())
+	}
+	return f
+}
+
+// TrySubmit is like Submit, but returns ErrPoolFull rather than waiting if the queue is
+// full, or ErrPoolClosed if the pool has been shut down.
+func (p *Pool) TrySubmit(doc []rune, opts ...FindOption) (*Future, error) {
+	f := newFuture(context.Background(), doc, opts)
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	select {
+	case p.jobs <- f:
+		return f, nil
+	default:
+		return nil, ErrPoolFull
+	}
+}
+
+// Shutdown stops the pool accepting documents and waits for those already submitted to be
+// searched, or for ctx to be cancelled, in which case it returns ctx.Err() and the
+// remaining documents are searched in the background. Documents submitted once the pool
+// has been shut down fail with ErrPoolClosed.
+func (p *Pool) Shutdown(ctx context.Context) error {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.jobs)
+	}
+	p.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		p.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func newFuture(ctx context.Context, doc []rune, opts []FindOption) *Future {
+	return &Future{
+		ctx:  ctx,
+		doc:  doc,
+		opts: opts[:len(opts):len(opts)],
+		done: make(chan struct{}),
+	}
+}
+
+func (f *Future) fail(err error) {
+	f.err = err
+	close(f.done)
+}
+
+// Done returns a channel which is closed once the document has been searched, or the
+// search has failed.
+func (f *Future) Done() <-chan struct{} {
+	return f.done
+}
+
+// Wait waits for the document to be searched, returning the results of FindAll or the
+// reason the search failed.
+func (f *Future) Wait() (Results, error) {
+	<-f.done
+	return f.results, f.err
+}
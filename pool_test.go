@@ -0,0 +1,79 @@
+package fastentity
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestPool(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"), []rune("golang"))
+	p := NewPool(store, 2, 4)
+
+	var futures []*Future
+	for i := 0; i < 20; i++ {
+		futures = append(futures, p.Submit([]rune("PHP and golang")))
+	}
+	for _, f := range futures {
+		r, err := f.Wait()
+		if err != nil || len(r["skills"]) != 2 {
+			t.Fatalf("Expected 2 skills, got %v (%v)", r, err)
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := p.SubmitContext(ctx, []rune("PHP")).Wait(); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := p.Submit([]rune("PHP")).Wait(); err != ErrPoolClosed {
+		t.Errorf("Expected ErrPoolClosed, got %v", err)
+	}
+	if _, err := p.TrySubmit([]rune("PHP")); err != ErrPoolClosed {
+		t.Errorf("Expected ErrPoolClosed, got %v", err)
+	}
+}
+
+func TestPoolFull(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"))
+	block := make(chan struct{})
+	store.AddPreprocessor(func(rs []rune) ([]rune, []Span) {
+		<-block
+		return rs, nil
+	})
+	p := NewPool(store, 1, 1)
+
+	first := p.Submit([]rune("PHP"))
+	var queued *Future
+	for queued == nil {
+		// Wait for the worker to take the first document, leaving room in the queue
+		f, err := p.TrySubmit([]rune("PHP"))
+		if err == nil {
+			queued = f
+		}
+	}
+	if _, err := p.TrySubmit([]rune("PHP")); err != ErrPoolFull {
+		t.Errorf("Expected ErrPoolFull, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := p.Shutdown(ctx); err != context.Canceled {
+		t.Errorf("Expected shutdown to be cancelled, got %v", err)
+	}
+	close(block)
+	for _, f := range []*Future{first, queued} {
+		if r, err := f.Wait(); err != nil || len(r["skills"]) != 1 {
+			t.Errorf("Expected submitted documents to be searched, got %v (%v)", r, err)
+		}
+	}
+	if err := p.Shutdown(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,337 @@
+package fastentity
+
+import (
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// Span is a range of runes [Start, End) in a document.
+type Span struct {
+	Start, End int
+}
+
+// A Preprocessor transforms a document before it is searched, for example to strip
+// markup. Along with the transformed document it returns the span of the input each rune
+// of the output came from, which must be in increasing order. Runes inserted rather than
+// transformed have an empty span at the position they were inserted.
+type Preprocessor func(rs []rune) ([]rune, []Span)
+
+// AddPreprocessor registers preprocessors to be applied in order to documents searched by
+// FindAll and Matches, after any already registered. The offsets and text of the entities
+// found refer to the original document: Text is the part of the original document the
+// match came from, and so may include markup or whitespace which was removed.
+func (s *Store) AddPreprocessor(ps ...Preprocessor) {
+	s.Lock()
+	s.preprocessors = append(s.preprocessors, ps...)
+	s.Unlock()
+	s.bump()
+}
+
+// document is a preprocessed document, with the spans of the original document each
+// rune came from.
+type document struct {
+	orig  []rune
+	text  []rune
+	spans []Span // nil if the document wasn't changed
+	tok   Tokenizer
+}
+
+// preprocess applies the registered preprocessors to rs.
+func (s *Store) preprocess(rs []rune) *document {
+	s.RLock()
+	ps := s.preprocessors
+	tok := s.tokenizer
+	s.RUnlock()
+
+	d := &document{orig: rs, text: rs, tok: tok}
+	for _, p := range ps {
+		text, spans := p(d.text)
+		if d.spans != nil {
+			spans = composeSpans(d.spans, spans, len(d.orig))
+		}
+		d.text, d.spans = text, spans
+	}
+	return d
+}
+
+// composeSpans maps spans of a preprocessed document back to the document which was
+// preprocessed to create it, given the spans prev of that document in the original of
+// length n.
+func composeSpans(prev, spans []Span, n int) []Span {
+	point := func(i int) int {
+		if i < len(prev) {
+			return prev[i].Start
+		}
+		return n
+	}
+	out := make([]Span, len(spans))
+	for i, sp := range spans {
+		if sp.Start == sp.End {
+			p := point(sp.Start)
+			out[i] = Span{p, p}
+			continue
+		}
+		out[i] = Span{prev[sp.Start].Start, prev[sp.End-1].End}
+	}
+	return out
+}
+
+// original maps e, found in the preprocessed document, to the original document.
+func (d *document) original(e Entity) Entity {
+	if d.spans == nil || len(e.Text) == 0 {
+		return e
+	}
+	first, last := d.spans[e.Offset], d.spans[e.Offset+len(e.Text)-1]
+	// Count the runes expanded from the same runes of the original as the first and last
+	// runes matched, which aren't part of the match
+	for i := e.Offset - 1; i >= 0 && first.Start < first.End && d.spans[i] == first; i-- {
+		e.Head++
+	}
+	for i := e.Offset + len(e.Text); i < len(d.spans) && last.Start < last.End && d.spans[i] == last; i++ {
+		e.Tail++
+	}
+	e.Offset = first.Start
+	e.Text = d.orig[first.Start:last.End]
+	return e
+}
+
+// OriginalText is where a match was found in a document before it was preprocessed.
+type OriginalText struct {
+	// Span is the runes of the original document the match came from, and Text their
+	// text, which may include markup or whitespace removed by preprocessing.
+	Span
+	Text []rune
+	// Partial is set if the match starts or ends part way through a rune of Text which
+	// was expanded into several, such as a ligature, see Entity.Head.
+	Partial bool
+}
+
+// Original returns the text of the document the match came from before preprocessing,
+// which is the Text of the match. Matches starting or ending part way through a rune
+// expanded into several by preprocessing include the whole rune, and are Partial.
+func (m Match) Original() OriginalText {
+	return OriginalText{
+		Span:    Span{m.Offset, m.Offset + len(m.Text)},
+		Text:    m.Text,
+		Partial: m.Head > 0 || m.Tail > 0,
+	}
+}
+
+// htmlInline are the elements which don't separate words when stripped.
+var htmlInline = map[string]bool{
+	"a": true, "abbr": true, "b": true, "bdi": true, "bdo": true, "cite": true,
+	"code": true, "em": true, "font": true, "i": true, "mark": true, "q": true,
+	"s": true, "small": true, "span": true, "strong": true, "sub": true, "sup": true,
+	"u": true, "wbr": true,
+}
+
+// htmlEntities are the named character references decoded by StripHTML.
+var htmlEntities = map[string]rune{
+	"amp": '&', "lt": '<', "gt": '>', "quot": '"', "apos": '\'', "nbsp": ' ',
+	"ndash": '–', "mdash": '—', "lsquo": '‘', "rsquo": '’', "ldquo": '“', "rdquo": '”',
+}
+
+// StripHTML returns a Preprocessor which removes HTML tags, comments and the contents of
+// script and style elements, and decodes character references. Tags other than inline
+// elements like <b> and <a> are replaced with a space so the text either side of them
+// isn't joined into a single word.
+func StripHTML() Preprocessor {
+	return stripHTML
+}
+
+func stripHTML(rs []rune) ([]rune, []Span) {
+	out := make([]rune, 0, len(rs))
+	spans := make([]Span, 0, len(rs))
+	emit := func(r rune, start, end int) {
+		out = append(out, r)
+		spans = append(spans, Span{start, end})
+	}
+
+	for i := 0; i < len(rs); {
+		switch rs[i] {
+		case '<':
+			end, name := htmlTag(rs, i)
+			if end < 0 {
+				emit(rs[i], i, i+1)
+				i++
+				continue
+			}
+			if name == "script" || name == "style" {
+				end = htmlSkipElement(rs, end, name)
+			}
+			if !htmlInline[name] && len(out) > 0 && !unicode.IsSpace(out[len(out)-1]) {
+				emit(' ', i, end)
+			}
+			i = end
+		case '&':
+			r, end := htmlEntity(rs, i)
+			if end < 0 {
+				emit(rs[i], i, i+1)
+				i++
+				continue
+			}
+			emit(r, i, end)
+			i = end
+		default:
+			emit(rs[i], i, i+1)
+			i++
+		}
+	}
+	return out, spans
+}
+
+// htmlTag returns the end of the tag or comment starting at rs[i], and the lower case
+// name of the element if it's an opening tag, or -1 if it's not a tag.
+func htmlTag(rs []rune, i int) (int, string) {
+	if i+4 <= len(rs) && string(rs[i:i+4]) == "<!--" {
+		for j := i + 4; j+2 < len(rs); j++ {
+			if rs[j] == '-' && rs[j+1] == '-' && rs[j+2] == '>' {
+				return j + 3, ""
+			}
+		}
+		return -1, ""
+	}
+
+	j := i + 1
+	closing := j < len(rs) && rs[j] == '/'
+	if closing {
+		j++
+	}
+	if j >= len(rs) || !(unicode.IsLetter(rs[j]) || rs[j] == '!') {
+		return -1, ""
+	}
+	start := j
+	for j < len(rs) && (unicode.IsLetter(rs[j]) || unicode.IsDigit(rs[j])) {
+		j++
+	}
+	name := strings.ToLower(string(rs[start:j]))
+	for ; j < len(rs); j++ {
+		if rs[j] == '>' {
+			if closing {
+				name = "/" + name
+			}
+			return j + 1, name
+		}
+	}
+	return -1, ""
+}
+
+// htmlSkipElement returns the end of the closing tag of the element name whose contents
+// start at rs[i], or the end of rs if it isn't closed.
+func htmlSkipElement(rs []rune, i int, name string) int {
+	for ; i < len(rs); i++ {
+		if rs[i] != '<' {
+			continue
+		}
+		if end, n := htmlTag(rs, i); end >= 0 && n == "/"+name {
+			return end
+		}
+	}
+	return len(rs)
+}
+
+// htmlEntity decodes the character reference starting at rs[i], returning the rune and
+// the end of the reference, or -1 if it isn't one.
+func htmlEntity(rs []rune, i int) (rune, int) {
+	end := -1
+	for j := i + 1; j < len(rs) && j < i+10; j++ {
+		if rs[j] == ';' {
+			end = j
+			break
+		}
+	}
+	if end < 0 {
+		return 0, -1
+	}
+
+	ref := string(rs[i+1 : end])
+	if strings.HasPrefix(ref, "#") {
+		base := 10
+		num := ref[1:]
+		if strings.HasPrefix(num, "x") || strings.HasPrefix(num, "X") {
+			base, num = 16, num[1:]
+		}
+		n, err := strconv.ParseUint(num, base, 32)
+		if err != nil || n > unicode.MaxRune {
+			return 0, -1
+		}
+		return rune(n), end + 1
+	}
+	if r, ok := htmlEntities[ref]; ok {
+		return r, end + 1
+	}
+	return 0, -1
+}
+
+// unicodeFolds are the replacements made by NormalizeUnicode, other than for spaces and
+// full width forms.
+var unicodeFolds = map[rune]string{
+	'‘': "'", '’': "'", '‚': "'", '‛': "'", '′': "'",
+	'“': `"`, '”': `"`, '„': `"`, '‟': `"`, '″': `"`,
+	'‐': "-", '‑': "-", '‒': "-", '–': "-", '—': "-", '―': "-", '−': "-",
+	'…': "...",
+	'ﬀ': "ff", 'ﬁ': "fi", 'ﬂ': "fl", 'ﬃ': "ffi", 'ﬄ': "ffl",
+}
+
+// NormalizeUnicode returns a Preprocessor which folds common typographic and compatibility
+// forms to their plain equivalents: curly quotes, dashes, ellipses and ligatures are
+// replaced, full width ASCII is narrowed, spaces such as no-break space become ' ' and
+// invisible formatting characters such as zero width spaces are removed. It doesn't
+// perform full Unicode normalization.
+func NormalizeUnicode() Preprocessor {
+	return normalizeUnicode
+}
+
+func normalizeUnicode(rs []rune) ([]rune, []Span) {
+	out := make([]rune, 0, len(rs))
+	spans := make([]Span, 0, len(rs))
+	for i, r := range rs {
+		sp := Span{i, i + 1}
+		switch {
+		case unicodeFolds[r] != "":
+			for _, f := range unicodeFolds[r] {
+				out = append(out, f)
+				spans = append(spans, sp)
+			}
+		case r >= '！' && r <= '～':
+			out = append(out, r-'！'+'!')
+			spans = append(spans, sp)
+		case unicode.Is(unicode.Zs, r):
+			out = append(out, ' ')
+			spans = append(spans, sp)
+		case unicode.Is(unicode.Cf, r):
+			// Drop invisible formatting
+		default:
+			out = append(out, r)
+			spans = append(spans, sp)
+		}
+	}
+	return out, spans
+}
+
+// CollapseWhitespace returns a Preprocessor which replaces each run of whitespace with a
+// single space, so entities match across line breaks and repeated spaces.
+func CollapseWhitespace() Preprocessor {
+	return collapseWhitespace
+}
+
+func collapseWhitespace(rs []rune) ([]rune, []Span) {
+	out := make([]rune, 0, len(rs))
+	spans := make([]Span, 0, len(rs))
+	for i := 0; i < len(rs); {
+		if !unicode.IsSpace(rs[i]) {
+			out = append(out, rs[i])
+			spans = append(spans, Span{i, i + 1})
+			i++
+			continue
+		}
+		start := i
+		for i < len(rs) && unicode.IsSpace(rs[i]) {
+			i++
+		}
+		out = append(out, ' ')
+		spans = append(spans, Span{start, i})
+	}
+	return out, spans
+}
@@ -0,0 +1,86 @@
+package fastentity
+
+import "testing"
+
+func TestPreprocess(t *testing.T) {
+	doc := []rune("<html><script>var x = 'PHP';</script><p>Based in  <b>San Francisco,\n USA</b></p><p>Uses PHP&amp;golang at AT&amp;T.</p></html>")
+
+	store := New()
+	store.Add("locations", []rune("San Francisco, USA"))
+	store.Add("skills", []rune("PHP"), []rune("golang"), []rune("AT&T"))
+	store.AddPreprocessor(StripHTML(), CollapseWhitespace())
+
+	results := store.FindAll(doc)
+	locations := results["locations"]
+	if len(locations) != 1 {
+		t.Fatalf("Expected 1 location, got %d", len(locations))
+	}
+	if e := locations[0]; e.Offset != 53 || string(e.Text) != "San Francisco,\n USA" || string(e.Canonical) != "San Francisco, USA" {
+		t.Errorf("Expected 'San Francisco,\\n USA' at 53, got %q at %d", string(e.Text), e.Offset)
+	}
+
+	expected := map[string]int{"PHP": 88, "golang": 96, "AT&amp;T": 106}
+	skills := results["skills"]
+	if len(skills) != len(expected) {
+		t.Errorf("Expected %d skills, got %d", len(expected), len(skills))
+	}
+	for _, e := range skills {
+		if off, ok := expected[string(e.Text)]; !ok || off != e.Offset {
+			t.Errorf("Unexpected skill %q at %d", string(e.Text), e.Offset)
+		}
+		if string(doc[e.Offset:e.Offset+len(e.Text)]) != string(e.Text) {
+			t.Errorf("Expected %q to be at %d in the original document", string(e.Text), e.Offset)
+		}
+	}
+}
+
+func TestNormalizeUnicode(t *testing.T) {
+	doc := []rune("It’s the ＰＨＰ developer​… ")
+
+	store := New()
+	store.Add("jobTitles", []rune("PHP developer"))
+	store.AddPreprocessor(NormalizeUnicode())
+
+	found := store.FindAll(doc)["jobTitles"]
+	if len(found) != 1 || found[0].Offset != 9 || string(found[0].Text) != "ＰＨＰ developer" {
+		t.Errorf("Expected to find 'ＰＨＰ developer' at 9, got %v", found)
+	}
+}
+
+func TestOriginal(t *testing.T) {
+	// Expands vulgar fractions, so "1" is found part way through "½"
+	fractions := func(rs []rune) ([]rune, []Span) {
+		var out []rune
+		var spans []Span
+		for i, r := range rs {
+			s := string(r)
+			if r == '½' {
+				s = "1/2"
+			}
+			for _, f := range s {
+				out = append(out, f)
+				spans = append(spans, Span{i, i + 1})
+			}
+		}
+		return out, spans
+	}
+
+	store := New()
+	store.Add("skills", []rune("finance team"))
+	store.Add("numbers", []rune("1"))
+	store.AddPreprocessor(NormalizeUnicode(), CollapseWhitespace(), fractions)
+	doc := []rune("Our ﬁnance \n team, ½ of it")
+
+	r := store.FindAll(doc)
+	if len(r["skills"]) != 1 || len(r["numbers"]) != 1 {
+		t.Fatalf("Expected a skill and a number, got %v", r)
+	}
+	o := Match{r["skills"][0]}.Original()
+	if o.Span != (Span{4, 17}) || string(o.Text) != "ﬁnance \n team" || o.Partial {
+		t.Errorf("Expected the whole skill in the original, got %+v", o)
+	}
+	m := Match{r["numbers"][0]}
+	if o := m.Original(); o.Span != (Span{19, 20}) || string(o.Text) != "½" || !o.Partial || m.Head != 0 || m.Tail != 2 {
+		t.Errorf("Expected the number part way through ½, got %+v (%d, %d)", o, m.Head, m.Tail)
+	}
+}
@@ -0,0 +1,168 @@
+// Package processor adapts a fastentity Store for use in stream processors, such as Kafka
+// consumers, which find the entities in each message of a stream and publish the results.
+//
+// A Matcher holds the store and the message format, and is shared by all the workers
+// consuming a stream. Each worker, typically a goroutine per partition, gets its own
+// Worker from the Matcher, which reuses its buffers from one message to the next:
+//
+//	m := processor.NewMatcher(store, processor.JSON)
+//	for i := 0; i < workers; i++ {
+//		go func() {
+//			w := m.NewWorker()
+//			for msg := range messages {
+//				res, err := w.ProcessMessage(msg.Value)
+//				if errors.Is(err, processor.ErrInvalidMessage) {
+//					// Send msg to a dead letter queue
+//					continue
+//				}
+//				out, _ := processor.Encode(res)
+//				publish(msg.Key, out)
+//			}
+//		}()
+//	}
+//
+// Messages are either the text of a document, or a JSON Message with an ID and
+// optionally a language. Results are encoded as JSON, e.g.
+//
+//	{"id": "42", "version": 3, "matches": [{"group": "locations", "text": "Sydney", "canonical": "Sydney", "offset": 24, "byte_offset": 24, "kind": "text", "score": 1, "weight": 1}]}
+package processor
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/sajari/fastentity"
+)
+
+// ErrInvalidMessage is returned by Worker.Process for messages which can't be decoded.
+// Such messages fail whenever they are processed, so shouldn't be retried.
+var ErrInvalidMessage = errors.New("invalid message")
+
+// Format is the format of the messages processed by a Matcher.
+type Format int
+
+const (
+	// Text messages are the text of a document, encoded as UTF-8.
+	Text Format = iota
+	// JSON messages are Messages encoded as JSON.
+	JSON
+)
+
+// Message is a document in a JSON message.
+type Message struct {
+	// ID identifies the document, and is copied to the Result.
+	ID   string `json:"id"`
+	Text string `json:"text"`
+	// Language limits the search to the groups of the language and those without one,
+	// see fastentity.FindAllLanguage.
+	Language string `json:"language,omitempty"`
+}
+
+// Result is the outcome of processing a message.
+type Result struct {
+	ID string `json:"id,omitempty"`
+	// Version is the version of the store searched.
+	Version uint64 `json:"version"`
+	// Matches are the entities found in the message in document order. Offsets count
+	// runes, and byte offsets bytes, from the start of the text.
+	Matches []fastentity.StreamMatch `json:"matches"`
+}
+
+// Matcher finds entities in messages of a format. A Matcher is safe for concurrent use,
+// and shared by the Workers processing a stream.
+//
+// The store may be modified while messages are processed, since each is searched as by
+// FindAll, and the version searched is reported in each Result. To switch to a new store,
+// such as one loaded from updated dictionaries, start new workers from a new Matcher.
+type Matcher struct {
+	store  *fastentity.Store
+	format Format
+}
+
+// NewMatcher returns a Matcher finding the entities of store in messages of the format.
+func NewMatcher(store *fastentity.Store, format Format) *Matcher {
+	return &Matcher{
+		store:  store,
+		format: format,
+	}
+}
+
+// Store returns the store searched by the matcher.
+func (m *Matcher) Store() *fastentity.Store {
+	return m.store
+}
+
+// NewWorker returns a Worker processing messages for the matcher.
+func (m *Matcher) NewWorker() *Worker {
+	return &Worker{m: m}
+}
+
+// Worker processes messages, reusing buffers between them. A Worker must only be used by
+// one goroutine at a time, so each worker of a stream processor needs its own.
+type Worker struct {
+	m       *Matcher
+	runes   []rune
+	msg     Message
+	scratch fastentity.Scratch
+}
+
+// Process finds the entities in the message, returning an error wrapping
+// ErrInvalidMessage if it can't be decoded. The matches don't refer to msg, which may be
+// reused once Process returns.
+func (w *Worker) Process(msg []byte) ([]fastentity.StreamMatch, error) {
+	res, err := w.ProcessMessage(msg)
+	if err != nil {
+		return nil, err
+	}
+	return res.Matches, nil
+}
+
+// ProcessMessage is like Process, but returns the Result for the message, including its
+// ID and the version of the store searched.
+func (w *Worker) ProcessMessage(msg []byte) (*Result, error) {
+	if !utf8.Valid(msg) {
+		return nil, fmt.Errorf("%w: not valid UTF-8", ErrInvalidMessage)
+	}
+	w.runes = w.runes[:0]
+	var id, lang string
+	if w.m.format == JSON {
+		w.msg = Message{}
+		if err := json.Unmarshal(msg, &w.msg); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidMessage, err)
+		}
+		id, lang = w.msg.ID, w.msg.Language
+		for _, r := range w.msg.Text {
+			w.runes = append(w.runes, r)
+		}
+	} else {
+		for len(msg) > 0 {
+			r, size := utf8.DecodeRune(msg)
+			w.runes = append(w.runes, r)
+			msg = msg[size:]
+		}
+	}
+
+	version := w.m.store.Version()
+	ms := w.m.store.FindAllLanguage(lang, w.runes, fastentity.WithScratch(&w.scratch)).MatchesIn(w.runes)
+	for i := range ms {
+		// Copied from the buffer of the worker, which is reused for the next message
+		ms[i].Text = append([]rune(nil), ms[i].Text...)
+	}
+	return &Result{ID: id, Version: version, Matches: ms}, nil
+}
+
+// Encode encodes the result as JSON, for publishing to a stream.
+func Encode(res *Result) ([]byte, error) {
+	return json.Marshal(res)
+}
+
+// Decode decodes a result encoded by Encode, for consumers of the published results.
+func Decode(b []byte) (*Result, error) {
+	var res Result
+	if err := json.Unmarshal(b, &res); err != nil {
+		return nil, err
+	}
+	return &res, nil
+}
@@ -0,0 +1,76 @@
+package processor
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/sajari/fastentity"
+)
+
+func TestProcess(t *testing.T) {
+	store := fastentity.New()
+	store.Add("skills", []rune("PHP"), []rune("本語"))
+	store.Add("locations", []rune("Sydney"))
+
+	ms, err := NewMatcher(store, Text).NewWorker().Process([]byte("PHP 本語 in Sydney"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []struct {
+		group, text        string
+		offset, byteOffset int
+	}{
+		{"skills", "PHP", 0, 0},
+		{"skills", "本語", 4, 4},
+		{"locations", "Sydney", 10, 14},
+	}
+	if len(ms) != len(expected) {
+		t.Fatalf("Expected %+v, got %+v", expected, ms)
+	}
+	for i, m := range ms {
+		want := expected[i]
+		if m.Group != want.group || string(m.Text) != want.text || string(m.Canonical) != want.text ||
+			m.Offset != want.offset || m.ByteOffset != want.byteOffset || m.Kind != fastentity.TextMatch || m.Weight != 1 {
+			t.Errorf("Expected %+v, got %+v", want, m)
+		}
+	}
+
+	m := NewMatcher(store, JSON)
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := m.NewWorker()
+			for j := 0; j < 100; j++ {
+				res, err := w.ProcessMessage([]byte(`{"id": "a", "text": "PHP in Sydney"}`))
+				if err != nil || res.ID != "a" || len(res.Matches) != 2 {
+					t.Errorf("Expected 2 matches in a, got %+v (%v)", res, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	w := m.NewWorker()
+	for _, msg := range []string{`{"text": `, "\xff"} {
+		if _, err := w.Process([]byte(msg)); !errors.Is(err, ErrInvalidMessage) {
+			t.Errorf("%q: expected ErrInvalidMessage, got %v", msg, err)
+		}
+	}
+
+	res, err := w.ProcessMessage([]byte(`{"id": "b", "text": "Sydney"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Encode(res)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := Decode(b); err != nil || !reflect.DeepEqual(got, res) {
+		t.Errorf("Expected %+v, got %+v (%v)", res, got, err)
+	}
+}
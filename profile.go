@@ -0,0 +1,99 @@
+package fastentity
+
+import "strings"
+
+// Profiles are the normalization presets for languages by ISO 639-1 code, for selecting
+// them by name, e.g. from configuration.
+var Profiles = map[string]func() GroupOption{
+	"de": German,
+	"fr": French,
+	"es": Spanish,
+}
+
+// German normalizes words of German entities and documents: umlauts match their
+// transcriptions and ß matches ss, so "München" matches "Muenchen" and "Straße" matches
+// "Strasse".
+func German() GroupOption {
+	return Normalize(FoldGerman)
+}
+
+// French normalizes words of French entities and documents: accents are ignored, the
+// ligatures œ and æ match oe and ae, and elided articles and pronouns are optional, so
+// "l'Hôpital" matches "hopital" and "L’Oréal" matches "Oréal".
+func French() GroupOption {
+	return func(g *group) {
+		if g.elisions == nil {
+			g.elisions = make(map[string]bool, len(frenchElisions))
+		}
+		for _, e := range frenchElisions {
+			g.elisions[e] = true
+		}
+		Normalize(FoldAccents)(g) // Reindexes the group
+	}
+}
+
+// Spanish normalizes words of Spanish entities and documents: accents are ignored, but ñ
+// is kept distinct from n, so "Málaga" matches "Malaga" but "año" doesn't match "ano".
+func Spanish() GroupOption {
+	return Normalize(foldSpanish)
+}
+
+// frenchElisions are the words which are elided before a vowel in French, e.g. "l'" in
+// "l'université".
+var frenchElisions = []string{"l", "d", "j", "m", "n", "s", "t", "c", "qu", "jusqu", "lorsqu", "puisqu"}
+
+// accentFolds map lower case accented Latin letters to their unaccented forms.
+var accentFolds = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'æ': "ae",
+	'ç': "c", 'è': "e", 'é': "e", 'ê': "e", 'ë': "e",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ñ': "n",
+	'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'œ': "oe",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ý': "y", 'ÿ': "y", 'ß': "ss",
+}
+
+// germanFolds map lower case German letters to their transcriptions.
+var germanFolds = map[rune]string{'ä': "ae", 'ö': "oe", 'ü': "ue", 'ß': "ss"}
+
+// FoldAccents is a Normalizer which removes the accents from Latin letters, e.g.
+// "café" becomes "cafe", and expands ligatures, e.g. "œuvre" becomes "oeuvre".
+func FoldAccents(word []rune) []rune {
+	return fold(word, accentFolds, 0)
+}
+
+// FoldGerman is a Normalizer which replaces umlauts with their transcriptions and ß with
+// ss, e.g. "Größe" becomes "groesse".
+func FoldGerman(word []rune) []rune {
+	return fold(word, germanFolds, 0)
+}
+
+func foldSpanish(word []rune) []rune {
+	return fold(word, accentFolds, 'ñ')
+}
+
+// fold replaces the runes of word found in folds, other than keep, returning word itself
+// if there are none.
+func fold(word []rune, folds map[rune]string, keep rune) []rune {
+	var out []rune
+	for i, r := range word {
+		f, ok := folds[r]
+		if !ok || r == keep {
+			if out != nil {
+				out = append(out, r)
+			}
+			continue
+		}
+		if out == nil {
+			out = append(make([]rune, 0, len(word)+2), word[:i]...)
+		}
+		out = append(out, []rune(f)...)
+	}
+	if out == nil {
+		return word
+	}
+	return out
+}
+
+// isApostrophe reports whether sep is a single apostrophe, as follows elided words.
+func isApostrophe(sep []rune) bool {
+	return len(sep) == 1 && strings.ContainsRune("'’ʼ", sep[0])
+}
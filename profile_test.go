@@ -0,0 +1,49 @@
+package fastentity
+
+import "testing"
+
+func TestProfiles(t *testing.T) {
+	tests := []struct {
+		profile  GroupOption
+		entity   string
+		text     string
+		expected string
+	}{
+		{German(), "München", "Flug nach Muenchen", "Muenchen"},
+		{German(), "Strasse", "Große Straße", "Straße"},
+		{French(), "l'Hôpital", "Urgences à hopital", "hopital"},
+		{French(), "Oréal", "Chez L’Oréal", "Oréal"},
+		{French(), "L'Oréal", "Chez l’oreal", "l’oreal"},
+		{French(), "École d'ingénieurs", "une ecole d’ingenieurs", "ecole d’ingenieurs"},
+		{Spanish(), "Málaga", "Vuelo a Malaga", "Malaga"},
+		{Spanish(), "año", "el ano", ""},
+	}
+	for _, test := range tests {
+		store := New()
+		store.Add("g", []rune(test.entity))
+		store.Group("g").Configure(test.profile)
+
+		ents := store.FindAll([]rune(test.text))["g"]
+		found := ""
+		for _, e := range ents {
+			if len(e.Text) > len(found) {
+				found = string(e.Text)
+			}
+		}
+		if found != test.expected {
+			t.Errorf("%q in %q: expected %q, got %v", test.entity, test.text, test.expected, ents)
+		}
+	}
+}
+
+func TestFoldAccents(t *testing.T) {
+	for in, expected := range map[string]string{
+		"café":  "cafe",
+		"œuvre": "oeuvre",
+		"plain": "plain",
+	} {
+		if out := string(FoldAccents([]rune(in))); out != expected {
+			t.Errorf("%q: expected %q, got %q", in, expected, out)
+		}
+	}
+}
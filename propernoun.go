@@ -0,0 +1,55 @@
+package fastentity
+
+import "unicode"
+
+// ProperNouns makes matches of entities with capitalized words, such as "Apple" and
+// "New York", which don't keep the capitals of the entity, such as "apple" and
+// "new York", score score rather than 1, or not match at all if score is 0. Words of the
+// entity starting with a lower case letter, such as "of" in "Bank of America", may have
+// any case, and text in upper case, as in headings, keeps the capitals of any entity.
+//
+// It applies to every kind of match, except those whose text has a different number of
+// words from the entity, such as acronyms and some synonyms. A score of 1 turns it off.
+func ProperNouns(score float64) GroupOption {
+	return func(g *group) {
+		g.properNouns, g.properNounScore = score < 1, score
+	}
+}
+
+// keepsCase reports whether the words of the entity ent which start with an upper case
+// letter also do in text, or the two can't be compared word by word.
+func keepsCase(ent, text []rune) bool {
+	capitalized := false
+	for _, r := range ent {
+		if unicode.IsUpper(r) {
+			capitalized = true
+			break
+		}
+	}
+	if !capitalized {
+		return true
+	}
+	ews, tws := words(ent), words(text)
+	if len(ews) != len(tws) {
+		return true
+	}
+	for i, w := range ews {
+		if unicode.IsUpper(ent[w[left]]) && !unicode.IsUpper(text[tws[i][left]]) {
+			return false
+		}
+	}
+	return true
+}
+
+// scoreCase scores the match e of the group for ProperNouns, returning false if it
+// doesn't match at all.
+func (g *group) scoreCase(e *Entity) bool {
+	if !g.properNouns || keepsCase(e.Canonical, e.Text) {
+		return true
+	}
+	if g.properNounScore <= 0 {
+		return false
+	}
+	e.Score *= g.properNounScore
+	return true
+}
@@ -0,0 +1,41 @@
+package fastentity
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+func TestProperNouns(t *testing.T) {
+	store := New()
+	store.Add("companies", []rune("Apple"), []rune("Bank of America"), []rune("salesforce"))
+	doc := []rune("an apple from Apple, bank of america, Bank Of America, BANK OF AMERICA, bank of America and Salesforce")
+
+	find := func() []string {
+		var got []string
+		for _, e := range store.FindAll(doc)["companies"] {
+			got = append(got, string(e.Text)+":"+fmt.Sprint(e.Score))
+		}
+		return got
+	}
+	if got := find(); len(got) != 7 {
+		t.Errorf("Expected 7 matches ignoring case, got %q", got)
+	}
+
+	store.Group("companies").Configure(ProperNouns(0))
+	want := []string{"Apple:1", "Bank Of America:1", "BANK OF AMERICA:1", "Salesforce:1"}
+	if got := find(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	store.Group("companies").Configure(ProperNouns(0.5))
+	want = []string{"apple:0.5", "Apple:1", "bank of america:0.5", "Bank Of America:1", "BANK OF AMERICA:1", "bank of America:0.5", "Salesforce:1"}
+	if got := find(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	store.Group("companies").Configure(ProperNouns(1))
+	if got := find(); len(got) != 7 {
+		t.Errorf("Expected 7 matches once turned off, got %q", got)
+	}
+}
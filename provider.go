@@ -0,0 +1,214 @@
+package fastentity
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GroupProvider supplies entities of a group from outside the store, such as a database or
+// a remote service, for dictionaries too large or changing too often to hold in memory.
+//
+// Lookup is passed the candidate keys of a document: the lower case text of each run of
+// words which could be an entity. It returns the entities matching each key, case
+// insensitively, leaving out keys with no entities. Lookup may be called concurrently.
+type GroupProvider interface {
+	Lookup(ctx context.Context, keys []string) (map[string][]ProvidedEntity, error)
+}
+
+// ProvidedEntity is an entity supplied by a GroupProvider.
+type ProvidedEntity struct {
+	Text   string  `json:"text"`
+	Weight float64 `json:"weight"`
+}
+
+// ProviderOptions configure how a group uses its GroupProvider.
+type ProviderOptions struct {
+	// MaxWords is the most words in an entity of the provider, 3 by default. Each word of
+	// a document is the last word of MaxWords candidate keys.
+	MaxWords int
+	// CacheSize is the number of keys whose entities, or lack of them, are cached, 100000
+	// by default. The least recently used keys are dropped first.
+	CacheSize int
+	// TTL is how long keys are cached, forever if 0.
+	TTL time.Duration
+}
+
+// Provide backs the group with the provider p, as well as the entities added to the
+// group. Before each document is searched, the keys of the document which aren't cached
+// are looked up with a single call to p.
+//
+// If the provider fails, the search fails with its error, so FindAll returns no results.
+// Use FindAllContext, which returns the error, or a provider which degrades gracefully.
+// Provided entities aren't included when the group is saved, and are only matched by
+// their text, regardless of other options of the group.
+func Provide(p GroupProvider, opts ProviderOptions) GroupOption {
+	if opts.MaxWords <= 0 {
+		opts.MaxWords = 3
+	}
+	if opts.CacheSize <= 0 {
+		opts.CacheSize = 100000
+	}
+	return func(g *group) {
+		g.provider = &providerCache{
+			p:       p,
+			opts:    opts,
+			entries: make(map[string]*list.Element),
+			lru:     list.New(),
+		}
+		if opts.MaxWords > g.maxWords {
+			g.maxWords = opts.MaxWords
+		}
+	}
+}
+
+// providerCache caches the entities looked up from a provider by key.
+type providerCache struct {
+	p    GroupProvider
+	opts ProviderOptions
+
+	mu      sync.Mutex
+	entries map[string]*list.Element // of *providedKey
+	lru     *list.List               // most recently used first
+}
+
+type providedKey struct {
+	key     string
+	ents    []entry
+	expires time.Time
+}
+
+// prefetch looks up the candidate keys of rs, split into words by tok, which aren't
+// cached.
+func (c *providerCache) prefetch(ctx context.Context, rs []rune, tok Tokenizer) error {
+	keys := candidateKeys(rs, tok, c.opts.MaxWords)
+	now := time.Now()
+	c.mu.Lock()
+	missing := keys[:0]
+	for _, k := range keys {
+		if el, ok := c.entries[k]; ok && !c.expired(el.Value.(*providedKey), now) {
+			c.lru.MoveToFront(el)
+			continue
+		}
+		missing = append(missing, k)
+	}
+	c.mu.Unlock()
+	if len(missing) == 0 {
+		return nil
+	}
+
+	found, err := c.p.Lookup(ctx, missing)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, k := range missing {
+		var ents []entry
+		for _, pe := range found[k] {
+			ents = append(ents, entry{text: []rune(pe.Text), weight: pe.Weight})
+		}
+		pk := &providedKey{key: k, ents: ents}
+		if c.opts.TTL > 0 {
+			pk.expires = now.Add(c.opts.TTL)
+		}
+		if el, ok := c.entries[k]; ok {
+			el.Value = pk
+			c.lru.MoveToFront(el)
+		} else {
+			c.entries[k] = c.lru.PushFront(pk)
+		}
+	}
+	for c.lru.Len() > c.opts.CacheSize {
+		el := c.lru.Back()
+		delete(c.entries, el.Value.(*providedKey).key)
+		c.lru.Remove(el)
+	}
+	return nil
+}
+
+func (c *providerCache) expired(pk *providedKey, now time.Time) bool {
+	return !pk.expires.IsZero() && now.After(pk.expires)
+}
+
+// lookup returns the cached entities for key.
+func (c *providerCache) lookup(key string) []entry {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		return el.Value.(*providedKey).ents
+	}
+	return nil
+}
+
+// candidateKeys returns the distinct lower case texts of the runs of up to maxWords words
+// of rs, split into words by tok, which are no longer than MaxEntityLen.
+func candidateKeys(rs []rune, tok Tokenizer, maxWords int) []string {
+	ws := tok.words(rs)
+	seen := make(map[string]bool)
+	var keys []string
+	var buf []byte
+	for i := range ws {
+		for j := i; j >= 0 && i-j < maxWords; j-- {
+			start, end := ws[j][left], ws[i][right]
+			if end-start > MaxEntityLen {
+				break
+			}
+			buf = buf[:0]
+			for _, r := range rs[start:end] {
+				buf = utf8.AppendRune(buf, unicode.ToLower(r))
+			}
+			if !seen[string(buf)] {
+				seen[string(buf)] = true
+				keys = append(keys, string(buf))
+			}
+		}
+	}
+	return keys
+}
+
+// prefetchProvided looks up the candidate keys of rs for the groups with providers.
+func prefetchProvided(ctx context.Context, rs []rune, tok Tokenizer, groups []*group) error {
+	for _, g := range groups {
+		if g.provider == nil {
+			continue
+		}
+		if err := g.provider.prefetch(ctx, rs, tok); err != nil {
+			return fmt.Errorf("group %q: %w", g.name, err)
+		}
+	}
+	return nil
+}
+
+// matchProvided calls fn for each provided entity matching the text of rs spanning the
+// words ws, returning false if fn does.
+func (g *group) matchProvided(rs []rune, ws []pair, sc *scratch, fn func(g *group, ent *entry, e Entity) bool) bool {
+	p1, p2 := ws[0], ws[len(ws)-1]
+	text := rs[p1[left]:p2[right]]
+	sc.key = sc.key[:0]
+	for _, r := range text {
+		sc.key = utf8.AppendRune(sc.key, unicode.ToLower(r))
+	}
+	ents := g.provider.lookup(string(sc.key))
+	for j := range ents {
+		if !equalFold(ents[j].text, text) || !g.caseMatches(ents[j].text, text) {
+			continue
+		}
+		e := Entity{
+			Text:      text,
+			Offset:    p1[left],
+			Canonical: ents[j].text,
+			Score:     1,
+			Weight:    ents[j].weight,
+			Group:     g.name,
+		}
+		if !fn(g, &ents[j], e) {
+			return false
+		}
+	}
+	return true
+}
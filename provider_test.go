@@ -0,0 +1,98 @@
+package fastentity
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type mapProvider struct {
+	mu      sync.Mutex
+	entries map[string][]ProvidedEntity
+	lookups [][]string
+	err     error
+}
+
+func (p *mapProvider) Lookup(ctx context.Context, keys []string) (map[string][]ProvidedEntity, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.lookups = append(p.lookups, append([]string(nil), keys...))
+	if p.err != nil {
+		return nil, p.err
+	}
+	found := make(map[string][]ProvidedEntity)
+	for _, k := range keys {
+		if ents, ok := p.entries[k]; ok {
+			found[k] = ents
+		}
+	}
+	return found, nil
+}
+
+func TestProvide(t *testing.T) {
+	p := &mapProvider{entries: map[string][]ProvidedEntity{
+		"software engineer": {{Text: "Software Engineer", Weight: 2}},
+		"go":                {{Text: "Go", Weight: 1}},
+	}}
+	store := New()
+	store.Add("skills", []rune("python"))
+	store.Group("skills").Configure(Provide(p, ProviderOptions{MaxWords: 2}))
+
+	str := []rune("A software engineer who knows Go and Python")
+	found := store.FindAll(str)["skills"]
+	var got []string
+	for _, e := range found {
+		got = append(got, string(e.Canonical)+"/"+string(e.Text))
+	}
+	expected := []string{"Software Engineer/software engineer", "Go/Go", "python/Python"}
+	if strings.Join(got, ",") != strings.Join(expected, ",") {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+	if found[0].Weight != 2 {
+		t.Errorf("Expected weight 2, got %v", found[0].Weight)
+	}
+
+	// Keys are only looked up once, in a single call per document
+	if len(p.lookups) != 1 {
+		t.Fatalf("Expected 1 lookup, got %d", len(p.lookups))
+	}
+	keys := p.lookups[0]
+	sort.Strings(keys)
+	if len(keys) != 15 || keys[0] != "a" {
+		t.Errorf("Unexpected keys %v", keys)
+	}
+	store.FindAll([]rune("Software engineer, go"))
+	if len(p.lookups) != 2 || len(p.lookups[1]) != 1 || p.lookups[1][0] != "engineer, go" {
+		t.Errorf("Expected only the new key to be looked up, got %v", p.lookups[1:])
+	}
+
+	// Provider errors fail the search
+	p.err = errors.New("unavailable")
+	if _, err := store.FindAllContext(context.Background(), []rune("something new")); !errors.Is(err, p.err) {
+		t.Errorf("Expected the provider error, got %v", err)
+	}
+}
+
+func TestProvideCache(t *testing.T) {
+	p := &mapProvider{}
+	store := New()
+	store.Group("skills").Configure(Provide(p, ProviderOptions{MaxWords: 1, CacheSize: 2, TTL: time.Hour}))
+
+	store.FindAll([]rune("one two three"))
+	store.FindAll([]rune("three"))
+	store.FindAll([]rune("one"))
+	if len(p.lookups) != 2 {
+		t.Errorf("Expected the least recently used key to be dropped, got lookups %v", p.lookups)
+	}
+
+	c := store.groups["skills"].provider
+	c.entries["three"].Value.(*providedKey).expires = time.Now().Add(-time.Second)
+	store.FindAll([]rune("three"))
+	if len(p.lookups) != 3 {
+		t.Errorf("Expected the expired key to be looked up, got lookups %v", p.lookups)
+	}
+}
@@ -0,0 +1,67 @@
+package fastentity
+
+import "sort"
+
+// Results maps group names to the entities found in each, as returned by FindAll.
+type Results map[string][]Entity
+
+// TopK returns the k highest ranked matches across all groups, or all of them if there
+// are fewer than k. less reports whether the match a ranks higher than b, see ByWeight,
+// ByLength, ByGroupPriority and Ranking. If less is nil, matches are ranked by the
+// weight of their entity, then by the length of the matched text. Matches which rank
+// equally are in document order, so the earliest ranks highest.
+func (r Results) TopK(k int, less func(a, b Match) bool) []Match {
+	if less == nil {
+		less = defaultRanking
+	}
+	ms := r.Matches()
+	sort.SliceStable(ms, func(i, j int) bool {
+		return less(ms[i], ms[j])
+	})
+	if k >= 0 && k < len(ms) {
+		ms = ms[:k]
+	}
+	return ms
+}
+
+var defaultRanking = Ranking(ByWeight, ByLength)
+
+// ByWeight ranks matches of entities with higher weights first.
+func ByWeight(a, b Match) bool {
+	return a.Weight > b.Weight
+}
+
+// ByLength ranks longer matches first, by the length of the matched text.
+func ByLength(a, b Match) bool {
+	return len(a.Text) > len(b.Text)
+}
+
+// ByGroupPriority ranks matches in the groups listed first, in the order listed, ahead of
+// matches in other groups.
+func ByGroupPriority(groups ...string) func(a, b Match) bool {
+	priority := make(map[string]int, len(groups))
+	for i, name := range groups {
+		if _, ok := priority[name]; !ok {
+			priority[name] = len(groups) - i
+		}
+	}
+	return func(a, b Match) bool {
+		return priority[a.Group] > priority[b.Group]
+	}
+}
+
+// Ranking combines comparators, ranking matches by each in turn until one ranks them
+// differently, e.g. Ranking(ByGroupPriority("skills"), ByLength).
+func Ranking(less ...func(a, b Match) bool) func(a, b Match) bool {
+	return func(a, b Match) bool {
+		for _, l := range less {
+			if l(a, b) {
+				return true
+			}
+			if l(b, a) {
+				return false
+			}
+		}
+		return false
+	}
+}
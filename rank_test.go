@@ -0,0 +1,111 @@
+package fastentity
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestTopK(t *testing.T) {
+	str := []rune("Moved from Springfield to Sydney, then New York City. ")
+
+	store := New()
+	store.Add("locations", []rune("Springfield"), []rune("New York City"))
+	store.AddWeighted("locations", []rune("Sydney"), 5)
+	store.Add("cities", []rune("Springfield"))
+
+	top := store.FindAll(str).TopK(3, nil)
+	expected := []Match{
+		{Entity: Entity{Group: "locations", Text: []rune("Sydney"), Offset: 26}},
+		{Entity: Entity{Group: "locations", Text: []rune("New York City"), Offset: 39}},
+		{Entity: Entity{Group: "cities", Text: []rune("Springfield"), Offset: 11}},
+	}
+	if len(top) != len(expected) {
+		t.Fatalf("Expected %d matches, got %d", len(expected), len(top))
+	}
+	for i, m := range top {
+		e := expected[i]
+		if m.Group != e.Group || string(m.Text) != string(e.Text) || m.Offset != e.Offset {
+			t.Errorf("Expected match %d to be %s %q at %d, got %s %q at %d", i, e.Group, string(e.Text), e.Offset, m.Group, string(m.Text), m.Offset)
+		}
+	}
+	if top[0].Weight != 5 || top[1].Weight != DefaultWeight {
+		t.Errorf("Expected weights 5 and %v, got %v and %v", DefaultWeight, top[0].Weight, top[1].Weight)
+	}
+	if n := len(store.FindAll(str).TopK(10, nil)); n != 4 {
+		t.Errorf("Expected all 4 matches, got %d", n)
+	}
+
+	top = store.FindAll(str).TopK(2, Ranking(ByGroupPriority("cities"), ByLength))
+	if len(top) != 2 || top[0].Group != "cities" || string(top[1].Text) != "New York City" {
+		t.Errorf("Expected the city first then the longest location, got %v", top)
+	}
+	top = store.FindAll(str).TopK(-1, ByLength)
+	if len(top) != 4 || string(top[1].Text) != "Springfield" || top[1].Group != "cities" || top[2].Group != "locations" {
+		t.Errorf("Expected matches of equal length in document order, got %v", top)
+	}
+}
+
+func TestTopKTies(t *testing.T) {
+	store := New()
+	store.Group("streets").Configure(NumericVariants())
+	store.Add("streets", []rune("Third Street"), []rune("3rd Street"))
+	store.Add("directions", []rune("Left"))
+	str := []rune(strings.Repeat("Left Third Street, ", 20))
+
+	// Each Third Street matches both entities, with equal weights and lengths
+	found := store.FindAll(str)["streets"]
+	top := store.FindAll(str).TopK(-1, ByGroupPriority("streets"))
+	if len(top) != 60 || len(found) != 40 {
+		t.Fatalf("Expected 60 matches, got %d with %d streets", len(top), len(found))
+	}
+	for i, e := range found {
+		if top[i].Offset != e.Offset || string(top[i].Canonical) != string(e.Canonical) {
+			t.Fatalf("Expected matches ranking equally in the order found, got %v", top)
+		}
+	}
+}
+
+func TestWeights(t *testing.T) {
+	dir := writeEntityFiles(t, map[string]string{
+		"locations" + entityFileSuffix: "San Francisco, USA,2.5\nSydney\nNew York,high\n",
+	})
+	defer os.RemoveAll(dir)
+
+	if _, err := FromDir(dir, Weights()); err == nil {
+		t.Errorf("Expected an error loading an invalid weight")
+	}
+	store, report, err := LoadDir(dir, Weights(), SkipErrors())
+	if err != nil {
+		t.Fatalf("Failed to load store: %v", err)
+	}
+	if skipped := report.Files[0].Skipped; len(skipped) != 1 || skipped[0].Line != 3 {
+		t.Errorf("Expected line 3 to be skipped, got %v", skipped)
+	}
+
+	found := store.FindAll([]rune("From Sydney or San Francisco, USA. "))["locations"]
+	weights := map[string]float64{}
+	for _, e := range found {
+		weights[string(e.Text)] = e.Weight
+	}
+	if weights["San Francisco, USA"] != 2.5 || weights["Sydney"] != DefaultWeight {
+		t.Errorf("Unexpected weights %v", weights)
+	}
+
+	out, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(out)
+	if err := store.Save(out, Weights(), Sorted()); err != nil {
+		t.Fatalf("Failed to save store: %v", err)
+	}
+	b, err := ioutil.ReadFile(out + "/locations" + entityFileSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(b) != "San Francisco, USA,2.5\nSydney,1\n" {
+		t.Errorf("Unexpected saved file %q", string(b))
+	}
+}
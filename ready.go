@@ -0,0 +1,78 @@
+package fastentity
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// readyPoll is how often WaitReady checks whether the store is ready.
+const readyPoll = 50 * time.Millisecond
+
+// Ready reports whether the store has finished warming up: every group loaded with the
+// Lazy option has been loaded, by being used or by Preload, and every WarmUp is done.
+// Stores loaded without Lazy are ready once loaded. Groups evicted under a memory limit
+// since they were loaded don't make the store unready, nor do groups which failed to load.
+//
+// Servers report Ready in their readiness probes, so that orchestrators don't route
+// searches to a node still loading its dictionaries.
+func (s *Store) Ready() bool {
+	s.RLock()
+	defer s.RUnlock()
+	if s.warming > 0 {
+		return false
+	}
+	for _, g := range s.groups {
+		if !g.loaded() {
+			return false
+		}
+	}
+	return true
+}
+
+// WaitReady waits until the store is Ready, returning ctx.Err() if ctx is done first. It
+// doesn't load lazy groups itself, so is usually called alongside Preload:
+//
+//	go store.Preload()
+//	err := store.WaitReady(ctx)
+func (s *Store) WaitReady(ctx context.Context) error {
+	t := time.NewTicker(readyPoll)
+	defer t.Stop()
+	for !s.Ready() {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// WarmUp marks the store as not Ready until done is called, for warming up other than
+// loading groups, such as catching up with the changes made to the dictionaries since they
+// were saved. done may be called more than once.
+func (s *Store) WarmUp() (done func()) {
+	s.Lock()
+	s.warming++
+	s.Unlock()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			s.Lock()
+			s.warming--
+			s.Unlock()
+		})
+	}
+}
+
+// loaded reports whether the entities of the group have been loaded, even if they have
+// since been evicted, or failed to load.
+func (g *group) loaded() bool {
+	if atomic.LoadUint32(&g.lazy) == 0 {
+		return true
+	}
+	g.RLock()
+	defer g.RUnlock()
+	return g.warm
+}
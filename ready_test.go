@@ -0,0 +1,54 @@
+package fastentity
+
+import (
+	"context"
+	"errors"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReady(t *testing.T) {
+	dir := writeEntityFiles(t, map[string]string{
+		"skills.entities.csv":    "PHP\ngolang\n",
+		"locations.entities.csv": "Sydney\n",
+	})
+	defer os.RemoveAll(dir)
+
+	if !New().Ready() {
+		t.Error("Expected a new store to be ready")
+	}
+	store, err := FromDir(dir, Lazy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if store.Ready() {
+		t.Error("Expected a lazy store not to be ready")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := store.WaitReady(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected context.DeadlineExceeded, got %v", err)
+	}
+
+	// Entities added before a group is loaded don't make it ready
+	store.Add("skills", []rune("rust"))
+	store.Preload("locations")
+	if store.Ready() {
+		t.Error("Expected the store not to be ready with skills unloaded")
+	}
+	go store.Preload()
+	if err := store.WaitReady(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	done := store.WarmUp()
+	if store.Ready() {
+		t.Error("Expected the store not to be ready while warming up")
+	}
+	done()
+	done()
+	if !store.Ready() {
+		t.Error("Expected the store to be ready once warmed up")
+	}
+}
@@ -0,0 +1,132 @@
+package fastentity
+
+import (
+	"container/list"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+// KeepRecent bounds the group to the n entities added to it most recently, removing the
+// oldest as others are added, for groups learned from a stream such as recently seen
+// usernames, which would otherwise grow forever. Adding an entity the group already has
+// makes it the most recent again. Entities already in the group are kept in no particular
+// order, and the excess removed, and n of 0 removes the bound.
+func KeepRecent(n int) GroupOption {
+	return func(g *group) {
+		g.keepRecent(n)
+	}
+}
+
+// recentEntity is an entity of a group bounded by KeepRecent, by its text and its lower
+// case text.
+type recentEntity struct {
+	key  string
+	text []rune
+}
+
+// recentEntities orders the entities of a group bounded by KeepRecent from the most
+// recently added.
+type recentEntities struct {
+	max   int
+	order *list.List               // of recentEntity
+	index map[string]*list.Element // by key
+}
+
+// keepRecent bounds the group to its n most recent entities. The caller must hold the
+// group lock.
+func (g *group) keepRecent(n int) {
+	if n <= 0 {
+		g.recent = nil
+		return
+	}
+	if g.recent == nil {
+		g.recent = &recentEntities{order: list.New(), index: make(map[string]*list.Element)}
+		for _, ents := range g.entities {
+			for _, e := range ents {
+				g.touch(strings.ToLower(string(e.text)), e.text)
+			}
+		}
+	}
+	g.recent.max = n
+	g.forgetOldest()
+}
+
+// touch makes the entity with the text and lower case text key the most recent. The
+// caller must hold the group lock.
+func (g *group) touch(key string, text []rune) {
+	if el, ok := g.recent.index[key]; ok {
+		g.recent.order.MoveToFront(el)
+		return
+	}
+	g.recent.index[key] = g.recent.order.PushFront(recentEntity{key: key, text: text})
+}
+
+// forgetOldest removes the oldest entities of the group until it has no more than its
+// bound. The caller must hold the group lock.
+func (g *group) forgetOldest() {
+	var oldest [][]rune
+	for g.recent.order.Len() > g.recent.max {
+		el := g.recent.order.Back()
+		re := g.recent.order.Remove(el).(recentEntity)
+		delete(g.recent.index, re.key)
+		oldest = append(oldest, re.text)
+	}
+	if len(oldest) == 0 {
+		return
+	}
+	if g.normalized != nil || g.acronyms != nil || g.phonetic != nil || g.synonyms != nil {
+		// Other indices are rebuilt from the remaining entities
+		g.remove(oldest)
+		return
+	}
+	for _, text := range oldest {
+		g.forget(text)
+	}
+}
+
+// forget removes the entity text from a group indexed by text alone, without rebuilding
+// its index as remove does. The caller must hold the group lock.
+func (g *group) forget(text []rune) {
+	h := hash(text)
+	ents := g.entities[h]
+	for i, e := range ents {
+		if !equalFold(e.text, text) {
+			continue
+		}
+		atomic.AddInt64(&g.size, -entrySize(e))
+		if g.counts != nil {
+			delete(g.counts, string(e.text))
+		}
+		// Copy the bucket, which may be shared with clones of the group
+		if len(ents) == 1 {
+			delete(g.entities, h)
+		} else {
+			g.entities[h] = append(ents[:i:i], ents[i+1:]...)
+		}
+		delete(g.folded, strings.ToLower(string(e.text)))
+		return
+	}
+}
+
+// clone copies the order of the entities.
+func (r *recentEntities) clone() *recentEntities {
+	c := &recentEntities{max: r.max, order: list.New(), index: make(map[string]*list.Element, len(r.index))}
+	for el := r.order.Back(); el != nil; el = el.Prev() {
+		re := el.Value.(recentEntity)
+		c.index[re.key] = c.order.PushFront(re)
+	}
+	return c
+}
+
+// oldestFirst sorts the entities ents of the group from the least recently added, so that
+// adding them again keeps their order. The caller must hold the group lock.
+func (g *group) oldestFirst(ents []entry) {
+	age := make(map[string]int, g.recent.order.Len())
+	for el := g.recent.order.Front(); el != nil; el = el.Next() {
+		age[el.Value.(recentEntity).key] = len(age)
+	}
+	sort.SliceStable(ents, func(i, j int) bool {
+		return age[strings.ToLower(string(ents[i].text))] > age[strings.ToLower(string(ents[j].text))]
+	})
+}
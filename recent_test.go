@@ -0,0 +1,51 @@
+package fastentity
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+)
+
+func TestKeepRecent(t *testing.T) {
+	for _, opts := range [][]GroupOption{{KeepRecent(3)}, {KeepRecent(3), FoldPlurals()}} {
+		store := New()
+		g := store.Group("users")
+		g.Configure(opts...)
+		entities := func() []string {
+			var es []string
+			g.Range(func(e []rune) bool {
+				es = append(es, string(e))
+				return true
+			})
+			sort.Strings(es)
+			return es
+		}
+
+		store.Add("users", []rune("alice"), []rune("bob"), []rune("carol"))
+		store.Add("users", []rune("Alice")) // Most recent again
+		store.Add("users", []rune("dave"))
+		if want := []string{"alice", "carol", "dave"}; !reflect.DeepEqual(entities(), want) {
+			t.Errorf("Expected %q, got %q", want, entities())
+		}
+		if r := store.FindAll([]rune("bob and dave")); len(r["users"]) != 1 {
+			t.Errorf("Expected only dave to be found, got %v", r["users"])
+		}
+
+		store.Remove("users", []rune("carol"))
+		store.Add("users", []rune("erin"), []rune("frank"))
+		if want := []string{"dave", "erin", "frank"}; !reflect.DeepEqual(entities(), want) {
+			t.Errorf("Expected %q, got %q", want, entities())
+		}
+
+		// Reducing the bound drops the excess
+		g.Configure(KeepRecent(1))
+		if n := g.Len(); n != 1 {
+			t.Errorf("Expected 1 entity, got %d", n)
+		}
+		g.Configure(KeepRecent(0))
+		store.Add("users", []rune("grace"), []rune("heidi"))
+		if n := g.Len(); n != 3 {
+			t.Errorf("Expected 3 entities once unbounded, got %d", n)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package fastentity
+
+import (
+	"strings"
+	"sync/atomic"
+)
+
+// Remove removes the entities from the group identified by name, ignoring case, returning
+// the number removed. Entities the group doesn't have are skipped. The indices of the
+// group, such as those of Normalize and Acronyms, are rebuilt from the remaining entities,
+// taking time in proportion to the size of the group, so entities are best removed many
+// at a time.
+func (s *Store) Remove(name string, entities ...[]rune) int {
+	s.recordEntities("", AuditRemove, name, nil, entities...)
+	return s.remove(name, entities)
+}
+
+// RemoveAs removes the entities from the group identified by name as Remove does,
+// recording actor as having removed them. If the audit sink fails to record the change,
+// the entities aren't removed and the error is returned, as is an error wrapping
+// ErrGroupFrozen if the group is frozen.
+func (s *Store) RemoveAs(actor, name string, entities ...[]rune) error {
+	if err := s.checkFrozen(name); err != nil {
+		return err
+	}
+	if err := s.recordEntities(actor, AuditRemove, name, nil, entities...); err != nil {
+		return err
+	}
+	s.remove(name, entities)
+	return nil
+}
+
+// Remove removes the entities from the group, see Store.Remove.
+func (g *Group) Remove(entities ...[]rune) int {
+	return g.s.Remove(g.name, entities...)
+}
+
+// remove removes the entities from the group identified by name, if it exists, returning
+// the number removed.
+func (s *Store) remove(name string, entities [][]rune) int {
+	s.RLock()
+	g, ok := s.groups[name]
+	s.RUnlock()
+	if !ok {
+		return 0
+	}
+	for {
+		g.load()
+		g.Lock()
+		if atomic.LoadUint32(&g.lazy) == 0 {
+			break
+		}
+		g.Unlock() // Evicted before it was locked
+	}
+	n := g.remove(entities)
+	g.Unlock()
+	if n > 0 {
+		s.bump()
+	}
+	return n
+}
+
+// remove removes the entities from the group, returning the number removed, and rebuilds
+// its indices from those left. The caller must hold the group lock.
+func (g *group) remove(entities [][]rune) int {
+	if g.frozen {
+		return 0
+	}
+	removed := make(map[string]bool, len(entities))
+	for _, e := range entities {
+		key := strings.ToLower(string(e))
+		if _, ok := g.folded[key]; ok {
+			removed[key] = true
+		}
+	}
+	if len(removed) == 0 {
+		return 0
+	}
+	kept := make([]entry, 0, g.len()-len(removed))
+	for _, ents := range g.entities {
+		for _, e := range ents {
+			if !removed[strings.ToLower(string(e.text))] {
+				kept = append(kept, e)
+			} else if g.counts != nil {
+				delete(g.counts, string(e.text))
+			}
+		}
+	}
+	if g.recent != nil {
+		g.oldestFirst(kept)
+	}
+	g.reset()
+	atomic.StoreInt64(&g.size, 0)
+	for _, e := range kept {
+		g.add(e)
+	}
+	return len(removed)
+}
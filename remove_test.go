@@ -0,0 +1,44 @@
+package fastentity
+
+import (
+	"testing"
+)
+
+func TestRemove(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("Go"), []rune("Machine Learning"), []rune("Rust"))
+	store.Group("skills").Configure(Acronyms(), FoldPlurals())
+	if err := store.CopyGroup("skills", "copy"); err != nil {
+		t.Fatal(err)
+	}
+
+	if n := store.Remove("skills", []rune("machine learning"), []rune("Haskell")); n != 1 {
+		t.Errorf("Expected 1 entity removed, got %d", n)
+	}
+	if n := store.Remove("missing", []rune("Go")); n != 0 {
+		t.Errorf("Expected nothing removed from a missing group, got %d", n)
+	}
+	if _, err := store.LookupGroup("missing"); err == nil {
+		t.Error("Expected Remove not to create the group")
+	}
+
+	doc := []rune("Go, Rust, ML and Machine Learning")
+	found := store.FindAll(doc)
+	if got := len(found["skills"]); got != 2 {
+		t.Errorf("Expected the removed entity and its acronym not to be found, got %v", found["skills"])
+	}
+	if got := len(found["copy"]); got != 4 {
+		t.Errorf("Expected the copy to keep the entity, got %v", found["copy"])
+	}
+	if n := store.Group("skills").Len(); n != 2 {
+		t.Errorf("Expected 2 entities left, got %d", n)
+	}
+
+	// The entity can be added again
+	if store.Add("skills", []rune("Machine learning")) != 0 {
+		t.Error("Expected the removed entity not to be a duplicate")
+	}
+	if got := len(store.FindAll(doc)["skills"]); got != 4 {
+		t.Errorf("Expected the entity to be found again, got %d matches", got)
+	}
+}
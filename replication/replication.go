@@ -0,0 +1,504 @@
+// Package replication keeps the dictionaries of a cluster of matcher instances in step,
+// without each instance reading the full dictionaries whenever they change.
+//
+// The leader records the changes made to its store in a Log, its audit sink, and serves
+// the log over HTTP. Followers start from the same dictionaries as the leader and tail the
+// log, applying each change to their own store:
+//
+//	// Leader
+//	log := replication.NewLog(100000)
+//	store.SetAuditSink(log)
+//	http.Handle("/oplog", log)
+//
+//	// Follower
+//	f := replication.NewFollower(store, "http://leader:8080/oplog", 0)
+//	err := f.Run(ctx)
+//
+// Changes are sent as a JSON array of Ops, e.g.
+//
+//	[{"seq": 1, "time": "2024-05-01T10:00:00Z", "actor": "alice", "group": "skills", "op": "add", "entities": ["rust"]}]
+//
+// The log only holds the latest changes, and is lost when the leader restarts, so
+// followers which fall too far behind must start again from the leader's dictionaries,
+// e.g. from a snapshot.
+//
+// Changes can also be delivered by other means, such as a message queue, with
+// Follower.Apply, in any order. Adds and removes are applied as they arrive, with each
+// entity keeping the latest change to it, and removes leaving tombstones so that an add
+// made before a remove but delivered after it isn't applied. Tombstones are compacted
+// once every change before them has been applied. Renames, copies and rollbacks are
+// barriers, applied in order with every change before them, and changes after them
+// wait for them.
+package replication
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/sajari/fastentity"
+)
+
+// ErrTruncated is returned when following a log from a change which has been dropped
+// from it, so the follower can no longer catch up.
+var ErrTruncated = errors.New("log truncated")
+
+// maxWait is the longest a request for changes waits for one to be made.
+const maxWait = time.Minute
+
+// maxOps is the most changes returned by a request.
+const maxOps = 1000
+
+// Op is a change in a Log, numbered in the order the changes were made from 1.
+type Op struct {
+	Seq uint64 `json:"seq"`
+	// Barrier is the number of the latest rename, copy or rollback made before the
+	// change, which must be applied before it.
+	Barrier uint64 `json:"barrier,omitempty"`
+	fastentity.AuditEvent
+}
+
+// isBarrier reports whether changes of the kind op must be applied in order.
+func isBarrier(op fastentity.AuditOp) bool {
+	return op != fastentity.AuditAdd && op != fastentity.AuditRemove
+}
+
+// Log is an op-log of the changes made to a store, which is an AuditSink for the store
+// and an http.Handler serving the changes to followers. The handler responds to GET
+// requests with the changes after the one numbered by the query parameter "after",
+// waiting for up to the duration "wait", e.g. "30s", for one to be made if there are
+// none. Requests for changes dropped from the log fail with status 410.
+//
+// A Log is safe for concurrent use.
+type Log struct {
+	mu      sync.Mutex
+	ops     []Op
+	first   uint64 // Seq of ops[0]
+	barrier uint64 // Seq of the latest barrier
+	limit   int
+	changed chan struct{} // closed when a change is made
+}
+
+// NewLog returns an empty log keeping the latest limit changes, or every change if limit
+// is 0.
+func NewLog(limit int) *Log {
+	return &Log{
+		first:   1,
+		limit:   limit,
+		changed: make(chan struct{}),
+	}
+}
+
+// Record adds the change e to the log.
+func (l *Log) Record(e fastentity.AuditEvent) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	op := Op{Seq: l.first + uint64(len(l.ops)), Barrier: l.barrier, AuditEvent: e}
+	if isBarrier(e.Op) {
+		l.barrier = op.Seq
+	}
+	l.ops = append(l.ops, op)
+	if n := len(l.ops) - l.limit; l.limit > 0 && n > 0 {
+		l.ops = append(l.ops[:0:0], l.ops[n:]...)
+		l.first += uint64(n)
+	}
+	close(l.changed)
+	l.changed = make(chan struct{})
+	return nil
+}
+
+// History returns the changes to the group still held in the log, oldest first.
+func (l *Log) History(group string) ([]fastentity.AuditEvent, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var events []fastentity.AuditEvent
+	for _, op := range l.ops {
+		if op.Group == group || op.To == group {
+			events = append(events, op.AuditEvent)
+		}
+	}
+	return events, nil
+}
+
+// Seq returns the number of the latest change, or 0 if none have been made.
+func (l *Log) Seq() uint64 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.first + uint64(len(l.ops)) - 1
+}
+
+// Since returns up to max of the changes made after the one numbered after, waiting
+// until ctx is done for one to be made if there are none. It returns ErrTruncated if
+// changes after it have been dropped from the log.
+func (l *Log) Since(ctx context.Context, after uint64, max int) ([]Op, error) {
+	for {
+		l.mu.Lock()
+		if after+1 < l.first {
+			l.mu.Unlock()
+			return nil, ErrTruncated
+		}
+		if i := after + 1 - l.first; i < uint64(len(l.ops)) {
+			ops := l.ops[i:]
+			if len(ops) > max {
+				ops = ops[:max]
+			}
+			ops = append([]Op(nil), ops...)
+			l.mu.Unlock()
+			return ops, nil
+		}
+		changed := l.changed
+		l.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, nil
+		}
+	}
+}
+
+func (l *Log) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	q := r.URL.Query()
+	var after uint64
+	if s := q.Get("after"); s != "" {
+		var err error
+		if after, err = strconv.ParseUint(s, 10, 64); err != nil {
+			httpError(w, http.StatusBadRequest, "invalid after: "+err.Error())
+			return
+		}
+	}
+	var wait time.Duration
+	if s := q.Get("wait"); s != "" {
+		var err error
+		if wait, err = time.ParseDuration(s); err != nil {
+			httpError(w, http.StatusBadRequest, "invalid wait: "+err.Error())
+			return
+		}
+		if wait > maxWait {
+			wait = maxWait
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), wait)
+	defer cancel()
+	ops, err := l.Since(ctx, after, maxOps)
+	if err != nil {
+		httpError(w, http.StatusGone, err.Error())
+		return
+	}
+	if ops == nil {
+		ops = []Op{}
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(ops)
+}
+
+func httpError(w http.ResponseWriter, status int, msg string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{"error": msg})
+}
+
+// Follower applies the changes served by a leader's Log to a store.
+type Follower struct {
+	seq    uint64 // accessed atomically, first for alignment
+	store  *fastentity.Store
+	url    string
+	client *http.Client
+	wait   time.Duration
+
+	mu      sync.Mutex       // held applying changes
+	ahead   map[uint64]bool  // changes applied after seq
+	pending map[uint64]Op    // changes waiting for a barrier
+	stamps  map[entity]stamp // latest changes to entities after seq
+}
+
+// entity identifies an entity of a group by its lower case text.
+type entity struct {
+	group, text string
+}
+
+// stamp is the latest change applied to an entity, which is a tombstone if it removed
+// the entity.
+type stamp struct {
+	seq     uint64
+	removed bool
+}
+
+// FollowerOption configures a Follower.
+type FollowerOption func(f *Follower)
+
+// Client makes requests to the leader with c rather than http.DefaultClient. Its timeout
+// must allow for requests waiting for changes, see PollWait.
+func Client(c *http.Client) FollowerOption {
+	return func(f *Follower) {
+		f.client = c
+	}
+}
+
+// PollWait is how long each request waits for the leader to make a change, 30 seconds by
+// default.
+func PollWait(d time.Duration) FollowerOption {
+	return func(f *Follower) {
+		f.wait = d
+	}
+}
+
+// NewFollower returns a Follower applying the changes served by the log at url to store,
+// after the change numbered after, which the store already reflects. Followers started
+// from the same dictionaries as the leader, before it made any changes, follow from 0.
+func NewFollower(store *fastentity.Store, url string, after uint64, opts ...FollowerOption) *Follower {
+	f := &Follower{
+		seq:     after,
+		store:   store,
+		url:     url,
+		client:  http.DefaultClient,
+		wait:    30 * time.Second,
+		ahead:   make(map[uint64]bool),
+		pending: make(map[uint64]Op),
+		stamps:  make(map[entity]stamp),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+// Seq returns the number of the latest change applied to the store, after which changes
+// may have been applied too, out of order.
+func (f *Follower) Seq() uint64 {
+	return atomic.LoadUint64(&f.seq)
+}
+
+// Run applies changes to the store as the leader makes them, until ctx is cancelled. The
+// leader is retried with backoff when it can't be reached. Run returns ctx.Err() once ctx
+// is cancelled, ErrTruncated if the follower has fallen too far behind to catch up, or an
+// error if a change can't be applied.
+//
+// The store isn't Ready until the follower has caught up with the changes the leader had
+// made when Run was called, or Run returns.
+func (f *Follower) Run(ctx context.Context) error {
+	warming := f.store.WarmUp()
+	defer warming()
+	backoff, wait := time.Duration(0), time.Duration(0)
+	for {
+		ops, err := f.fetch(ctx, wait)
+		if errors.Is(err, ErrTruncated) {
+			return err
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if backoff = 2 * backoff; backoff == 0 {
+				backoff = time.Second
+			} else if backoff > 30*time.Second {
+				backoff = 30 * time.Second
+			}
+			select {
+			case <-time.After(backoff):
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+			continue
+		}
+		backoff = 0
+		for _, op := range ops {
+			if err := f.Apply(op); err != nil {
+				return err
+			}
+		}
+		if len(ops) < maxOps {
+			warming()
+			wait = f.wait
+		}
+	}
+}
+
+// fetch requests the changes after the latest applied, waiting up to wait for one to be
+// made.
+func (f *Follower) fetch(ctx context.Context, wait time.Duration) ([]Op, error) {
+	u, err := url.Parse(f.url)
+	if err != nil {
+		return nil, err
+	}
+	q := u.Query()
+	q.Set("after", strconv.FormatUint(f.Seq(), 10))
+	q.Set("wait", wait.String())
+	u.RawQuery = q.Encode()
+	req, err := http.NewRequest(http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := f.client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusGone:
+		return nil, ErrTruncated
+	default:
+		return nil, fmt.Errorf("leader responded with %v", resp.Status)
+	}
+	var ops []Op
+	if err := json.NewDecoder(resp.Body).Decode(&ops); err != nil {
+		return nil, err
+	}
+	return ops, nil
+}
+
+// Apply applies the change op to the store, for changes delivered other than by Run,
+// which may be out of order or more than once. Adds and removes are applied once the
+// barrier before them has been, and renames, copies and rollbacks once every change
+// before them has been, so Apply keeps changes delivered early until then. Changes
+// already applied are ignored. Apply returns an error if a change can't be applied.
+func (f *Follower) Apply(op Op) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if op.Seq <= f.Seq() || f.ahead[op.Seq] {
+		return nil
+	}
+	f.pending[op.Seq] = op
+	return f.advance()
+}
+
+// ready reports whether the change op can be applied.
+func (f *Follower) ready(op Op) bool {
+	if isBarrier(op.Op) {
+		return op.Seq == f.Seq()+1
+	}
+	return op.Barrier <= f.Seq()
+}
+
+// advance applies the pending changes which are ready, moving seq past the changes
+// applied, and compacts the stamps of the changes before it.
+func (f *Follower) advance() error {
+	for progress := true; progress; {
+		progress = false
+		for seq, op := range f.pending {
+			if !f.ready(op) {
+				continue
+			}
+			delete(f.pending, seq)
+			if err := f.apply(op); err != nil {
+				return err
+			}
+			f.ahead[seq] = true
+			progress = true
+		}
+		for seq := f.Seq() + 1; f.ahead[seq]; seq++ {
+			delete(f.ahead, seq)
+			atomic.StoreUint64(&f.seq, seq)
+		}
+	}
+	// No change before seq can arrive to be ordered against the stamps
+	for e, st := range f.stamps {
+		if st.seq <= f.Seq() {
+			delete(f.stamps, e)
+		}
+	}
+	return nil
+}
+
+// apply makes the change op to the store.
+func (f *Follower) apply(op Op) error {
+	switch op.Op {
+	case fastentity.AuditAdd:
+		if err := f.add(op); err != nil {
+			return err
+		}
+	case fastentity.AuditRemove:
+		f.remove(op)
+	case fastentity.AuditRename:
+		if err := f.store.RenameGroup(op.Group, op.To); err != nil {
+			return fmt.Errorf("change %d: %w", op.Seq, err)
+		}
+	case fastentity.AuditCopy:
+		if err := f.store.CopyGroup(op.Group, op.To); err != nil {
+			return fmt.Errorf("change %d: %w", op.Seq, err)
+		}
+	case fastentity.AuditRollback:
+		if err := f.rollback(op); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("change %d has unsupported op %q", op.Seq, op.Op)
+	}
+	return nil
+}
+
+// latest records op as the latest change to the entity e of its group, returning false
+// if a later change to it has already been applied.
+func (f *Follower) latest(op Op, e string) bool {
+	k := entity{op.Group, strings.ToLower(e)}
+	if st, ok := f.stamps[k]; ok && st.seq > op.Seq {
+		return false
+	}
+	f.stamps[k] = stamp{seq: op.Seq, removed: op.Op == fastentity.AuditRemove}
+	return true
+}
+
+// add adds the entities of op to the store, with their weights, unless they have been
+// changed since.
+func (f *Follower) add(op Op) error {
+	if op.Weights != nil && len(op.Weights) != len(op.Entities) {
+		return fmt.Errorf("change %d has %d weights for %d entities", op.Seq, len(op.Weights), len(op.Entities))
+	}
+	for i, e := range op.Entities {
+		if !f.latest(op, e) {
+			continue
+		}
+		if op.Weights != nil {
+			f.store.AddWeighted(op.Group, []rune(e), op.Weights[i])
+		} else {
+			f.store.Add(op.Group, []rune(e))
+		}
+	}
+	return nil
+}
+
+// remove removes the entities of op from the store, leaving tombstones, unless they have
+// been changed since.
+func (f *Follower) remove(op Op) {
+	var ents [][]rune
+	for _, e := range op.Entities {
+		if f.latest(op, e) {
+			ents = append(ents, []rune(e))
+		}
+	}
+	f.store.Remove(op.Group, ents...)
+}
+
+// rollback replaces the entities of the group of op with those it lists. The follower
+// needn't have the version rolled back to, but groups the rollback removed are left
+// empty rather than removed, and entities the group already has keep their weights.
+func (f *Follower) rollback(op Op) error {
+	keep := make(map[string]bool, len(op.Entities))
+	for _, e := range op.Entities {
+		keep[strings.ToLower(e)] = true
+	}
+	var stale [][]rune
+	f.store.Group(op.Group).Range(func(e []rune) bool {
+		if !keep[strings.ToLower(string(e))] {
+			stale = append(stale, e)
+		}
+		return true
+	})
+	f.store.Remove(op.Group, stale...)
+	return f.add(op)
+}
@@ -0,0 +1,138 @@
+package replication
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sajari/fastentity"
+)
+
+func TestReplication(t *testing.T) {
+	log := NewLog(0)
+	leader := fastentity.New()
+	leader.Add("skills", []rune("go"))
+	leader.SetAuditSink(log)
+	srv := httptest.NewServer(log)
+	defer srv.Close()
+
+	follower := fastentity.New()
+	follower.Add("skills", []rune("go"))
+	f := NewFollower(follower, srv.URL, 0, PollWait(time.Second))
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- f.Run(ctx) }()
+
+	leader.AddAs("alice", "skills", []rune("rust"), []rune("python"), []rune("perl"))
+	leader.AddWeighted("locations", []rune("Sydney"), 2)
+	leader.Remove("skills", []rune("Perl"))
+	leader.CopyGroup("locations", "cities")
+	leader.RenameGroup("cities", "places")
+	deadline := time.Now().Add(5 * time.Second)
+	for f.Seq() < log.Seq() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !follower.Ready() {
+		t.Error("Expected the follower to be ready once caught up")
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+
+	if f.Seq() != 5 {
+		t.Fatalf("Expected 5 changes applied, got %d", f.Seq())
+	}
+	doc := []rune("go, rust, perl and python in Sydney")
+	want, got := leader.FindAll(doc), follower.FindAll(doc)
+	for group, ents := range want {
+		if len(got[group]) != len(ents) {
+			t.Errorf("Expected %v in %s, got %v", ents, group, got[group])
+		}
+	}
+	if ents := got["locations"]; len(ents) != 1 || ents[0].Weight != 2 {
+		t.Errorf("Expected Sydney with weight 2, got %v", ents)
+	}
+	if len(got["places"]) != 1 {
+		t.Errorf("Expected the renamed copy, got %v", got)
+	}
+	if h, _ := log.History("skills"); len(h) != 2 || h[0].Actor != "alice" {
+		t.Errorf("Unexpected history %v", h)
+	}
+}
+
+func TestLogTruncated(t *testing.T) {
+	log := NewLog(2)
+	for i := 0; i < 5; i++ {
+		log.Record(fastentity.AuditEvent{Group: "skills", Op: fastentity.AuditAdd, Entities: []string{"go"}})
+	}
+	if log.Seq() != 5 {
+		t.Errorf("Expected seq 5, got %d", log.Seq())
+	}
+	ctx := context.Background()
+	if ops, err := log.Since(ctx, 3, 10); err != nil || len(ops) != 2 || ops[0].Seq != 4 {
+		t.Errorf("Expected changes 4 and 5, got %v, %v", ops, err)
+	}
+	if _, err := log.Since(ctx, 2, 10); !errors.Is(err, ErrTruncated) {
+		t.Errorf("Expected ErrTruncated, got %v", err)
+	}
+
+	srv := httptest.NewServer(log)
+	defer srv.Close()
+	f := NewFollower(fastentity.New(), srv.URL, 0)
+	if err := f.Run(ctx); !errors.Is(err, ErrTruncated) {
+		t.Errorf("Expected ErrTruncated, got %v", err)
+	}
+}
+
+func TestFollowerRollback(t *testing.T) {
+	store := fastentity.New()
+	store.Add("skills", []rune("go"), []rune("perl"))
+	f := NewFollower(store, "", 0)
+	op := Op{Seq: 1, AuditEvent: fastentity.AuditEvent{Group: "skills", Op: fastentity.AuditRollback, Version: "v1", Entities: []string{"Go", "rust"}}}
+	if err := f.Apply(op); err != nil {
+		t.Fatal(err)
+	}
+	if got := store.FindAll([]rune("go, perl and rust"))["skills"]; len(got) != 2 || string(got[1].Canonical) != "rust" {
+		t.Errorf("Expected the entities of the version, got %v", got)
+	}
+}
+
+func TestFollowerOutOfOrder(t *testing.T) {
+	log := NewLog(0)
+	for _, e := range []fastentity.AuditEvent{
+		{Group: "skills", Op: fastentity.AuditAdd, Entities: []string{"rust"}},
+		{Group: "skills", Op: fastentity.AuditRemove, Entities: []string{"Rust"}},
+		{Group: "skills", Op: fastentity.AuditAdd, Entities: []string{"perl"}},
+		{Group: "skills", Op: fastentity.AuditRename, To: "languages"},
+		{Group: "languages", Op: fastentity.AuditAdd, Entities: []string{"python"}},
+		{Group: "languages", Op: fastentity.AuditRemove, Entities: []string{"perl"}},
+	} {
+		log.Record(e)
+	}
+	ops, _ := log.Since(context.Background(), 0, 10)
+	if ops[4].Barrier != 4 || ops[3].Barrier != 0 {
+		t.Errorf("Expected changes after the rename to wait for it, got %+v", ops)
+	}
+
+	store := fastentity.New()
+	store.Add("skills", []rune("go"))
+	f := NewFollower(store, "", 0)
+	for i, seq := range []int{2, 6, 5, 1, 4, 3, 2} {
+		if err := f.Apply(ops[seq-1]); err != nil {
+			t.Fatal(err)
+		}
+		if i == 0 && !f.stamps[entity{"skills", "rust"}].removed {
+			t.Errorf("Expected a tombstone for rust, got %v", f.stamps)
+		}
+	}
+	if f.Seq() != 6 || len(f.pending) != 0 || len(f.stamps) != 0 {
+		t.Errorf("Expected every change applied and compacted, got %d, %v, %v", f.Seq(), f.pending, f.stamps)
+	}
+	got := store.FindAll([]rune("go, rust, perl and python"))
+	if len(got["languages"]) != 2 || string(got["languages"][1].Text) != "python" || len(got["skills"]) != 0 {
+		t.Errorf("Expected go and python, got %v", got)
+	}
+}
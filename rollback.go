@@ -0,0 +1,185 @@
+package fastentity
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	// ErrNotVersioned is returned when tagging or rolling back a store without a version
+	// directory, see SetVersionDir.
+	ErrNotVersioned = errors.New("no version directory")
+	// ErrVersionNotFound is returned when rolling back to a version which hasn't been
+	// tagged.
+	ErrVersionNotFound = errors.New("version not found")
+	// ErrVersionExists is returned when tagging a version which has already been tagged.
+	ErrVersionExists = errors.New("version already exists")
+)
+
+// versionExt is the extension of the snapshots of tagged versions.
+const versionExt = ".snap"
+
+// SetVersionDir keeps the versions tagged with Tag as snapshots in dir, named by version
+// with the extension ".snap", so they can be restored with Rollback.
+func (s *Store) SetVersionDir(dir string) {
+	s.Lock()
+	s.versionDir = dir
+	s.Unlock()
+}
+
+// Tag saves the current entities of the store as the named version, e.g. before making
+// changes to the dictionaries. Versions can't be overwritten, so tagging a version again
+// returns an error wrapping ErrVersionExists. Names must be valid file names.
+func (s *Store) Tag(version string) error {
+	p, err := s.versionPath(version)
+	if err != nil {
+		return err
+	}
+	if f, err := openFile(p); err == nil {
+		f.Close()
+		return fmt.Errorf("%q: %w", version, ErrVersionExists)
+	}
+	if err := mkdirAll(filepath.Dir(p)); err != nil {
+		return err
+	}
+	// Write to a temporary file so that a partial snapshot is never a version
+	tmp := p + ".tmp"
+	if err := s.SaveSnapshot(tmp, Compress(Gzip)); err != nil {
+		return err
+	}
+	return renameFile(tmp, p)
+}
+
+// Versions returns the names of the versions tagged in the version directory, in order
+// of name.
+func (s *Store) Versions() ([]string, error) {
+	s.RLock()
+	dir := s.versionDir
+	s.RUnlock()
+	if dir == "" {
+		return nil, ErrNotVersioned
+	}
+	files, err := listFiles(dir, false)
+	if err != nil {
+		return nil, err
+	}
+	var versions []string
+	for _, f := range files {
+		if strings.HasSuffix(f, versionExt) {
+			versions = append(versions, strings.TrimSuffix(f, versionExt))
+		}
+	}
+	sort.Strings(versions)
+	return versions, nil
+}
+
+// Rollback replaces the entities of the store with those of the named version, returning
+// an error wrapping ErrVersionNotFound if it hasn't been tagged. The version is read
+// before any groups are changed, and then replaces all of them at once, so searches see
+// either the entities before the rollback or those of the version. Entities added during
+// the rollback are lost.
+//
+// Groups keep their configuration, such as synonyms and normalizers, and frozen groups
+// stay frozen, while groups which weren't in the version are removed, and new groups are
+// created empty of options. The version of the store, see Store.Version, still increases.
+func (s *Store) Rollback(version string) error {
+	p, err := s.versionPath(version)
+	if err != nil {
+		return err
+	}
+	f, err := openFile(p)
+	if err != nil {
+		return fmt.Errorf("%q: %v: %w", version, err, ErrVersionNotFound)
+	}
+	restored, err := ReadSnapshot(f)
+	f.Close()
+	if err != nil {
+		return fmt.Errorf("reading version %q: %w", version, err)
+	}
+
+	s.RLock()
+	groups := make(map[string]*group, len(restored.groups))
+	for name, r := range restored.groups {
+		var g *group
+		if old, ok := s.groups[name]; ok {
+			old.RLock()
+			g = old.clone(name)
+			old.RUnlock()
+			// Frozen groups are thawed to add the entities, and frozen again after
+			frozen := g.frozen
+			g.frozen, g.exact = false, nil
+			g.reset()
+			g.lazy, g.source, g.loadErr = 0, nil, nil
+			atomic.StoreInt64(&g.size, 0)
+			for _, e := range r.all() {
+				g.add(e)
+			}
+			if frozen {
+				g.freeze()
+				g.frozen = true
+			}
+		} else {
+			g = s.newGroup(name)
+			for _, e := range r.all() {
+				g.add(e)
+			}
+		}
+		groups[name] = g
+	}
+	s.RUnlock()
+
+	s.Lock()
+	removed := make([]string, 0, len(s.groups))
+	for name := range s.groups {
+		if _, ok := groups[name]; !ok {
+			removed = append(removed, name)
+		}
+	}
+	s.groups = groups
+	s.Unlock()
+	s.bump()
+
+	names := make([]string, 0, len(restored.groups))
+	for name := range restored.groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		ents := restored.groups[name].all()
+		sort.Slice(ents, func(i, j int) bool { return string(ents[i].text) < string(ents[j].text) })
+		s.recordRollback(name, version, ents)
+	}
+	sort.Strings(removed)
+	for _, name := range removed {
+		s.recordRollback(name, version, nil)
+	}
+	return nil
+}
+
+// recordRollback records the group identified by name being rolled back to version,
+// leaving it with the entries ents.
+func (s *Store) recordRollback(name, version string, ents []entry) {
+	e := AuditEvent{Group: name, Op: AuditRollback, Version: version}
+	if ents != nil {
+		e.Entities, e.Weights = auditEntries(ents)
+	}
+	s.record(e)
+}
+
+// versionPath returns the path of the snapshot of the named version.
+func (s *Store) versionPath(version string) (string, error) {
+	s.RLock()
+	dir := s.versionDir
+	s.RUnlock()
+	if dir == "" {
+		return "", ErrNotVersioned
+	}
+	if version == "" || version == "." || version == ".." || strings.ContainsAny(version, `/\`) {
+		return "", fmt.Errorf("invalid version name %q", version)
+	}
+	return filepath.Join(dir, version+versionExt), nil
+}
@@ -0,0 +1,106 @@
+package fastentity
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestRollback(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := New()
+	if err := store.Tag("v1"); !errors.Is(err, ErrNotVersioned) {
+		t.Errorf("Expected ErrNotVersioned, got %v", err)
+	}
+	store.SetVersionDir(dir)
+	store.Add("locations", []rune("New York City"))
+	store.Group("locations").Configure(Synonyms([]string{"NYC", "New York City"}))
+	store.AddWeighted("skills", []rune("go"), 2)
+	if err := store.Tag("v1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.Tag("v1"); !errors.Is(err, ErrVersionExists) {
+		t.Errorf("Expected ErrVersionExists, got %v", err)
+	}
+	if err := store.Tag("../v1"); err == nil {
+		t.Error("Expected an invalid version name")
+	}
+
+	// A bad edit
+	store.Add("locations", []rune("Sydney"))
+	store.Add("spam", []rune("buy now"))
+	if err := store.Tag("v2"); err != nil {
+		t.Fatal(err)
+	}
+	if versions, err := store.Versions(); err != nil || !reflect.DeepEqual(versions, []string{"v1", "v2"}) {
+		t.Errorf("Expected versions v1 and v2, got %v, %v", versions, err)
+	}
+
+	version := store.Version()
+	if err := store.Rollback("v1"); err != nil {
+		t.Fatal(err)
+	}
+	if store.Version() <= version {
+		t.Errorf("Expected the version to increase from %d, got %d", version, store.Version())
+	}
+	found := store.FindAll([]rune("From NYC to Sydney, buy now and learn go"))
+	if len(found["locations"]) != 1 || string(found["locations"][0].Canonical) != "New York City" {
+		t.Errorf("Expected only the synonym of New York City, got %v", found["locations"])
+	}
+	if len(found["skills"]) != 1 || found["skills"][0].Weight != 2 {
+		t.Errorf("Expected go with weight 2, got %v", found["skills"])
+	}
+	if _, ok := found["spam"]; ok {
+		t.Error("Expected the spam group to be removed")
+	}
+
+	if err := store.Rollback("v3"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("Expected ErrVersionNotFound, got %v", err)
+	}
+}
+
+func TestRollbackFrozen(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := New()
+	store.SetVersionDir(dir)
+	store.Add("skills", []rune("python"), []rune("golang"))
+	if err := store.Tag("v1"); err != nil {
+		t.Fatal(err)
+	}
+	store.Add("skills", []rune("perl"))
+	if err := store.FreezeGroup("skills"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := store.Rollback("v1"); err != nil {
+		t.Fatal(err)
+	}
+	g := store.Group("skills")
+	if !g.Frozen() || g.Len() != 2 {
+		t.Errorf("Expected the 2 entities of the version, still frozen, got %d (frozen %v)", g.Len(), g.Frozen())
+	}
+	if found := store.FindAll([]rune("python, golang and perl"))["skills"]; len(found) != 2 || string(found[0].Text) != "python" {
+		t.Errorf("Expected python and golang, got %v", found)
+	}
+	if skipped := store.Add("skills", []rune("rust")); skipped != 1 {
+		t.Errorf("Expected rust skipped while the group is frozen, skipped %d", skipped)
+	}
+	if err := store.ThawGroup("skills"); err != nil {
+		t.Fatal(err)
+	}
+	if skipped := store.Add("skills", []rune("python"), []rune("rust")); skipped != 1 {
+		t.Errorf("Expected only python skipped once thawed, skipped %d", skipped)
+	}
+}
@@ -0,0 +1,39 @@
+package fastentity
+
+import "unicode"
+
+// RuneClass is a set of runes declared by Unicode category and range, such as the
+// symbols a Tokenizer treats as word boundaries, which unlike a function can be saved
+// with the store in snapshots.
+type RuneClass struct {
+	// Categories are the names of Unicode general categories, as in unicode.Categories,
+	// e.g. "Sm" for math symbols or "N" for all numbers. Unknown names match no runes.
+	Categories []string
+	// Ranges are ranges of runes.
+	Ranges []RuneRange
+}
+
+// RuneRange is the runes from Lo to Hi inclusive.
+type RuneRange struct {
+	Lo, Hi rune
+}
+
+// Contains reports whether r is in the class.
+func (c RuneClass) Contains(r rune) bool {
+	for _, rr := range c.Ranges {
+		if rr.Lo <= r && r <= rr.Hi {
+			return true
+		}
+	}
+	for _, name := range c.Categories {
+		if t, ok := unicode.Categories[name]; ok && unicode.Is(t, r) {
+			return true
+		}
+	}
+	return false
+}
+
+// empty reports whether the class has no runes declared.
+func (c RuneClass) empty() bool {
+	return len(c.Categories) == 0 && len(c.Ranges) == 0
+}
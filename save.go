@@ -0,0 +1,233 @@
+package fastentity
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// SaveOption configures how entity files are written by Save.
+type SaveOption interface {
+	applySave(c *saveConfig)
+}
+
+type saveOptionFunc func(c *saveConfig)
+
+func (f saveOptionFunc) applySave(c *saveConfig) {
+	f(c)
+}
+
+type saveConfig struct {
+	layout    Layout
+	shardSize int
+	sorted    bool
+	weights   bool
+}
+
+// ShardSize splits groups with more than n entities across several files of at most n
+// entities each, named <group>.00.entities.csv, <group>.01.entities.csv and so on, which
+// are written in parallel. FromDir loads the shards of a group in parallel too.
+func ShardSize(n int) SaveOption {
+	return saveOptionFunc(func(c *saveConfig) {
+		c.shardSize = n
+	})
+}
+
+// Sorted writes the entities of each group in sorted order, so that saving the same
+// entities always produces the same files, which can be diffed meaningfully. Without it
+// entities are written in no particular order, which is faster for large groups.
+func Sorted() SaveOption {
+	return saveOptionFunc(func(c *saveConfig) {
+		c.sorted = true
+	})
+}
+
+// Save writes the existing entities to disk under the given directory path (assumed
+// to already exist). Each entity group becomes a file <group>.entities.csv, and the
+// Manifest of the store is written to the ManifestFile of the directory.
+func (s *Store) Save(dir string, opts ...SaveOption) error {
+	return s.SaveContext(context.Background(), dir, opts...)
+}
+
+// SaveContext is like Save, but aborts writing when ctx is cancelled, returning an error
+// wrapping ctx.Err(). Files already written are left in place.
+//
+// Files left by earlier saves of the groups are removed once the groups are written, so
+// that a group saved unsharded after being saved with ShardSize, or in fewer shards, isn't
+// loaded along with its old shards.
+func (s *Store) SaveContext(ctx context.Context, dir string, opts ...SaveOption) error {
+	var c saveConfig
+	for _, opt := range opts {
+		opt.applySave(&c)
+	}
+
+	s.RLock()
+	defer s.RUnlock()
+
+	dir = strings.TrimRight(dir, "/")
+	counts := make(map[string]int, len(s.groups))
+	for name, g := range s.groups {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("saving to %v: %w", dir, err)
+		}
+
+		ents := g.all()
+		counts[name] = len(ents)
+		if c.sorted {
+			sort.Slice(ents, func(i, j int) bool {
+				return lessRunes(ents[i].text, ents[j].text)
+			})
+		}
+		shards := 0
+		if c.shardSize > 0 && len(ents) > c.shardSize {
+			shards = (len(ents) + c.shardSize - 1) / c.shardSize
+			if err := saveShards(ctx, dir, name, ents, &c); err != nil {
+				return err
+			}
+		} else {
+			path, err := c.layout.path(dir, name, noShard)
+			if err != nil {
+				return err
+			}
+			if err := writeFile(ctx, path, ents, &c); err != nil {
+				return err
+			}
+		}
+		if err := removeStale(dir, name, shards, &c); err != nil {
+			return err
+		}
+	}
+	return writeManifest(dir, s.newManifest(counts))
+}
+
+// removeStale removes the files of the group identified by name left in dir by earlier
+// saves, given the number of shards it was just saved in, or 0 if it wasn't sharded, so
+// that they aren't loaded along with the files written.
+func removeStale(dir, name string, shards int, c *saveConfig) error {
+	path, err := c.layout.path(dir, name, noShard)
+	if err != nil {
+		return err
+	}
+	var stale []string
+	if shards > 0 {
+		stale = append(stale, path)
+	}
+
+	suffix := c.layout.suffix()
+	fileDir, base := filepath.Dir(path), strings.TrimSuffix(filepath.Base(path), suffix)
+	files, err := listFiles(fileDir, false)
+	if err != nil {
+		return fmt.Errorf("error listing %v: %w", fileDir, err)
+	}
+	var names []string
+	for _, f := range files {
+		if strings.HasSuffix(f, suffix) {
+			names = append(names, strings.TrimSuffix(f, suffix))
+		}
+	}
+	for shard, b := range shardBases(names) {
+		if _, n, _ := splitShard(shard); b == base && n >= shards {
+			stale = append(stale, fileDir+"/"+shard+suffix)
+		}
+	}
+
+	for _, p := range stale {
+		if err := removeFile(p); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return fmt.Errorf("error removing %v: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// lessRunes reports whether a sorts before b, comparing rune by rune.
+func lessRunes(a, b []rune) bool {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			return a[i] < b[i]
+		}
+	}
+	return len(a) < len(b)
+}
+
+// saveShards writes the entities of the group identified by name to shard files in
+// parallel.
+func saveShards(ctx context.Context, dir, name string, ents []entry, c *saveConfig) error {
+	n := (len(ents) + c.shardSize - 1) / c.shardSize
+	errs := make([]error, n)
+
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		path, err := c.layout.path(dir, name, i)
+		if err != nil {
+			return err
+		}
+		end := (i + 1) * c.shardSize
+		if end > len(ents) {
+			end = len(ents)
+		}
+
+		wg.Add(1)
+		go func(i int, path string, shard []entry) {
+			defer wg.Done()
+			errs[i] = writeFile(ctx, path, shard, c)
+		}(i, path, ents[i*c.shardSize:end])
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFile writes the entities to the file at path, creating any missing directories.
+func writeFile(ctx context.Context, path string, ents []entry, c *saveConfig) error {
+	if err := mkdirAll(filepath.Dir(path)); err != nil {
+		return fmt.Errorf("error creating directory for %v: %w", path, err)
+	}
+	f, err := createFile(path)
+	if err != nil {
+		return fmt.Errorf("error creating %v: %w", path, err)
+	}
+	defer f.Close()
+
+	err = writeEntities(ctx, f, ents, c.weights)
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("error writing to %v: %w", path, err)
+	}
+	return nil
+}
+
+// writeEntities writes the entities to w, one per line and followed by their weights if
+// set, stopping early if ctx is cancelled.
+func writeEntities(ctx context.Context, w io.Writer, ents []entry, weights bool) error {
+	bw := bufio.NewWriter(w)
+	for i, e := range ents {
+		if i%checkInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+		}
+		line := string(e.text)
+		if weights {
+			line += "," + strconv.FormatFloat(e.weight, 'g', -1, 64)
+		}
+		if _, err := bw.WriteString(line + "\n"); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
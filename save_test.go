@@ -0,0 +1,143 @@
+package fastentity
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestSaveShards(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := New()
+	for i := 0; i < 25; i++ {
+		store.Add("names", []rune(fmt.Sprintf("name%d", i)))
+	}
+	store.Add("skills", []rune("golang"))
+
+	if err := store.Save(dir, ShardSize(10)); err != nil {
+		t.Fatalf("Failed to save store: %v", err)
+	}
+	for _, name := range []string{"names.00", "names.01", "names.02", "skills"} {
+		if _, err := os.Stat(dir + "/" + name + entityFileSuffix); err != nil {
+			t.Errorf("Expected file %s to be written: %v", name, err)
+		}
+	}
+
+	loaded, report, err := LoadDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to load store: %v", err)
+	}
+	if len(report.Files) != 4 {
+		t.Errorf("Expected 4 files to be loaded, got %d", len(report.Files))
+	}
+	if len(loaded.groups) != 2 {
+		t.Errorf("Expected 2 groups, got %d", len(loaded.groups))
+	}
+	if n := loaded.Group("names").Len(); n != 25 {
+		t.Errorf("Expected 25 names, got %d", n)
+	}
+}
+
+func TestShardBases(t *testing.T) {
+	names := []string{
+		"names.00", "names.01", "names.02",
+		"names", "version.1", "names.0a",
+		"a.b.00", "a.b.01",
+		"release.2023", "gaps.00", "gaps.02", "one.00",
+		"skills/x.7",
+	}
+	expected := map[string]string{
+		"names.00": "names",
+		"names.01": "names",
+		"names.02": "names",
+		"a.b.00":   "a.b",
+		"a.b.01":   "a.b",
+	}
+	if got := shardBases(names); !reflect.DeepEqual(got, expected) {
+		t.Errorf("Expected shards %v, got %v", expected, got)
+	}
+}
+
+func TestResave(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := New()
+	for i := 0; i < 4; i++ {
+		store.Add("names", []rune(fmt.Sprintf("name%d", i)))
+	}
+	store.Add("release.2023", []rune("v1"))
+
+	exists := func(name string) bool {
+		_, err := os.Stat(dir + "/" + name + entityFileSuffix)
+		return err == nil
+	}
+	if err := store.Save(dir, ShardSize(1)); err != nil {
+		t.Fatalf("Failed to save store: %v", err)
+	}
+	if !exists("names.03") || exists("names") {
+		t.Fatalf("Expected names to be saved in 4 shards")
+	}
+
+	// Fewer shards, then unsharded, removing the files of earlier saves
+	if err := store.Save(dir, ShardSize(3)); err != nil {
+		t.Fatalf("Failed to save store: %v", err)
+	}
+	if !exists("names.01") || exists("names.02") || exists("names.03") {
+		t.Errorf("Expected names to be saved in 2 shards, removing the others")
+	}
+	if err := store.Save(dir); err != nil {
+		t.Fatalf("Failed to save store: %v", err)
+	}
+	if !exists("names") || exists("names.00") || exists("names.01") {
+		t.Errorf("Expected names to be saved unsharded, removing the shards")
+	}
+	if !exists("release.2023") {
+		t.Errorf("Expected release.2023 to be kept")
+	}
+
+	loaded, err := FromDir(dir)
+	if err != nil {
+		t.Fatalf("Failed to load store: %v", err)
+	}
+	if n := loaded.Group("names").Len(); n != 4 {
+		t.Errorf("Expected 4 names, got %d", n)
+	}
+	if n := loaded.Group("release.2023").Len(); n != 1 {
+		t.Errorf("Expected release.2023 to be loaded as its own group, got %d entities", n)
+	}
+	if len(loaded.groups) != 2 {
+		t.Errorf("Expected 2 groups, got %d", len(loaded.groups))
+	}
+}
+
+func TestSaveSorted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	store := New()
+	store.Add("skills", []rune("golang"), []rune("PHP"), []rune("本語"), []rune("C"), []rune("go"), []rune("Rust"))
+	if err := store.Save(dir, Sorted()); err != nil {
+		t.Fatalf("Failed to save store: %v", err)
+	}
+	b, err := ioutil.ReadFile(dir + "/skills" + entityFileSuffix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if expected := "C\nPHP\nRust\ngo\ngolang\n本語\n"; string(b) != expected {
+		t.Errorf("Expected sorted output %q, got %q", expected, string(b))
+	}
+}
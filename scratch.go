@@ -0,0 +1,64 @@
+package fastentity
+
+// Scratch holds the buffers used while searching a document, which are allocated for each
+// search unless one is passed with WithScratch. A Scratch can only be used by one search
+// at a time, so workers searching many documents in a loop typically allocate one each and
+// pass it to every search. The zero value is ready to use.
+type Scratch struct {
+	sc      scratch
+	pairs   []pair
+	found   []uint64
+	windows []int
+	depths  []int
+	limits  matchLimiter
+}
+
+// WithScratch searches with the buffers of buf, avoiding allocating them for the search.
+// The results are allocated as usual, and don't refer to buf.
+func WithScratch(buf *Scratch) FindOption {
+	return func(c *findConfig) {
+		c.scratch = buf
+	}
+}
+
+// reset prepares the scratch buffers for searching rs, split into words by tok.
+func (b *Scratch) reset(rs []rune, tok Tokenizer) *scratch {
+	b.sc.tok, b.sc.hasBidi = tok, hasBidiControls(rs)
+	for off := range b.sc.codes {
+		delete(b.sc.codes, off)
+	}
+	return &b.sc
+}
+
+// counts returns n match counts set to 0.
+func (b *Scratch) counts(n int) []uint64 {
+	if cap(b.found) < n {
+		b.found = make([]uint64, n)
+	}
+	b.found = b.found[:n]
+	for i := range b.found {
+		b.found[i] = 0
+	}
+	return b.found
+}
+
+// windowsFor returns space for the windows and depths of n groups.
+func (b *Scratch) windowsFor(n int) ([]int, []int) {
+	if cap(b.windows) < n {
+		b.windows = make([]int, n)
+		b.depths = make([]int, n)
+	}
+	b.windows, b.depths = b.windows[:n], b.depths[:n]
+	return b.windows, b.depths
+}
+
+// limiter returns an empty matchLimiter.
+func (b *Scratch) limiter() matchLimiter {
+	if b.limits == nil {
+		b.limits = make(matchLimiter)
+	}
+	for k := range b.limits {
+		delete(b.limits, k)
+	}
+	return b.limits
+}
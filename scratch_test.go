@@ -0,0 +1,33 @@
+package fastentity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWithScratch(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"), []rune("golang developer"))
+	store.Add("names", []rune("Jon Smith"))
+	store.Group("names").Configure(Phonetic(), MaxMatchesPerEntity(1))
+
+	var buf Scratch
+	for _, doc := range []string{
+		"PHP and golang developer John Smith and John Smith",
+		"Jon Smyth, a golang developer",
+		"nothing",
+		"PHP PHP PHP golang developer",
+	} {
+		want := store.FindAll([]rune(doc))
+		if got := store.FindAll([]rune(doc), WithScratch(&buf)); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: expected %v, got %v", doc, want, got)
+		}
+	}
+
+	doc := []rune("PHP and golang developer")
+	allocs := testing.AllocsPerRun(100, func() { store.FindAll(doc) })
+	reused := testing.AllocsPerRun(100, func() { store.FindAll(doc, WithScratch(&buf)) })
+	if reused >= allocs {
+		t.Errorf("Expected fewer than %v allocations with a Scratch, got %v", allocs, reused)
+	}
+}
@@ -0,0 +1,75 @@
+package fastentity
+
+import (
+	"errors"
+	"fmt"
+	"unicode"
+)
+
+// ErrScriptMismatch is the reason entities are skipped when loading with CheckScripts.
+var ErrScriptMismatch = errors.New("script doesn't match the language of the group")
+
+// LanguageScripts are the scripts the entities of groups of each language are written
+// in, by ISO 639-1 code, which CheckScripts checks. Groups of other languages aren't
+// checked.
+var LanguageScripts = map[string][]*unicode.RangeTable{
+	"en": {unicode.Latin},
+	"de": {unicode.Latin},
+	"fr": {unicode.Latin},
+	"es": {unicode.Latin},
+	"it": {unicode.Latin},
+	"nl": {unicode.Latin},
+	"pt": {unicode.Latin},
+	"pl": {unicode.Latin},
+	"sv": {unicode.Latin},
+	"tr": {unicode.Latin},
+	"ru": {unicode.Cyrillic},
+	"uk": {unicode.Cyrillic},
+	"el": {unicode.Greek},
+	"ar": {unicode.Arabic},
+	"fa": {unicode.Arabic},
+	"he": {unicode.Hebrew},
+	"hi": {unicode.Devanagari},
+	"th": {unicode.Thai},
+	"zh": {unicode.Han},
+	"ja": {unicode.Han, unicode.Hiragana, unicode.Katakana},
+	"ko": {unicode.Hangul, unicode.Han},
+}
+
+// CheckScripts skips entities whose letters aren't all in the scripts of the language of
+// their group, see LanguageScripts, such as CJK text in an English list of skills, which
+// is usually the result of a mistake in encoding the data. The entities skipped are
+// recorded in the LoadReport, with reasons wrapping ErrScriptMismatch, whether or not
+// SkipErrors is passed. Groups without a language aren't checked.
+func CheckScripts() LoadOption {
+	return loadOptionFunc(func(c *loadConfig) {
+		c.checkScripts = true
+	})
+}
+
+// scriptCheck returns a function checking the script of the entities of the group, or
+// nil if they needn't be checked.
+func scriptCheck(group string) func(e []rune) error {
+	scripts := LanguageScripts[languageOf(group)]
+	if scripts == nil {
+		return nil
+	}
+	return func(e []rune) error {
+		for _, r := range e {
+			if unicode.IsLetter(r) && !unicode.In(r, scripts...) {
+				return fmt.Errorf("%q has %s letters: %w", string(e), scriptName(r), ErrScriptMismatch)
+			}
+		}
+		return nil
+	}
+}
+
+// scriptName returns the name of the script of r.
+func scriptName(r rune) string {
+	for name, table := range unicode.Scripts {
+		if unicode.Is(table, r) {
+			return name
+		}
+	}
+	return "unknown"
+}
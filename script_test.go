@@ -0,0 +1,41 @@
+package fastentity
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+func TestCheckScripts(t *testing.T) {
+	dir := writeEntityFiles(t, map[string]string{
+		"skills@en.entities.csv": "golang\nC++\n日本語\nmachine learning\nпрограммирование\n",
+		"skills@ja.entities.csv": "機械学習\nプログラミング\ngolang\n",
+		"skills.entities.csv":    "golang\n日本語\n",
+	})
+	defer os.RemoveAll(dir)
+
+	store, r, err := LoadDir(dir, CheckScripts())
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]int{"skills@en": {3, 5}, "skills@ja": {3}, "skills": nil}
+	for _, f := range r.Files {
+		var lines []int
+		for _, s := range f.Skipped {
+			if !errors.Is(s.Reason, ErrScriptMismatch) {
+				t.Errorf("%s: expected a script mismatch, got %v", f.Path, s.Reason)
+			}
+			lines = append(lines, s.Line)
+		}
+		if len(lines) != len(want[f.Group]) || (len(lines) > 0 && lines[0] != want[f.Group][0]) {
+			t.Errorf("%s: expected lines %v skipped, got %v", f.Path, want[f.Group], f.Skipped)
+		}
+	}
+	if n := store.Stats()["skills@en"].Entities; n != 3 {
+		t.Errorf("Expected 3 English skills, got %d", n)
+	}
+
+	if _, r, _ := LoadDir(dir); len(r.Files[0].Skipped)+len(r.Files[1].Skipped)+len(r.Files[2].Skipped) != 0 {
+		t.Error("Expected scripts to be checked only with CheckScripts")
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"crypto/subtle"
+	"crypto/x509"
+	"errors"
+	"net/http"
+)
+
+// An Authenticator checks the credentials of a request, returning an error if it may not
+// be served.
+type Authenticator func(r *http.Request) error
+
+// Authenticate requires requests to be accepted by auth, other than health checks.
+// Requests it rejects fail with status 401, and gRPC calls with the code Unauthenticated.
+// If given more than once, requests must be accepted by every Authenticator.
+func Authenticate(auth Authenticator) Option {
+	mw := Middleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/healthz" && r.URL.Path != "/readyz" {
+				if err := auth(r); err != nil {
+					httpError(w, http.StatusUnauthorized, err.Error())
+					return
+				}
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	return func(s *Server) {
+		s.auths = append(s.auths, auth)
+		mw(s)
+	}
+}
+
+// Middleware wraps the handler of the server with mw, for example to log requests. Later
+// middleware wraps earlier middleware, and so sees requests first.
+func Middleware(mw func(http.Handler) http.Handler) Option {
+	return func(s *Server) {
+		s.handler = mw(s.handler)
+	}
+}
+
+var (
+	errNoAPIKey      = errors.New("missing API key")
+	errInvalidAPIKey = errors.New("invalid API key")
+	errNoClientCert  = errors.New("missing client certificate")
+)
+
+// APIKey accepts requests with one of keys in the given header, e.g. "X-API-Key".
+func APIKey(header string, keys ...string) Authenticator {
+	return func(r *http.Request) error {
+		got := r.Header.Get(header)
+		if got == "" {
+			return errNoAPIKey
+		}
+		ok := 0
+		for _, key := range keys {
+			ok |= subtle.ConstantTimeCompare([]byte(got), []byte(key))
+		}
+		if ok == 0 {
+			return errInvalidAPIKey
+		}
+		return nil
+	}
+}
+
+// ClientCert accepts requests made with a TLS client certificate which verify accepts,
+// for servers using mutual TLS. The certificate chain has already been verified by the
+// TLS configuration of the http.Server, so verify need only check its identity, e.g. its
+// subject or DNS names.
+func ClientCert(verify func(cert *x509.Certificate) error) Authenticator {
+	return func(r *http.Request) error {
+		if r.TLS == nil || len(r.TLS.PeerCertificates) == 0 {
+			return errNoClientCert
+		}
+		return verify(r.TLS.PeerCertificates[0])
+	}
+}
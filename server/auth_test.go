@@ -0,0 +1,73 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sajari/fastentity"
+)
+
+func TestAPIKey(t *testing.T) {
+	var seen []string
+	logged := Middleware(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			seen = append(seen, r.URL.Path)
+			next.ServeHTTP(w, r)
+		})
+	})
+	ts := httptest.NewServer(New(fastentity.New(), Authenticate(APIKey("X-API-Key", "k1", "k2")), logged))
+	defer ts.Close()
+
+	for _, test := range []struct {
+		path, key string
+		status    int
+	}{
+		{"/groups", "", http.StatusUnauthorized},
+		{"/groups", "k3", http.StatusUnauthorized},
+		{"/groups", "k2", http.StatusOK},
+		{"/healthz", "", http.StatusOK},
+		{"/readyz", "", http.StatusOK},
+	} {
+		req, _ := http.NewRequest(http.MethodGet, ts.URL+test.path, nil)
+		if test.key != "" {
+			req.Header.Set("X-API-Key", test.key)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Errorf("%s with key %q: expected status %d, got %d", test.path, test.key, test.status, resp.StatusCode)
+		}
+	}
+	if len(seen) != 5 {
+		t.Errorf("Expected the middleware to see every request before authentication, got %v", seen)
+	}
+}
+
+func TestClientCert(t *testing.T) {
+	auth := ClientCert(func(cert *x509.Certificate) error {
+		if cert.Subject.CommonName != "indexer" {
+			return errors.New("unknown client")
+		}
+		return nil
+	})
+
+	r := httptest.NewRequest(http.MethodPost, "/match", strings.NewReader(""))
+	if err := auth(r); err == nil {
+		t.Error("Expected an error without TLS")
+	}
+	for name, ok := range map[string]bool{"indexer": true, "crawler": false} {
+		r.TLS = &tls.ConnectionState{PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: name}}}}
+		if err := auth(r); (err == nil) != ok {
+			t.Errorf("%s: expected accepted %v, got %v", name, ok, err)
+		}
+	}
+}
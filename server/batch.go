@@ -0,0 +1,72 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/sajari/fastentity"
+)
+
+// BatchRequest is a request to the /match/batch endpoint.
+type BatchRequest struct {
+	// Documents are the documents to search, by ID.
+	Documents map[string]string `json:"documents"`
+}
+
+// BatchResponse is the response of the /match/batch endpoint.
+type BatchResponse struct {
+	// Version is the version of the store searched, which is the same for every document
+	// of the batch.
+	Version uint64 `json:"version"`
+	// Results are the matches of each document in document order, by ID.
+	Results map[string][]fastentity.StreamMatch `json:"results"`
+}
+
+func (s *Server) handleMatchBatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	body, err := s.readBody(r)
+	if err == errTooLarge {
+		httpError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	var req BatchRequest
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+	if err != nil {
+		httpError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !s.acquire(w) {
+		return
+	}
+	defer s.release()
+	ctx, cancel := s.context(r)
+	defer cancel()
+
+	docs := make(map[string][]rune, len(req.Documents))
+	for id, text := range req.Documents {
+		docs[id] = []rune(text)
+	}
+	store := s.Store()
+	version := store.Version()
+	results, err := store.FindAllMultiContext(ctx, docs)
+	if err != nil {
+		searchError(w, err)
+		return
+	}
+
+	resp := BatchResponse{
+		Version: version,
+		Results: make(map[string][]fastentity.StreamMatch, len(results)),
+	}
+	for id, res := range results {
+		resp.Results[id] = resultMatches(res, docs[id])
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
@@ -0,0 +1,19 @@
+//go:build go1.21
+
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// enableFullDuplex allows an HTTP/1 handler to keep reading the request body after it
+// starts writing the response.
+func enableFullDuplex(w http.ResponseWriter) {
+	http.NewResponseController(w).EnableFullDuplex()
+}
+
+// setReadDeadline makes reads of the request body fail after t.
+func setReadDeadline(w http.ResponseWriter, t time.Time) {
+	http.NewResponseController(w).SetReadDeadline(t)
+}
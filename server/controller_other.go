@@ -0,0 +1,17 @@
+//go:build !go1.21
+
+package server
+
+import (
+	"net/http"
+	"time"
+)
+
+// enableFullDuplex does nothing before Go 1.21, where an HTTP/1 request body may not be
+// readable once the response has started, so large documents should be streamed over
+// HTTP/2.
+func enableFullDuplex(w http.ResponseWriter) {}
+
+// setReadDeadline does nothing before Go 1.21, so streamed documents are only read until
+// the deadline if the client keeps sending them.
+func setReadDeadline(w http.ResponseWriter, t time.Time) {}
@@ -0,0 +1,98 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/sajari/fastentity"
+)
+
+// EditRequest is a request to the /entities/add and /entities/remove endpoints.
+type EditRequest struct {
+	Group    string   `json:"group"`
+	Entities []string `json:"entities"`
+}
+
+// EditResponse is the response of the /entities/add and /entities/remove endpoints.
+type EditResponse struct {
+	// Version is the version of the store after the change.
+	Version uint64 `json:"version"`
+}
+
+// Actor identifies who made the changes requested of the server, such as the owner of the
+// API key or client certificate of the request, for the audit sink of the store. By
+// default changes are recorded without an actor. gRPC calls are passed to actor as HTTP
+// requests, as they are to Authenticators.
+func Actor(actor func(r *http.Request) string) Option {
+	return func(s *Server) {
+		s.actor = actor
+	}
+}
+
+var errNoGroup = errors.New("missing group")
+
+func (s *Server) handleAdd(w http.ResponseWriter, r *http.Request) {
+	s.handleEdit(w, r, (*fastentity.Store).AddAs)
+}
+
+func (s *Server) handleRemove(w http.ResponseWriter, r *http.Request) {
+	s.handleEdit(w, r, (*fastentity.Store).RemoveAs)
+}
+
+// handleEdit makes the change requested with edit, recorded with the actor of the
+// request.
+func (s *Server) handleEdit(w http.ResponseWriter, r *http.Request, edit func(s *fastentity.Store, actor, name string, entities ...[]rune) error) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	body, err := s.readBody(r)
+	if err == errTooLarge {
+		httpError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	var req EditRequest
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+	var ents [][]rune
+	if err == nil {
+		ents, err = editEntities(&req)
+	}
+	if err != nil {
+		httpError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	store := s.Store()
+	if err := edit(store, s.actorOf(r), req.Group, ents...); err != nil {
+		httpError(w, http.StatusInternalServerError, "recording change: "+err.Error())
+		return
+	}
+	writeJSON(w, http.StatusOK, EditResponse{Version: store.Version()})
+}
+
+// editEntities checks the request, returning its entities.
+func editEntities(req *EditRequest) ([][]rune, error) {
+	if req.Group == "" {
+		return nil, errNoGroup
+	}
+	ents := make([][]rune, len(req.Entities))
+	for i, e := range req.Entities {
+		ents[i] = []rune(e)
+		if err := fastentity.ValidateEntity(ents[i]); err != nil {
+			return nil, err
+		}
+	}
+	return ents, nil
+}
+
+// actorOf returns the actor making the request.
+func (s *Server) actorOf(r *http.Request) string {
+	if s.actor == nil {
+		return ""
+	}
+	return s.actor(r)
+}
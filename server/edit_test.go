@@ -0,0 +1,100 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/sajari/fastentity"
+	"github.com/sajari/fastentity/server/fastentitypb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+func TestEdit(t *testing.T) {
+	store := fastentity.New()
+	store.Add("skills", []rune("PHP"))
+	store.SetAuditSink(fastentity.NewAuditLog())
+	actor := Actor(func(r *http.Request) string { return r.Header.Get("X-User") })
+	ts := httptest.NewServer(New(store, actor))
+	defer ts.Close()
+
+	for _, test := range []struct {
+		path, body string
+		status     int
+	}{
+		{"/entities/add", `{"group": "skills", "entities": ["Go", "Rust"]}`, http.StatusOK},
+		{"/entities/remove", `{"group": "skills", "entities": ["php"]}`, http.StatusOK},
+		{"/entities/add", `{"entities": ["Go"]}`, http.StatusBadRequest},
+		{"/entities/add", `{"group": "skills", "entities": ["` + strings.Repeat("x", fastentity.MaxEntityLen+1) + `"]}`, http.StatusBadRequest},
+	} {
+		req, _ := http.NewRequest(http.MethodPost, ts.URL+test.path, strings.NewReader(test.body))
+		req.Header.Set("X-User", "alice")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var edit EditResponse
+		json.NewDecoder(resp.Body).Decode(&edit)
+		resp.Body.Close()
+		if resp.StatusCode != test.status {
+			t.Errorf("%s %s: expected status %d, got %d", test.path, test.body, test.status, resp.StatusCode)
+		}
+		if resp.StatusCode == http.StatusOK && edit.Version != store.Version() {
+			t.Errorf("Expected version %d, got %d", store.Version(), edit.Version)
+		}
+	}
+
+	if got := store.FindAll([]rune("PHP, Go and Rust"))["skills"]; len(got) != 2 {
+		t.Errorf("Expected Go and Rust, got %v", got)
+	}
+	history, _ := store.History("skills")
+	if len(history) != 2 || history[0].Actor != "alice" || history[1].Op != fastentity.AuditRemove {
+		t.Errorf("Expected the changes recorded with their actor, got %+v", history)
+	}
+}
+
+func TestGRPCEdit(t *testing.T) {
+	var actors []string
+	conn := newTestGRPCConn(t, Actor(func(r *http.Request) string {
+		actors = append(actors, r.Header.Get("x-user")+" "+r.URL.Path)
+		return r.Header.Get("x-user")
+	}))
+	client := fastentitypb.NewEditorClient(conn)
+	ctx := metadata.AppendToOutgoingContext(context.Background(), "x-user", "bob")
+
+	if _, err := client.Add(ctx, &fastentitypb.EditRequest{Group: "skills", Entities: []string{"Go"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Remove(ctx, &fastentitypb.EditRequest{Group: "skills", Entities: []string{"php"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client.Add(ctx, &fastentitypb.EditRequest{Entities: []string{"Go"}}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument without a group, got %v", err)
+	}
+	if len(actors) != 2 || actors[0] != "bob /fastentity.v1.Editor/Add" {
+		t.Errorf("Unexpected actors %v", actors)
+	}
+
+	stream, err := fastentitypb.NewMatcherClient(conn).Match(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	stream.Send(&fastentitypb.MatchRequest{Text: []byte("PHP and Go")})
+	stream.CloseSend()
+	var got []string
+	for {
+		resp, err := stream.Recv()
+		if err != nil {
+			break
+		}
+		got = append(got, resp.Match.Text)
+	}
+	if len(got) != 1 || got[0] != "Go" {
+		t.Errorf("Expected only Go, got %v", got)
+	}
+}
@@ -0,0 +1,3 @@
+// Package fastentitypb is the gRPC API served by the server package, generated from
+// fastentity.proto.
+package fastentitypb
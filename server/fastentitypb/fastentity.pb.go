@@ -0,0 +1,408 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: fastentitypb/fastentity.proto
+
+package fastentitypb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// MatchRequest is the next chunk of a document streamed to Match.
+type MatchRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Text is the next bytes of the UTF-8 encoded document, which may end part way
+	// through a character, continued by the next chunk.
+	Text          []byte `protobuf:"bytes,1,opt,name=text,proto3" json:"text,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MatchRequest) Reset() {
+	*x = MatchRequest{}
+	mi := &file_fastentitypb_fastentity_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MatchRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchRequest) ProtoMessage() {}
+
+func (x *MatchRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fastentitypb_fastentity_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchRequest.ProtoReflect.Descriptor instead.
+func (*MatchRequest) Descriptor() ([]byte, []int) {
+	return file_fastentitypb_fastentity_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *MatchRequest) GetText() []byte {
+	if x != nil {
+		return x.Text
+	}
+	return nil
+}
+
+// MatchResponse is a match found by Match.
+type MatchResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Match         *Match                 `protobuf:"bytes,1,opt,name=match,proto3" json:"match,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *MatchResponse) Reset() {
+	*x = MatchResponse{}
+	mi := &file_fastentitypb_fastentity_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *MatchResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*MatchResponse) ProtoMessage() {}
+
+func (x *MatchResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fastentitypb_fastentity_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use MatchResponse.ProtoReflect.Descriptor instead.
+func (*MatchResponse) Descriptor() ([]byte, []int) {
+	return file_fastentitypb_fastentity_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *MatchResponse) GetMatch() *Match {
+	if x != nil {
+		return x.Match
+	}
+	return nil
+}
+
+// Match is an entity found in a document.
+type Match struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Group string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Text  string                 `protobuf:"bytes,2,opt,name=text,proto3" json:"text,omitempty"`
+	// Canonical is the entity as it was added to its group.
+	Canonical string `protobuf:"bytes,3,opt,name=canonical,proto3" json:"canonical,omitempty"`
+	// Offset is the offset of the match in runes from the start of the document, across
+	// all the chunks streamed.
+	Offset int64 `protobuf:"varint,4,opt,name=offset,proto3" json:"offset,omitempty"`
+	// ByteOffset is the offset of the match in bytes from the start of the document.
+	ByteOffset int64 `protobuf:"varint,5,opt,name=byte_offset,json=byteOffset,proto3" json:"byte_offset,omitempty"`
+	// Kind is how the entity was matched, e.g. "text" or "acronym".
+	Kind          string  `protobuf:"bytes,6,opt,name=kind,proto3" json:"kind,omitempty"`
+	Score         float64 `protobuf:"fixed64,7,opt,name=score,proto3" json:"score,omitempty"`
+	Weight        float64 `protobuf:"fixed64,8,opt,name=weight,proto3" json:"weight,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Match) Reset() {
+	*x = Match{}
+	mi := &file_fastentitypb_fastentity_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Match) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Match) ProtoMessage() {}
+
+func (x *Match) ProtoReflect() protoreflect.Message {
+	mi := &file_fastentitypb_fastentity_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Match.ProtoReflect.Descriptor instead.
+func (*Match) Descriptor() ([]byte, []int) {
+	return file_fastentitypb_fastentity_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Match) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *Match) GetText() string {
+	if x != nil {
+		return x.Text
+	}
+	return ""
+}
+
+func (x *Match) GetCanonical() string {
+	if x != nil {
+		return x.Canonical
+	}
+	return ""
+}
+
+func (x *Match) GetOffset() int64 {
+	if x != nil {
+		return x.Offset
+	}
+	return 0
+}
+
+func (x *Match) GetByteOffset() int64 {
+	if x != nil {
+		return x.ByteOffset
+	}
+	return 0
+}
+
+func (x *Match) GetKind() string {
+	if x != nil {
+		return x.Kind
+	}
+	return ""
+}
+
+func (x *Match) GetScore() float64 {
+	if x != nil {
+		return x.Score
+	}
+	return 0
+}
+
+func (x *Match) GetWeight() float64 {
+	if x != nil {
+		return x.Weight
+	}
+	return 0
+}
+
+// EditRequest is a change to the entities of a group.
+type EditRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Group         string                 `protobuf:"bytes,1,opt,name=group,proto3" json:"group,omitempty"`
+	Entities      []string               `protobuf:"bytes,2,rep,name=entities,proto3" json:"entities,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EditRequest) Reset() {
+	*x = EditRequest{}
+	mi := &file_fastentitypb_fastentity_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EditRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EditRequest) ProtoMessage() {}
+
+func (x *EditRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_fastentitypb_fastentity_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EditRequest.ProtoReflect.Descriptor instead.
+func (*EditRequest) Descriptor() ([]byte, []int) {
+	return file_fastentitypb_fastentity_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *EditRequest) GetGroup() string {
+	if x != nil {
+		return x.Group
+	}
+	return ""
+}
+
+func (x *EditRequest) GetEntities() []string {
+	if x != nil {
+		return x.Entities
+	}
+	return nil
+}
+
+// EditResponse is the result of a change.
+type EditResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Version is the version of the store after the change.
+	Version       uint64 `protobuf:"varint,1,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *EditResponse) Reset() {
+	*x = EditResponse{}
+	mi := &file_fastentitypb_fastentity_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *EditResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*EditResponse) ProtoMessage() {}
+
+func (x *EditResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_fastentitypb_fastentity_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use EditResponse.ProtoReflect.Descriptor instead.
+func (*EditResponse) Descriptor() ([]byte, []int) {
+	return file_fastentitypb_fastentity_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *EditResponse) GetVersion() uint64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+var File_fastentitypb_fastentity_proto protoreflect.FileDescriptor
+
+const file_fastentitypb_fastentity_proto_rawDesc = "" +
+	"\n" +
+	"\x1dfastentitypb/fastentity.proto\x12\rfastentity.v1\"\"\n" +
+	"\fMatchRequest\x12\x12\n" +
+	"\x04text\x18\x01 \x01(\fR\x04text\";\n" +
+	"\rMatchResponse\x12*\n" +
+	"\x05match\x18\x01 \x01(\v2\x14.fastentity.v1.MatchR\x05match\"\xca\x01\n" +
+	"\x05Match\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x12\n" +
+	"\x04text\x18\x02 \x01(\tR\x04text\x12\x1c\n" +
+	"\tcanonical\x18\x03 \x01(\tR\tcanonical\x12\x16\n" +
+	"\x06offset\x18\x04 \x01(\x03R\x06offset\x12\x1f\n" +
+	"\vbyte_offset\x18\x05 \x01(\x03R\n" +
+	"byteOffset\x12\x12\n" +
+	"\x04kind\x18\x06 \x01(\tR\x04kind\x12\x14\n" +
+	"\x05score\x18\a \x01(\x01R\x05score\x12\x16\n" +
+	"\x06weight\x18\b \x01(\x01R\x06weight\"?\n" +
+	"\vEditRequest\x12\x14\n" +
+	"\x05group\x18\x01 \x01(\tR\x05group\x12\x1a\n" +
+	"\bentities\x18\x02 \x03(\tR\bentities\"(\n" +
+	"\fEditResponse\x12\x18\n" +
+	"\aversion\x18\x01 \x01(\x04R\aversion2Q\n" +
+	"\aMatcher\x12F\n" +
+	"\x05Match\x12\x1b.fastentity.v1.MatchRequest\x1a\x1c.fastentity.v1.MatchResponse(\x010\x012\x8b\x01\n" +
+	"\x06Editor\x12>\n" +
+	"\x03Add\x12\x1a.fastentity.v1.EditRequest\x1a\x1b.fastentity.v1.EditResponse\x12A\n" +
+	"\x06Remove\x12\x1a.fastentity.v1.EditRequest\x1a\x1b.fastentity.v1.EditResponseB2Z0github.com/sajari/fastentity/server/fastentitypbb\x06proto3"
+
+var (
+	file_fastentitypb_fastentity_proto_rawDescOnce sync.Once
+	file_fastentitypb_fastentity_proto_rawDescData []byte
+)
+
+func file_fastentitypb_fastentity_proto_rawDescGZIP() []byte {
+	file_fastentitypb_fastentity_proto_rawDescOnce.Do(func() {
+		file_fastentitypb_fastentity_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_fastentitypb_fastentity_proto_rawDesc), len(file_fastentitypb_fastentity_proto_rawDesc)))
+	})
+	return file_fastentitypb_fastentity_proto_rawDescData
+}
+
+var file_fastentitypb_fastentity_proto_msgTypes = make([]protoimpl.MessageInfo, 5)
+var file_fastentitypb_fastentity_proto_goTypes = []any{
+	(*MatchRequest)(nil),  // 0: fastentity.v1.MatchRequest
+	(*MatchResponse)(nil), // 1: fastentity.v1.MatchResponse
+	(*Match)(nil),         // 2: fastentity.v1.Match
+	(*EditRequest)(nil),   // 3: fastentity.v1.EditRequest
+	(*EditResponse)(nil),  // 4: fastentity.v1.EditResponse
+}
+var file_fastentitypb_fastentity_proto_depIdxs = []int32{
+	2, // 0: fastentity.v1.MatchResponse.match:type_name -> fastentity.v1.Match
+	0, // 1: fastentity.v1.Matcher.Match:input_type -> fastentity.v1.MatchRequest
+	3, // 2: fastentity.v1.Editor.Add:input_type -> fastentity.v1.EditRequest
+	3, // 3: fastentity.v1.Editor.Remove:input_type -> fastentity.v1.EditRequest
+	1, // 4: fastentity.v1.Matcher.Match:output_type -> fastentity.v1.MatchResponse
+	4, // 5: fastentity.v1.Editor.Add:output_type -> fastentity.v1.EditResponse
+	4, // 6: fastentity.v1.Editor.Remove:output_type -> fastentity.v1.EditResponse
+	4, // [4:7] is the sub-list for method output_type
+	1, // [1:4] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_fastentitypb_fastentity_proto_init() }
+func file_fastentitypb_fastentity_proto_init() {
+	if File_fastentitypb_fastentity_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_fastentitypb_fastentity_proto_rawDesc), len(file_fastentitypb_fastentity_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   5,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_fastentitypb_fastentity_proto_goTypes,
+		DependencyIndexes: file_fastentitypb_fastentity_proto_depIdxs,
+		MessageInfos:      file_fastentitypb_fastentity_proto_msgTypes,
+	}.Build()
+	File_fastentitypb_fastentity_proto = out.File
+	file_fastentitypb_fastentity_proto_goTypes = nil
+	file_fastentitypb_fastentity_proto_depIdxs = nil
+}
@@ -0,0 +1,279 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: fastentitypb/fastentity.proto
+
+package fastentitypb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	Matcher_Match_FullMethodName = "/fastentity.v1.Matcher/Match"
+)
+
+// MatcherClient is the client API for Matcher service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Matcher finds entities in documents with the dictionaries of a fastentity server.
+type MatcherClient interface {
+	// Match finds the entities in a document streamed in chunks, streaming back each
+	// match as soon as it's found, so that documents of any size can be searched while
+	// only a chunk is held in memory on either side. The document is complete when the
+	// client closes its side of the stream.
+	Match(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MatchRequest, MatchResponse], error)
+}
+
+type matcherClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewMatcherClient(cc grpc.ClientConnInterface) MatcherClient {
+	return &matcherClient{cc}
+}
+
+func (c *matcherClient) Match(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[MatchRequest, MatchResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &Matcher_ServiceDesc.Streams[0], Matcher_Match_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[MatchRequest, MatchResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Matcher_MatchClient = grpc.BidiStreamingClient[MatchRequest, MatchResponse]
+
+// MatcherServer is the server API for Matcher service.
+// All implementations must embed UnimplementedMatcherServer
+// for forward compatibility.
+//
+// Matcher finds entities in documents with the dictionaries of a fastentity server.
+type MatcherServer interface {
+	// Match finds the entities in a document streamed in chunks, streaming back each
+	// match as soon as it's found, so that documents of any size can be searched while
+	// only a chunk is held in memory on either side. The document is complete when the
+	// client closes its side of the stream.
+	Match(grpc.BidiStreamingServer[MatchRequest, MatchResponse]) error
+	mustEmbedUnimplementedMatcherServer()
+}
+
+// UnimplementedMatcherServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedMatcherServer struct{}
+
+func (UnimplementedMatcherServer) Match(grpc.BidiStreamingServer[MatchRequest, MatchResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method Match not implemented")
+}
+func (UnimplementedMatcherServer) mustEmbedUnimplementedMatcherServer() {}
+func (UnimplementedMatcherServer) testEmbeddedByValue()                 {}
+
+// UnsafeMatcherServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to MatcherServer will
+// result in compilation errors.
+type UnsafeMatcherServer interface {
+	mustEmbedUnimplementedMatcherServer()
+}
+
+func RegisterMatcherServer(s grpc.ServiceRegistrar, srv MatcherServer) {
+	// If the following call pancis, it indicates UnimplementedMatcherServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Matcher_ServiceDesc, srv)
+}
+
+func _Matcher_Match_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(MatcherServer).Match(&grpc.GenericServerStream[MatchRequest, MatchResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type Matcher_MatchServer = grpc.BidiStreamingServer[MatchRequest, MatchResponse]
+
+// Matcher_ServiceDesc is the grpc.ServiceDesc for Matcher service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Matcher_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "fastentity.v1.Matcher",
+	HandlerType: (*MatcherServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Match",
+			Handler:       _Matcher_Match_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "fastentitypb/fastentity.proto",
+}
+
+const (
+	Editor_Add_FullMethodName    = "/fastentity.v1.Editor/Add"
+	Editor_Remove_FullMethodName = "/fastentity.v1.Editor/Remove"
+)
+
+// EditorClient is the client API for Editor service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// Editor changes the entities of the dictionaries of a fastentity server. Changes are
+// recorded by the audit sink of its store, if it has one.
+type EditorClient interface {
+	// Add adds the entities to a group, creating the group if it doesn't exist. Entities
+	// the group already has, ignoring case, are skipped.
+	Add(ctx context.Context, in *EditRequest, opts ...grpc.CallOption) (*EditResponse, error)
+	// Remove removes the entities from a group, ignoring case.
+	Remove(ctx context.Context, in *EditRequest, opts ...grpc.CallOption) (*EditResponse, error)
+}
+
+type editorClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewEditorClient(cc grpc.ClientConnInterface) EditorClient {
+	return &editorClient{cc}
+}
+
+func (c *editorClient) Add(ctx context.Context, in *EditRequest, opts ...grpc.CallOption) (*EditResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EditResponse)
+	err := c.cc.Invoke(ctx, Editor_Add_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *editorClient) Remove(ctx context.Context, in *EditRequest, opts ...grpc.CallOption) (*EditResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(EditResponse)
+	err := c.cc.Invoke(ctx, Editor_Remove_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// EditorServer is the server API for Editor service.
+// All implementations must embed UnimplementedEditorServer
+// for forward compatibility.
+//
+// Editor changes the entities of the dictionaries of a fastentity server. Changes are
+// recorded by the audit sink of its store, if it has one.
+type EditorServer interface {
+	// Add adds the entities to a group, creating the group if it doesn't exist. Entities
+	// the group already has, ignoring case, are skipped.
+	Add(context.Context, *EditRequest) (*EditResponse, error)
+	// Remove removes the entities from a group, ignoring case.
+	Remove(context.Context, *EditRequest) (*EditResponse, error)
+	mustEmbedUnimplementedEditorServer()
+}
+
+// UnimplementedEditorServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedEditorServer struct{}
+
+func (UnimplementedEditorServer) Add(context.Context, *EditRequest) (*EditResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Add not implemented")
+}
+func (UnimplementedEditorServer) Remove(context.Context, *EditRequest) (*EditResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method Remove not implemented")
+}
+func (UnimplementedEditorServer) mustEmbedUnimplementedEditorServer() {}
+func (UnimplementedEditorServer) testEmbeddedByValue()                {}
+
+// UnsafeEditorServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to EditorServer will
+// result in compilation errors.
+type UnsafeEditorServer interface {
+	mustEmbedUnimplementedEditorServer()
+}
+
+func RegisterEditorServer(s grpc.ServiceRegistrar, srv EditorServer) {
+	// If the following call pancis, it indicates UnimplementedEditorServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&Editor_ServiceDesc, srv)
+}
+
+func _Editor_Add_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EditRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EditorServer).Add(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Editor_Add_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EditorServer).Add(ctx, req.(*EditRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Editor_Remove_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EditRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(EditorServer).Remove(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: Editor_Remove_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(EditorServer).Remove(ctx, req.(*EditRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// Editor_ServiceDesc is the grpc.ServiceDesc for Editor service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var Editor_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "fastentity.v1.Editor",
+	HandlerType: (*EditorServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "Add",
+			Handler:    _Editor_Add_Handler,
+		},
+		{
+			MethodName: "Remove",
+			Handler:    _Editor_Remove_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "fastentitypb/fastentity.proto",
+}
@@ -0,0 +1,209 @@
+package server
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative fastentitypb/fastentity.proto
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/sajari/fastentity"
+	"github.com/sajari/fastentity/server/fastentitypb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// GRPCServer returns a gRPC server serving the Matcher and Editor services of the
+// fastentitypb package for the store being served, alongside or instead of the HTTP API. The options
+// are passed to grpc.NewServer, e.g. for TLS credentials or interceptors.
+//
+// The limits of the Options apply to gRPC calls too: chunks of documents are limited to
+// MaxBodySize, unless overridden by grpc.MaxRecvMsgSize in opts, and calls over the
+// limits fail with the codes ResourceExhausted and DeadlineExceeded.
+//
+// Calls are checked by the Authenticators of the server, as HTTP requests with the
+// metadata of the call as headers and the TLS state of its connection, and fail with the
+// code Unauthenticated if rejected. Middleware only applies to the HTTP API.
+func (s *Server) GRPCServer(opts ...grpc.ServerOption) *grpc.Server {
+	opts = append([]grpc.ServerOption{
+		grpc.MaxRecvMsgSize(int(s.maxBodySize)),
+		grpc.ChainUnaryInterceptor(func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+			if err := s.authenticate(ctx, info.FullMethod); err != nil {
+				return nil, err
+			}
+			return handler(ctx, req)
+		}),
+		grpc.ChainStreamInterceptor(func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+			if err := s.authenticate(ss.Context(), info.FullMethod); err != nil {
+				return err
+			}
+			return handler(srv, ss)
+		}),
+	}, opts...)
+	gs := grpc.NewServer(opts...)
+	fastentitypb.RegisterMatcherServer(gs, &matcherServer{s: s})
+	fastentitypb.RegisterEditorServer(gs, &editorServer{s: s})
+	return gs
+}
+
+// authenticate checks a gRPC call to method with the Authenticators of the server.
+func (s *Server) authenticate(ctx context.Context, method string) error {
+	if len(s.auths) == 0 {
+		return nil
+	}
+	r := grpcRequest(ctx, method)
+	for _, auth := range s.auths {
+		if err := auth(r); err != nil {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+	}
+	return nil
+}
+
+// grpcRequest returns an HTTP request standing in for the gRPC call to method, with the
+// metadata of the call as headers and the TLS state of its connection.
+func grpcRequest(ctx context.Context, method string) *http.Request {
+	r := &http.Request{
+		Method:     http.MethodPost,
+		URL:        &url.URL{Path: method},
+		Proto:      "HTTP/2.0",
+		ProtoMajor: 2,
+		Header:     make(http.Header),
+	}
+	md, _ := metadata.FromIncomingContext(ctx)
+	for k, vs := range md {
+		for _, v := range vs {
+			r.Header.Add(k, v)
+		}
+	}
+	if p, ok := peer.FromContext(ctx); ok {
+		if p.Addr != nil {
+			r.RemoteAddr = p.Addr.String()
+		}
+		if info, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+			r.TLS = &info.State
+		}
+	}
+	return r.WithContext(ctx)
+}
+
+// matcherServer implements the Matcher service.
+type matcherServer struct {
+	fastentitypb.UnimplementedMatcherServer
+	s *Server
+}
+
+// Match streams the chunks of the document received through a pipe to FindReader, so
+// that receiving is held up while the chunks already received are searched.
+func (m *matcherServer) Match(stream fastentitypb.Matcher_MatchServer) error {
+	if !m.s.tryAcquire() {
+		return status.Error(codes.ResourceExhausted, errTooBusy.Error())
+	}
+	defer m.s.release()
+	// Let the client know the search has started, as matches may be some way off
+	if err := stream.SendHeader(metadata.MD{}); err != nil {
+		return err
+	}
+	ctx, cancel := m.s.searchContext(stream.Context())
+	defer cancel()
+
+	pr, pw := io.Pipe()
+	go func() {
+		for {
+			req, err := stream.Recv()
+			if err == io.EOF {
+				pw.Close()
+				return
+			}
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			if _, err := pw.Write(req.Text); err != nil {
+				return // The search has ended
+			}
+		}
+	}()
+	defer pr.Close()
+	// Stop reading the document when the search is cancelled or times out, which
+	// FindReaderContext only notices between reads
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			pw.CloseWithError(ctx.Err())
+		case <-done:
+		}
+	}()
+
+	var sendErr error
+	err := m.s.Store().FindReaderContext(ctx, pr, func(sm fastentity.StreamMatch) bool {
+		sendErr = stream.Send(&fastentitypb.MatchResponse{Match: newPBMatch(sm)})
+		return sendErr == nil
+	})
+	if err == nil {
+		err = sendErr
+	}
+	return grpcError(err)
+}
+
+// editorServer implements the Editor service.
+type editorServer struct {
+	fastentitypb.UnimplementedEditorServer
+	s *Server
+}
+
+func (e *editorServer) Add(ctx context.Context, req *fastentitypb.EditRequest) (*fastentitypb.EditResponse, error) {
+	return e.edit(ctx, req, (*fastentity.Store).AddAs)
+}
+
+func (e *editorServer) Remove(ctx context.Context, req *fastentitypb.EditRequest) (*fastentitypb.EditResponse, error) {
+	return e.edit(ctx, req, (*fastentity.Store).RemoveAs)
+}
+
+// edit makes the change requested with edit, recorded with the actor of the call.
+func (e *editorServer) edit(ctx context.Context, req *fastentitypb.EditRequest, edit func(s *fastentity.Store, actor, name string, entities ...[]rune) error) (*fastentitypb.EditResponse, error) {
+	ents, err := editEntities(&EditRequest{Group: req.Group, Entities: req.Entities})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	method, _ := grpc.Method(ctx)
+	store := e.s.Store()
+	if err := edit(store, e.s.actorOf(grpcRequest(ctx, method)), req.Group, ents...); err != nil {
+		return nil, status.Error(codes.Internal, "recording change: "+err.Error())
+	}
+	return &fastentitypb.EditResponse{Version: store.Version()}, nil
+}
+
+// grpcError returns err with the status code of the reason a search failed.
+func grpcError(err error) error {
+	switch {
+	case err == nil:
+		return nil
+	case timedOut(err):
+		return status.Error(codes.DeadlineExceeded, "search timed out")
+	case errors.Is(err, context.Canceled):
+		return status.FromContextError(err).Err()
+	}
+	return err
+}
+
+func newPBMatch(m fastentity.StreamMatch) *fastentitypb.Match {
+	return &fastentitypb.Match{
+		Group:      m.Group,
+		Text:       string(m.Text),
+		Canonical:  string(m.Canonical),
+		Offset:     int64(m.Offset),
+		ByteOffset: int64(m.ByteOffset),
+		Kind:       m.Kind.String(),
+		Score:      m.Score,
+		Weight:     m.Weight,
+	}
+}
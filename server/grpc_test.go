@@ -0,0 +1,168 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sajari/fastentity"
+	"github.com/sajari/fastentity/server/fastentitypb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// newTestGRPCClient serves the gRPC API of a Server for a test store, returning a
+// Matcher client for it.
+func newTestGRPCClient(t *testing.T, opts ...Option) fastentitypb.MatcherClient {
+	return fastentitypb.NewMatcherClient(newTestGRPCConn(t, opts...))
+}
+
+// newTestGRPCConn serves the gRPC API of a Server for a test store, returning a
+// connection to it.
+func newTestGRPCConn(t *testing.T, opts ...Option) *grpc.ClientConn {
+	store := fastentity.New()
+	store.Add("skills", []rune("PHP"), []rune("本語"))
+	store.Add("locations", []rune("Sydney"))
+	gs := New(store, opts...).GRPCServer()
+	lis := bufconn.Listen(1 << 20)
+	go gs.Serve(lis)
+	t.Cleanup(gs.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+func TestGRPCMatch(t *testing.T) {
+	client := newTestGRPCClient(t)
+	stream, err := client.Match(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Chunks split words and characters, and matches are found across them
+	doc := strings.Repeat("日 本語 in Syd", 1000) + "ney. "
+	go func() {
+		b := []byte(doc)
+		for len(b) > 0 {
+			n := 7
+			if n > len(b) {
+				n = len(b)
+			}
+			if err := stream.Send(&fastentitypb.MatchRequest{Text: b[:n]}); err != nil {
+				return
+			}
+			b = b[n:]
+		}
+		stream.CloseSend()
+	}()
+
+	var ms []*fastentitypb.Match
+	for {
+		resp, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		ms = append(ms, resp.Match)
+	}
+	if len(ms) != 1001 {
+		t.Fatalf("Expected 1001 matches, got %d", len(ms))
+	}
+	last := ms[len(ms)-1]
+	rs := []rune(doc)
+	if last.Group != "locations" || last.Text != "Sydney" || last.Offset != int64(len(rs)-8) || last.ByteOffset != int64(len(doc)-8) {
+		t.Errorf("Expected Sydney at the end of the document, got %v", last)
+	}
+	if ms[999].Offset != int64(999*len([]rune("日 本語 in Syd"))+2) {
+		t.Errorf("Expected global offsets, got %d for match 999", ms[999].Offset)
+	}
+}
+
+func TestGRPCLimits(t *testing.T) {
+	client := newTestGRPCClient(t, MaxConcurrency(1), MaxBodySize(16), Timeout(200*time.Millisecond))
+	ctx := context.Background()
+
+	// Hold the only search open, so that others are rejected
+	held, err := client.Match(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := held.Send(&fastentitypb.MatchRequest{Text: []byte("Sydney")}); err != nil {
+		t.Fatal(err)
+	}
+	// The headers are sent once the search has the slot
+	if _, err := held.Header(); err != nil {
+		t.Fatal(err)
+	}
+	busy, err := client.Match(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	busy.CloseSend()
+	if _, err := busy.Recv(); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted with too many searches, got %v", err)
+	}
+
+	// The held search times out waiting for the rest of the document
+	if _, err := held.Recv(); status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+
+	large, err := client.Match(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	large.Send(&fastentitypb.MatchRequest{Text: []byte(strings.Repeat("x", 100))})
+	if _, err := large.Recv(); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("Expected ResourceExhausted for a chunk over MaxBodySize, got %v", err)
+	}
+}
+
+func TestGRPCAuthenticate(t *testing.T) {
+	client := newTestGRPCClient(t, Authenticate(APIKey("X-API-Key", "k1")))
+	for _, test := range []struct {
+		key  string
+		code codes.Code
+	}{
+		{"", codes.Unauthenticated},
+		{"k2", codes.Unauthenticated},
+		{"k1", codes.OK},
+	} {
+		ctx := context.Background()
+		if test.key != "" {
+			ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", test.key)
+		}
+		stream, err := client.Match(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		stream.Send(&fastentitypb.MatchRequest{Text: []byte("Sydney")})
+		stream.CloseSend()
+		_, err = stream.Recv()
+		if test.code == codes.OK {
+			if err != nil {
+				t.Errorf("With key %q: expected a match, got %v", test.key, err)
+			}
+		} else if status.Code(err) != test.code {
+			t.Errorf("With key %q: expected %v, got %v", test.key, test.code, err)
+		}
+	}
+}
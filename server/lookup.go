@@ -0,0 +1,69 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/sajari/fastentity"
+)
+
+// LookupRequest is a request to the /lookup endpoint.
+type LookupRequest struct {
+	Group string `json:"group"`
+	// Keys are the texts to look up, ignoring case.
+	Keys []string `json:"keys"`
+}
+
+// LookupResponse is the response of the /lookup endpoint.
+type LookupResponse struct {
+	// Version is the version of the store.
+	Version uint64 `json:"version"`
+	// Entities are the entities of the group matching each key, leaving out keys with
+	// none.
+	Entities map[string][]fastentity.ProvidedEntity `json:"entities"`
+}
+
+func (s *Server) handleLookup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	body, err := s.readBody(r)
+	if err == errTooLarge {
+		httpError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	var req LookupRequest
+	if err == nil {
+		err = json.Unmarshal(body, &req)
+	}
+	if err != nil {
+		httpError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !s.acquire(w) {
+		return
+	}
+	defer s.release()
+	ctx, cancel := s.context(r)
+	defer cancel()
+
+	store := s.Store()
+	g, err := store.LookupGroup(req.Group)
+	if errors.Is(err, fastentity.ErrGroupNotFound) {
+		httpError(w, http.StatusNotFound, err.Error())
+		return
+	}
+	resp := LookupResponse{Version: store.Version()}
+	if err == nil {
+		resp.Entities, err = g.Lookup(ctx, req.Keys)
+	}
+	if err != nil {
+		searchError(w, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
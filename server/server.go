@@ -0,0 +1,426 @@
+// Package server provides an HTTP API, and a gRPC API, for finding entities in documents
+// with a fastentity Store.
+//
+// The API has the endpoints:
+//
+//	POST /match         find entities in the document in the request body
+//	POST /match/stream  find entities in a document streamed in the request body
+//	POST /match/batch   find entities in each of a batch of documents
+//	POST /lookup        look up the entities of a group by key, for shards of a store
+//	GET  /groups        list the groups of the store and their stats
+//	GET  /healthz       report that the server is up
+//	GET  /readyz        report whether the store has finished loading
+//
+// Documents are sent as plain text, or as a JSON MatchRequest with the Content-Type
+// application/json. Matches are returned as a JSON object, e.g.
+//
+//	{"version": 3, "matches": [{"group": "locations", "text": "Sydney", "canonical": "Sydney", "offset": 24, "byte_offset": 24, "kind": "text", "score": 1, "weight": 1}]}
+//
+// Offsets count runes, and byte offsets bytes, from the start of the document.
+//
+// Batches of documents are sent to /match/batch as a JSON BatchRequest, and the matches
+// of each are returned by document ID in a BatchResponse.
+//
+// Requests over the limits set by the Options fail with status 413 if the document is too
+// large, 429 if too many documents are being searched, and 503 if the search takes too
+// long. gRPC calls fail with the codes ResourceExhausted, for chunks larger than
+// MaxBodySize or too many searches, and DeadlineExceeded. Access can be restricted with
+// Authenticate, e.g. to clients with an API key or a TLS client certificate, for both the
+// HTTP and gRPC APIs.
+//
+// Documents of any size can be streamed to /match/stream as plain text. Matches are
+// streamed back as they are found, one JSON fastentity.StreamMatch per line, while only a
+// chunk of the document is held in memory. Servers built with Go 1.21 or later respond while the
+// request is still being read; with earlier versions, stream over HTTP/2.
+//
+// Entities are added to and removed from a group of the store by sending a JSON
+// EditRequest to /entities/add and /entities/remove. Changes are recorded by the audit sink
+// of the store, if it has one, with the actor identified by the Actor option, and are lost
+// when the store is replaced with SetStore unless saved.
+//
+// The gRPC API, defined in fastentitypb/fastentity.proto, is served by the grpc.Server
+// returned by GRPCServer. Its Match method searches documents streamed in chunks, as
+// /match/stream does, streaming back the matches, and the Editor service adds and removes
+// entities as /entities/add and /entities/remove do.
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/sajari/fastentity"
+)
+
+// DefaultMaxBodySize is the default maximum size of a request body, in bytes.
+const DefaultMaxBodySize = 10 << 20
+
+// Server serves the HTTP API for a Store.
+type Server struct {
+	mu    sync.RWMutex // protects store
+	store *fastentity.Store
+
+	maxBodySize int64
+	timeout     time.Duration
+	scans       chan struct{} // limits concurrent scans, nil if unlimited
+	mux         *http.ServeMux
+	handler     http.Handler // mux wrapped by any Middleware
+	auths       []Authenticator
+	actor       func(r *http.Request) string
+}
+
+// Option configures a Server.
+type Option func(s *Server)
+
+// MaxBodySize limits request bodies to n bytes. Larger requests fail with status 413.
+func MaxBodySize(n int64) Option {
+	return func(s *Server) {
+		s.maxBodySize = n
+	}
+}
+
+// MaxConcurrency limits the number of documents searched at once to n. Further requests
+// are rejected with status 429 until a search finishes.
+func MaxConcurrency(n int) Option {
+	return func(s *Server) {
+		if n > 0 {
+			s.scans = make(chan struct{}, n)
+		}
+	}
+}
+
+// Timeout limits the time spent searching the document of each request to d, including
+// the time spent reading a streamed document. Searches which take longer fail with status
+// 503, or end the stream with an error once matches have been written.
+func Timeout(d time.Duration) Option {
+	return func(s *Server) {
+		s.timeout = d
+	}
+}
+
+// New creates a Server for the store.
+func New(store *fastentity.Store, opts ...Option) *Server {
+	s := &Server{
+		store:       store,
+		maxBodySize: DefaultMaxBodySize,
+		mux:         http.NewServeMux(),
+	}
+	s.handler = s.mux
+	s.mux.HandleFunc("/match", s.handleMatch)
+	s.mux.HandleFunc("/match/stream", s.handleMatchStream)
+	s.mux.HandleFunc("/match/batch", s.handleMatchBatch)
+	s.mux.HandleFunc("/lookup", s.handleLookup)
+	s.mux.HandleFunc("/groups", s.handleGroups)
+	s.mux.HandleFunc("/entities/add", s.handleAdd)
+	s.mux.HandleFunc("/entities/remove", s.handleRemove)
+	s.mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("ok\n"))
+	})
+	s.mux.HandleFunc("/readyz", s.handleReady)
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// Store returns the store being served.
+func (s *Server) Store() *fastentity.Store {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.store
+}
+
+// SetStore replaces the store being served, for example when dictionaries are reloaded.
+// Requests in progress complete with the previous store.
+func (s *Server) SetStore(store *fastentity.Store) {
+	s.mu.Lock()
+	s.store = store
+	s.mu.Unlock()
+}
+
+// handleReady responds with status 503 until the store is ready, see
+// fastentity.Store.Ready, for readiness probes.
+func (s *Server) handleReady(w http.ResponseWriter, r *http.Request) {
+	if !s.Store().Ready() {
+		httpError(w, http.StatusServiceUnavailable, "loading")
+		return
+	}
+	w.Write([]byte("ok\n"))
+}
+
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.handler.ServeHTTP(w, r)
+}
+
+// MatchRequest is a JSON request to the /match endpoint.
+type MatchRequest struct {
+	Text string `json:"text"`
+	// Candidates are entities to find in the document as well as those in the store,
+	// by group, for trying out entities before adding them to the dictionaries. They
+	// match exactly, ignoring case, regardless of how the groups are configured.
+	Candidates map[string][]string `json:"candidates,omitempty"`
+	// Language limits the search to the groups of the language and those without one,
+	// see fastentity.FindAllLanguage. It's detected from the text if "auto", and all
+	// groups are searched if it's empty or can't be detected.
+	Language string `json:"language,omitempty"`
+	// Top limits the matches from the store to the highest ranked, if greater than 0.
+	// Matches in the groups listed in Priority rank first, in the order listed, followed
+	// by those of entities with higher weights, and then longer matches, see
+	// fastentity.Results.TopK. The matches kept are still in document order, and
+	// candidate matches aren't limited.
+	Top      int      `json:"top,omitempty"`
+	Priority []string `json:"priority,omitempty"`
+	// NonOverlapping limits the matches from the store to a single layer, for
+	// highlighting, see fastentity.NonOverlapping.
+	NonOverlapping bool `json:"non_overlapping,omitempty"`
+}
+
+// ranking returns the ranking of matches for Top.
+func (req *MatchRequest) ranking() func(a, b fastentity.Match) bool {
+	return fastentity.Ranking(fastentity.ByGroupPriority(req.Priority...), fastentity.ByWeight, fastentity.ByLength)
+}
+
+// MatchResponse is the response of the /match endpoint.
+type MatchResponse struct {
+	// Version is the version of the store searched.
+	Version uint64 `json:"version"`
+	// Language is the language of the groups searched, if limited to one.
+	Language string                   `json:"language,omitempty"`
+	Matches  []fastentity.StreamMatch `json:"matches"`
+	// Candidates are the matches of the candidate entities of the request, in document
+	// order.
+	Candidates []fastentity.StreamMatch `json:"candidates,omitempty"`
+}
+
+func (s *Server) handleMatch(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	req, err := s.readRequest(r)
+	if err == errTooLarge {
+		httpError(w, http.StatusRequestEntityTooLarge, err.Error())
+		return
+	}
+	if err != nil {
+		httpError(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	if !s.acquire(w) {
+		return
+	}
+	defer s.release()
+	ctx, cancel := s.context(r)
+	defer cancel()
+
+	store := s.Store()
+	doc := []rune(req.Text)
+	lang := req.Language
+	if lang == "auto" {
+		lang = fastentity.DetectLanguage(doc)
+	}
+	var opts []fastentity.FindOption
+	if req.NonOverlapping {
+		opts = append(opts, fastentity.NonOverlapping())
+	}
+	results, err := store.FindAllLanguageContext(ctx, lang, doc, opts...)
+	if err != nil {
+		searchError(w, err)
+		return
+	}
+	if req.Top > 0 {
+		results = topResults(results, req.Top, req.ranking())
+	}
+	resp := MatchResponse{
+		Version:  store.Version(),
+		Language: lang,
+		Matches:  resultMatches(results, doc),
+	}
+	if len(req.Candidates) > 0 {
+		cms, err := candidateMatches(ctx, req.Candidates, doc)
+		if err != nil {
+			searchError(w, err)
+			return
+		}
+		resp.Candidates = cms
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// acquire reserves a search allowed by MaxConcurrency, or responds with status 429 and
+// returns false if there are too many already. Searches must be released when they
+// finish.
+func (s *Server) acquire(w http.ResponseWriter) bool {
+	if !s.tryAcquire() {
+		w.Header().Set("Retry-After", "1")
+		httpError(w, http.StatusTooManyRequests, errTooBusy.Error())
+		return false
+	}
+	return true
+}
+
+var errTooBusy = errors.New("too many documents being searched")
+
+// tryAcquire reserves a search allowed by MaxConcurrency, returning false if there are
+// too many already.
+func (s *Server) tryAcquire() bool {
+	if s.scans == nil {
+		return true
+	}
+	select {
+	case s.scans <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+func (s *Server) release() {
+	if s.scans != nil {
+		<-s.scans
+	}
+}
+
+// context returns the context for searching the request's document, with the deadline
+// set by Timeout.
+func (s *Server) context(r *http.Request) (context.Context, context.CancelFunc) {
+	return s.searchContext(r.Context())
+}
+
+// searchContext returns a context for searching a document derived from ctx, with the
+// deadline set by Timeout.
+func (s *Server) searchContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if s.timeout > 0 {
+		return context.WithTimeout(ctx, s.timeout)
+	}
+	return context.WithCancel(ctx)
+}
+
+// timedOut reports whether err is due to the deadline set by Timeout.
+func timedOut(err error) bool {
+	return errors.Is(err, context.DeadlineExceeded) || errors.Is(err, os.ErrDeadlineExceeded)
+}
+
+// searchError responds with the reason a search failed.
+func searchError(w http.ResponseWriter, err error) {
+	if timedOut(err) {
+		httpError(w, http.StatusServiceUnavailable, "search timed out")
+		return
+	}
+	httpError(w, http.StatusInternalServerError, err.Error())
+}
+
+var errTooLarge = errors.New("document too large")
+
+// readBody reads the request body, up to the maximum size.
+func (s *Server) readBody(r *http.Request) ([]byte, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(r.Body, s.maxBodySize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(body)) > s.maxBodySize {
+		return nil, errTooLarge
+	}
+	return body, nil
+}
+
+// readRequest reads the document, and any candidates, from the request body.
+func (s *Server) readRequest(r *http.Request) (*MatchRequest, error) {
+	body, err := s.readBody(r)
+	if err != nil {
+		return nil, err
+	}
+	if ct, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); ct != "application/json" {
+		return &MatchRequest{Text: string(body)}, nil
+	}
+	var req MatchRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		return nil, err
+	}
+	return &req, nil
+}
+
+// candidateMatches finds the candidate entities in doc.
+func candidateMatches(ctx context.Context, candidates map[string][]string, doc []rune) ([]fastentity.StreamMatch, error) {
+	store := fastentity.New()
+	for group, ents := range candidates {
+		for _, e := range ents {
+			store.Add(group, []rune(e))
+		}
+	}
+	r, err := store.FindAllContext(ctx, doc)
+	if err != nil {
+		return nil, err
+	}
+	return resultMatches(r, doc), nil
+}
+
+// topResults returns the k highest ranked matches of r by less.
+func topResults(r fastentity.Results, k int, less func(a, b fastentity.Match) bool) fastentity.Results {
+	top := make(fastentity.Results, len(r))
+	for _, m := range r.TopK(k, less) {
+		top[m.Group] = append(top[m.Group], m.Entity)
+	}
+	return top
+}
+
+// resultMatches returns the matches of the results of searching doc, in document order.
+func resultMatches(r fastentity.Results, doc []rune) []fastentity.StreamMatch {
+	ms := r.MatchesIn(doc)
+	if ms == nil {
+		ms = []fastentity.StreamMatch{} // Encoded as an empty array
+	}
+	return ms
+}
+
+// Group is a group in the response of the /groups endpoint.
+type Group struct {
+	Name      string `json:"name"`
+	Entities  int    `json:"entities"`
+	Documents uint64 `json:"documents"`
+	Matched   uint64 `json:"matched"`
+	Matches   uint64 `json:"matches"`
+}
+
+func (s *Server) handleGroups(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		w.Header().Set("Allow", http.MethodGet)
+		httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	stats := s.Store().Stats()
+	groups := make([]Group, 0, len(stats))
+	for name, gs := range stats {
+		groups = append(groups, Group{
+			Name:      name,
+			Entities:  gs.Entities,
+			Documents: gs.Documents,
+			Matched:   gs.Matched,
+			Matches:   gs.Matches,
+		})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return groups[i].Name < groups[j].Name
+	})
+	writeJSON(w, http.StatusOK, groups)
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func httpError(w http.ResponseWriter, status int, msg string) {
+	writeJSON(w, status, map[string]string{"error": msg})
+}
@@ -0,0 +1,366 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sajari/fastentity"
+)
+
+func newTestServer(opts ...Option) *httptest.Server {
+	store := fastentity.New()
+	store.Add("skills", []rune("PHP"), []rune("本語"))
+	store.Add("locations", []rune("Sydney"))
+	return httptest.NewServer(New(store, opts...))
+}
+
+func postMatch(t *testing.T, url, contentType, body string) (*http.Response, MatchResponse) {
+	resp, err := http.Post(url+"/match", contentType, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var mr MatchResponse
+	if resp.StatusCode == http.StatusOK {
+		if err := json.NewDecoder(resp.Body).Decode(&mr); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return resp, mr
+}
+
+func TestMatch(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	for _, req := range []struct{ contentType, body string }{
+		{"text/plain", "日 本語 in Sydney. "},
+		{"application/json", `{"text": "日 本語 in Sydney. "}`},
+	} {
+		resp, mr := postMatch(t, ts.URL, req.contentType, req.body)
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		expected := []struct {
+			group, text        string
+			offset, byteOffset int
+		}{
+			{"skills", "本語", 2, 4},
+			{"locations", "Sydney", 8, 14},
+		}
+		if len(mr.Matches) != len(expected) {
+			t.Fatalf("Expected %d matches, got %+v", len(expected), mr.Matches)
+		}
+		for i, want := range expected {
+			m := mr.Matches[i]
+			if m.Group != want.group || string(m.Text) != want.text || string(m.Canonical) != want.text || m.Offset != want.offset ||
+				m.ByteOffset != want.byteOffset || m.Kind != fastentity.TextMatch || m.Score != 1 || m.Weight != 1 {
+				t.Errorf("Expected match %d to be %+v, got %+v", i, want, m)
+			}
+		}
+	}
+
+	resp, err := http.Get(ts.URL + "/match")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusMethodNotAllowed {
+		t.Errorf("Expected status 405 for GET, got %d", resp.StatusCode)
+	}
+}
+
+func TestMaxBodySize(t *testing.T) {
+	ts := newTestServer(MaxBodySize(10))
+	defer ts.Close()
+
+	if resp, _ := postMatch(t, ts.URL, "text/plain", "Maybe PHP"); resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected status 200, got %d", resp.StatusCode)
+	}
+	if resp, _ := postMatch(t, ts.URL, "text/plain", "Maybe PHP, or PDX"); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Errorf("Expected status 413, got %d", resp.StatusCode)
+	}
+}
+
+func TestReady(t *testing.T) {
+	store := fastentity.New()
+	srv := New(store)
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+
+	get := func() int {
+		resp, err := http.Get(ts.URL + "/readyz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		return resp.StatusCode
+	}
+	done := store.WarmUp()
+	if status := get(); status != http.StatusServiceUnavailable {
+		t.Errorf("Expected status 503 while warming up, got %d", status)
+	}
+	done()
+	if status := get(); status != http.StatusOK {
+		t.Errorf("Expected status 200 once ready, got %d", status)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+	postMatch(t, ts.URL, "text/plain", "Maybe PHP")
+
+	resp, err := http.Get(ts.URL + "/groups")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var groups []Group
+	if err := json.NewDecoder(resp.Body).Decode(&groups); err != nil {
+		t.Fatal(err)
+	}
+	expected := []Group{
+		{Name: "locations", Entities: 1, Documents: 1},
+		{Name: "skills", Entities: 2, Documents: 1, Matched: 1, Matches: 1},
+	}
+	if len(groups) != len(expected) {
+		t.Fatalf("Expected %d groups, got %+v", len(expected), groups)
+	}
+	for i := range expected {
+		if groups[i] != expected[i] {
+			t.Errorf("Expected group %d to be %+v, got %+v", i, expected[i], groups[i])
+		}
+	}
+}
+
+func TestCandidates(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	_, mr := postMatch(t, ts.URL, "application/json", `{"text": "Maybe PHP, or golang. ", "candidates": {"skills": ["golang"]}}`)
+	if len(mr.Matches) != 1 || string(mr.Matches[0].Text) != "PHP" {
+		t.Errorf("Expected PHP to match, got %+v", mr.Matches)
+	}
+	if len(mr.Candidates) != 1 {
+		t.Fatalf("Expected 1 candidate match, got %+v", mr.Candidates)
+	}
+	if m := mr.Candidates[0]; string(m.Text) != "golang" || m.Group != "skills" || m.Offset != 14 || m.ByteOffset != 14 {
+		t.Errorf("Expected golang to match as a candidate, got %+v", m)
+	}
+}
+
+func TestUI(t *testing.T) {
+	for _, ui := range []bool{false, true} {
+		var opts []Option
+		if ui {
+			opts = append(opts, UI())
+		}
+		ts := newTestServer(opts...)
+		resp, err := http.Get(ts.URL + "/")
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		ts.Close()
+
+		if ui && (resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/html")) {
+			t.Errorf("Expected the UI to be served, got status %d", resp.StatusCode)
+		}
+		if !ui && resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected no UI without the option, got status %d", resp.StatusCode)
+		}
+	}
+}
+
+func TestMatchStream(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	doc := strings.Repeat("日 本語 in Sydney. ", 10000)
+	resp, err := http.Post(ts.URL+"/match/stream", "text/plain", strings.NewReader(doc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	n := 0
+	for dec.More() {
+		var m fastentity.StreamMatch
+		if err := dec.Decode(&m); err != nil {
+			t.Fatal(err)
+		}
+		if m.Group == "locations" && (m.Offset != 8+16*(n/2) || m.ByteOffset != 14+22*(n/2)) {
+			t.Fatalf("Expected match %d at offset %d, got %+v", n, 8+16*(n/2), m)
+		}
+		n++
+	}
+	if n != 20000 {
+		t.Errorf("Expected 20000 matches, got %d", n)
+	}
+}
+
+func TestLimits(t *testing.T) {
+	store := fastentity.New()
+	store.Add("skills", []rune("PHP"))
+	srv := New(store, MaxConcurrency(1), Timeout(time.Nanosecond))
+	ts := httptest.NewServer(srv)
+	defer ts.Close()
+	// The server responds without reading all of the documents, then closes the
+	// connections, so they mustn't be reused
+	client := &http.Client{Transport: &http.Transport{DisableKeepAlives: true}}
+
+	doc := strings.Repeat("PHP and ", 10000)
+	for _, path := range []string{"/match", "/match/stream"} {
+		resp, err := client.Post(ts.URL+path, "text/plain", strings.NewReader(doc))
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusServiceUnavailable {
+			t.Errorf("%s: expected status 503 for a search past its deadline, got %d", path, resp.StatusCode)
+		}
+	}
+
+	srv.scans <- struct{}{} // Take the only search
+	resp, err := client.Post(ts.URL+"/match", "text/plain", strings.NewReader("PHP"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusTooManyRequests || resp.Header.Get("Retry-After") == "" {
+		t.Errorf("Expected status 429 with Retry-After when busy, got %d", resp.StatusCode)
+	}
+}
+
+func TestMatchBatch(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	body := `{"documents": {"a": "PHP in Sydney", "b": "日 本語", "c": "nothing"}}`
+	resp, err := http.Post(ts.URL+"/match/batch", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+	}
+	var br BatchResponse
+	if err := json.NewDecoder(resp.Body).Decode(&br); err != nil {
+		t.Fatal(err)
+	}
+
+	expected := map[string][]string{"a": {"PHP", "Sydney"}, "b": {"本語"}, "c": {}}
+	if len(br.Results) != len(expected) {
+		t.Fatalf("Expected results for %d documents, got %+v", len(expected), br.Results)
+	}
+	for id, texts := range expected {
+		ms := br.Results[id]
+		if ms == nil || len(ms) != len(texts) {
+			t.Errorf("%s: expected matches %v, got %+v", id, texts, ms)
+			continue
+		}
+		for i, text := range texts {
+			if string(ms[i].Text) != text {
+				t.Errorf("%s: expected match %d to be %q, got %+v", id, i, text, ms[i])
+			}
+		}
+	}
+	if br.Results["b"][0].ByteOffset != 4 {
+		t.Errorf("Expected byte offsets within each document, got %+v", br.Results["b"][0])
+	}
+}
+
+func TestMatchTop(t *testing.T) {
+	store := fastentity.New()
+	store.Add("skills", []rune("PHP"), []rune("machine learning"))
+	store.AddWeighted("skills", []rune("golang"), 2)
+	store.Add("locations", []rune("Sydney"))
+	ts := httptest.NewServer(New(store))
+	defer ts.Close()
+
+	text := "PHP and golang in Sydney, machine learning"
+	for _, c := range []struct {
+		req      MatchRequest
+		expected []string
+	}{
+		{MatchRequest{Text: text, Top: 2}, []string{"golang", "machine learning"}},
+		{MatchRequest{Text: text, Top: 2, Priority: []string{"locations"}}, []string{"golang", "Sydney"}},
+		{MatchRequest{Text: text}, []string{"PHP", "golang", "Sydney", "machine learning"}},
+	} {
+		body, _ := json.Marshal(c.req)
+		_, mr := postMatch(t, ts.URL, "application/json", string(body))
+		var got []string
+		for _, m := range mr.Matches {
+			got = append(got, string(m.Text))
+		}
+		if !reflect.DeepEqual(got, c.expected) {
+			t.Errorf("Top %d %v: expected %q, got %q", c.req.Top, c.req.Priority, c.expected, got)
+		}
+	}
+}
+
+func TestMatchLanguage(t *testing.T) {
+	store := fastentity.New()
+	store.Add("skills@en", []rune("Java"))
+	store.Add("skills@de", []rune("Java"))
+	ts := httptest.NewServer(New(store))
+	defer ts.Close()
+
+	for lang, expected := range map[string]string{"": "", "en": "en", "auto": "de"} {
+		body, _ := json.Marshal(MatchRequest{Text: "Wir suchen einen Java Entwickler für die Buchhaltung", Language: lang})
+		resp, mr := postMatch(t, ts.URL, "application/json", string(body))
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("Expected status 200, got %d", resp.StatusCode)
+		}
+		if mr.Language != expected {
+			t.Errorf("%q: expected language %q, got %q", lang, expected, mr.Language)
+		}
+		n := 2
+		if expected != "" {
+			n = 1
+		}
+		if len(mr.Matches) != n || (n == 1 && mr.Matches[0].Group != "skills@"+expected) {
+			t.Errorf("%q: expected %d matches, got %+v", lang, n, mr.Matches)
+		}
+	}
+}
+
+func TestLookup(t *testing.T) {
+	ts := newTestServer()
+	defer ts.Close()
+
+	body := `{"group": "skills", "keys": ["php", "java"]}`
+	resp, err := http.Post(ts.URL+"/lookup", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var lr LookupResponse
+	if err := json.NewDecoder(resp.Body).Decode(&lr); err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]fastentity.ProvidedEntity{"php": {{Text: "PHP", Weight: fastentity.DefaultWeight}}}
+	if !reflect.DeepEqual(lr.Entities, expected) {
+		t.Errorf("Expected %v, got %v", expected, lr.Entities)
+	}
+
+	resp, err = http.Post(ts.URL+"/lookup", "application/json", strings.NewReader(`{"group": "jobs"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected status 404, got %d", resp.StatusCode)
+	}
+}
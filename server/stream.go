@@ -0,0 +1,58 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/sajari/fastentity"
+)
+
+// handleMatchStream finds the entities in the document streamed in the request body,
+// writing each match as a line of JSON as soon as it's found.
+func (s *Server) handleMatchStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		httpError(w, http.StatusMethodNotAllowed, "method not allowed")
+		return
+	}
+	if !s.acquire(w) {
+		return
+	}
+	defer s.release()
+	ctx, cancel := s.context(r)
+	defer cancel()
+	if deadline, ok := ctx.Deadline(); ok {
+		setReadDeadline(w, deadline)
+	}
+
+	store := s.Store()
+	enableFullDuplex(w)
+	w.Header().Set("Content-Type", "application/x-ndjson")
+	w.Header().Set("Fastentity-Version", strconv.FormatUint(store.Version(), 10))
+	flusher, _ := w.(http.Flusher)
+	enc := json.NewEncoder(w)
+	written := false
+	err := store.FindReaderContext(ctx, r.Body, func(m fastentity.StreamMatch) bool {
+		if err := enc.Encode(m); err != nil {
+			return false // The client has gone
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		written = true
+		return true
+	})
+	switch {
+	case err == nil:
+	case !written && timedOut(err):
+		searchError(w, err)
+	case r.Context().Err() != nil:
+		// The client has gone
+	case !written:
+		httpError(w, http.StatusBadRequest, err.Error())
+	default:
+		// Too late for an error status, so end the stream with the error
+		enc.Encode(map[string]string{"error": err.Error()})
+	}
+}
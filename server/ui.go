@@ -0,0 +1,26 @@
+package server
+
+import (
+	_ "embed"
+	"net/http"
+)
+
+//go:embed ui/index.html
+var uiPage []byte
+
+// UI serves a page at / for trying out the dictionaries: documents pasted into it are
+// searched with the matches highlighted by group, and candidate entities can be tested
+// before adding them to the dictionaries. It's intended for development rather than
+// public deployment.
+func UI() Option {
+	return func(s *Server) {
+		s.mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(uiPage)
+		})
+	}
+}
@@ -0,0 +1,91 @@
+package fastentity
+
+import (
+	"context"
+	"hash/fnv"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ShardMap lists the shards a store has been split into with Split, for dictionaries too
+// large for one process. It's saved in snapshots of the store, so a snapshot of the empty
+// groups serves as a manifest from which clients find the shards, see the shard package.
+type ShardMap struct {
+	// Shards are the addresses of the shards, by shard number.
+	Shards []string
+}
+
+// ShardOf returns which of n shards the entities with the text key belong to, ignoring
+// case.
+func ShardOf(key string, n int) int {
+	h := fnv.New32a()
+	var buf [utf8.UTFMax]byte
+	for _, r := range key {
+		h.Write(buf[:utf8.EncodeRune(buf[:], unicode.ToLower(r))])
+	}
+	return int(h.Sum32() % uint32(n))
+}
+
+// Split partitions the entities of the store into n stores by ShardOf their text, each
+// with every group of the store so that shards can be searched alike. Only the entities
+// and their weights are copied, not the configuration of the groups.
+func (s *Store) Split(n int) []*Store {
+	if n < 1 {
+		n = 1
+	}
+	shards := make([]*Store, n)
+	for i := range shards {
+		shards[i] = New()
+	}
+	for name, ents := range s.entries() {
+		parts := make([][]entry, n)
+		for _, e := range ents {
+			i := ShardOf(string(e.text), n)
+			parts[i] = append(parts[i], e)
+		}
+		for i, shard := range shards {
+			shard.addEntries(name, parts[i])
+		}
+	}
+	return shards
+}
+
+// SetShardMap records the shards the store has been split into, which is saved in
+// snapshots of the store.
+func (s *Store) SetShardMap(m ShardMap) {
+	s.Lock()
+	s.shards = append([]string(nil), m.Shards...)
+	s.Unlock()
+	s.bump()
+}
+
+// ShardMap returns the shards recorded with SetShardMap, or read from a snapshot.
+func (s *Store) ShardMap() ShardMap {
+	s.RLock()
+	defer s.RUnlock()
+	return ShardMap{Shards: append([]string(nil), s.shards...)}
+}
+
+// Lookup returns the entities of the group matching each of keys, ignoring case, leaving
+// out keys with no entities. It serves the group to other stores as a GroupProvider, e.g.
+// from a shard.
+func (g *Group) Lookup(ctx context.Context, keys []string) (map[string][]ProvidedEntity, error) {
+	grp := g.s.group(g.name)
+	grp.rlock()
+	defer grp.RUnlock()
+	found := make(map[string][]ProvidedEntity)
+	for i, key := range keys {
+		if i%checkInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		rs := []rune(key)
+		for _, e := range grp.entities[hash(rs)] {
+			if equalFold(e.text, rs) {
+				found[key] = append(found[key], ProvidedEntity{Text: string(e.text), Weight: e.weight})
+			}
+		}
+	}
+	return found, nil
+}
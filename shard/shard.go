@@ -0,0 +1,163 @@
+// Package shard searches dictionaries too large for one process, split across shards
+// served by the fastentity server.
+//
+// A store is split by the text of its entities with Store.Split, and each shard is served
+// from its own process. A manifest, a snapshot of the empty groups with the addresses of
+// the shards, is written for clients:
+//
+//	shards := store.Split(3)
+//	for i, s := range shards {
+//		s.SaveSnapshot(fmt.Sprintf("shard%d.snap", i)) // served at shard0:8080, ...
+//	}
+//	manifest := fastentity.New(groups...)
+//	manifest.SetShardMap(fastentity.ShardMap{Shards: []string{"http://shard0:8080", "http://shard1:8080", "http://shard2:8080"}})
+//	manifest.SaveSnapshot("manifest.snap")
+//
+// Clients load the manifest and attach the shards to it, and then search it as any other
+// store. The candidate keys of each document are sent to the shard they hash to, and the
+// entities found merged:
+//
+//	store, err := fastentity.LoadSnapshot("manifest.snap")
+//	err = shard.Attach(store, fastentity.ProviderOptions{MaxWords: 4})
+//	results, err := store.FindAllContext(ctx, doc)
+//
+// Entities in shards are only matched by their text, see fastentity.Provide.
+package shard
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/sajari/fastentity"
+	"github.com/sajari/fastentity/server"
+)
+
+// ErrNoShards is returned when attaching the shards of a store without a ShardMap.
+var ErrNoShards = errors.New("no shards")
+
+// Option configures the Client of the shards.
+type Option func(c *Client)
+
+// HTTPClient makes requests to the shards with hc rather than http.DefaultClient.
+func HTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.http = hc
+	}
+}
+
+// Client looks up keys in the shards of a store, over HTTP.
+type Client struct {
+	shards []string
+	http   *http.Client
+}
+
+// NewClient returns a Client of the shards in m.
+func NewClient(m fastentity.ShardMap, opts ...Option) *Client {
+	c := &Client{
+		shards: m.Shards,
+		http:   http.DefaultClient,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Attach backs every group of the store with the shards in its ShardMap, returning
+// ErrNoShards if it has none.
+func Attach(store *fastentity.Store, opts fastentity.ProviderOptions, copts ...Option) error {
+	m := store.ShardMap()
+	if len(m.Shards) == 0 {
+		return ErrNoShards
+	}
+	c := NewClient(m, copts...)
+	for name := range store.Stats() {
+		store.Group(name).Configure(fastentity.Provide(c.Provider(name), opts))
+	}
+	return nil
+}
+
+// Provider returns a GroupProvider looking up the entities of the named group in the
+// shards.
+func (c *Client) Provider(group string) fastentity.GroupProvider {
+	return provider{c: c, group: group}
+}
+
+type provider struct {
+	c     *Client
+	group string
+}
+
+// Lookup looks up each key in the shard it belongs to, querying the shards concurrently.
+func (p provider) Lookup(ctx context.Context, keys []string) (map[string][]fastentity.ProvidedEntity, error) {
+	byShard := make([][]string, len(p.c.shards))
+	for _, k := range keys {
+		i := fastentity.ShardOf(k, len(p.c.shards))
+		byShard[i] = append(byShard[i], k)
+	}
+
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		found = make(map[string][]fastentity.ProvidedEntity)
+		errs  = make([]error, len(byShard))
+	)
+	for i, ks := range byShard {
+		if len(ks) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(i int, ks []string) {
+			defer wg.Done()
+			ents, err := p.c.lookup(ctx, p.c.shards[i], p.group, ks)
+			if err != nil {
+				errs[i] = fmt.Errorf("shard %d: %w", i, err)
+				return
+			}
+			mu.Lock()
+			for k, es := range ents {
+				found[k] = es
+			}
+			mu.Unlock()
+		}(i, ks)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return found, nil
+}
+
+// lookup looks up the keys in the group of the shard at addr.
+func (c *Client) lookup(ctx context.Context, addr, group string, keys []string) (map[string][]fastentity.ProvidedEntity, error) {
+	body, err := json.Marshal(server.LookupRequest{Group: group, Keys: keys})
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(addr, "/")+"/lookup", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := c.http.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("shard responded with %v", resp.Status)
+	}
+	var lr server.LookupResponse
+	if err := json.NewDecoder(resp.Body).Decode(&lr); err != nil {
+		return nil, err
+	}
+	return lr.Entities, nil
+}
@@ -0,0 +1,53 @@
+package shard
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/sajari/fastentity"
+	"github.com/sajari/fastentity/server"
+)
+
+func TestAttach(t *testing.T) {
+	store := fastentity.New()
+	store.Add("skills", []rune("go"), []rune("python"), []rune("rust"), []rune("machine learning"))
+	store.AddWeighted("locations", []rune("New York"), 2)
+	store.Add("locations", []rune("Sydney"))
+
+	var m fastentity.ShardMap
+	for _, s := range store.Split(3) {
+		ts := httptest.NewServer(server.New(s))
+		defer ts.Close()
+		m.Shards = append(m.Shards, ts.URL)
+	}
+	manifest := fastentity.New("skills", "locations")
+	if err := Attach(manifest, fastentity.ProviderOptions{}); !errors.Is(err, ErrNoShards) {
+		t.Errorf("Expected ErrNoShards, got %v", err)
+	}
+	manifest.SetShardMap(m)
+	var buf bytes.Buffer
+	if err := manifest.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	client, err := fastentity.ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := Attach(client, fastentity.ProviderOptions{MaxWords: 2}); err != nil {
+		t.Fatal(err)
+	}
+
+	doc := []rune("Machine learning with Go and Rust in New York and Sydney")
+	want := store.FindAll(doc)
+	got, err := client.FindAllContext(context.Background(), doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
@@ -0,0 +1,49 @@
+package fastentity
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("go"), []rune("python"), []rune("rust"), []rune("java"), []rune("haskell"))
+	store.Add("empty")
+
+	shards := store.Split(3)
+	total := 0
+	for i, shard := range shards {
+		if _, err := shard.LookupGroup("empty"); err != nil {
+			t.Errorf("Shard %d: %v", i, err)
+		}
+		shard.Group("skills").Range(func(e []rune) bool {
+			if got := ShardOf(string(e), 3); got != i {
+				t.Errorf("Expected %q in shard %d, got %d", string(e), got, i)
+			}
+			total++
+			return true
+		})
+	}
+	if total != 5 {
+		t.Errorf("Expected 5 entities across the shards, got %d", total)
+	}
+	if ShardOf("Python", 3) != ShardOf("python", 3) {
+		t.Error("Expected shards to ignore case")
+	}
+
+	// The shard map is kept in snapshots
+	m := ShardMap{Shards: []string{"http://a", "http://b", "http://c"}}
+	store.SetShardMap(m)
+	var buf bytes.Buffer
+	if err := store.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	read, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(read.ShardMap(), m) {
+		t.Errorf("Expected %v, got %v", m, read.ShardMap())
+	}
+}
@@ -0,0 +1,36 @@
+package fastentity
+
+// MinMatchLength makes the group only find matches of at least n runes, however they were
+// matched. Very short entities such as "C" are found in many documents where they don't
+// mean the entity, and this drops them without removing them from the group.
+func MinMatchLength(n int) GroupOption {
+	return func(g *group) {
+		g.minLen = n
+	}
+}
+
+// ExactCase makes the entities of the group with fewer than n runes only match text with
+// the same case as the entity, e.g. the entity "IT" matches "IT" but not "it", and "Go"
+// doesn't match "go". Longer entities still match ignoring case. It applies to matches
+// on the text of entities, including provided entities, and to normalized matches, which
+// must have the case of the entity for as many runes as the entity has, e.g. with
+// FoldPlurals "SQL" matches "SQLs" but not "sqls".
+func ExactCase(n int) GroupOption {
+	return func(g *group) {
+		g.exactCaseBelow = n
+	}
+}
+
+// caseMatches reports whether text may match the entity ent, which are equal ignoring
+// case or once normalized, see ExactCase.
+func (g *group) caseMatches(ent, text []rune) bool {
+	if len(ent) >= g.exactCaseBelow {
+		return true
+	}
+	for i, r := range ent {
+		if i >= len(text) || text[i] != r {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,44 @@
+package fastentity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestShortEntities(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("C"), []rune("Go"), []rune("IT"), []rune("golang"))
+	doc := []rune("c and C, go and Go, it and IT, Golang")
+
+	texts := func() []string {
+		var ts []string
+		for _, e := range store.FindAll(doc)["skills"] {
+			ts = append(ts, string(e.Text))
+		}
+		return ts
+	}
+	if got := texts(); len(got) != 7 {
+		t.Errorf("Expected 7 matches ignoring case, got %q", got)
+	}
+
+	store.Group("skills").Configure(MinMatchLength(2), ExactCase(3))
+	want := []string{"Go", "IT", "Golang"}
+	if got := texts(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestShortEntitiesNormalized(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("IT"), []rune("SQL"), []rune("golang"))
+	store.Group("skills").Configure(FoldPlurals(), ExactCase(4))
+
+	var got []string
+	for _, m := range store.FindAll([]rune("it and IT, sqls and SQLs, Golangs")).Matches() {
+		got = append(got, m.String())
+	}
+	want := []string{"skills:7:2:IT", "skills:20:4:SQLs", "skills:26:7:Golangs"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
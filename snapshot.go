@@ -0,0 +1,412 @@
+package fastentity
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sync"
+	"sync/atomic"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ErrCorruptSnapshot is returned when a snapshot can't be decoded.
+var ErrCorruptSnapshot = errors.New("corrupt snapshot")
+
+// snapshotMagic identifies snapshots, and is followed by the format version.
+const (
+	snapshotMagic   = "FESNAP"
+	snapshotVersion = 1
+)
+
+// Codec compresses snapshots. Snapshots record the name of the codec used to write them,
+// and any codec registered with RegisterCodec can be used to read them back.
+//
+// Gzip and Zstd are registered by default. Other codecs can be plugged in by wrapping a
+// third party implementation and registering it.
+type Codec interface {
+	// Name identifies the codec in snapshots, and must be at most 255 bytes.
+	Name() string
+	NewWriter(w io.Writer) (io.WriteCloser, error)
+	NewReader(r io.Reader) (io.ReadCloser, error)
+}
+
+var (
+	// Gzip compresses snapshots with gzip.
+	Gzip Codec = gzipCodec{}
+
+	// Zstd compresses snapshots with Zstandard, which compresses them smaller than gzip
+	// and decompresses them several times faster. Snapshots are decompressed as they are
+	// read, without buffering the whole snapshot.
+	Zstd Codec = zstdCodec{}
+)
+
+var codecs = struct {
+	sync.RWMutex
+	m map[string]Codec
+}{
+	m: map[string]Codec{
+		Gzip.Name(): Gzip,
+		Zstd.Name(): Zstd,
+	},
+}
+
+// RegisterCodec makes the codec available for reading snapshots, replacing any codec
+// registered with the same name.
+func RegisterCodec(c Codec) {
+	codecs.Lock()
+	codecs.m[c.Name()] = c
+	codecs.Unlock()
+}
+
+// LookupCodec returns the registered codec with the given name.
+func LookupCodec(name string) (Codec, bool) {
+	codecs.RLock()
+	c, ok := codecs.m[name]
+	codecs.RUnlock()
+	return c, ok
+}
+
+type gzipCodec struct{}
+
+func (gzipCodec) Name() string {
+	return "gzip"
+}
+
+func (gzipCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return gzip.NewWriter(w), nil
+}
+
+func (gzipCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	return gzip.NewReader(r)
+}
+
+type zstdCodec struct{}
+
+func (zstdCodec) Name() string {
+	return "zstd"
+}
+
+func (zstdCodec) NewWriter(w io.Writer) (io.WriteCloser, error) {
+	return zstd.NewWriter(w)
+}
+
+func (zstdCodec) NewReader(r io.Reader) (io.ReadCloser, error) {
+	// Decode on the reading goroutine, so that nothing is left running if the snapshot
+	// isn't read to the end
+	d, err := zstd.NewReader(r, zstd.WithDecoderConcurrency(1))
+	if err != nil {
+		return nil, err
+	}
+	return d.IOReadCloser(), nil
+}
+
+// SnapshotOption configures how snapshots are written.
+type SnapshotOption func(c *snapshotConfig)
+
+type snapshotConfig struct {
+	codec Codec
+}
+
+// Compress compresses the snapshot with the codec.
+func Compress(c Codec) SnapshotOption {
+	return func(sc *snapshotConfig) {
+		sc.codec = c
+	}
+}
+
+// snapshotChunkSize is the maximum number of entities in each chunk of a snapshot, which
+// keeps the size of individual messages down for very large groups.
+const snapshotChunkSize = 1 << 16
+
+// snapshotHeader starts the body of a snapshot. Shards is the ShardMap of the store.
+// Snapshots written before manifests were added have none.
+type snapshotHeader struct {
+	Chunks    int
+	Version   uint64
+	Shards    []string
+	Manifest  *Manifest
+	Tokenizer Tokenizer
+}
+
+// snapshotChunk holds some or all of the entities of a group in a snapshot. Weights are
+// only included if any differ from DefaultWeight, and Sources if any entities have one.
+type snapshotChunk struct {
+	Group    string
+	Entities []string
+	Weights  []float64
+	Sources  []string
+}
+
+// WriteSnapshot writes all the groups in the store to w in a compact binary format,
+// which can be read back with ReadSnapshot. The snapshot records the version of the store,
+// which is restored when it's read, its Manifest and its Tokenizer.
+func (s *Store) WriteSnapshot(w io.Writer, opts ...SnapshotOption) error {
+	var c snapshotConfig
+	for _, opt := range opts {
+		opt(&c)
+	}
+
+	var name string
+	if c.codec != nil {
+		name = c.codec.Name()
+		if len(name) > 255 {
+			return fmt.Errorf("codec name %q too long", name)
+		}
+	}
+	header := append([]byte(snapshotMagic), snapshotVersion, byte(len(name)))
+	if _, err := w.Write(append(header, name...)); err != nil {
+		return err
+	}
+
+	var bw io.WriteCloser = nopWriteCloser{w}
+	if c.codec != nil {
+		var err error
+		if bw, err = c.codec.NewWriter(w); err != nil {
+			return err
+		}
+	}
+
+	version := s.Version()
+	s.RLock()
+	shards, tok := s.shards, s.tokenizer
+	names := make([]string, 0, len(s.groups))
+	entries := make([][]entry, 0, len(s.groups))
+	counts := make(map[string]int, len(s.groups))
+	chunks := 0
+	for name, g := range s.groups {
+		ents := g.all()
+		counts[name] = len(ents)
+		names = append(names, name)
+		entries = append(entries, ents)
+		chunks += (len(ents) + snapshotChunkSize - 1) / snapshotChunkSize
+		if len(ents) == 0 {
+			chunks++ // Keep empty groups
+		}
+	}
+	manifest := s.newManifest(counts)
+	s.RUnlock()
+	manifest.Version = version
+
+	enc := gob.NewEncoder(bw)
+	if err := enc.Encode(snapshotHeader{Chunks: chunks, Version: version, Shards: shards, Manifest: manifest, Tokenizer: tok}); err != nil {
+		return err
+	}
+	for i, name := range names {
+		ents := entries[i]
+		for start := 0; start == 0 || start < len(ents); start += snapshotChunkSize {
+			end := start + snapshotChunkSize
+			if end > len(ents) {
+				end = len(ents)
+			}
+			c := snapshotChunk{
+				Group:    name,
+				Entities: make([]string, 0, end-start),
+			}
+			for _, e := range ents[start:end] {
+				c.Entities = append(c.Entities, string(e.text))
+			}
+			for j, e := range ents[start:end] {
+				if e.weight == DefaultWeight {
+					continue
+				}
+				if c.Weights == nil {
+					c.Weights = make([]float64, end-start)
+					for k := range c.Weights {
+						c.Weights[k] = DefaultWeight
+					}
+				}
+				c.Weights[j] = e.weight
+			}
+			for j, e := range ents[start:end] {
+				if e.source == "" {
+					continue
+				}
+				if c.Sources == nil {
+					c.Sources = make([]string, end-start)
+				}
+				c.Sources[j] = e.source
+			}
+			if err := enc.Encode(c); err != nil {
+				return err
+			}
+		}
+	}
+	return bw.Close()
+}
+
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error {
+	return nil
+}
+
+// ReadSnapshot creates a new Store from a snapshot written by WriteSnapshot. Compressed
+// snapshots are decoded as they are read, using the registered codec they were written
+// with. Errors decoding the snapshot wrap ErrCorruptSnapshot.
+func ReadSnapshot(r io.Reader) (*Store, error) {
+	sr, err := newSnapshotReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer sr.Close()
+
+	s := New()
+	for i := 0; i < sr.header.Chunks; i++ {
+		c, err := sr.chunk()
+		if err != nil {
+			return nil, err
+		}
+		g := s.group(c.Group)
+		g.Lock()
+		for _, e := range appendChunk(nil, c) {
+			g.add(e)
+		}
+		g.Unlock()
+	}
+
+	// Drain the body so that codecs verify their checksums.
+	if _, err := io.Copy(ioutil.Discard, sr.body); err != nil {
+		return nil, fmt.Errorf("%v: %w", err, ErrCorruptSnapshot)
+	}
+	s.shards, s.manifest, s.tokenizer = sr.header.Shards, sr.header.Manifest, sr.header.Tokenizer
+	atomic.StoreUint64(&s.version, sr.header.Version)
+	return s, nil
+}
+
+// snapshotReader decodes the body of a snapshot.
+type snapshotReader struct {
+	header snapshotHeader
+	body   io.Reader
+	dec    *gob.Decoder
+	close  func() error
+}
+
+// newSnapshotReader reads the header of the snapshot read from r, and returns a reader
+// of its chunks. The reader must be closed once done with.
+func newSnapshotReader(r io.Reader) (*snapshotReader, error) {
+	br := bufio.NewReader(r)
+	header := make([]byte, len(snapshotMagic)+2)
+	if _, err := io.ReadFull(br, header); err != nil {
+		return nil, fmt.Errorf("reading header: %v: %w", err, ErrCorruptSnapshot)
+	}
+	if string(header[:len(snapshotMagic)]) != snapshotMagic {
+		return nil, fmt.Errorf("not a snapshot: %w", ErrCorruptSnapshot)
+	}
+	if v := header[len(snapshotMagic)]; v != snapshotVersion {
+		return nil, fmt.Errorf("unsupported snapshot version %d", v)
+	}
+	name := make([]byte, header[len(snapshotMagic)+1])
+	if _, err := io.ReadFull(br, name); err != nil {
+		return nil, fmt.Errorf("reading header: %v: %w", err, ErrCorruptSnapshot)
+	}
+
+	sr := &snapshotReader{
+		body:  br,
+		close: func() error { return nil },
+	}
+	if len(name) > 0 {
+		c, ok := LookupCodec(string(name))
+		if !ok {
+			return nil, fmt.Errorf("snapshot compressed with unknown codec %q", name)
+		}
+		rc, err := c.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("%v: %w", err, ErrCorruptSnapshot)
+		}
+		sr.body, sr.close = rc, rc.Close
+	}
+
+	sr.dec = gob.NewDecoder(sr.body)
+	if err := sr.dec.Decode(&sr.header); err != nil {
+		sr.Close()
+		return nil, fmt.Errorf("decoding header: %v: %w", err, ErrCorruptSnapshot)
+	}
+	return sr, nil
+}
+
+// chunk decodes the next chunk of the snapshot.
+func (sr *snapshotReader) chunk() (*snapshotChunk, error) {
+	var c snapshotChunk
+	if err := sr.dec.Decode(&c); err != nil {
+		return nil, fmt.Errorf("decoding group: %v: %w", err, ErrCorruptSnapshot)
+	}
+	if c.Weights != nil && len(c.Weights) != len(c.Entities) {
+		return nil, fmt.Errorf("group %q has %d weights for %d entities: %w", c.Group, len(c.Weights), len(c.Entities), ErrCorruptSnapshot)
+	}
+	if c.Sources != nil && len(c.Sources) != len(c.Entities) {
+		return nil, fmt.Errorf("group %q has %d sources for %d entities: %w", c.Group, len(c.Sources), len(c.Entities), ErrCorruptSnapshot)
+	}
+	return &c, nil
+}
+
+func (sr *snapshotReader) Close() error {
+	return sr.close()
+}
+
+// appendChunk appends the entities of the chunk to ents.
+func appendChunk(ents []entry, c *snapshotChunk) []entry {
+	for j, e := range c.Entities {
+		ent := newEntry([]rune(e))
+		if c.Weights != nil {
+			ent.weight = c.Weights[j]
+		}
+		if c.Sources != nil {
+			ent.source = c.Sources[j]
+		}
+		ents = append(ents, ent)
+	}
+	return ents
+}
+
+// SaveSnapshot writes a snapshot of the store to the file at path.
+func (s *Store) SaveSnapshot(path string, opts ...SnapshotOption) error {
+	f, err := createFile(path)
+	if err != nil {
+		return fmt.Errorf("error creating %v: %w", path, err)
+	}
+	defer f.Close()
+
+	bw := bufio.NewWriter(f)
+	err = s.WriteSnapshot(bw, opts...)
+	if err == nil {
+		err = bw.Flush()
+	}
+	if err == nil {
+		err = f.Close()
+	}
+	if err != nil {
+		return fmt.Errorf("error writing to %v: %w", path, err)
+	}
+	return nil
+}
+
+// LoadSnapshot creates a new Store from the snapshot file at path. Of the LoadOptions,
+// only Lazy applies to snapshots.
+func LoadSnapshot(path string, opts ...LoadOption) (*Store, error) {
+	var c loadConfig
+	for _, opt := range opts {
+		opt.applyLoad(&c)
+	}
+	if c.lazy {
+		return lazySnapshot(path)
+	}
+
+	f, err := openFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening %v: %w", path, err)
+	}
+	defer f.Close()
+
+	s, err := ReadSnapshot(f)
+	if err != nil {
+		return nil, fmt.Errorf("error reading from %v: %w", path, err)
+	}
+	return s, nil
+}
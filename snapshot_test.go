@@ -0,0 +1,94 @@
+package fastentity
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	store := New("empty")
+	store.Add("locations", []rune("San Francisco, USA"))
+	store.Add("skills", []rune("PHP"), []rune("本語"), []rune("PRC"))
+	for i := 0; i < snapshotChunkSize+10; i++ {
+		store.Add("names", []rune(fmt.Sprintf("name%d", i)))
+	}
+
+	for _, opts := range [][]SnapshotOption{nil, {Compress(Gzip)}, {Compress(Zstd)}} {
+		var buf bytes.Buffer
+		if err := store.WriteSnapshot(&buf, opts...); err != nil {
+			t.Fatalf("Failed to write snapshot: %v", err)
+		}
+		loaded, err := ReadSnapshot(&buf)
+		if err != nil {
+			t.Fatalf("Failed to read snapshot: %v", err)
+		}
+		expected := map[string]int{"empty": 0, "locations": 1, "skills": 3, "names": snapshotChunkSize + 10}
+		if len(loaded.groups) != len(expected) {
+			t.Errorf("Expected %d groups, got %d", len(expected), len(loaded.groups))
+		}
+		for name, n := range expected {
+			if _, ok := loaded.groups[name]; !ok {
+				t.Errorf("Expected group %q", name)
+			} else if got := loaded.Group(name).Len(); got != n {
+				t.Errorf("Expected %d entities in %q, got %d", n, name, got)
+			}
+		}
+		if len(loaded.FindAll([]rune("Maybe PHP, or PDX. "))["skills"]) != 1 {
+			t.Errorf("Expected to find entities in the loaded store")
+		}
+		if loaded.Version() != store.Version() {
+			t.Errorf("Expected version %d, got %d", store.Version(), loaded.Version())
+		}
+	}
+}
+
+func TestSnapshotCorrupt(t *testing.T) {
+	if _, err := ReadSnapshot(bytes.NewReader([]byte("skills.entities.csv"))); !errors.Is(err, ErrCorruptSnapshot) {
+		t.Errorf("Expected ErrCorruptSnapshot for bad magic, got %v", err)
+	}
+
+	var buf bytes.Buffer
+	store := New()
+	store.Add("skills", []rune("PHP"))
+	for _, codec := range []Codec{Gzip, Zstd} {
+		buf.Reset()
+		if err := store.WriteSnapshot(&buf, Compress(codec)); err != nil {
+			t.Fatal(err)
+		}
+		truncated := buf.Bytes()[:buf.Len()-10]
+		if _, err := ReadSnapshot(bytes.NewReader(truncated)); !errors.Is(err, ErrCorruptSnapshot) {
+			t.Errorf("Expected ErrCorruptSnapshot for truncated %s snapshot, got %v", codec.Name(), err)
+		}
+	}
+}
+
+func TestSnapshotZstd(t *testing.T) {
+	store := New()
+	for i := 0; i < 10000; i++ {
+		store.Add("names", []rune(fmt.Sprintf("software engineer %d", i)))
+	}
+
+	var gz, zs bytes.Buffer
+	if err := store.WriteSnapshot(&gz, Compress(Gzip)); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.WriteSnapshot(&zs, Compress(Zstd)); err != nil {
+		t.Fatal(err)
+	}
+	if zs.Len() >= gz.Len() {
+		t.Errorf("Expected zstd snapshot smaller than gzip, got %d bytes vs %d", zs.Len(), gz.Len())
+	}
+	if c, ok := LookupCodec("zstd"); !ok || c != Zstd {
+		t.Errorf("Expected zstd to be registered, got %v", c)
+	}
+
+	loaded, err := ReadSnapshot(&zs)
+	if err != nil {
+		t.Fatalf("Failed to read zstd snapshot: %v", err)
+	}
+	if n := loaded.Group("names").Len(); n != 10000 {
+		t.Errorf("Expected 10000 names, got %d", n)
+	}
+}
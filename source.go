@@ -0,0 +1,34 @@
+package fastentity
+
+// SourceName records name as the source of the entities added by AddFromReader, such as
+// the URL or path they were read from, see Group.Source. Entities loaded by FromDir and
+// LoadDir have the path of their file as their source, so it has no effect on them.
+func SourceName(name string) LoadOption {
+	return loadOptionFunc(func(c *loadConfig) {
+		c.source = name
+	})
+}
+
+// Source returns where the entity e of the group, ignoring case, came from: the path of
+// the entity file it was loaded from, or the name given to AddFromReader with SourceName.
+// It returns false if the group doesn't have the entity, and an empty source for entities
+// added otherwise. Sources are kept in snapshots, so a bad match can be traced to the
+// dictionary file it came from.
+func (g *Group) Source(e []rune) (string, bool) {
+	grp := g.s.group(g.name)
+	grp.rlock()
+	defer grp.RUnlock()
+
+	ent := grp.lookup(e)
+	if ent == nil {
+		return "", false
+	}
+	return ent.source, true
+}
+
+// setSource sets the source of the entries ents.
+func setSource(ents []entry, source string) {
+	for i := range ents {
+		ents[i].source = source
+	}
+}
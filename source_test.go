@@ -0,0 +1,59 @@
+package fastentity
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSource(t *testing.T) {
+	dir, err := ioutil.TempDir("", "fastentity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := dir + "/skills" + entityFileSuffix
+	if err := ioutil.WriteFile(path, []byte("golang\nPHP\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	store, _, err := LoadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := AddFromReader(strings.NewReader("rust\n"), store, "skills", SourceName("https://example.com/skills.csv")); err != nil {
+		t.Fatal(err)
+	}
+	store.Add("skills", []rune("java"))
+
+	want := map[string]string{
+		"GOLANG": path,
+		"php":    path,
+		"rust":   "https://example.com/skills.csv",
+		"java":   "",
+	}
+	check := func(s *Store) {
+		t.Helper()
+		for e, w := range want {
+			if got, ok := s.Group("skills").Source([]rune(e)); !ok || got != w {
+				t.Errorf("Expected source %q for %q, got %q (%v)", w, e, got, ok)
+			}
+		}
+		if _, ok := s.Group("skills").Source([]rune("perl")); ok {
+			t.Error("Expected no source for a missing entity")
+		}
+	}
+	check(store)
+
+	var buf bytes.Buffer
+	if err := store.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(restored)
+}
@@ -0,0 +1,61 @@
+package fastentity
+
+import "sync/atomic"
+
+// GroupStats describes how a group has been used since it was created.
+type GroupStats struct {
+	// Entities is the number of entities in the group.
+	Entities int
+	// Documents is the number of documents the group has been searched in, and Matched the
+	// number in which at least one of its entities was found.
+	Documents, Matched uint64
+	// Matches is the total number of matches of its entities, before any result filters.
+	Matches uint64
+	// Duplicates is the number of entities which weren't added because the group already
+	// had them, ignoring case.
+	Duplicates uint64
+}
+
+// HitRate returns the fraction of the documents searched in which the group's entities
+// were found.
+func (gs GroupStats) HitRate() float64 {
+	if gs.Documents == 0 {
+		return 0
+	}
+	return float64(gs.Matched) / float64(gs.Documents)
+}
+
+// Stats returns the stats of each group in the store. All searches are counted, whether
+// by FindAll, Matches or the Find method of a single group.
+func (s *Store) Stats() map[string]GroupStats {
+	s.RLock()
+	defer s.RUnlock()
+
+	stats := make(map[string]GroupStats, len(s.groups))
+	for name, g := range s.groups {
+		g.RLock()
+		stats[name] = GroupStats{
+			Entities:   g.len(),
+			Documents:  atomic.LoadUint64(&g.stats.documents),
+			Matched:    atomic.LoadUint64(&g.stats.matched),
+			Matches:    atomic.LoadUint64(&g.stats.matches),
+			Duplicates: atomic.LoadUint64(&g.stats.duplicates),
+		}
+		g.RUnlock()
+	}
+	return stats
+}
+
+// groupStats are the counters behind GroupStats.
+type groupStats struct {
+	documents, matched, matches, duplicates uint64
+}
+
+// record counts a search of a document which found n matches.
+func (gs *groupStats) record(n uint64) {
+	atomic.AddUint64(&gs.documents, 1)
+	if n > 0 {
+		atomic.AddUint64(&gs.matched, 1)
+		atomic.AddUint64(&gs.matches, n)
+	}
+}
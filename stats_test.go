@@ -0,0 +1,28 @@
+package fastentity
+
+import "testing"
+
+func TestStats(t *testing.T) {
+	store := New("empty")
+	store.Add("skills", []rune("PHP"), []rune("golang"))
+	store.Add("locations", []rune("Sydney"))
+
+	store.FindAll([]rune("Maybe PHP, or golang. "))
+	store.FindAll([]rune("From Sydney, with PHP. "))
+	store.Group("skills").Find([]rune("Nothing here. "))
+
+	expected := map[string]GroupStats{
+		"empty":     {Entities: 0, Documents: 2},
+		"skills":    {Entities: 2, Documents: 3, Matched: 2, Matches: 3},
+		"locations": {Entities: 1, Documents: 2, Matched: 1, Matches: 1},
+	}
+	stats := store.Stats()
+	for name, e := range expected {
+		if got := stats[name]; got != e {
+			t.Errorf("Expected %q stats %+v, got %+v", name, e, got)
+		}
+	}
+	if r := stats["locations"].HitRate(); r != 0.5 {
+		t.Errorf("Expected hit rate 0.5, got %v", r)
+	}
+}
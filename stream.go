@@ -0,0 +1,176 @@
+package fastentity
+
+import (
+	"bufio"
+	"context"
+	"io"
+)
+
+// streamChunk is the number of runes read from a stream before they are searched.
+var streamChunk = 64 << 10
+
+// StreamMatch is a match found by FindReader, with its offset in bytes from the start of
+// the stream as well as in runes.
+type StreamMatch struct {
+	Match
+	ByteOffset int
+}
+
+// FindReader searches the document read from r, calling fn for each entity found across
+// all groups until fn returns false or r is exhausted. Offsets are from the start of the
+// stream, and entities spanning the chunks r is read in are found as if the whole
+// document had been read at once.
+//
+// Memory use is bounded: only a chunk of the document and the last MaxEntityLen runes of
+// the previous chunk are held at a time. Groups are locked while each chunk is searched
+// rather than for the whole stream, so the store may be modified concurrently, and
+// later parts of the document are searched with the changes. Preprocessors and result
+// filters, which need the whole document, aren't applied, nor are FindOptions other than
+// DocumentID.
+func (s *Store) FindReader(r io.Reader, fn func(StreamMatch) bool, opts ...FindOption) error {
+	return s.FindReaderContext(context.Background(), r, fn, opts...)
+}
+
+// FindReaderContext is like FindReader, but stops reading when ctx is cancelled,
+// returning ctx.Err().
+func (s *Store) FindReaderContext(ctx context.Context, r io.Reader, fn func(StreamMatch) bool, opts ...FindOption) error {
+	c := newFindConfig(opts)
+	rr, ok := r.(io.RuneReader)
+	if !ok {
+		rr = bufio.NewReader(r)
+	}
+
+	var (
+		buf   []rune
+		sizes []int // size in bytes of each rune of buf
+		bytes []int // offset in bytes of each rune of buf from buf[0], while searching
+
+		base, baseByte int  // offset of buf[0] in the stream
+		skipping       bool // discarding a word too long to match
+		eof            bool
+		stop           bool
+	)
+	tok := s.Tokenizer()
+	chunk := streamChunk
+	if n := 4 * MaxEntityLen; n > chunk {
+		chunk = n // Room for a word left over from the previous chunk, and another
+	}
+	found := make(map[*group]uint64)
+	limits := make(matchLimiter)
+	defer func() {
+		for g, n := range found {
+			g.stats.record(n)
+		}
+	}()
+
+	var scratch Scratch
+	for !eof && !stop {
+		// Read a chunk, skipping words which are too long to be part of an entity
+		for len(buf) < chunk {
+			c, size, err := rr.ReadRune()
+			if err == io.EOF {
+				eof = true
+				break
+			}
+			if err != nil {
+				return err
+			}
+			if skipping {
+				if !tok.isBoundary(c) {
+					base++
+					baseByte += size
+					continue
+				}
+				skipping = false
+			}
+			buf = append(buf, c)
+			sizes = append(sizes, size)
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		// Search up to the end of the last complete word, reporting the matches which
+		// start before the words which may still be part of an entity continuing in the
+		// next chunk. Those are searched again with the next chunk.
+		end, keep := len(buf), len(buf)
+		if !eof {
+			end = tok.lastBoundary(buf) + 1
+			if len(buf)-end > MaxEntityLen {
+				// The last word is too long to be part of an entity, nothing continues
+				skipping = true
+			} else {
+				keep = tok.firstWord(buf, end-MaxEntityLen, end)
+			}
+		}
+
+		bytes = bytes[:0]
+		n := 0
+		for _, size := range sizes[:end] {
+			bytes = append(bytes, n)
+			n += size
+		}
+		bytes = append(bytes, n)
+
+		groups := s.rlockGroups()
+		for _, g := range groups {
+			if _, ok := found[g]; !ok {
+				found[g] = 0 // Still counts as a document searched
+			}
+		}
+		err := search(ctx, buf[:end], tok, groups, make([]uint64, len(groups)), &scratch, func(g *group, ent *entry, e Entity) bool {
+			if e.Offset >= keep || g.deny || !limits.allow(g, ent) {
+				return true
+			}
+			found[g]++
+			g.count(ent)
+			m := StreamMatch{Match: Match{Entity: e}, ByteOffset: baseByte + bytes[e.Offset]}
+			m.Offset += base
+			m.Text = append([]rune(nil), e.Text...)
+			m.Doc = c.doc
+			if !fn(m) {
+				stop = true
+			}
+			return !stop
+		})
+		runlockGroups(groups)
+		if err != nil {
+			return err
+		}
+
+		if skipping {
+			keep = len(buf)
+		}
+		base += keep
+		for _, size := range sizes[:keep] {
+			baseByte += size
+		}
+		buf = append(buf[:0], buf[keep:]...)
+		sizes = append(sizes[:0], sizes[keep:]...)
+	}
+	return nil
+}
+
+// lastBoundary returns the index of the last word boundary in rs, or -1 if there is none.
+func (t Tokenizer) lastBoundary(rs []rune) int {
+	for i := len(rs) - 1; i >= 0; i-- {
+		if t.boundary(rs, i) {
+			return i
+		}
+	}
+	return -1
+}
+
+// firstWord returns the offset of the first word of rs starting in [from, to), or to if
+// none do.
+func (t Tokenizer) firstWord(rs []rune, from, to int) int {
+	if from < 0 {
+		from = 0
+	}
+	for i := from; i < to; i++ {
+		if !t.boundary(rs, i) && (i == 0 || t.boundary(rs, i-1)) {
+			return i
+		}
+	}
+	return to
+}
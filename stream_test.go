@@ -0,0 +1,84 @@
+package fastentity
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestFindReader(t *testing.T) {
+	defer func(n int) { streamChunk = n }(streamChunk)
+	streamChunk = 1 // As small as allowed, so entities span chunks
+
+	store := New()
+	store.Add("skills", []rune("PHP"), []rune("golang"), []rune("machine learning"))
+	store.Add("locations", []rune("Sydney"), []rune("São Paulo"), []rune("New York City"))
+
+	words := []string{"PHP", "golang", "machine", "learning", "Sydney", "São", "Paulo",
+		"New", "York", "City", "and", "in", "the", "🙂", strings.Repeat("x", 2*MaxEntityLen)}
+	seps := []string{" ", ", ", ". ", "\n"}
+	rng := rand.New(rand.NewSource(1))
+	var b strings.Builder
+	for i := 0; i < 2000; i++ {
+		b.WriteString(words[rng.Intn(len(words))])
+		b.WriteString(seps[rng.Intn(len(seps))])
+	}
+	doc := b.String()
+	rs := []rune(doc)
+
+	expected := store.FindAll(rs).Matches()
+	var got []Match
+	x := NewOffsetIndex(rs)
+	err := store.FindReader(iotest.OneByteReader(strings.NewReader(doc)), func(m StreamMatch) bool {
+		if b := x.Byte(m.Offset); m.ByteOffset != b {
+			t.Errorf("Expected byte offset %d for %q at %d, got %d", b, string(m.Text), m.Offset, m.ByteOffset)
+		}
+		got = append(got, m.Match)
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(expected) < 100 {
+		t.Fatalf("Expected more matches in the document, got %d", len(expected))
+	}
+	if !reflect.DeepEqual(groupMatches(got).Matches(), expected) {
+		t.Errorf("Expected the same matches as FindAll, got %d of %d", len(got), len(expected))
+	}
+}
+
+func groupMatches(ms []Match) Results {
+	r := make(Results)
+	for _, m := range ms {
+		r[m.Group] = append(r[m.Group], m.Entity)
+	}
+	return r
+}
+
+func TestFindReaderStop(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("PHP"))
+
+	n := 0
+	err := store.FindReader(strings.NewReader("PHP, PHP and PHP"), func(m StreamMatch) bool {
+		n++
+		return n < 2
+	})
+	if err != nil || n != 2 {
+		t.Errorf("Expected to stop after 2 matches, got %d (%v)", n, err)
+	}
+	if s := store.Stats()["skills"]; s.Documents != 1 || s.Matches != 2 {
+		t.Errorf("Expected 1 document with 2 matches, got %+v", s)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	err = store.FindReaderContext(ctx, strings.NewReader("PHP"), func(StreamMatch) bool { return true })
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package fastentity
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// Synonyms makes phrases match each other wherever they appear in the entities of the
+// group, ignoring case. Each set lists phrases which are equivalent, e.g. with the set
+// {"NYC", "New York City"} the entity "New York City" also matches "NYC", and the entity
+// "New York City Marathon" also matches "NYC Marathon". Unlike adding each form as an
+// entity, a set applies to every entity containing one of its phrases, including those
+// added later, and matches report the entity as added in Canonical.
+//
+// Phrases must start and end on word boundaries within an entity to be replaced, and only
+// one phrase of an entity is replaced at a time. Synonym matches are reported with Kind
+// SynonymMatch. Sets are added to any already configured for the group.
+func Synonyms(sets ...[]string) GroupOption {
+	return func(g *group) {
+		added := false
+		for _, set := range sets {
+			if len(set) < 2 {
+				continue
+			}
+			phrases := make([][]rune, len(set))
+			for i, p := range set {
+				phrases[i] = lowerRunes([]rune(p))
+			}
+			g.synonymSets = append(g.synonymSets, phrases)
+			added = true
+		}
+		if !added {
+			return
+		}
+		g.synonyms = make(map[string][]entry)
+		for _, ents := range g.entities {
+			for _, e := range ents {
+				g.addSynonyms(e)
+			}
+		}
+	}
+}
+
+// addSynonyms adds the forms of e with a phrase replaced by each of its synonyms, and with
+// a number in another form if the group has NumericVariants, to the synonym index of the
+// group. The caller must hold the group lock.
+func (g *group) addSynonyms(e entry) {
+	text := lowerRunes(e.text)
+	ws := words(text)
+	seen := map[string]bool{string(text): true}
+	for _, set := range g.synonymSets {
+		for _, phrase := range set {
+			for _, w := range ws {
+				end := w[left] + len(phrase)
+				if !hasPhrase(text, w[left], phrase) {
+					continue
+				}
+				for _, alt := range set {
+					form := make([]rune, 0, len(text)-len(phrase)+len(alt))
+					form = append(append(append(form, text[:w[left]]...), alt...), text[end:]...)
+					key := string(form)
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+					g.synonyms[key] = append(g.synonyms[key], e)
+					if n := wordCount(form); n > g.maxWords {
+						g.maxWords = n
+					}
+				}
+			}
+		}
+	}
+	if g.numeric {
+		g.addNumericVariants(e, text, ws, seen)
+	}
+}
+
+// hasPhrase reports whether the word starting at text[i] begins the phrase, ending on a
+// word boundary.
+func hasPhrase(text []rune, i int, phrase []rune) bool {
+	end := i + len(phrase)
+	if len(phrase) == 0 || end > len(text) || (end < len(text) && !isBoundary(text[end])) {
+		return false
+	}
+	for j, r := range phrase {
+		if text[i+j] != r {
+			return false
+		}
+	}
+	return true
+}
+
+// lowerRunes returns a lower case copy of rs.
+func lowerRunes(rs []rune) []rune {
+	lower := make([]rune, len(rs))
+	for i, r := range rs {
+		lower[i] = unicode.ToLower(r)
+	}
+	return lower
+}
+
+// matchSynonyms calls fn for each entity in the group with a synonym form matching the
+// text of rs spanning the words ws, returning false if fn does.
+func (g *group) matchSynonyms(rs []rune, ws []pair, sc *scratch, fn func(g *group, ent *entry, e Entity) bool) bool {
+	p1, p2 := ws[0], ws[len(ws)-1]
+	text := rs[p1[left]:p2[right]]
+	sc.key = sc.key[:0]
+	for _, r := range text {
+		sc.key = utf8.AppendRune(sc.key, unicode.ToLower(r))
+	}
+	ents := g.synonyms[string(sc.key)]
+	for j := range ents {
+		e := Entity{
+			Text:      text,
+			Offset:    p1[left],
+			Canonical: ents[j].text,
+			Kind:      SynonymMatch,
+			Score:     1,
+			Weight:    ents[j].weight,
+			Group:     g.name,
+		}
+		if !fn(g, &ents[j], e) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,39 @@
+package fastentity
+
+import "testing"
+
+func TestSynonyms(t *testing.T) {
+	store := New()
+	store.Add("locations", []rune("New York City"))
+	store.Group("locations").Configure(Synonyms([]string{"NYC", "New York City", "the Big Apple"}, []string{"Mt", "Mount"}))
+	store.Add("locations", []rune("New York City Marathon"), []rune("Mount Everest"))
+
+	str := []rune("Running the NYC marathon in the big apple, then Mt Everest. ")
+	found := store.FindAll(str)["locations"]
+	expected := []struct {
+		text, canonical string
+		offset          int
+	}{
+		{"NYC", "New York City", 12},
+		{"NYC marathon", "New York City Marathon", 12},
+		{"the big apple", "New York City", 28},
+		{"Mt Everest", "Mount Everest", 48},
+	}
+	if len(found) != len(expected) {
+		t.Fatalf("Expected %d entities, got %d: %v", len(expected), len(found), found)
+	}
+	for i, e := range expected {
+		f := found[i]
+		if string(f.Text) != e.text || string(f.Canonical) != e.canonical || f.Offset != e.offset || f.Kind != SynonymMatch {
+			t.Errorf("Expected %q (%q) at %d, got %q (%q) at %d as %v",
+				e.text, e.canonical, e.offset, string(f.Text), string(f.Canonical), f.Offset, f.Kind)
+		}
+	}
+
+	// Phrases only replace whole words
+	store.Add("skills", []rune("Mtg planning"))
+	store.Group("skills").Configure(Synonyms([]string{"Mt", "Mount"}))
+	if found := store.FindAll([]rune("Mountg planning"))["skills"]; len(found) != 0 {
+		t.Errorf("Expected no matches, got %v", found)
+	}
+}
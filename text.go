@@ -0,0 +1,75 @@
+package fastentity
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// errTextForm is returned decoding an entity not in the form of MarshalText.
+var errTextForm = errors.New("invalid text form")
+
+// MarshalText encodes the entity in the form "group:offset:length:text", where length is
+// the length of the text in runes, e.g. "locations:24:6:Sydney". Groups containing ':'
+// or needing escapes are quoted as Go strings, e.g. "\"geo:cities\":24:6:Sydney". Only
+// the group, text and offset are encoded, so the form is stable across changes to how the
+// entity was matched, and entities can be stored one per line in flat files, compared in
+// tests, and diffed across runs.
+func (e Entity) MarshalText() ([]byte, error) {
+	return []byte(textForm(e)), nil
+}
+
+// UnmarshalText decodes an entity encoded by MarshalText, setting its group, text and
+// offset.
+func (e *Entity) UnmarshalText(b []byte) error {
+	s := string(b)
+	var group string
+	if strings.HasPrefix(s, `"`) {
+		q, err := strconv.QuotedPrefix(s)
+		if err != nil {
+			return fmt.Errorf("%q has invalid group: %w", b, errTextForm)
+		}
+		group, _ = strconv.Unquote(q)
+		s = s[len(q):]
+		if !strings.HasPrefix(s, ":") {
+			return fmt.Errorf("%q: %w", b, errTextForm)
+		}
+		s = s[1:]
+	} else {
+		i := strings.IndexByte(s, ':')
+		if i < 0 {
+			return fmt.Errorf("%q: %w", b, errTextForm)
+		}
+		group, s = s[:i], s[i+1:]
+	}
+	parts := strings.SplitN(s, ":", 3)
+	if len(parts) != 3 {
+		return fmt.Errorf("%q: %w", b, errTextForm)
+	}
+	off, err := strconv.Atoi(parts[0])
+	if err != nil || off < 0 {
+		return fmt.Errorf("%q has invalid offset: %w", b, errTextForm)
+	}
+	n, err := strconv.Atoi(parts[1])
+	if err != nil || n != utf8.RuneCountInString(parts[2]) {
+		return fmt.Errorf("%q has invalid length: %w", b, errTextForm)
+	}
+	*e = Entity{Text: []rune(parts[2]), Offset: off, Group: group}
+	return nil
+}
+
+// String returns the entity in the form of MarshalText.
+func (e Entity) String() string {
+	return textForm(e)
+}
+
+// textForm returns the entity e in the form of Entity.MarshalText.
+func textForm(e Entity) string {
+	group := e.Group
+	if q := strconv.Quote(group); strings.Contains(group, ":") || q[1:len(q)-1] != group {
+		group = q
+	}
+	return group + ":" + strconv.Itoa(e.Offset) + ":" + strconv.Itoa(len(e.Text)) + ":" + string(e.Text)
+}
@@ -0,0 +1,64 @@
+package fastentity
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMatchText(t *testing.T) {
+	store := New()
+	store.Add("locations", []rune("Sydney"), []rune("São Paulo: Centro"))
+	doc := []rune("From São Paulo: Centro to Sydney")
+
+	var got []string
+	for _, m := range store.FindAll(doc).Matches() {
+		b, err := m.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, string(b))
+
+		var dm Match
+		if err := dm.UnmarshalText(b); err != nil {
+			t.Fatal(err)
+		}
+		if dm.Group != m.Group || dm.Offset != m.Offset || string(dm.Text) != string(m.Text) {
+			t.Errorf("Expected %v after round trip, got %v", m, dm)
+		}
+	}
+	want := []string{"locations:5:17:São Paulo: Centro", "locations:26:6:Sydney"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	for _, s := range []string{"", "locations", "locations:5", "locations:x:6:Sydney", "locations:5:7:Sydney", "locations:-1:6:Sydney"} {
+		var m Match
+		if err := m.UnmarshalText([]byte(s)); err == nil {
+			t.Errorf("Expected error decoding %q", s)
+		}
+	}
+	for _, s := range []string{`"locations:5:6:Sydney`, `"locations"5:6:Sydney`} {
+		var e Entity
+		if err := e.UnmarshalText([]byte(s)); err == nil {
+			t.Errorf("Expected error decoding %q", s)
+		}
+	}
+
+	// Groups are quoted if needed, and the text form is the same as String
+	for group, want := range map[string]string{
+		"locations":  "locations:2:2:NY",
+		"geo:cities": `"geo:cities":2:2:NY`,
+		`"quoted"`:   `"\"quoted\"":2:2:NY`,
+		"":           ":2:2:NY",
+	} {
+		e := Entity{Text: []rune("NY"), Offset: 2, Group: group}
+		b, _ := e.MarshalText()
+		if string(b) != want || e.String() != want || (Match{Entity: e}).String() != want {
+			t.Errorf("Expected %s, got %s and %s", want, b, e)
+		}
+		var got Entity
+		if err := got.UnmarshalText(b); err != nil || got.Group != group || got.Offset != 2 || string(got.Text) != "NY" {
+			t.Errorf("Expected %s after round trip, got %s (%v)", want, got, err)
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package fastentity
+
+import "unicode"
+
+// Tokenize returns the spans of the words in rs, using the same rules as the matcher by
+// default: words are separated by punctuation and space, and entities are only found
+// which start and end on word boundaries. Stores with other rules are tokenized by their
+// Tokenizer.
+func Tokenize(rs []rune) []Span {
+	ws := words(rs)
+	spans := make([]Span, len(ws))
+	for i, w := range ws {
+		spans[i] = Span{w[left], w[right]}
+	}
+	return spans
+}
+
+// Sentences returns the spans of the sentences in rs, excluding surrounding space. A
+// sentence ends with a run of terminal punctuation such as '.', '?' or '。', optionally
+// followed by closing quotes or brackets, which is followed by space or the end of rs,
+// so abbreviations like "U.S." only end a sentence when followed by space. Any text
+// after the last terminal is returned as the final sentence.
+func Sentences(rs []rune) []Span {
+	var spans []Span
+	start := -1
+	for i := 0; i < len(rs); i++ {
+		if unicode.IsSpace(rs[i]) {
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+		if !unicode.Is(unicode.Sentence_Terminal, rs[i]) {
+			continue
+		}
+		end := i + 1
+		for end < len(rs) && unicode.Is(unicode.Sentence_Terminal, rs[end]) {
+			end++
+		}
+		for end < len(rs) && isClosing(rs[end]) {
+			end++
+		}
+		if end == len(rs) || unicode.IsSpace(rs[end]) {
+			spans = append(spans, Span{start, end})
+			start = -1
+		}
+		i = end - 1
+	}
+	if start >= 0 {
+		end := len(rs)
+		for unicode.IsSpace(rs[end-1]) {
+			end--
+		}
+		spans = append(spans, Span{start, end})
+	}
+	return spans
+}
+
+// isClosing reports whether r closes a quotation or bracket.
+func isClosing(r rune) bool {
+	return r == '"' || r == '\'' || unicode.In(r, unicode.Pe, unicode.Pf)
+}
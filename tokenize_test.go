@@ -0,0 +1,41 @@
+package fastentity
+
+import "testing"
+
+func spanTexts(rs []rune, spans []Span) []string {
+	texts := make([]string, len(spans))
+	for i, sp := range spans {
+		texts[i] = string(rs[sp.Start:sp.End])
+	}
+	return texts
+}
+
+func TestTokenize(t *testing.T) {
+	rs := []rune("日 本語. San Francisco, USA...golang")
+	expected := []string{"日", "本語", "San", "Francisco", "USA", "golang"}
+
+	got := spanTexts(rs, Tokenize(rs))
+	if len(got) != len(expected) {
+		t.Fatalf("Expected tokens %q, got %q", expected, got)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("Expected token %d to be %q, got %q", i, expected[i], got[i])
+		}
+	}
+}
+
+func TestSentences(t *testing.T) {
+	rs := []rune("  Moved to the U.S. in 2010. \"Why?!\" he asked.\n日本語です。 And then  ")
+	expected := []string{"Moved to the U.S.", "in 2010.", "\"Why?!\"", "he asked.", "日本語です。", "And then"}
+
+	got := spanTexts(rs, Sentences(rs))
+	if len(got) != len(expected) {
+		t.Fatalf("Expected sentences %q, got %q", expected, got)
+	}
+	for i := range got {
+		if got[i] != expected[i] {
+			t.Errorf("Expected sentence %d to be %q, got %q", i, expected[i], got[i])
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package fastentity
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Tokenizer configures how the documents searched by a store are split into words, on
+// whose boundaries entities must start and end. The zero value splits words on
+// punctuation, space and emoji, keeping sequences of emoji joined by U+200D together.
+type Tokenizer struct {
+	// Joiners are punctuation which doesn't separate words when between two letters or
+	// digits, e.g. '-' in "kube-proxy". Entities must then match the whole of such
+	// words, so "proxy" isn't found in "kube-proxy".
+	Joiners string
+
+	// Splitters are punctuation which separates the words of compounds, and matches a
+	// space in entities when between two letters or digits, e.g. with '-' and '/',
+	// "front-end" matches the entity "front end", and "Python/Django" matches "Python"
+	// and "Django". Entities containing the splitters still match them exactly. Splitters
+	// take precedence over Joiners, and don't apply to groups with normalized matching,
+	// see Normalize.
+	Splitters string
+
+	// EmojiInWords makes emoji part of the words next to them, rather than separating
+	// words, so "Python" isn't found in "Python🐍rocks".
+	EmojiInWords bool
+
+	// BoundaryClass declares runes which separate words besides punctuation, space and
+	// emoji, e.g. the math symbols "Sm", so "C" is found in "C+Python". JoinerClass
+	// declares runes which are joiners, as Joiners does. Unlike Joiners and Splitters,
+	// they can cover whole categories and ranges of runes.
+	BoundaryClass RuneClass
+	JoinerClass   RuneClass
+
+	// DigitBoundaries makes words end where letters and digits meet, so "Texas" is found
+	// in "Texas77034", and "golang" in "golang1.21". Groups with entities which run
+	// letters and digits together can opt out, see NoDigitBoundaries.
+	DigitBoundaries bool
+}
+
+// CodeTokenizer returns a Tokenizer for technical text, such as logs and source code, in
+// which '_', '-', '.' and '/' within identifiers don't separate words. Entities like
+// "kube-proxy", "us-east-1" and "libc.so.6" are matched whole, and not within longer
+// identifiers.
+func CodeTokenizer() Tokenizer {
+	return Tokenizer{Joiners: "_-./"}
+}
+
+// SetTokenizer sets how documents searched by the store are split into words, by default
+// the zero Tokenizer.
+func (s *Store) SetTokenizer(t Tokenizer) {
+	s.Lock()
+	s.tokenizer = t
+	s.Unlock()
+	s.bump()
+}
+
+// Tokenizer returns the Tokenizer set with SetTokenizer.
+func (s *Store) Tokenizer() Tokenizer {
+	s.RLock()
+	defer s.RUnlock()
+	return s.tokenizer
+}
+
+// Tokenize returns the spans of the words in rs, as Tokenize does with the rules of t.
+func (t Tokenizer) Tokenize(rs []rune) []Span {
+	ws := t.words(rs)
+	spans := make([]Span, len(ws))
+	for i, w := range ws {
+		spans[i] = Span{w[left], w[right]}
+	}
+	return spans
+}
+
+// boundary reports whether rs[i] separates words.
+func (t Tokenizer) boundary(rs []rune, i int) bool {
+	r := rs[i]
+	if !t.isBoundary(r) {
+		return !t.EmojiInWords && inEmojiSequence(rs, i)
+	}
+	if isEmoji(r) {
+		return !t.EmojiInWords
+	}
+	if !t.isJoiner(r) || strings.ContainsRune(t.Splitters, r) {
+		return true
+	}
+	return i == 0 || i == len(rs)-1 || !isWordRune(rs[i-1]) || !isWordRune(rs[i+1])
+}
+
+// isBoundary reports whether r separates words, without the context of the runes around
+// it, see isBoundary.
+func (t Tokenizer) isBoundary(r rune) bool {
+	return isBoundary(r) || (!t.BoundaryClass.empty() && t.BoundaryClass.Contains(r))
+}
+
+// isJoiner reports whether r is one of the Joiners or in the JoinerClass.
+func (t Tokenizer) isJoiner(r rune) bool {
+	return strings.ContainsRune(t.Joiners, r) || (!t.JoinerClass.empty() && t.JoinerClass.Contains(r))
+}
+
+// splitCompounds appends text to buf with the splitters between two letters or digits
+// replaced by spaces, returning false if there are none.
+func (t Tokenizer) splitCompounds(buf, text []rune) ([]rune, bool) {
+	split := false
+	for i, r := range text {
+		if i > 0 && i < len(text)-1 && strings.ContainsRune(t.Splitters, r) && isWordRune(text[i-1]) && isWordRune(text[i+1]) {
+			r, split = ' ', true
+		}
+		buf = append(buf, r)
+	}
+	return buf, split
+}
+
+// isWordRune reports whether r is a letter or digit, which joiners join.
+func isWordRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// wordCount returns the most words rs is split into by any Tokenizer without a
+// BoundaryClass, which differ only in whether emoji are words, see group.depthFor.
+func wordCount(rs []rune) int {
+	n := len(words(rs))
+	for _, r := range rs {
+		if isEmoji(r) {
+			if m := len(Tokenizer{EmojiInWords: true}.words(rs)); m > n {
+				n = m
+			}
+			break
+		}
+	}
+	return n
+}
+
+// words returns the start and end offsets of the words in rs.
+func (t Tokenizer) words(rs []rune) []pair {
+	var ws []pair
+	start := -1
+	for off := range rs {
+		if t.boundary(rs, off) {
+			if start >= 0 {
+				ws = append(ws, pair{start, off})
+				start = -1
+			}
+		} else if start < 0 {
+			start = off
+		} else if t.digitBoundary(rs, off) {
+			ws = append(ws, pair{start, off})
+			start = off
+		}
+	}
+	if start >= 0 {
+		ws = append(ws, pair{start, len(rs)})
+	}
+	return ws
+}
@@ -0,0 +1,126 @@
+package fastentity
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCodeTokenizer(t *testing.T) {
+	store := New()
+	store.Add("services", []rune("kube-proxy"), []rune("proxy"), []rune("us-east-1"), []rune("libc.so.6"), []rune("so"))
+	doc := []rune("kube-proxy failed in us-east-1: libc.so.6 not found. Restarting proxy.")
+
+	texts := func(ents []Entity) []string {
+		var ts []string
+		for _, e := range ents {
+			ts = append(ts, string(e.Text))
+		}
+		return ts
+	}
+	if got := texts(store.FindAll(doc)["services"]); len(got) != 6 {
+		t.Errorf("Expected parts of identifiers to match by default, got %q", got)
+	}
+
+	store.SetTokenizer(CodeTokenizer())
+	want := []string{"kube-proxy", "us-east-1", "libc.so.6", "proxy"}
+	if got := texts(store.FindAll(doc)["services"]); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	var streamed []string
+	err := store.FindReader(strings.NewReader(string(doc)), func(m StreamMatch) bool {
+		streamed = append(streamed, string(m.Text))
+		return true
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(streamed, want) {
+		t.Errorf("Expected %q streamed, got %q", want, streamed)
+	}
+
+	spans := CodeTokenizer().Tokenize([]rune("a_b -c- d/e."))
+	if want := []Span{{0, 3}, {5, 6}, {8, 11}}; !reflect.DeepEqual(spans, want) {
+		t.Errorf("Expected spans %v, got %v", want, spans)
+	}
+}
+
+func TestSplitters(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("front end"), []rune("Python"), []rune("Django"), []rune("CI/CD"))
+	store.SetTokenizer(Tokenizer{Joiners: "-/", Splitters: "-/"})
+	doc := []rune("Front-end work with Python/Django and CI/CD")
+
+	var got []string
+	for _, m := range store.FindAll(doc).Matches() {
+		got = append(got, m.String())
+	}
+	want := []string{"skills:0:9:Front-end", "skills:20:6:Python", "skills:27:6:Django", "skills:38:5:CI/CD"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestSplittersNormalized(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("front end developer"))
+	store.Group("skills").Configure(FoldPlurals())
+	store.SetTokenizer(Tokenizer{Joiners: "-", Splitters: "-"})
+
+	var got []string
+	for _, m := range store.FindAll([]rune("Front-end developers wanted")).Matches() {
+		got = append(got, m.String())
+	}
+	want := []string{"skills:0:20:Front-end developers"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+}
+
+func TestRuneClasses(t *testing.T) {
+	store := New()
+	store.Add("skills", []rune("C"), []rune("Python"), []rune("proxy"), []rune("x+y=z"))
+	tok := Tokenizer{
+		BoundaryClass: RuneClass{Categories: []string{"Sm"}},
+		JoinerClass:   RuneClass{Categories: []string{"Pd"}, Ranges: []RuneRange{{'_', '_'}}},
+	}
+	doc := []rune("C+Python, kube-proxy and kube_proxy, so x+y=z")
+
+	texts := func(r Results) []string {
+		var ts []string
+		for _, e := range r["skills"] {
+			ts = append(ts, string(e.Text))
+		}
+		return ts
+	}
+	if got, want := texts(store.FindAll(doc)), []string{"proxy", "proxy", "x+y=z"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q by default, got %q", want, got)
+	}
+	store.SetTokenizer(tok)
+	want := []string{"C", "Python", "x+y=z"}
+	if got := texts(store.FindAll(doc)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q, got %q", want, got)
+	}
+
+	// The tokenizer is kept in snapshots
+	var buf bytes.Buffer
+	if err := store.WriteSnapshot(&buf); err != nil {
+		t.Fatal(err)
+	}
+	restored, err := ReadSnapshot(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(restored.Tokenizer(), tok) {
+		t.Errorf("Expected tokenizer %+v, got %+v", tok, restored.Tokenizer())
+	}
+	if got := texts(restored.FindAll(doc)); !reflect.DeepEqual(got, want) {
+		t.Errorf("Expected %q from the snapshot, got %q", want, got)
+	}
+
+	if !tok.JoinerClass.Contains('—') || tok.JoinerClass.Contains('a') || (RuneClass{Categories: []string{"Xx"}}).Contains('a') {
+		t.Error("Expected runes to be in classes by category and range")
+	}
+}
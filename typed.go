@@ -0,0 +1,109 @@
+//go:build go1.18
+
+package fastentity
+
+import "encoding/json"
+
+// TypedGroup is a view of a group in a Store where each entity carries a value of type T,
+// which is returned alongside the entity whenever it is found.
+//
+// Entities added to the underlying group without a value (i.e. through Store.Add) are
+// reported with the zero value of T.
+type TypedGroup[T any] struct {
+	s    *Store
+	name string
+}
+
+// TypedEntity is an Entity found in a document along with the value attached to it.
+type TypedEntity[T any] struct {
+	Entity
+	Value T
+}
+
+// NewTypedGroup returns a TypedGroup for the group identified by name in s, creating the
+// group if it doesn't exist.
+func NewTypedGroup[T any](s *Store, name string) *TypedGroup[T] {
+	s.group(name)
+	return &TypedGroup[T]{
+		s:    s,
+		name: name,
+	}
+}
+
+// Add adjoins the entity e to the group, attaching the value v.
+func (t *TypedGroup[T]) Add(e []rune, v T) {
+	t.s.recordEntities("", AuditAdd, t.name, nil, e)
+	g := t.s.addGroup(t.name)
+	g.Lock()
+	ent := newEntry(e)
+	ent.value = v
+	g.add(ent)
+	g.Unlock()
+	t.s.bump()
+}
+
+// Value returns the value attached to the entity e, and whether the entity was found.
+func (t *TypedGroup[T]) Value(e []rune) (T, bool) {
+	var zero T
+	g, err := t.s.existingGroup(t.name)
+	if err != nil {
+		return zero, false
+	}
+	g.rlock()
+	defer g.RUnlock()
+
+	ent := g.lookup(e)
+	if ent == nil {
+		return zero, false
+	}
+	return typedValue[T](ent), true
+}
+
+// Find searches the input returning the entities of this group found, along with their
+// attached values, as Group.Find does.
+func (t *TypedGroup[T]) Find(rs []rune) []TypedEntity[T] {
+	ents := t.s.findGroup(t.name, rs)
+	if len(ents) == 0 {
+		return nil
+	}
+	g := t.s.group(t.name)
+	g.rlock()
+	defer g.RUnlock()
+
+	results := make([]TypedEntity[T], len(ents))
+	for i, e := range ents {
+		results[i].Entity = e
+		if ent := g.lookup(e.Canonical); ent != nil {
+			results[i].Value = typedValue[T](ent)
+		}
+	}
+	return results
+}
+
+func typedValue[T any](ent *entry) T {
+	v, _ := ent.value.(T)
+	return v
+}
+
+// MarshalJSON encodes the entity as Entity.MarshalJSON does, along with its value, e.g.
+//
+//	{"group": "locations", "text": "sydney", "canonical": "Sydney", "offset": 24, "kind": "text", "score": 1, "weight": 1, "value": {"id": "Q3130"}}
+func (e TypedEntity[T]) MarshalJSON() ([]byte, error) {
+	return json.Marshal(typedEntityJSON[T]{newEntityJSON(e.Entity), e.Value})
+}
+
+// UnmarshalJSON decodes an entity encoded by MarshalJSON.
+func (e *TypedEntity[T]) UnmarshalJSON(b []byte) error {
+	var tj typedEntityJSON[T]
+	if err := json.Unmarshal(b, &tj); err != nil {
+		return err
+	}
+	*e = TypedEntity[T]{Entity: tj.entity(), Value: tj.Value}
+	return nil
+}
+
+// typedEntityJSON is the JSON encoding of a TypedEntity.
+type typedEntityJSON[T any] struct {
+	entityJSON
+	Value T `json:"value"`
+}
@@ -0,0 +1,93 @@
+//go:build go1.18
+
+package fastentity
+
+import (
+	"encoding/json"
+	"errors"
+	"reflect"
+	"testing"
+)
+
+type location struct {
+	ID       int
+	Lat, Lng float64
+}
+
+func TestTypedGroup(t *testing.T) {
+	store := New()
+	locations := NewTypedGroup[location](store, "locations")
+	locations.Add([]rune("San Francisco, USA"), location{ID: 1, Lat: 37.77, Lng: -122.42})
+	locations.Add([]rune("Sydney"), location{ID: 2, Lat: -33.87, Lng: 151.21})
+	store.Add("locations", []rune("Houston"))
+
+	str := []rune("jack was from sydney, then San Francisco, USA... and houston. ")
+	found := locations.Find(str)
+	if len(found) != 3 {
+		t.Fatalf("Expected 3 typed entities, got %d", len(found))
+	}
+	for _, f := range found {
+		switch string(f.Text) {
+		case "sydney":
+			if f.Value.ID != 2 {
+				t.Errorf("Expected 'sydney' to carry ID 2, got %v", f.Value)
+			}
+		case "San Francisco, USA":
+			if f.Value.ID != 1 {
+				t.Errorf("Expected 'San Francisco, USA' to carry ID 1, got %v", f.Value)
+			}
+		case "houston":
+			if f.Value != (location{}) {
+				t.Errorf("Expected untyped entity to carry the zero value, got %v", f.Value)
+			}
+		default:
+			t.Errorf("Unexpected entity %q", string(f.Text))
+		}
+	}
+
+	if v, ok := locations.Value([]rune("SYDNEY")); !ok || v.ID != 2 {
+		t.Errorf("Expected to look up 'SYDNEY', got %v, %v", v, ok)
+	}
+	if _, ok := locations.Value([]rune("Melbourne")); ok {
+		t.Errorf("Expected not to find 'Melbourne'")
+	}
+
+	// The group is still visible through the untyped API.
+	if len(store.FindAll(str)["locations"]) != 3 {
+		t.Errorf("Expected typed entities to be found by FindAll")
+	}
+
+	// Looking up a value in a missing group doesn't create it
+	if err := store.RenameGroup("locations", "places"); err != nil {
+		t.Fatal(err)
+	}
+	version := store.Version()
+	if v, ok := locations.Value([]rune("Sydney")); ok || v != (location{}) {
+		t.Errorf("Expected no value in a missing group, got %v, %v", v, ok)
+	}
+	if _, err := store.LookupGroup("locations"); !errors.Is(err, ErrGroupNotFound) || store.Version() != version {
+		t.Errorf("Expected the missing group not to be created, got %v and version %d", err, store.Version())
+	}
+}
+
+func TestTypedEntityJSON(t *testing.T) {
+	e := TypedEntity[location]{
+		Entity: Entity{Text: []rune("sydney"), Offset: 9, Canonical: []rune("Sydney"), Score: 1, Weight: 1, Group: "locations"},
+		Value:  location{ID: 2, Lat: -33.87, Lng: 151.21},
+	}
+	b, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"group":"locations","text":"sydney","canonical":"Sydney","offset":9,"kind":"text","score":1,"weight":1,"value":{"ID":2,"Lat":-33.87,"Lng":151.21}}`
+	if string(b) != want {
+		t.Errorf("Expected %s, got %s", want, b)
+	}
+	var got TypedEntity[location]
+	if err := json.Unmarshal(b, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, e) {
+		t.Errorf("Expected %+v after round trip, got %+v", e, got)
+	}
+}
@@ -0,0 +1,15 @@
+package fastentity
+
+import "sync/atomic"
+
+// Version returns the version of the store, which is incremented after every change to
+// the entities in the store or how they're matched, so results cached for a version can
+// be invalidated when it changes. Stores read from snapshots have the version of the
+// store the snapshot was written from.
+func (s *Store) Version() uint64 {
+	return atomic.LoadUint64(&s.version)
+}
+
+func (s *Store) bump() {
+	atomic.AddUint64(&s.version, 1)
+}
@@ -0,0 +1,33 @@
+package fastentity
+
+import "testing"
+
+func TestVersion(t *testing.T) {
+	store := New("skills")
+	if v := store.Version(); v != 0 {
+		t.Errorf("Expected a new store to have version 0, got %d", v)
+	}
+
+	v := store.Version()
+	changes := []func(){
+		func() { store.Add("skills", []rune("PHP")) },
+		func() { store.AddWeighted("skills", []rune("golang"), 2) },
+		func() { store.Group("jobTitles") },
+		func() { store.Group("jobTitles").Configure(FoldPlurals()) },
+		func() { store.SetMaxEntityWords(3) },
+		func() { store.AddPreprocessor(CollapseWhitespace()) },
+	}
+	for i, change := range changes {
+		change()
+		if store.Version() <= v {
+			t.Errorf("Expected change %d to increment the version from %d", i, v)
+		}
+		v = store.Version()
+	}
+
+	store.FindAll([]rune("Maybe PHP, or golang. "))
+	store.Group("skills").Len()
+	if store.Version() != v {
+		t.Errorf("Expected reads not to change the version")
+	}
+}
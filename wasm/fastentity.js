@@ -0,0 +1,53 @@
+// JavaScript binding for the fastentity matcher compiled to WebAssembly, see main.go.
+// Requires the wasm_exec.js shipped with Go to be loaded first, e.g.
+//
+//   <script src="wasm_exec.js"></script>
+//   <script type="module">
+//     import { load } from "./fastentity.js";
+//     const matcher = await load("fastentity.wasm");
+//     await matcher.loadSnapshot("dictionaries.snap");
+//     for (const m of matcher.findAll(text)) {
+//       highlight(m.start, m.end, m.group);
+//     }
+//   </script>
+
+function check(result) {
+  if (result && result.error) {
+    throw new Error("fastentity: " + result.error);
+  }
+  return result;
+}
+
+// load instantiates the WebAssembly module at url and returns the matcher.
+export async function load(url = "fastentity.wasm") {
+  const go = new Go();
+  const { instance } = await WebAssembly.instantiateStreaming(fetch(url), go.importObject);
+  go.run(instance);
+  const api = globalThis.fastentity;
+
+  return {
+    // addEntities adds an array of entities to the group.
+    addEntities(group, entities) {
+      check(api.addEntities(group, entities));
+    },
+
+    // loadSnapshot replaces the dictionaries with a snapshot written by
+    // `fastentity build` or Store.SaveSnapshot, given its bytes or a URL to fetch.
+    async loadSnapshot(snapshot) {
+      if (typeof snapshot === "string") {
+        const resp = await fetch(snapshot);
+        if (!resp.ok) {
+          throw new Error(`fastentity: fetching ${snapshot}: ${resp.status}`);
+        }
+        snapshot = new Uint8Array(await resp.arrayBuffer());
+      }
+      check(api.loadSnapshot(snapshot));
+    },
+
+    // findAll returns the matches in text in document order. start and end are
+    // offsets into text, so text.slice(m.start, m.end) is the text matched.
+    findAll(text) {
+      return check(api.findAll(text));
+    },
+  };
+}
@@ -0,0 +1,95 @@
+//go:build js && wasm
+
+// Command wasm exposes the matcher to JavaScript when compiled to WebAssembly, so the
+// same dictionaries used on the server can drive highlighting in the browser. Build it
+// with:
+//
+//	GOOS=js GOARCH=wasm go build -o fastentity.wasm ./wasm
+//
+// and load it with fastentity.js, alongside the wasm_exec.js shipped with Go.
+//
+// It sets a global fastentity object with the functions:
+//
+//	addEntities(group, entities)  add an array of entities to a group
+//	loadSnapshot(bytes)           replace the dictionaries with a snapshot in a Uint8Array
+//	findAll(text)                 find the entities in text, in document order
+//
+// Matches are objects with the fields group, text, canonical, kind, score and weight, and
+// start and end, the offsets of the match in UTF-16 code units as used by JavaScript
+// strings, so text.slice(start, end) is the text matched. Errors are returned as an
+// object with an error field, which fastentity.js throws.
+package main
+
+import (
+	"bytes"
+	"syscall/js"
+
+	"github.com/sajari/fastentity"
+)
+
+var store = fastentity.New()
+
+func main() {
+	js.Global().Set("fastentity", js.ValueOf(map[string]interface{}{
+		"addEntities":  js.FuncOf(addEntities),
+		"loadSnapshot": js.FuncOf(loadSnapshot),
+		"findAll":      js.FuncOf(findAll),
+	}))
+	select {}
+}
+
+func jsError(msg string) interface{} {
+	return map[string]interface{}{"error": msg}
+}
+
+func addEntities(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 || args[0].Type() != js.TypeString || args[1].Type() != js.TypeObject {
+		return jsError("addEntities(group, entities) expects a string and an array")
+	}
+	group, ents := args[0].String(), args[1]
+	for i := 0; i < ents.Length(); i++ {
+		e := []rune(ents.Index(i).String())
+		if err := fastentity.ValidateEntity(e); err != nil {
+			return jsError(err.Error())
+		}
+		store.Add(group, e)
+	}
+	return nil
+}
+
+func loadSnapshot(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || !args[0].InstanceOf(js.Global().Get("Uint8Array")) {
+		return jsError("loadSnapshot(bytes) expects a Uint8Array")
+	}
+	b := make([]byte, args[0].Length())
+	js.CopyBytesToGo(b, args[0])
+	s, err := fastentity.ReadSnapshot(bytes.NewReader(b))
+	if err != nil {
+		return jsError(err.Error())
+	}
+	store = s
+	return nil
+}
+
+func findAll(this js.Value, args []js.Value) interface{} {
+	if len(args) != 1 || args[0].Type() != js.TypeString {
+		return jsError("findAll(text) expects a string")
+	}
+	doc := []rune(args[0].String())
+
+	x := fastentity.NewOffsetIndex(doc)
+	ms := []interface{}{}
+	for _, m := range store.FindAll(doc).Matches() {
+		ms = append(ms, map[string]interface{}{
+			"group":     m.Group,
+			"text":      string(m.Text),
+			"canonical": string(m.Canonical),
+			"start":     x.UTF16(m.Offset),
+			"end":       x.UTF16(m.Offset + len(m.Text)),
+			"kind":      m.Kind.String(),
+			"score":     m.Score,
+			"weight":    m.Weight,
+		})
+	}
+	return ms
+}
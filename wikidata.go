@@ -0,0 +1,148 @@
+package fastentity
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// wikidataBatch is the number of entities AddFromWikidata adds to the store at a time.
+const wikidataBatch = 10000
+
+// WikidataOptions configures AddFromWikidata.
+type WikidataOptions struct {
+	// Languages are the languages of the labels and aliases added, by default "en".
+	Languages []string
+	// Types are the IDs of the classes the items added must be instances of, by their
+	// "instance of" (P31) claims, e.g. "Q515" for cities. Items of any type are added if
+	// there are none.
+	Types []string
+	// NoAliases adds only the labels of items, and not their aliases.
+	NoAliases bool
+}
+
+// wikidataEntity is the part of an entity in a Wikidata JSON dump read by
+// AddFromWikidata.
+type wikidataEntity struct {
+	ID           string                     `json:"id"`
+	Labels       map[string]wikidataValue   `json:"labels"`
+	Descriptions map[string]wikidataValue   `json:"descriptions"`
+	Aliases      map[string][]wikidataValue `json:"aliases"`
+	Claims       map[string][]wikidataClaim `json:"claims"`
+}
+
+type wikidataValue struct {
+	Value string `json:"value"`
+}
+
+type wikidataClaim struct {
+	Mainsnak struct {
+		Datavalue struct {
+			Value json.RawMessage `json:"value"`
+		} `json:"datavalue"`
+	} `json:"mainsnak"`
+}
+
+// AddFromWikidata adds the items of a Wikidata JSON dump read from r to the group name,
+// returning the number of items added. The dump is either a JSON array of entities with
+// one per line, as in the dumps published by Wikidata, or JSON Lines such as those
+// filtered from them. The labels and aliases of each item in the languages of opts are
+// added as entities, with the ID of the item, e.g. "Q3130", and its label and description
+// in the first language as "label" and "description" metadata attached as an EntityInfo.
+// Labels and aliases shared by several items are added for the first only.
+func AddFromWikidata(r io.Reader, store *Store, name string, opts WikidataOptions) (int, error) {
+	if err := store.checkFrozen(name); err != nil {
+		return 0, err
+	}
+	langs := opts.Languages
+	if len(langs) == 0 {
+		langs = []string{"en"}
+	}
+	types := make(map[string]bool, len(opts.Types))
+	for _, t := range opts.Types {
+		types[t] = true
+	}
+
+	br := bufio.NewReader(r)
+	dec := json.NewDecoder(br)
+	if b, err := br.Peek(1); err == nil && b[0] == '[' {
+		if _, err := dec.Token(); err != nil {
+			return 0, err
+		}
+	}
+
+	var ents []entry
+	added := 0
+	flush := func() {
+		if len(ents) == 0 {
+			return
+		}
+		store.recordEntries(AuditAdd, name, ents)
+		store.addEntries(name, ents)
+		ents = ents[:0]
+	}
+	for i := 0; dec.More(); i++ {
+		var we wikidataEntity
+		if err := dec.Decode(&we); err != nil {
+			return added, fmt.Errorf("entity %d: %w", i, err)
+		}
+		if len(types) > 0 && !we.instanceOf(types) {
+			continue
+		}
+		n := len(ents)
+		ents = we.appendEntries(ents, langs, !opts.NoAliases)
+		if len(ents) > n {
+			added++
+		}
+		if len(ents) >= wikidataBatch {
+			flush()
+		}
+	}
+	flush()
+	return added, nil
+}
+
+// instanceOf reports whether the entity is an instance of any of the types, by ID.
+func (we *wikidataEntity) instanceOf(types map[string]bool) bool {
+	for _, c := range we.Claims["P31"] {
+		var v struct {
+			ID string `json:"id"`
+		}
+		if json.Unmarshal(c.Mainsnak.Datavalue.Value, &v) == nil && types[v.ID] {
+			return true
+		}
+	}
+	return false
+}
+
+// appendEntries appends the labels, and aliases if set, of the entity in the languages
+// langs to ents, with its ID and metadata attached.
+func (we *wikidataEntity) appendEntries(ents []entry, langs []string, aliases bool) []entry {
+	info := EntityInfo{ID: we.ID, Metadata: make(map[string]string, 2)}
+	for _, lang := range langs {
+		if l, ok := we.Labels[lang]; ok && info.Metadata["label"] == "" {
+			info.Metadata["label"] = l.Value
+		}
+		if d, ok := we.Descriptions[lang]; ok && info.Metadata["description"] == "" {
+			info.Metadata["description"] = d.Value
+		}
+	}
+	add := func(text string) {
+		if text == "" {
+			return
+		}
+		e := newEntry([]rune(text))
+		e.value = info
+		ents = append(ents, e)
+	}
+	for _, lang := range langs {
+		add(we.Labels[lang].Value)
+		if aliases {
+			for _, a := range we.Aliases[lang] {
+				add(a.Value)
+			}
+		}
+	}
+	return ents
+}
@@ -0,0 +1,51 @@
+package fastentity
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const wikidataDump = `[
+{"type":"item","id":"Q3130","labels":{"en":{"language":"en","value":"Sydney"},"de":{"language":"de","value":"Sydney"}},"descriptions":{"en":{"language":"en","value":"capital city of New South Wales, Australia"}},"aliases":{"en":[{"language":"en","value":"Sydney, Australia"},{"language":"en","value":"Harbour City"}]},"claims":{"P31":[{"mainsnak":{"snaktype":"value","property":"P31","datavalue":{"value":{"entity-type":"item","numeric-id":1637706,"id":"Q1637706"},"type":"wikibase-entityid"}}},{"mainsnak":{"datavalue":{"value":{"id":"Q515"}}}}],"P17":[{"mainsnak":{"datavalue":{"value":{"id":"Q408"}}}}]}},
+{"type":"item","id":"Q42","labels":{"en":{"language":"en","value":"Douglas Adams"}},"aliases":{"en":[{"language":"en","value":"DNA"}]},"claims":{"P31":[{"mainsnak":{"datavalue":{"value":{"id":"Q5"}}}}]}},
+{"type":"item","id":"Q1524","labels":{"fr":{"language":"fr","value":"Athènes"}},"claims":{"P31":[{"mainsnak":{"datavalue":{"value":{"id":"Q515"}}}}]}}
+]
+`
+
+func TestAddFromWikidata(t *testing.T) {
+	store := New()
+	n, err := AddFromWikidata(strings.NewReader(wikidataDump), store, "cities", WikidataOptions{Types: []string{"Q515"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 1 {
+		t.Errorf("Expected 1 city added, got %d", n)
+	}
+	cities := store.group("cities")
+	want := EntityInfo{ID: "Q3130", Metadata: map[string]string{"label": "Sydney", "description": "capital city of New South Wales, Australia"}}
+	for _, e := range []string{"Sydney", "Sydney, Australia", "harbour city"} {
+		if ent := cities.lookup([]rune(e)); ent == nil || !reflect.DeepEqual(ent.value, want) {
+			t.Errorf("Expected %q to be %+v, got %+v", e, want, ent)
+		}
+	}
+	if n := store.Group("cities").Len(); n != 3 {
+		t.Errorf("Expected 3 entities, got %d", n)
+	}
+
+	// JSON Lines, in other languages and without aliases
+	lines := strings.Join(strings.Split(wikidataDump, "\n")[1:4], "\n")
+	lines = strings.Replace(lines, "},\n", "}\n", -1)
+	store = New()
+	n, err = AddFromWikidata(strings.NewReader(lines), store, "all", WikidataOptions{Languages: []string{"fr", "en"}, NoAliases: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n != 3 || store.Group("all").Len() != 3 {
+		t.Errorf("Expected the labels of 3 items, got %d items and %d entities", n, store.Group("all").Len())
+	}
+
+	if _, err := AddFromWikidata(strings.NewReader(`[{"id": 1}]`), New(), "bad", WikidataOptions{}); err == nil {
+		t.Error("Expected an error decoding a bad dump")
+	}
+}